@@ -10,6 +10,58 @@
 // In particular, it can only write relatively "shallow" Clone methods.
 // That is, if a type contains another named struct type, cloner assumes that
 // named type will also have a Clone method.
+//
+// Fields of type error are copied by reference rather than cloned, as errors
+// are immutable by convention. The same applies to some standard library types
+// that contain pointers to immutable memory, such as *time.Location and
+// *regexp.Regexp; see codegen.ContainsPointers.
+//
+// Values of type any, including in slices and maps, are assumed to hold
+// arbitrary JSON as decoded by encoding/json and are deep-copied with
+// [tailscale.com/util/jsonclone.Value].
+//
+// Types whose doc comment contains a //codegen:clonevalue directive get a
+// Clone method that returns a value rather than a pointer.
+//
+// Types whose doc comment contains a //codegen:pooled directive additionally
+// get GetT and PutT funcs backed by a sync.Pool, to reduce allocations of
+// short-lived values. PutT zeroes the value before returning it to the pool,
+// so that pooled values retain no memory they reference.
+//
+// Types whose doc comment contains a //codegen:cloneskip directive followed by
+// space-separated field name globs, such as "//codegen:cloneskip *Cache", get a
+// Clone method that sets the matching fields to their zero value rather than
+// copying them. This is useful for caches and scratch buffers, where
+// codegen:noclone struct tags, which shallow-copy a field, would alias memory.
+//
+// Types whose doc comment contains a //codegen:maxdepth=N directive, for a
+// positive N, get a Clone method that tracks how deeply nested the value being
+// copied is, to bound the work done for pathologically deep values, such as
+// ones decoded from untrusted config. Values nested N levels below the
+// receiver of Clone are copied shallowly, so that they share any memory they
+// reference with the original, and a message is logged. The depth is tracked
+// across fields of all such types in the package.
+//
+// Types whose doc comment contains a //codegen:benchclone directive
+// additionally get a BenchmarkTClone func, written to a separate
+// <pkg>_clone_bench_test.go file, that clones a representative value of the
+// type in a loop. This lets regressions in the cost of Clone show up in
+// benchmark results. The representative value sets each field that can be set
+// in a composite literal to a non-zero value, with one element in each slice
+// and map. Self-referential types are nested in themselves once.
+//
+// Fields of the concurrency-safe map types sync.Map and
+// tailscale.com/syncs.Map, as reported by codegen.IsConcurrentMap, are copied
+// by ranging over the entries of the original and storing them in the clone,
+// as the maps must not be copied as values. Types with such fields are copied
+// field by field rather than as a whole. The values of a syncs.Map are cloned
+// like other values; the keys and values of a sync.Map have unknown types and
+// are shared with the original. Concurrency-safe maps nested in other fields
+// are not supported.
+//
+// Types whose doc comment contains a //codegen:ordered directive get a
+// stricter "needs regeneration" assertion that also fails if struct tags
+// change; see codegen.AssertStructUnchangedOrdered.
 package main
 
 import (
@@ -19,15 +71,25 @@
 	"go/types"
 	"log"
 	"os"
+	"path"
+	"strconv"
 	"strings"
 
 	"tailscale.com/util/codegen"
+	"tailscale.com/util/mak"
 )
 
 var (
 	flagTypes     = flag.String("type", "", "comma-separated list of types; required")
 	flagBuildTags = flag.String("tags", "", "compiler build tags to apply")
 	flagCloneFunc = flag.Bool("clonefunc", false, "add a top-level Clone func")
+
+	orderedTypes    map[string]bool           // types marked //codegen:ordered
+	cloneValueTypes map[types.Object]bool     // types marked //codegen:clonevalue
+	pooledTypes     map[types.Object]bool     // types marked //codegen:pooled
+	skipFields      map[types.Object][]string // field name globs from //codegen:cloneskip
+	maxDepths       map[types.Object]int      // depth limits from //codegen:maxdepth
+	benchTypes      map[types.Object]bool     // types marked //codegen:benchclone
 )
 
 func main() {
@@ -45,13 +107,43 @@ func main() {
 		log.Fatal(err)
 	}
 	it := codegen.NewImportTracker(pkg.Types)
+	orderedTypes = codegen.OrderedTypes(pkg.Syntax)
+	for name := range codegen.TypesWithDirective(pkg.Syntax, "//codegen:clonevalue") {
+		mak.Set(&cloneValueTypes, pkg.Types.Scope().Lookup(name), true)
+	}
+	for name := range codegen.TypesWithDirective(pkg.Syntax, "//codegen:pooled") {
+		mak.Set(&pooledTypes, pkg.Types.Scope().Lookup(name), true)
+	}
+	for name, globs := range codegen.TypeDirectiveArgs(pkg.Syntax, "//codegen:cloneskip") {
+		for _, g := range globs {
+			if _, err := path.Match(g, ""); err != nil {
+				log.Fatalf("type %s: invalid //codegen:cloneskip pattern %q: %v", name, g, err)
+			}
+		}
+		mak.Set(&skipFields, pkg.Types.Scope().Lookup(name), globs)
+	}
+	for name, args := range codegen.TypeDirectiveArgs(pkg.Syntax, "//codegen:maxdepth") {
+		n, err := strconv.Atoi(args[0])
+		if len(args) != 1 || err != nil || n < 1 {
+			log.Fatalf("type %s: invalid //codegen:maxdepth %q: must be a positive integer", name, strings.Join(args, " "))
+		}
+		mak.Set(&maxDepths, pkg.Types.Scope().Lookup(name), n)
+	}
+	for name := range codegen.TypesWithDirective(pkg.Syntax, "//codegen:benchclone") {
+		mak.Set(&benchTypes, pkg.Types.Scope().Lookup(name), true)
+	}
 	buf := new(bytes.Buffer)
+	benchBuf := new(bytes.Buffer)
+	benchIt := codegen.NewImportTracker(pkg.Types)
 	for _, typeName := range typeNames {
 		typ, ok := namedTypes[typeName].(*types.Named)
 		if !ok {
 			log.Fatalf("could not find type %s", typeName)
 		}
 		gen(buf, it, typ)
+		if benchTypes[typ.Obj()] {
+			genBench(benchBuf, benchIt, typ)
+		}
 	}
 
 	w := func(format string, args ...any) {
@@ -66,11 +158,28 @@ func main() {
 		for _, typeName := range typeNames {
 			w("	case *%s:", typeName)
 			w("		switch dst := dst.(type) {")
+			if hasConcurrentMap(namedTypes[typeName]) {
+				// The value must not be copied, as it contains a
+				// concurrency-safe map.
+				w("		case **%s:", typeName)
+				w("			*dst = src.Clone()")
+				w("			return true")
+				w("		}")
+				continue
+			}
 			w("		case *%s:", typeName)
-			w("			*dst = *src.Clone()")
-			w("			return true")
-			w("		case **%s:", typeName)
-			w("			*dst = src.Clone()")
+			if named, _ := codegen.NamedTypeOf(namedTypes[typeName]); named != nil && cloneValueTypes[named.Obj()] {
+				it.Import("", "tailscale.com/types/ptr")
+				w("			*dst = src.Clone()")
+				w("			return true")
+				w("		case **%s:", typeName)
+				w("			*dst = ptr.To(src.Clone())")
+			} else {
+				w("			*dst = *src.Clone()")
+				w("			return true")
+				w("		case **%s:", typeName)
+				w("			*dst = src.Clone()")
+			}
 			w("			return true")
 			w("		}")
 		}
@@ -86,6 +195,12 @@ func main() {
 	if err := codegen.WritePackageFile("tailscale.com/cmd/cloner", pkg, cloneOutput, it, buf); err != nil {
 		log.Fatal(err)
 	}
+	if benchBuf.Len() > 0 {
+		benchOutput := pkg.Name + "_clone_bench_test.go"
+		if err := codegen.WritePackageFile("tailscale.com/cmd/cloner", pkg, benchOutput, benchIt, benchBuf); err != nil {
+			log.Fatal(err)
+		}
+	}
 }
 
 func gen(buf *bytes.Buffer, it *codegen.ImportTracker, typ *types.Named) {
@@ -98,20 +213,104 @@ func gen(buf *bytes.Buffer, it *codegen.ImportTracker, typ *types.Named) {
 	typeParams := typ.Origin().TypeParams()
 	_, typeParamNames := codegen.FormatTypeParams(typeParams, it)
 	nameWithParams := name + typeParamNames
+	maxDepth := maxDepths[typ.Origin().Obj()]
+	depthTracked := maxDepth > 0
 	fmt.Fprintf(buf, "// Clone makes a deep copy of %s.\n", name)
-	fmt.Fprintf(buf, "// The result aliases no memory with the original.\n")
-	fmt.Fprintf(buf, "func (src *%s) Clone() *%s {\n", nameWithParams, nameWithParams)
+	if depthTracked {
+		fmt.Fprintf(buf, "// The result aliases no memory with the original, except for the memory\n")
+		fmt.Fprintf(buf, "// referenced by values nested %d or more levels deep, which are copied\n", maxDepth)
+		fmt.Fprintf(buf, "// shallowly.\n")
+	} else {
+		fmt.Fprintf(buf, "// The result aliases no memory with the original.\n")
+	}
 	writef := func(format string, args ...any) {
 		fmt.Fprintf(buf, "\t"+format+"\n", args...)
 	}
-	writef("if src == nil {")
-	writef("\treturn nil")
-	writef("}")
-	writef("dst := new(%s)", nameWithParams)
-	writef("*dst = *src")
+	// clone returns the method call that clones src, a value of type ft,
+	// which is cloneDepth for types with a //codegen:maxdepth directive when
+	// generating cloneDepth itself.
+	clone := func(ft types.Type) string {
+		if named, _ := codegen.NamedTypeOf(ft); depthTracked && named != nil && maxDepths[named.Origin().Obj()] > 0 {
+			return "cloneDepth(depth + 1)"
+		}
+		return "Clone()"
+	}
+	cloneName, cloneParams := "Clone", ""
+	if depthTracked {
+		cloneName, cloneParams = "cloneDepth", "depth int"
+		result := "*" + nameWithParams
+		if cloneValueTypes[typ.Origin().Obj()] {
+			result = nameWithParams
+		}
+		fmt.Fprintf(buf, "func (src *%s) Clone() %s {\n", nameWithParams, result)
+		writef("return src.cloneDepth(0)")
+		fmt.Fprintf(buf, "}\n\n")
+		fmt.Fprintf(buf, "// cloneDepth clones src, which is nested depth levels below the receiver\n")
+		fmt.Fprintf(buf, "// of Clone. At a depth of %d or more, it returns a shallow copy of src.\n", maxDepth)
+	}
+	// Concurrency-safe maps must not be copied as values, so types with
+	// fields of such types are copied field by field.
+	var concurrentMaps []int
+	for i := range t.NumFields() {
+		if codegen.IsConcurrentMap(t.Field(i).Type()) {
+			concurrentMaps = append(concurrentMaps, i)
+		}
+	}
+	copyFields := func() {
+		for i := range t.NumFields() {
+			if fname := t.Field(i).Name(); fname != "_" && !codegen.IsConcurrentMap(t.Field(i).Type()) {
+				writef("dst.%s = src.%s", fname, fname)
+			}
+		}
+	}
+	if cloneValueTypes[typ.Origin().Obj()] {
+		// Types marked //codegen:clonevalue return a value. The receiver
+		// remains a pointer so that Clone can be called on nil fields.
+		fmt.Fprintf(buf, "func (src *%s) %s(%s) %s {\n", nameWithParams, cloneName, cloneParams, nameWithParams)
+		writef("if src == nil {")
+		writef("\treturn %s{}", nameWithParams)
+		writef("}")
+		if len(concurrentMaps) > 0 {
+			writef("var dst %s", nameWithParams)
+			copyFields()
+		} else {
+			writef("dst := *src")
+		}
+	} else {
+		fmt.Fprintf(buf, "func (src *%s) %s(%s) *%s {\n", nameWithParams, cloneName, cloneParams, nameWithParams)
+		writef("if src == nil {")
+		writef("\treturn nil")
+		writef("}")
+		writef("dst := new(%s)", nameWithParams)
+		if len(concurrentMaps) > 0 {
+			copyFields()
+		} else {
+			writef("*dst = *src")
+		}
+	}
+	for _, i := range concurrentMaps {
+		if fname := t.Field(i).Name(); !isSkipped(typ, fname) {
+			writeConcurrentMapCopy(writef, fname, t.Field(i).Type(), clone)
+		}
+	}
+	if depthTracked {
+		it.Import("", "log")
+		writef("if depth >= %d {", maxDepth)
+		writef("\tlog.Printf(\"%s.Clone: maximum depth of %d reached, copying shallowly\")", name, maxDepth)
+		writef("\treturn dst")
+		writef("}")
+	}
 	for i := range t.NumFields() {
 		fname := t.Field(i).Name()
 		ft := t.Field(i).Type()
+		if codegen.IsConcurrentMap(ft) {
+			// Copied above.
+			continue
+		}
+		if isSkipped(typ, fname) {
+			writef("dst.%s = %s", fname, zeroValue(it, ft))
+			continue
+		}
 		if !codegen.ContainsPointers(ft) || codegen.HasNoClone(t.Tag(i)) {
 			continue
 		}
@@ -124,8 +323,10 @@ func gen(buf *bytes.Buffer, it *codegen.ImportTracker, typ *types.Named) {
 				// don't dereference if the underlying type is an interface
 				if _, isInterface := ft.Underlying().(*types.Interface); isInterface {
 					writef("if src.%s != nil { dst.%s = src.%s.Clone() }", fname, fname, fname)
+				} else if cloneReturnsValue(ft) {
+					writef("dst.%s = src.%s.%s", fname, fname, clone(ft))
 				} else {
-					writef("dst.%s = *src.%s.Clone()", fname, fname)
+					writef("dst.%s = *src.%s.%s", fname, fname, clone(ft))
 				}
 				continue
 			}
@@ -143,20 +344,26 @@ func gen(buf *bytes.Buffer, it *codegen.ImportTracker, typ *types.Named) {
 						if _, isIface := ptr.Elem().Underlying().(*types.Interface); isIface {
 							it.Import("", "tailscale.com/types/ptr")
 							writef("\tdst.%s[i] = ptr.To((*src.%s[i]).Clone())", fname, fname)
+						} else if cloneReturnsValue(ptr.Elem()) {
+							it.Import("", "tailscale.com/types/ptr")
+							writef("\tdst.%s[i] = ptr.To(src.%s[i].%s)", fname, fname, clone(ptr.Elem()))
 						} else {
-							writef("\tdst.%s[i] = src.%s[i].Clone()", fname, fname)
+							writef("\tdst.%s[i] = src.%s[i].%s", fname, fname, clone(ptr.Elem()))
 						}
 					} else {
 						it.Import("", "tailscale.com/types/ptr")
 						writef("\tdst.%s[i] = ptr.To(*src.%s[i])", fname, fname)
 					}
 					writef("}")
+				} else if isEmptyInterface(ft.Elem()) {
+					it.Import("", "tailscale.com/util/jsonclone")
+					writef("\tdst.%s[i] = jsonclone.Value(src.%s[i])", fname, fname)
 				} else if ft.Elem().String() == "encoding/json.RawMessage" {
 					writef("\tdst.%s[i] = append(src.%s[i][:0:0], src.%s[i]...)", fname, fname, fname)
-				} else if _, isIface := ft.Elem().Underlying().(*types.Interface); isIface {
-					writef("\tdst.%s[i] = src.%s[i].Clone()", fname, fname)
+				} else if _, isIface := ft.Elem().Underlying().(*types.Interface); isIface || cloneReturnsValue(ft.Elem()) {
+					writef("\tdst.%s[i] = src.%s[i].%s", fname, fname, clone(ft.Elem()))
 				} else {
-					writef("\tdst.%s[i] = *src.%s[i].Clone()", fname, fname)
+					writef("\tdst.%s[i] = *src.%s[i].%s", fname, fname, clone(ft.Elem()))
 				}
 				writef("}")
 				writef("}")
@@ -167,7 +374,14 @@ func gen(buf *bytes.Buffer, it *codegen.ImportTracker, typ *types.Named) {
 			base := ft.Elem()
 			hasPtrs := codegen.ContainsPointers(base)
 			if named, _ := codegen.NamedTypeOf(base); named != nil && hasPtrs {
-				writef("dst.%s = src.%s.Clone()", fname, fname)
+				if cloneReturnsValue(named) {
+					it.Import("", "tailscale.com/types/ptr")
+					writef("if dst.%s != nil {", fname)
+					writef("\tdst.%s = ptr.To(src.%s.%s)", fname, fname, clone(named))
+					writef("}")
+				} else {
+					writef("dst.%s = src.%s.%s", fname, fname, clone(named))
+				}
 				continue
 			}
 			it.Import("", "tailscale.com/types/ptr")
@@ -217,11 +431,17 @@ func gen(buf *bytes.Buffer, it *codegen.ImportTracker, typ *types.Named) {
 					DstExpr:    fmt.Sprintf("dst.%s[k]", fname),
 					BaseIndent: "\t",
 					Depth:      1,
+					Clone:      clone,
 				})
 				writef("\t}")
 				writef("}")
 			}
 		case *types.Interface:
+			if isEmptyInterface(t.Field(i).Type()) {
+				it.Import("", "tailscale.com/util/jsonclone")
+				writef("dst.%s = jsonclone.Value(src.%s)", fname, fname)
+				continue
+			}
 			// If ft is an interface with a "Clone() ft" method, it can be used to clone the field.
 			// This includes scenarios where ft is a constrained type parameter.
 			if cloneResultType := methodResultType(ft, "Clone"); cloneResultType.Underlying() == ft {
@@ -236,10 +456,275 @@ func gen(buf *bytes.Buffer, it *codegen.ImportTracker, typ *types.Named) {
 	writef("return dst")
 	fmt.Fprintf(buf, "}\n\n")
 
-	buf.Write(codegen.AssertStructUnchanged(t, name, typeParams, "Clone", it))
+	assertUnchanged := codegen.AssertStructUnchanged
+	if orderedTypes[name] {
+		assertUnchanged = codegen.AssertStructUnchangedOrdered
+	}
+	buf.Write(assertUnchanged(t, name, typeParams, "Clone", it))
+
+	if pooledTypes[typ.Origin().Obj()] {
+		genPool(buf, it, typ)
+	}
+}
+
+// hasConcurrentMap reports whether typ is a struct with a field of a
+// concurrency-safe map type.
+func hasConcurrentMap(typ types.Type) bool {
+	t, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+	for i := range t.NumFields() {
+		if codegen.IsConcurrentMap(t.Field(i).Type()) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeConcurrentMapCopy writes the code that copies the entries of the
+// field fname of src, of the concurrency-safe map type ft, to dst. The values
+// of a syncs.Map are cloned with clone if they are pointers to named types.
+func writeConcurrentMapCopy(writef func(format string, args ...any), fname string, ft types.Type, clone func(types.Type) string) {
+	named, _ := codegen.NamedTypeOf(ft)
+	if named.Obj().Pkg().Path() == "sync" {
+		writef("src.%s.Range(func(k, v any) bool {", fname)
+		writef("\tdst.%s.Store(k, v)", fname)
+		writef("\treturn true")
+		writef("})")
+		return
+	}
+	v := "v"
+	elem := named.TypeArgs().At(1)
+	if codegen.ContainsPointers(elem) && !codegen.IsViewType(elem) {
+		p, isPtr := elem.(*types.Pointer)
+		if !isPtr || cloneReturnsValue(p.Elem()) {
+			writef(`panic("TODO: %s (%v)")`, fname, ft)
+			return
+		}
+		if base, _ := codegen.NamedTypeOf(p.Elem()); base == nil {
+			writef(`panic("TODO: %s (%v)")`, fname, ft)
+			return
+		}
+		v = "v." + clone(p.Elem())
+	}
+	writef("for k, v := range src.%s.All() {", fname)
+	writef("\tdst.%s.Store(k, %s)", fname, v)
+	writef("}")
+}
+
+// genPool writes the sync.Pool-backed GetT and PutT funcs for typ, which is
+// marked //codegen:pooled.
+func genPool(buf *bytes.Buffer, it *codegen.ImportTracker, typ *types.Named) {
+	name := typ.Obj().Name()
+	if tp := typ.Origin().TypeParams(); tp != nil && tp.Len() > 0 {
+		log.Fatalf("type %s: //codegen:pooled is not supported for generic types", name)
+	}
+	it.Import("", "sync")
+	w := func(format string, args ...any) {
+		fmt.Fprintf(buf, format+"\n", args...)
+	}
+	w("")
+	w("var pool%s = sync.Pool{New: func() any { return new(%s) }}", name, name)
+	w("")
+	w("// Get%s returns a zero %s from a pool.", name, name)
+	w("// Return it with Put%s once it is no longer used.", name)
+	w("func Get%s() *%s {", name, name)
+	w("	return pool%s.Get().(*%s)", name, name)
+	w("}")
+	w("")
+	w("// Put%s zeroes v, so that it retains no memory it references, and returns", name)
+	w("// it to the pool used by Get%s. v must not be used after the call.", name)
+	w("func Put%s(v *%s) {", name, name)
+	w("	if v == nil {")
+	w("		return")
+	w("	}")
+	w("	*v = %s{}", name)
+	w("	pool%s.Put(v)", name)
+	w("}")
+}
+
+// maxSampleNesting is how many times sampleValue nests a type in itself.
+const maxSampleNesting = 2
+
+// genBench writes a BenchmarkTClone func for typ, which is marked
+// //codegen:benchclone, that clones a representative value of typ.
+func genBench(buf *bytes.Buffer, it *codegen.ImportTracker, typ *types.Named) {
+	name := typ.Obj().Name()
+	if tp := typ.Origin().TypeParams(); tp != nil && tp.Len() > 0 {
+		log.Fatalf("type %s: //codegen:benchclone is not supported for generic types", name)
+	}
+	if _, ok := typ.Underlying().(*types.Struct); !ok {
+		return
+	}
+	it.Import("", "testing")
+	src := sampleValue(it, typ.Obj().Pkg(), typ, nil)
+	if src == "" {
+		src = name + "{}"
+	}
+	w := func(format string, args ...any) {
+		fmt.Fprintf(buf, format+"\n", args...)
+	}
+	w("func Benchmark%sClone(b *testing.B) {", name)
+	w("	src := &%s", src)
+	w("	b.ReportAllocs()")
+	w("	for b.Loop() {")
+	w("		src.Clone()")
+	w("	}")
+	w("}")
+	w("")
+}
+
+// sampleValue returns an expression for a representative, non-zero value of
+// typ, for use in benchmarks. It returns the empty string if it cannot
+// construct one, such as for channels, funcs and non-empty interfaces other
+// than error, in which case the zero value should be used. Unexported fields
+// are only set for types in pkg, the package being generated. The stack holds
+// the named types whose values are being constructed, to bound the nesting of
+// self-referential types.
+func sampleValue(it *codegen.ImportTracker, pkg *types.Package, typ types.Type, stack []*types.Named) string {
+	if types.Identical(typ, types.Universe.Lookup("error").Type()) {
+		it.Import("", "errors")
+		return `errors.New("error")`
+	}
+	if isEmptyInterface(typ) {
+		return `map[string]any{"key": "value"}`
+	}
+	named, isNamed := codegen.NamedTypeOf(typ)
+	if isNamed {
+		if codegen.IsViewType(typ) {
+			return ""
+		}
+		n := 0
+		for _, t := range stack {
+			if t == named {
+				n++
+			}
+		}
+		if n >= maxSampleNesting {
+			return ""
+		}
+		stack = append(stack, named)
+	}
+	switch u := typ.Underlying().(type) {
+	case *types.Basic:
+		var lit string
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			lit = "true"
+		case u.Info()&types.IsString != 0:
+			lit = `"value"`
+		case u.Info()&types.IsNumeric != 0:
+			lit = "1"
+		default:
+			return ""
+		}
+		// Untyped constants default to these types; others need a
+		// conversion.
+		switch types.Unalias(typ) {
+		case types.Typ[types.Bool], types.Typ[types.String], types.Typ[types.Int]:
+			return lit
+		}
+		return it.QualifiedName(typ) + "(" + lit + ")"
+	case *types.Pointer:
+		elem := sampleValue(it, pkg, u.Elem(), stack)
+		if elem == "" {
+			return ""
+		}
+		if _, isStruct := u.Elem().Underlying().(*types.Struct); isStruct {
+			return "&" + elem
+		}
+		it.Import("", "tailscale.com/types/ptr")
+		return "ptr.To(" + elem + ")"
+	case *types.Slice:
+		elem := sampleValue(it, pkg, u.Elem(), stack)
+		if elem == "" {
+			return ""
+		}
+		return it.QualifiedName(typ) + "{" + elideType(it, u.Elem(), elem) + "}"
+	case *types.Array:
+		elem := sampleValue(it, pkg, u.Elem(), stack)
+		if elem == "" {
+			return ""
+		}
+		return it.QualifiedName(typ) + "{" + elideType(it, u.Elem(), elem) + "}"
+	case *types.Map:
+		k, v := sampleValue(it, pkg, u.Key(), stack), sampleValue(it, pkg, u.Elem(), stack)
+		if k == "" || v == "" {
+			return ""
+		}
+		return it.QualifiedName(typ) + "{" + elideType(it, u.Key(), k) + ": " + elideType(it, u.Elem(), v) + "}"
+	case *types.Struct:
+		var fields []string
+		for i := range u.NumFields() {
+			f := u.Field(i)
+			if !f.Exported() && f.Pkg() != pkg {
+				continue
+			}
+			if v := sampleValue(it, pkg, f.Type(), stack); v != "" {
+				fields = append(fields, f.Name()+": "+v+",\n")
+			}
+		}
+		if len(fields) == 0 {
+			return ""
+		}
+		return it.QualifiedName(typ) + "{\n" + strings.Join(fields, "") + "}"
+	}
+	return ""
+}
+
+// elideType returns expr, a composite literal of type typ as returned by
+// sampleValue, without its type, as allowed for the elements and keys of a
+// composite literal. Other expressions are returned unchanged.
+func elideType(it *codegen.ImportTracker, typ types.Type, expr string) string {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		if rest, ok := strings.CutPrefix(expr, "&"+it.QualifiedName(ptr.Elem())+"{"); ok {
+			return "{" + rest
+		}
+		return expr
+	}
+	if rest, ok := strings.CutPrefix(expr, it.QualifiedName(typ)+"{"); ok {
+		return "{" + rest
+	}
+	return expr
 }
 
 // hasBasicUnderlying reports true when typ.Underlying() is a slice or a map.
+// isSkipped reports whether the field fname of typ matches one of the globs in
+// typ's //codegen:cloneskip directive.
+func isSkipped(typ *types.Named, fname string) bool {
+	for _, g := range skipFields[typ.Origin().Obj()] {
+		if ok, _ := path.Match(g, fname); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// zeroValue returns an expression for the zero value of typ.
+func zeroValue(it *codegen.ImportTracker, typ types.Type) string {
+	switch u := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false"
+		case u.Info()&types.IsString != 0:
+			return `""`
+		case u.Info()&types.IsNumeric != 0:
+			return "0"
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature:
+		return "nil"
+	case *types.Interface:
+		if _, isTypeParam := typ.(*types.TypeParam); !isTypeParam {
+			return "nil"
+		}
+	case *types.Struct, *types.Array:
+		return it.QualifiedName(typ) + "{}"
+	}
+	return "*new(" + it.QualifiedName(typ) + ")"
+}
+
 func hasBasicUnderlying(typ types.Type) bool {
 	switch typ.Underlying().(type) {
 	case *types.Slice, *types.Map:
@@ -249,6 +734,14 @@ func hasBasicUnderlying(typ types.Type) bool {
 	}
 }
 
+// isEmptyInterface reports whether typ is any (or interface{}). Such values
+// are assumed to hold arbitrary JSON and are cloned with jsonclone.Value.
+// Type parameters and named interface types are not included.
+func isEmptyInterface(typ types.Type) bool {
+	iface, ok := types.Unalias(typ).(*types.Interface)
+	return ok && iface.Empty()
+}
+
 func methodResultType(typ types.Type, method string) types.Type {
 	viewMethod := codegen.LookupMethod(typ, method)
 	if viewMethod == nil {
@@ -278,6 +771,9 @@ type mapValueCloneParams struct {
 	BaseIndent string
 	// Depth is the current nesting depth (1 for first level, 2 for second, etc.)
 	Depth int
+	// Clone returns the method call that clones a value of the provided
+	// type, such as "Clone()".
+	Clone func(types.Type) string
 }
 
 // writeMapValueClone generates code to clone a map value recursively.
@@ -295,8 +791,11 @@ func writeMapValueClone(params mapValueCloneParams) {
 			if _, isIface := base.(*types.Interface); isIface {
 				params.It.Import("", "tailscale.com/types/ptr")
 				writef("\t%s = ptr.To((*%s).Clone())", params.DstExpr, params.SrcExpr)
+			} else if cloneReturnsValue(elem.Elem()) {
+				params.It.Import("", "tailscale.com/types/ptr")
+				writef("\t%s = ptr.To(%s.%s)", params.DstExpr, params.SrcExpr, params.Clone(elem.Elem()))
 			} else {
-				writef("\t%s = %s.Clone()", params.DstExpr, params.SrcExpr)
+				writef("\t%s = %s.%s", params.DstExpr, params.SrcExpr, params.Clone(elem.Elem()))
 			}
 		} else {
 			params.It.Import("", "tailscale.com/types/ptr")
@@ -336,13 +835,17 @@ func writeMapValueClone(params mapValueCloneParams) {
 				DstExpr:    nestedDstExpr,
 				BaseIndent: params.BaseIndent,
 				Depth:      params.Depth + 1,
+				Clone:      params.Clone,
 			})
 
 			writef("}")
 		}
 
 	case *types.Interface:
-		if cloneResultType := methodResultType(elem, "Clone"); cloneResultType != nil {
+		if isEmptyInterface(params.Elem) {
+			params.It.Import("", "tailscale.com/util/jsonclone")
+			writef("%s = jsonclone.Value(%s)", params.DstExpr, params.SrcExpr)
+		} else if cloneResultType := methodResultType(elem, "Clone"); cloneResultType != nil {
 			if _, isPtr := cloneResultType.(*types.Pointer); isPtr {
 				writef("%s = *(%s.Clone())", params.DstExpr, params.SrcExpr)
 			} else {
@@ -353,6 +856,32 @@ func writeMapValueClone(params mapValueCloneParams) {
 		}
 
 	default:
-		writef("%s = *(%s.Clone())", params.DstExpr, params.SrcExpr)
+		if cloneReturnsValue(params.Elem) {
+			writef("%s = %s.%s", params.DstExpr, params.SrcExpr, params.Clone(params.Elem))
+		} else {
+			writef("%s = *(%s.%s)", params.DstExpr, params.SrcExpr, params.Clone(params.Elem))
+		}
+	}
+}
+
+// cloneReturnsValue reports whether the Clone method of typ returns a value
+// rather than a pointer, either because typ is marked //codegen:clonevalue in
+// the package being generated or because its existing Clone method does so.
+func cloneReturnsValue(typ types.Type) bool {
+	named, ok := codegen.NamedTypeOf(typ)
+	if !ok {
+		return false
+	}
+	if _, isIface := named.Underlying().(*types.Interface); isIface {
+		return false
+	}
+	if cloneValueTypes[named.Origin().Obj()] {
+		return true
+	}
+	res := methodResultType(named, "Clone")
+	if res == nil {
+		return false
 	}
+	_, isPtr := res.(*types.Pointer)
+	return !isPtr
 }
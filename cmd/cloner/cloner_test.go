@@ -4,10 +4,30 @@
 package main
 
 import (
+	"bytes"
+	"errors"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"maps"
+	"net/netip"
+	"os"
+	"os/exec"
 	"reflect"
+	"regexp"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"tailscale.com/cmd/cloner/clonerex"
+	"tailscale.com/types/ptr"
+	"tailscale.com/util/codegen"
+	"tailscale.com/util/mak"
 )
 
 func TestSliceContainer(t *testing.T) {
@@ -214,3 +234,869 @@ func TestDeeplyNestedMap(t *testing.T) {
 		t.Errorf("Clone() aliased FourLevels map: new nested key appeared in original")
 	}
 }
+
+func TestErrorContainer(t *testing.T) {
+	errFoo := errors.New("foo")
+	orig := &clonerex.ErrorContainer{
+		Err:   errFoo,
+		Errs:  []error{errFoo, errors.New("bar")},
+		Other: &clonerex.CloneableImpl{Value: 42},
+	}
+
+	cloned := orig.Clone()
+	if !reflect.DeepEqual(orig, cloned) {
+		t.Errorf("Clone() = %v, want %v", cloned, orig)
+	}
+
+	// Errors are copied by reference.
+	if cloned.Err != errFoo || cloned.Errs[0] != errFoo {
+		t.Errorf("Clone() did not copy errors by reference")
+	}
+
+	// The slice holding the errors must not be aliased.
+	cloned.Errs[1] = errors.New("baz")
+	if orig.Errs[1].Error() != "bar" {
+		t.Errorf("Clone() aliased memory in Errs: original was modified")
+	}
+
+	// Other interfaces are still cloned.
+	cloned.Other.(*clonerex.CloneableImpl).Value = 999
+	if orig.Other.(*clonerex.CloneableImpl).Value == 999 {
+		t.Errorf("Clone() aliased memory in Other: original was modified")
+	}
+}
+
+func TestGenErrorFields(t *testing.T) {
+	pkg, namedTypes, err := codegen.LoadTypes("", "./clonerex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ, ok := namedTypes["ErrorContainer"].(*types.Named)
+	if !ok {
+		t.Fatal("could not find type ErrorContainer")
+	}
+	buf := bytes.NewBufferString("package clonerex\n\n")
+	gen(buf, codegen.NewImportTracker(pkg.Types), typ)
+	got, err := format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, buf.Bytes())
+	}
+
+	const want = `package clonerex
+
+// Clone makes a deep copy of ErrorContainer.
+// The result aliases no memory with the original.
+func (src *ErrorContainer) Clone() *ErrorContainer {
+	if src == nil {
+		return nil
+	}
+	dst := new(ErrorContainer)
+	*dst = *src
+	dst.Errs = append(src.Errs[:0:0], src.Errs...)
+	if src.Other != nil {
+		dst.Other = src.Other.Clone()
+	}
+	return dst
+}
+
+// A compilation failure here means this code must be regenerated, with the command at the top of this file.
+var _ErrorContainerCloneNeedsRegeneration = ErrorContainer(struct {
+	Err   error
+	Errs  []error
+	Other Cloneable
+}{})
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("generated code mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestValueClonedContainer(t *testing.T) {
+	orig := &clonerex.ValueClonedContainer{
+		Value:    clonerex.ValueCloned{Names: []string{"value"}},
+		Ptr:      &clonerex.ValueCloned{Names: []string{"ptr"}},
+		Slice:    []clonerex.ValueCloned{{Names: []string{"slice"}}},
+		PtrSlice: []*clonerex.ValueCloned{{Names: []string{"ptrslice"}}, nil},
+		Map:      map[string]clonerex.ValueCloned{"k": {Names: []string{"map"}}},
+	}
+
+	cloned := orig.Clone()
+	if !reflect.DeepEqual(orig, cloned) {
+		t.Errorf("Clone() = %v, want %v", cloned, orig)
+	}
+
+	cloned.Value.Names[0] = "changed"
+	cloned.Ptr.Names[0] = "changed"
+	cloned.Slice[0].Names[0] = "changed"
+	cloned.PtrSlice[0].Names[0] = "changed"
+	cloned.Map["k"].Names[0] = "changed"
+	want := &clonerex.ValueClonedContainer{
+		Value:    clonerex.ValueCloned{Names: []string{"value"}},
+		Ptr:      &clonerex.ValueCloned{Names: []string{"ptr"}},
+		Slice:    []clonerex.ValueCloned{{Names: []string{"slice"}}},
+		PtrSlice: []*clonerex.ValueCloned{{Names: []string{"ptrslice"}}, nil},
+		Map:      map[string]clonerex.ValueCloned{"k": {Names: []string{"map"}}},
+	}
+	if diff := cmp.Diff(want, orig); diff != "" {
+		t.Errorf("Clone() aliased memory, original was modified (-want +got):\n%s", diff)
+	}
+
+	var nilValue *clonerex.ValueCloned
+	if got := nilValue.Clone(); !reflect.DeepEqual(got, clonerex.ValueCloned{}) {
+		t.Errorf("Clone() of nil = %v, want zero value", got)
+	}
+}
+
+func TestJSONContainer(t *testing.T) {
+	orig := &clonerex.JSONContainer{
+		Value:  map[string]any{"nested": []any{"a", 1.0}},
+		Object: map[string]any{"k": map[string]any{"v": true}, "null": nil},
+		Array:  []any{[]any{"x"}, "y"},
+	}
+
+	cloned := orig.Clone()
+	if !reflect.DeepEqual(orig, cloned) {
+		t.Errorf("Clone() = %v, want %v", cloned, orig)
+	}
+
+	cloned.Value.(map[string]any)["nested"].([]any)[0] = "changed"
+	cloned.Object["k"].(map[string]any)["v"] = false
+	cloned.Array[0].([]any)[0] = "changed"
+	want := &clonerex.JSONContainer{
+		Value:  map[string]any{"nested": []any{"a", 1.0}},
+		Object: map[string]any{"k": map[string]any{"v": true}, "null": nil},
+		Array:  []any{[]any{"x"}, "y"},
+	}
+	if diff := cmp.Diff(want, orig); diff != "" {
+		t.Errorf("Clone() aliased memory, original was modified (-want +got):\n%s", diff)
+	}
+}
+
+func TestGenJSONFields(t *testing.T) {
+	pkg, namedTypes, err := codegen.LoadTypes("", "./clonerex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ, ok := namedTypes["JSONContainer"].(*types.Named)
+	if !ok {
+		t.Fatal("could not find type JSONContainer")
+	}
+	buf := bytes.NewBufferString("package clonerex\n\n")
+	gen(buf, codegen.NewImportTracker(pkg.Types), typ)
+	got, err := format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, buf.Bytes())
+	}
+
+	const want = `package clonerex
+
+// Clone makes a deep copy of JSONContainer.
+// The result aliases no memory with the original.
+func (src *JSONContainer) Clone() *JSONContainer {
+	if src == nil {
+		return nil
+	}
+	dst := new(JSONContainer)
+	*dst = *src
+	dst.Value = jsonclone.Value(src.Value)
+	if dst.Object != nil {
+		dst.Object = map[string]any{}
+		for k, v := range src.Object {
+			dst.Object[k] = jsonclone.Value(v)
+		}
+	}
+	if src.Array != nil {
+		dst.Array = make([]any, len(src.Array))
+		for i := range dst.Array {
+			dst.Array[i] = jsonclone.Value(src.Array[i])
+		}
+	}
+	return dst
+}
+
+// A compilation failure here means this code must be regenerated, with the command at the top of this file.
+var _JSONContainerCloneNeedsRegeneration = JSONContainer(struct {
+	Value  any
+	Object map[string]any
+	Array  []any
+}{})
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("generated code mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGenCloneValue(t *testing.T) {
+	pkg, namedTypes, err := codegen.LoadTypes("", "./clonerex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name := range codegen.TypesWithDirective(pkg.Syntax, "//codegen:clonevalue") {
+		mak.Set(&cloneValueTypes, pkg.Types.Scope().Lookup(name), true)
+	}
+	t.Cleanup(func() { cloneValueTypes = nil })
+
+	tests := []struct {
+		typ  string
+		want string
+	}{
+		{
+			typ: "ValueCloned",
+			want: `package clonerex
+
+// Clone makes a deep copy of ValueCloned.
+// The result aliases no memory with the original.
+func (src *ValueCloned) Clone() ValueCloned {
+	if src == nil {
+		return ValueCloned{}
+	}
+	dst := *src
+	dst.Names = append(src.Names[:0:0], src.Names...)
+	return dst
+}
+
+// A compilation failure here means this code must be regenerated, with the command at the top of this file.
+var _ValueClonedCloneNeedsRegeneration = ValueCloned(struct {
+	Names []string
+}{})
+`,
+		},
+		{
+			typ: "ValueClonedContainer",
+			want: `package clonerex
+
+// Clone makes a deep copy of ValueClonedContainer.
+// The result aliases no memory with the original.
+func (src *ValueClonedContainer) Clone() *ValueClonedContainer {
+	if src == nil {
+		return nil
+	}
+	dst := new(ValueClonedContainer)
+	*dst = *src
+	dst.Value = src.Value.Clone()
+	if dst.Ptr != nil {
+		dst.Ptr = ptr.To(src.Ptr.Clone())
+	}
+	if src.Slice != nil {
+		dst.Slice = make([]ValueCloned, len(src.Slice))
+		for i := range dst.Slice {
+			dst.Slice[i] = src.Slice[i].Clone()
+		}
+	}
+	if src.PtrSlice != nil {
+		dst.PtrSlice = make([]*ValueCloned, len(src.PtrSlice))
+		for i := range dst.PtrSlice {
+			if src.PtrSlice[i] == nil {
+				dst.PtrSlice[i] = nil
+			} else {
+				dst.PtrSlice[i] = ptr.To(src.PtrSlice[i].Clone())
+			}
+		}
+	}
+	if dst.Map != nil {
+		dst.Map = map[string]ValueCloned{}
+		for k, v := range src.Map {
+			dst.Map[k] = v.Clone()
+		}
+	}
+	return dst
+}
+
+// A compilation failure here means this code must be regenerated, with the command at the top of this file.
+var _ValueClonedContainerCloneNeedsRegeneration = ValueClonedContainer(struct {
+	Value    ValueCloned
+	Ptr      *ValueCloned
+	Slice    []ValueCloned
+	PtrSlice []*ValueCloned
+	Map      map[string]ValueCloned
+}{})
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			typ, ok := namedTypes[tt.typ].(*types.Named)
+			if !ok {
+				t.Fatalf("could not find type %s", tt.typ)
+			}
+			buf := bytes.NewBufferString("package clonerex\n\n")
+			gen(buf, codegen.NewImportTracker(pkg.Types), typ)
+			got, err := format.Source(buf.Bytes())
+			if err != nil {
+				t.Fatalf("generated code does not parse: %v\n%s", err, buf.Bytes())
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("generated code mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPooled(t *testing.T) {
+	v := clonerex.GetPooled()
+	if !reflect.DeepEqual(*v, clonerex.Pooled{}) {
+		t.Fatalf("GetPooled() = %+v, want zero value", *v)
+	}
+	v.ID = 1
+	v.Buf = []byte("data")
+	v.Attrs = map[string]string{"k": "v"}
+	v.Parent = &clonerex.SliceContainer{}
+
+	// PutPooled must zero the value so that the pool does not retain the
+	// memory it referenced. Inspecting v after the call is only safe here
+	// because nothing else uses the pool.
+	clonerex.PutPooled(v)
+	if !reflect.DeepEqual(*v, clonerex.Pooled{}) {
+		t.Fatalf("after PutPooled, value = %+v, want zero value", *v)
+	}
+
+	// The pool may drop values at any time, in particular with the race
+	// detector enabled, so only require that a value is reused eventually.
+	reused := false
+	for range 100 {
+		got := clonerex.GetPooled()
+		if !reflect.DeepEqual(*got, clonerex.Pooled{}) {
+			t.Fatalf("GetPooled() = %+v, want zero value", *got)
+		}
+		if got == v {
+			reused = true
+			break
+		}
+		got.Buf = []byte("more data")
+		clonerex.PutPooled(got)
+		v = got
+	}
+	if !reused {
+		t.Error("GetPooled never reused a value returned with PutPooled")
+	}
+
+	clonerex.PutPooled(nil) // must not panic
+}
+
+func TestTree(t *testing.T) {
+	leaf := &clonerex.Tree{Name: "leaf"}
+	orig := &clonerex.Tree{
+		Name: "root",
+		Left: &clonerex.Tree{
+			Name: "left",
+			Left: &clonerex.Tree{Name: "left-left"},
+		},
+		Children: []*clonerex.Tree{leaf, nil, {Name: "child", Children: []*clonerex.Tree{{Name: "grandchild"}}}},
+		Values:   []clonerex.Tree{{Name: "value", Left: &clonerex.Tree{Name: "value-left"}}},
+		ByName:   map[string]*clonerex.Tree{"leaf": leaf, "nil": nil},
+	}
+
+	cloned := orig.Clone()
+	if !reflect.DeepEqual(orig, cloned) {
+		t.Fatalf("Clone() = %+v, want %+v", cloned, orig)
+	}
+
+	// Mutate every level of the clone and check that the original is
+	// unaffected.
+	cloned.Left.Left.Name = "changed"
+	cloned.Children[0].Name = "changed"
+	cloned.Children[2].Children[0].Name = "changed"
+	cloned.Values[0].Left.Name = "changed"
+	cloned.ByName["leaf"].Name = "changed"
+	if orig.Left.Left.Name != "left-left" {
+		t.Errorf("Clone() aliased memory in Left.Left")
+	}
+	if leaf.Name != "leaf" {
+		t.Errorf("Clone() aliased memory in Children or ByName")
+	}
+	if orig.Children[2].Children[0].Name != "grandchild" {
+		t.Errorf("Clone() aliased memory in Children[2].Children")
+	}
+	if orig.Values[0].Left.Name != "value-left" {
+		t.Errorf("Clone() aliased memory in Values[0].Left")
+	}
+}
+
+func TestGenRecursive(t *testing.T) {
+	pkg, namedTypes, err := codegen.LoadTypes("", "./clonerex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ, ok := namedTypes["Tree"].(*types.Named)
+	if !ok {
+		t.Fatal("could not find type Tree")
+	}
+	buf := bytes.NewBufferString("package clonerex\n\n")
+	gen(buf, codegen.NewImportTracker(pkg.Types), typ)
+	got, err := format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, buf.Bytes())
+	}
+
+	// Fields of the type itself are cloned with a recursive call to Clone.
+	const want = `package clonerex
+
+// Clone makes a deep copy of Tree.
+// The result aliases no memory with the original.
+func (src *Tree) Clone() *Tree {
+	if src == nil {
+		return nil
+	}
+	dst := new(Tree)
+	*dst = *src
+	dst.Left = src.Left.Clone()
+	if src.Children != nil {
+		dst.Children = make([]*Tree, len(src.Children))
+		for i := range dst.Children {
+			if src.Children[i] == nil {
+				dst.Children[i] = nil
+			} else {
+				dst.Children[i] = src.Children[i].Clone()
+			}
+		}
+	}
+	if src.Values != nil {
+		dst.Values = make([]Tree, len(src.Values))
+		for i := range dst.Values {
+			dst.Values[i] = *src.Values[i].Clone()
+		}
+	}
+	if dst.ByName != nil {
+		dst.ByName = map[string]*Tree{}
+		for k, v := range src.ByName {
+			if v == nil {
+				dst.ByName[k] = nil
+			} else {
+				dst.ByName[k] = v.Clone()
+			}
+		}
+	}
+	return dst
+}
+
+// A compilation failure here means this code must be regenerated, with the command at the top of this file.
+var _TreeCloneNeedsRegeneration = Tree(struct {
+	Name     string
+	Left     *Tree
+	Children []*Tree
+	Values   []Tree
+	ByName   map[string]*Tree
+}{})
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("generated code mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCached(t *testing.T) {
+	orig := &clonerex.Cached{
+		Names:       []string{"a", "b"},
+		Ptr:         ptr.To(1),
+		LookupCache: map[string]*int{"a": ptr.To(2)},
+		HitsCache:   3,
+		StatsCache:  [2]int{4, 5},
+	}
+	cloned := orig.Clone()
+	want := &clonerex.Cached{
+		Names: []string{"a", "b"},
+		Ptr:   ptr.To(1),
+	}
+	if !reflect.DeepEqual(cloned, want) {
+		t.Fatalf("Clone() = %+v, want %+v", cloned, want)
+	}
+
+	cloned.Names[0] = "changed"
+	*cloned.Ptr = 100
+	if orig.Names[0] != "a" || *orig.Ptr != 1 {
+		t.Errorf("Clone() aliased memory with the original: %+v", orig)
+	}
+}
+
+func TestStdlibContainer(t *testing.T) {
+	orig := &clonerex.StdlibContainer{
+		Pattern:   regexp.MustCompile("^a+$"),
+		Location:  time.FixedZone("UTC+1", 60*60),
+		AddrPorts: []netip.AddrPort{netip.MustParseAddrPort("1.2.3.4:80")},
+	}
+	cloned := orig.Clone()
+	if !reflect.DeepEqual(cloned, orig) {
+		t.Fatalf("Clone() = %+v, want %+v", cloned, orig)
+	}
+
+	// Compiled regexps and locations are immutable, so they are shared.
+	if cloned.Pattern != orig.Pattern {
+		t.Errorf("Clone() copied the *regexp.Regexp rather than sharing it")
+	}
+	if cloned.Location != orig.Location {
+		t.Errorf("Clone() copied the *time.Location rather than sharing it")
+	}
+
+	cloned.AddrPorts[0] = netip.MustParseAddrPort("5.6.7.8:80")
+	if orig.AddrPorts[0] != netip.MustParseAddrPort("1.2.3.4:80") {
+		t.Errorf("Clone() aliased memory with the original: %+v", orig)
+	}
+}
+
+func TestGenCloneSkip(t *testing.T) {
+	pkg, namedTypes, err := codegen.LoadTypes("", "./clonerex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, globs := range codegen.TypeDirectiveArgs(pkg.Syntax, "//codegen:cloneskip") {
+		mak.Set(&skipFields, pkg.Types.Scope().Lookup(name), globs)
+	}
+	t.Cleanup(func() { skipFields = nil })
+
+	typ, ok := namedTypes["Cached"].(*types.Named)
+	if !ok {
+		t.Fatal("could not find type Cached")
+	}
+	buf := bytes.NewBufferString("package clonerex\n\n")
+	gen(buf, codegen.NewImportTracker(pkg.Types), typ)
+	got, err := format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, buf.Bytes())
+	}
+
+	// Fields matching the globs are zeroed, including those without
+	// pointers, while the others are deep-copied.
+	const want = `package clonerex
+
+// Clone makes a deep copy of Cached.
+// The result aliases no memory with the original.
+func (src *Cached) Clone() *Cached {
+	if src == nil {
+		return nil
+	}
+	dst := new(Cached)
+	*dst = *src
+	dst.Names = append(src.Names[:0:0], src.Names...)
+	if dst.Ptr != nil {
+		dst.Ptr = ptr.To(*src.Ptr)
+	}
+	dst.LookupCache = nil
+	dst.HitsCache = 0
+	dst.StatsCache = [2]int{}
+	dst.scratchBuf = nil
+	return dst
+}
+
+// A compilation failure here means this code must be regenerated, with the command at the top of this file.
+var _CachedCloneNeedsRegeneration = Cached(struct {
+	Names       []string
+	Ptr         *int
+	LookupCache map[string]*int
+	HitsCache   int
+	StatsCache  [2]int
+	scratchBuf  []byte
+}{})
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("generated code mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDeepTree(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	// A chain of 6 values, with value i nested i levels deep.
+	var chain []*clonerex.DeepTree
+	var next *clonerex.DeepTree
+	for i := 5; i >= 0; i-- {
+		next = &clonerex.DeepTree{Name: string(rune('a' + i)), Next: next}
+		chain = append([]*clonerex.DeepTree{next}, chain...)
+	}
+	orig := chain[0]
+	orig.Children = []*clonerex.DeepTree{{Name: "child", Tree: &clonerex.Tree{Name: "tree"}}}
+	cloned := orig.Clone()
+	if !reflect.DeepEqual(cloned, orig) {
+		t.Fatalf("Clone() = %+v, want %+v", cloned, orig)
+	}
+
+	// Values nested less than 3 levels deep are deep-copied, the value at
+	// depth 3 is copied shallowly, and deeper values are shared.
+	got := cloned
+	for i, want := range chain {
+		switch {
+		case i <= 3 && got == want:
+			t.Errorf("Clone() shared the value at depth %d with the original", i)
+		case i > 3 && got != want:
+			t.Errorf("Clone() copied the value at depth %d, want it shared with the original", i)
+		}
+		got = got.Next
+	}
+	if cloned.Children[0] == orig.Children[0] || cloned.Children[0].Tree == orig.Children[0].Tree {
+		t.Errorf("Clone() shared Children[0] with the original")
+	}
+	if !strings.Contains(logs.String(), "DeepTree.Clone: maximum depth of 3 reached") {
+		t.Errorf("Clone() logged %q, want a message about the maximum depth", logs.String())
+	}
+
+	// Values that are not nested as deep as the limit are deep-copied without
+	// logging.
+	logs.Reset()
+	shallow := &clonerex.DeepTree{Name: "a", ByName: map[string]*clonerex.DeepTree{"b": {Name: "b", Next: &clonerex.DeepTree{Name: "c"}}}}
+	cloned = shallow.Clone()
+	if !reflect.DeepEqual(cloned, shallow) {
+		t.Fatalf("Clone() = %+v, want %+v", cloned, shallow)
+	}
+	cloned.ByName["b"].Next.Name = "changed"
+	if shallow.ByName["b"].Next.Name != "c" {
+		t.Errorf("Clone() aliased memory in ByName[b].Next")
+	}
+	if logs.Len() > 0 {
+		t.Errorf("Clone() logged %q, want nothing", logs.String())
+	}
+}
+
+func TestGenMaxDepth(t *testing.T) {
+	pkg, namedTypes, err := codegen.LoadTypes("", "./clonerex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, args := range codegen.TypeDirectiveArgs(pkg.Syntax, "//codegen:maxdepth") {
+		if args[0] != "3" {
+			t.Fatalf("type %s has //codegen:maxdepth %q, want 3", name, args)
+		}
+		mak.Set(&maxDepths, pkg.Types.Scope().Lookup(name), 3)
+	}
+	t.Cleanup(func() { maxDepths = nil })
+
+	typ, ok := namedTypes["DeepTree"].(*types.Named)
+	if !ok {
+		t.Fatal("could not find type DeepTree")
+	}
+	buf := bytes.NewBufferString("package clonerex\n\n")
+	gen(buf, codegen.NewImportTracker(pkg.Types), typ)
+	got, err := format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, buf.Bytes())
+	}
+
+	// Clone calls cloneDepth, which stops at the maximum depth and passes
+	// the depth on to fields of the type itself, but not to those of other
+	// types.
+	const want = `package clonerex
+
+// Clone makes a deep copy of DeepTree.
+// The result aliases no memory with the original, except for the memory
+// referenced by values nested 3 or more levels deep, which are copied
+// shallowly.
+func (src *DeepTree) Clone() *DeepTree {
+	return src.cloneDepth(0)
+}
+
+// cloneDepth clones src, which is nested depth levels below the receiver
+// of Clone. At a depth of 3 or more, it returns a shallow copy of src.
+func (src *DeepTree) cloneDepth(depth int) *DeepTree {
+	if src == nil {
+		return nil
+	}
+	dst := new(DeepTree)
+	*dst = *src
+	if depth >= 3 {
+		log.Printf("DeepTree.Clone: maximum depth of 3 reached, copying shallowly")
+		return dst
+	}
+	dst.Next = src.Next.cloneDepth(depth + 1)
+	if src.Children != nil {
+		dst.Children = make([]*DeepTree, len(src.Children))
+		for i := range dst.Children {
+			if src.Children[i] == nil {
+				dst.Children[i] = nil
+			} else {
+				dst.Children[i] = src.Children[i].cloneDepth(depth + 1)
+			}
+		}
+	}
+	if src.Values != nil {
+		dst.Values = make([]DeepTree, len(src.Values))
+		for i := range dst.Values {
+			dst.Values[i] = *src.Values[i].cloneDepth(depth + 1)
+		}
+	}
+	if dst.ByName != nil {
+		dst.ByName = map[string]*DeepTree{}
+		for k, v := range src.ByName {
+			if v == nil {
+				dst.ByName[k] = nil
+			} else {
+				dst.ByName[k] = v.cloneDepth(depth + 1)
+			}
+		}
+	}
+	dst.Tree = src.Tree.Clone()
+	return dst
+}
+
+// A compilation failure here means this code must be regenerated, with the command at the top of this file.
+var _DeepTreeCloneNeedsRegeneration = DeepTree(struct {
+	Name     string
+	Next     *DeepTree
+	Children []*DeepTree
+	Values   []DeepTree
+	ByName   map[string]*DeepTree
+	Tree     *Tree
+}{})
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("generated code mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGenBench(t *testing.T) {
+	pkg, namedTypes, err := codegen.LoadTypes("", "./clonerex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ, ok := namedTypes["Tree"].(*types.Named)
+	if !ok {
+		t.Fatal("could not find type Tree")
+	}
+	buf := bytes.NewBufferString("package clonerex\n\n")
+	genBench(buf, codegen.NewImportTracker(pkg.Types), typ)
+	got, err := format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, buf.Bytes())
+	}
+
+	// Each field is set, and Tree is nested in itself once.
+	const want = `package clonerex
+
+func BenchmarkTreeClone(b *testing.B) {
+	src := &Tree{
+		Name: "value",
+		Left: &Tree{
+			Name: "value",
+		},
+		Children: []*Tree{{
+			Name: "value",
+		}},
+		Values: []Tree{{
+			Name: "value",
+		}},
+		ByName: map[string]*Tree{"value": {
+			Name: "value",
+		}},
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		src.Clone()
+	}
+}
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("generated code mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestBenchClone checks that the generated benchmarks of the types in
+// clonerex marked //codegen:benchclone compile and run.
+func TestBenchClone(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "clonerex/clonerex.go", nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := slices.Sorted(maps.Keys(codegen.TypesWithDirective([]*ast.File{f}, "//codegen:benchclone")))
+	if len(names) == 0 {
+		t.Fatal("no types marked //codegen:benchclone in clonerex")
+	}
+
+	out, err := exec.Command("go", "test", "-run=^$", "-bench=Clone$", "-benchtime=1x", "./clonerex").CombinedOutput()
+	if err != nil {
+		t.Fatalf("running benchmarks: %v\n%s", err, out)
+	}
+	for _, name := range names {
+		if !bytes.Contains(out, []byte("Benchmark"+name+"Clone")) {
+			t.Errorf("Benchmark%sClone did not run; output:\n%s", name, out)
+		}
+	}
+}
+
+func TestConcurrentContainer(t *testing.T) {
+	orig := &clonerex.ConcurrentContainer{Name: "foo"}
+	orig.Cache.Store("a", 1)
+	orig.Trees.Store("t", &clonerex.Tree{Name: "root"})
+	orig.Counts.Store("n", 2)
+
+	cloned := orig.Clone()
+	if cloned.Name != "foo" {
+		t.Errorf("Name = %q, want %q", cloned.Name, "foo")
+	}
+	if v, ok := cloned.Cache.Load("a"); !ok || v != 1 {
+		t.Errorf("Cache[a] = %v, %v; want 1, true", v, ok)
+	}
+	if n, ok := cloned.Counts.Load("n"); !ok || n != 2 {
+		t.Errorf("Counts[n] = %v, %v; want 2, true", n, ok)
+	}
+	tree, ok := cloned.Trees.Load("t")
+	if !ok || tree.Name != "root" {
+		t.Fatalf("Trees[t] = %v, %v; want %q", tree, ok, "root")
+	}
+
+	// The maps of the clone are independent of the original's.
+	tree.Name = "other"
+	cloned.Cache.Store("b", 2)
+	cloned.Counts.Delete("n")
+	if orig, _ := orig.Trees.Load("t"); orig.Name != "root" {
+		t.Error("Clone() aliased a value of Trees")
+	}
+	if _, ok := orig.Cache.Load("b"); ok {
+		t.Error("Clone() aliased Cache")
+	}
+	if _, ok := orig.Counts.Load("n"); !ok {
+		t.Error("Clone() aliased Counts")
+	}
+}
+
+func TestGenConcurrentMap(t *testing.T) {
+	pkg, namedTypes, err := codegen.LoadTypes("", "./clonerex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ, ok := namedTypes["ConcurrentContainer"].(*types.Named)
+	if !ok {
+		t.Fatal("could not find type ConcurrentContainer")
+	}
+	buf := bytes.NewBufferString("package clonerex\n\n")
+	gen(buf, codegen.NewImportTracker(pkg.Types), typ)
+	got, err := format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, buf.Bytes())
+	}
+	const want = `package clonerex
+
+// Clone makes a deep copy of ConcurrentContainer.
+// The result aliases no memory with the original.
+func (src *ConcurrentContainer) Clone() *ConcurrentContainer {
+	if src == nil {
+		return nil
+	}
+	dst := new(ConcurrentContainer)
+	dst.Name = src.Name
+	src.Cache.Range(func(k, v any) bool {
+		dst.Cache.Store(k, v)
+		return true
+	})
+	for k, v := range src.Trees.All() {
+		dst.Trees.Store(k, v.Clone())
+	}
+	for k, v := range src.Counts.All() {
+		dst.Counts.Store(k, v)
+	}
+	return dst
+}
+
+// A compilation failure here means this code must be regenerated, with the command at the top of this file.
+var _ConcurrentContainerCloneNeedsRegeneration = ConcurrentContainer(struct {
+	Name   string
+	Cache  sync.Map
+	Trees  syncs.Map[string, *Tree]
+	Counts syncs.Map[string, int]
+}{})
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("generated code mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -1,11 +1,20 @@
 // Copyright (c) Tailscale Inc & AUTHORS
 // SPDX-License-Identifier: BSD-3-Clause
 
-//go:generate go run tailscale.com/cmd/cloner  -clonefunc=true -type SliceContainer,InterfaceContainer,MapWithPointers,DeeplyNestedMap
+//go:generate go run tailscale.com/cmd/cloner  -clonefunc=true -type SliceContainer,InterfaceContainer,MapWithPointers,DeeplyNestedMap,ErrorContainer,ValueCloned,ValueClonedContainer,JSONContainer,Pooled,Tree,Cached,StdlibContainer,DeepTree,ConcurrentContainer
 
 // Package clonerex is an example package for the cloner tool.
 package clonerex
 
+import (
+	"net/netip"
+	"regexp"
+	"sync"
+	"time"
+
+	"tailscale.com/syncs"
+)
+
 type SliceContainer struct {
 	Slice []*int
 }
@@ -33,6 +42,10 @@ type InterfaceContainer struct {
 	Interface Cloneable
 }
 
+// MapWithPointers has maps whose values contain pointers. Its Clone method is
+// benchmarked.
+//
+//codegen:benchclone
 type MapWithPointers struct {
 	Nested          map[string]*int
 	WithCloneMethod map[string]*SliceContainer
@@ -44,3 +57,107 @@ type DeeplyNestedMap struct {
 	ThreeLevels map[string]map[string]map[string]int
 	FourLevels  map[string]map[string]map[string]map[string]*SliceContainer
 }
+
+// ErrorContainer has error fields, which are copied by reference since errors
+// are immutable by convention, alongside an interface field that must still
+// be cloned.
+//
+//codegen:benchclone
+type ErrorContainer struct {
+	Err   error
+	Errs  []error
+	Other Cloneable
+}
+
+// ValueCloned has a Clone method that returns a value rather than a pointer.
+//
+//codegen:clonevalue
+type ValueCloned struct {
+	Names []string
+}
+
+// ValueClonedContainer holds ValueCloned in fields of various shapes, which
+// tests that the cloner adapts to its Clone method returning a value.
+type ValueClonedContainer struct {
+	Value    ValueCloned
+	Ptr      *ValueCloned
+	Slice    []ValueCloned
+	PtrSlice []*ValueCloned
+	Map      map[string]ValueCloned
+}
+
+// JSONContainer holds arbitrary JSON in fields of type any, which are
+// deep-copied with jsonclone.
+type JSONContainer struct {
+	Value  any
+	Object map[string]any
+	Array  []any
+}
+
+// Pooled is allocated from a pool with GetPooled and PutPooled.
+//
+//codegen:pooled
+type Pooled struct {
+	ID     int
+	Buf    []byte
+	Attrs  map[string]string
+	Parent *SliceContainer
+}
+
+// Tree is a self-referential type, which tests that the cloner clones
+// recursive fields by calling Clone rather than expanding them. Its Clone
+// method is benchmarked.
+//
+//codegen:benchclone
+type Tree struct {
+	Name     string
+	Left     *Tree
+	Children []*Tree
+	Values   []Tree
+	ByName   map[string]*Tree
+}
+
+// Cached has fields that are zeroed rather than cloned.
+//
+//codegen:cloneskip *Cache scratch*
+type Cached struct {
+	Names       []string
+	Ptr         *int
+	LookupCache map[string]*int
+	HitsCache   int
+	StatsCache  [2]int
+	scratchBuf  []byte
+}
+
+// StdlibContainer has fields of standard library types that contain
+// pointers. Immutable ones are shared with the clone, others are copied.
+//
+//codegen:benchclone
+type StdlibContainer struct {
+	Pattern   *regexp.Regexp
+	Location  *time.Location
+	AddrPorts []netip.AddrPort
+}
+
+// DeepTree is a self-referential type whose values may be deeply nested, such
+// as when decoded from untrusted input. Its Clone method copies values nested
+// 3 or more levels deep shallowly.
+//
+//codegen:maxdepth=3
+type DeepTree struct {
+	Name     string
+	Next     *DeepTree
+	Children []*DeepTree
+	Values   []DeepTree
+	ByName   map[string]*DeepTree
+	Tree     *Tree
+}
+
+// ConcurrentContainer has fields of concurrency-safe map types, which its
+// Clone method copies by ranging over their entries.
+type ConcurrentContainer struct {
+	Name   string
+	Cache  sync.Map
+	Trees  syncs.Map[string, *Tree]
+	Counts syncs.Map[string, int]
+}
@@ -6,9 +6,16 @@
 package clonerex
 
 import (
+	"log"
 	"maps"
+	"net/netip"
+	"regexp"
+	"sync"
+	"time"
 
+	"tailscale.com/syncs"
 	"tailscale.com/types/ptr"
+	"tailscale.com/util/jsonclone"
 )
 
 // Clone makes a deep copy of SliceContainer.
@@ -161,9 +168,349 @@ func (src *DeeplyNestedMap) Clone() *DeeplyNestedMap {
 	FourLevels  map[string]map[string]map[string]map[string]*SliceContainer
 }{})
 
+// Clone makes a deep copy of ErrorContainer.
+// The result aliases no memory with the original.
+func (src *ErrorContainer) Clone() *ErrorContainer {
+	if src == nil {
+		return nil
+	}
+	dst := new(ErrorContainer)
+	*dst = *src
+	dst.Errs = append(src.Errs[:0:0], src.Errs...)
+	if src.Other != nil {
+		dst.Other = src.Other.Clone()
+	}
+	return dst
+}
+
+// A compilation failure here means this code must be regenerated, with the command at the top of this file.
+var _ErrorContainerCloneNeedsRegeneration = ErrorContainer(struct {
+	Err   error
+	Errs  []error
+	Other Cloneable
+}{})
+
+// Clone makes a deep copy of ValueCloned.
+// The result aliases no memory with the original.
+func (src *ValueCloned) Clone() ValueCloned {
+	if src == nil {
+		return ValueCloned{}
+	}
+	dst := *src
+	dst.Names = append(src.Names[:0:0], src.Names...)
+	return dst
+}
+
+// A compilation failure here means this code must be regenerated, with the command at the top of this file.
+var _ValueClonedCloneNeedsRegeneration = ValueCloned(struct {
+	Names []string
+}{})
+
+// Clone makes a deep copy of ValueClonedContainer.
+// The result aliases no memory with the original.
+func (src *ValueClonedContainer) Clone() *ValueClonedContainer {
+	if src == nil {
+		return nil
+	}
+	dst := new(ValueClonedContainer)
+	*dst = *src
+	dst.Value = src.Value.Clone()
+	if dst.Ptr != nil {
+		dst.Ptr = ptr.To(src.Ptr.Clone())
+	}
+	if src.Slice != nil {
+		dst.Slice = make([]ValueCloned, len(src.Slice))
+		for i := range dst.Slice {
+			dst.Slice[i] = src.Slice[i].Clone()
+		}
+	}
+	if src.PtrSlice != nil {
+		dst.PtrSlice = make([]*ValueCloned, len(src.PtrSlice))
+		for i := range dst.PtrSlice {
+			if src.PtrSlice[i] == nil {
+				dst.PtrSlice[i] = nil
+			} else {
+				dst.PtrSlice[i] = ptr.To(src.PtrSlice[i].Clone())
+			}
+		}
+	}
+	if dst.Map != nil {
+		dst.Map = map[string]ValueCloned{}
+		for k, v := range src.Map {
+			dst.Map[k] = v.Clone()
+		}
+	}
+	return dst
+}
+
+// A compilation failure here means this code must be regenerated, with the command at the top of this file.
+var _ValueClonedContainerCloneNeedsRegeneration = ValueClonedContainer(struct {
+	Value    ValueCloned
+	Ptr      *ValueCloned
+	Slice    []ValueCloned
+	PtrSlice []*ValueCloned
+	Map      map[string]ValueCloned
+}{})
+
+// Clone makes a deep copy of JSONContainer.
+// The result aliases no memory with the original.
+func (src *JSONContainer) Clone() *JSONContainer {
+	if src == nil {
+		return nil
+	}
+	dst := new(JSONContainer)
+	*dst = *src
+	dst.Value = jsonclone.Value(src.Value)
+	if dst.Object != nil {
+		dst.Object = map[string]any{}
+		for k, v := range src.Object {
+			dst.Object[k] = jsonclone.Value(v)
+		}
+	}
+	if src.Array != nil {
+		dst.Array = make([]any, len(src.Array))
+		for i := range dst.Array {
+			dst.Array[i] = jsonclone.Value(src.Array[i])
+		}
+	}
+	return dst
+}
+
+// A compilation failure here means this code must be regenerated, with the command at the top of this file.
+var _JSONContainerCloneNeedsRegeneration = JSONContainer(struct {
+	Value  any
+	Object map[string]any
+	Array  []any
+}{})
+
+// Clone makes a deep copy of Pooled.
+// The result aliases no memory with the original.
+func (src *Pooled) Clone() *Pooled {
+	if src == nil {
+		return nil
+	}
+	dst := new(Pooled)
+	*dst = *src
+	dst.Buf = append(src.Buf[:0:0], src.Buf...)
+	dst.Attrs = maps.Clone(src.Attrs)
+	dst.Parent = src.Parent.Clone()
+	return dst
+}
+
+// A compilation failure here means this code must be regenerated, with the command at the top of this file.
+var _PooledCloneNeedsRegeneration = Pooled(struct {
+	ID     int
+	Buf    []byte
+	Attrs  map[string]string
+	Parent *SliceContainer
+}{})
+
+var poolPooled = sync.Pool{New: func() any { return new(Pooled) }}
+
+// GetPooled returns a zero Pooled from a pool.
+// Return it with PutPooled once it is no longer used.
+func GetPooled() *Pooled {
+	return poolPooled.Get().(*Pooled)
+}
+
+// PutPooled zeroes v, so that it retains no memory it references, and returns
+// it to the pool used by GetPooled. v must not be used after the call.
+func PutPooled(v *Pooled) {
+	if v == nil {
+		return
+	}
+	*v = Pooled{}
+	poolPooled.Put(v)
+}
+
+// Clone makes a deep copy of Tree.
+// The result aliases no memory with the original.
+func (src *Tree) Clone() *Tree {
+	if src == nil {
+		return nil
+	}
+	dst := new(Tree)
+	*dst = *src
+	dst.Left = src.Left.Clone()
+	if src.Children != nil {
+		dst.Children = make([]*Tree, len(src.Children))
+		for i := range dst.Children {
+			if src.Children[i] == nil {
+				dst.Children[i] = nil
+			} else {
+				dst.Children[i] = src.Children[i].Clone()
+			}
+		}
+	}
+	if src.Values != nil {
+		dst.Values = make([]Tree, len(src.Values))
+		for i := range dst.Values {
+			dst.Values[i] = *src.Values[i].Clone()
+		}
+	}
+	if dst.ByName != nil {
+		dst.ByName = map[string]*Tree{}
+		for k, v := range src.ByName {
+			if v == nil {
+				dst.ByName[k] = nil
+			} else {
+				dst.ByName[k] = v.Clone()
+			}
+		}
+	}
+	return dst
+}
+
+// A compilation failure here means this code must be regenerated, with the command at the top of this file.
+var _TreeCloneNeedsRegeneration = Tree(struct {
+	Name     string
+	Left     *Tree
+	Children []*Tree
+	Values   []Tree
+	ByName   map[string]*Tree
+}{})
+
+// Clone makes a deep copy of Cached.
+// The result aliases no memory with the original.
+func (src *Cached) Clone() *Cached {
+	if src == nil {
+		return nil
+	}
+	dst := new(Cached)
+	*dst = *src
+	dst.Names = append(src.Names[:0:0], src.Names...)
+	if dst.Ptr != nil {
+		dst.Ptr = ptr.To(*src.Ptr)
+	}
+	dst.LookupCache = nil
+	dst.HitsCache = 0
+	dst.StatsCache = [2]int{}
+	dst.scratchBuf = nil
+	return dst
+}
+
+// A compilation failure here means this code must be regenerated, with the command at the top of this file.
+var _CachedCloneNeedsRegeneration = Cached(struct {
+	Names       []string
+	Ptr         *int
+	LookupCache map[string]*int
+	HitsCache   int
+	StatsCache  [2]int
+	scratchBuf  []byte
+}{})
+
+// Clone makes a deep copy of StdlibContainer.
+// The result aliases no memory with the original.
+func (src *StdlibContainer) Clone() *StdlibContainer {
+	if src == nil {
+		return nil
+	}
+	dst := new(StdlibContainer)
+	*dst = *src
+	dst.AddrPorts = append(src.AddrPorts[:0:0], src.AddrPorts...)
+	return dst
+}
+
+// A compilation failure here means this code must be regenerated, with the command at the top of this file.
+var _StdlibContainerCloneNeedsRegeneration = StdlibContainer(struct {
+	Pattern   *regexp.Regexp
+	Location  *time.Location
+	AddrPorts []netip.AddrPort
+}{})
+
+// Clone makes a deep copy of DeepTree.
+// The result aliases no memory with the original, except for the memory
+// referenced by values nested 3 or more levels deep, which are copied
+// shallowly.
+func (src *DeepTree) Clone() *DeepTree {
+	return src.cloneDepth(0)
+}
+
+// cloneDepth clones src, which is nested depth levels below the receiver
+// of Clone. At a depth of 3 or more, it returns a shallow copy of src.
+func (src *DeepTree) cloneDepth(depth int) *DeepTree {
+	if src == nil {
+		return nil
+	}
+	dst := new(DeepTree)
+	*dst = *src
+	if depth >= 3 {
+		log.Printf("DeepTree.Clone: maximum depth of 3 reached, copying shallowly")
+		return dst
+	}
+	dst.Next = src.Next.cloneDepth(depth + 1)
+	if src.Children != nil {
+		dst.Children = make([]*DeepTree, len(src.Children))
+		for i := range dst.Children {
+			if src.Children[i] == nil {
+				dst.Children[i] = nil
+			} else {
+				dst.Children[i] = src.Children[i].cloneDepth(depth + 1)
+			}
+		}
+	}
+	if src.Values != nil {
+		dst.Values = make([]DeepTree, len(src.Values))
+		for i := range dst.Values {
+			dst.Values[i] = *src.Values[i].cloneDepth(depth + 1)
+		}
+	}
+	if dst.ByName != nil {
+		dst.ByName = map[string]*DeepTree{}
+		for k, v := range src.ByName {
+			if v == nil {
+				dst.ByName[k] = nil
+			} else {
+				dst.ByName[k] = v.cloneDepth(depth + 1)
+			}
+		}
+	}
+	dst.Tree = src.Tree.Clone()
+	return dst
+}
+
+// A compilation failure here means this code must be regenerated, with the command at the top of this file.
+var _DeepTreeCloneNeedsRegeneration = DeepTree(struct {
+	Name     string
+	Next     *DeepTree
+	Children []*DeepTree
+	Values   []DeepTree
+	ByName   map[string]*DeepTree
+	Tree     *Tree
+}{})
+
+// Clone makes a deep copy of ConcurrentContainer.
+// The result aliases no memory with the original.
+func (src *ConcurrentContainer) Clone() *ConcurrentContainer {
+	if src == nil {
+		return nil
+	}
+	dst := new(ConcurrentContainer)
+	dst.Name = src.Name
+	src.Cache.Range(func(k, v any) bool {
+		dst.Cache.Store(k, v)
+		return true
+	})
+	for k, v := range src.Trees.All() {
+		dst.Trees.Store(k, v.Clone())
+	}
+	for k, v := range src.Counts.All() {
+		dst.Counts.Store(k, v)
+	}
+	return dst
+}
+
+// A compilation failure here means this code must be regenerated, with the command at the top of this file.
+var _ConcurrentContainerCloneNeedsRegeneration = ConcurrentContainer(struct {
+	Name   string
+	Cache  sync.Map
+	Trees  syncs.Map[string, *Tree]
+	Counts syncs.Map[string, int]
+}{})
+
 // Clone duplicates src into dst and reports whether it succeeded.
 // To succeed, <src, dst> must be of types <*T, *T> or <*T, **T>,
-// where T is one of SliceContainer,InterfaceContainer,MapWithPointers,DeeplyNestedMap.
+// where T is one of SliceContainer,InterfaceContainer,MapWithPointers,DeeplyNestedMap,ErrorContainer,ValueCloned,ValueClonedContainer,JSONContainer,Pooled,Tree,Cached,StdlibContainer,DeepTree,ConcurrentContainer.
 func Clone(dst, src any) bool {
 	switch src := src.(type) {
 	case *SliceContainer:
@@ -202,6 +549,93 @@ func Clone(dst, src any) bool {
 			*dst = src.Clone()
 			return true
 		}
+	case *ErrorContainer:
+		switch dst := dst.(type) {
+		case *ErrorContainer:
+			*dst = *src.Clone()
+			return true
+		case **ErrorContainer:
+			*dst = src.Clone()
+			return true
+		}
+	case *ValueCloned:
+		switch dst := dst.(type) {
+		case *ValueCloned:
+			*dst = src.Clone()
+			return true
+		case **ValueCloned:
+			*dst = ptr.To(src.Clone())
+			return true
+		}
+	case *ValueClonedContainer:
+		switch dst := dst.(type) {
+		case *ValueClonedContainer:
+			*dst = *src.Clone()
+			return true
+		case **ValueClonedContainer:
+			*dst = src.Clone()
+			return true
+		}
+	case *JSONContainer:
+		switch dst := dst.(type) {
+		case *JSONContainer:
+			*dst = *src.Clone()
+			return true
+		case **JSONContainer:
+			*dst = src.Clone()
+			return true
+		}
+	case *Pooled:
+		switch dst := dst.(type) {
+		case *Pooled:
+			*dst = *src.Clone()
+			return true
+		case **Pooled:
+			*dst = src.Clone()
+			return true
+		}
+	case *Tree:
+		switch dst := dst.(type) {
+		case *Tree:
+			*dst = *src.Clone()
+			return true
+		case **Tree:
+			*dst = src.Clone()
+			return true
+		}
+	case *Cached:
+		switch dst := dst.(type) {
+		case *Cached:
+			*dst = *src.Clone()
+			return true
+		case **Cached:
+			*dst = src.Clone()
+			return true
+		}
+	case *StdlibContainer:
+		switch dst := dst.(type) {
+		case *StdlibContainer:
+			*dst = *src.Clone()
+			return true
+		case **StdlibContainer:
+			*dst = src.Clone()
+			return true
+		}
+	case *DeepTree:
+		switch dst := dst.(type) {
+		case *DeepTree:
+			*dst = *src.Clone()
+			return true
+		case **DeepTree:
+			*dst = src.Clone()
+			return true
+		}
+	case *ConcurrentContainer:
+		switch dst := dst.(type) {
+		case **ConcurrentContainer:
+			*dst = src.Clone()
+			return true
+		}
 	}
 	return false
 }
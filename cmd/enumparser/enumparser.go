@@ -0,0 +1,151 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Enumparser is a tool to automate the creation of Parse functions for
+// enum-like types.
+//
+// For each type T, it emits a ParseT(string) (T, error) func that returns the
+// constant of type T whose string representation matches the input. The
+// constants are discovered from the package being processed. For types with a
+// string underlying type, the string representation is the constant's value;
+// other types must have a String method.
+//
+// Constants marked with a //codegen:noparse directive (for example sentinel
+// values) are not accepted by the generated parser. Constants of a string
+// type that share a value are reported as an error, as they would generate
+// duplicate cases; mark all but one of them //codegen:noparse.
+//
+// The generated file has the build constraint of the files that declare the
+// types, which must all have the same one.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/build/constraint"
+	"go/constant"
+	"go/types"
+	"log"
+	"os"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+	"tailscale.com/util/codegen"
+)
+
+var (
+	flagTypes     = flag.String("type", "", "comma-separated list of types; required")
+	flagBuildTags = flag.String("tags", "", "compiler build tags to apply")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("enumparser: ")
+	flag.Parse()
+	if len(*flagTypes) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	typeNames := strings.Split(*flagTypes, ",")
+
+	pkg, namedTypes, err := codegen.LoadTypes(*flagBuildTags, ".")
+	if err != nil {
+		log.Fatal(err)
+	}
+	it := codegen.NewImportTracker(pkg.Types)
+	buf := new(bytes.Buffer)
+	var buildConstraint string
+	for i, typeName := range typeNames {
+		typ, ok := namedTypes[typeName].(*types.Named)
+		if !ok {
+			log.Fatalf("could not find type %s", typeName)
+		}
+		c := fileBuildConstraint(pkg, typ)
+		if i > 0 && c != buildConstraint {
+			log.Fatalf("types %s and %s are declared in files with different build constraints", typeNames[0], typeName)
+		}
+		buildConstraint = c
+		if err := gen(buf, it, pkg, typ); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	parseOutput := pkg.Name + "_parse"
+	if *flagBuildTags == "test" {
+		parseOutput += "_test"
+	}
+	parseOutput += ".go"
+	// Without the constraint, the generated file would be the only one in
+	// the package for other builds, and refer to undefined types.
+	if err := codegen.WriteConstrainedPackageFile("tailscale.com/cmd/enumparser", pkg, parseOutput, buildConstraint, it, buf); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// fileBuildConstraint returns the //go:build line of the file in pkg that
+// declares typ, or the empty string if it has none.
+func fileBuildConstraint(pkg *packages.Package, typ *types.Named) string {
+	pos := typ.Obj().Pos()
+	for _, f := range pkg.Syntax {
+		if pos < f.FileStart || pos >= f.FileEnd {
+			continue
+		}
+		for _, cg := range f.Comments {
+			if cg.Pos() > f.Package {
+				break
+			}
+			for _, c := range cg.List {
+				if constraint.IsGoBuild(c.Text) {
+					return c.Text
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func gen(buf *bytes.Buffer, it *codegen.ImportTracker, pkg *packages.Package, typ *types.Named) error {
+	name := typ.Obj().Name()
+	consts := codegen.TypedConstants(pkg.Syntax, pkg.TypesInfo, typ)
+	if len(consts) == 0 {
+		return fmt.Errorf("no constants of type %s found", name)
+	}
+	basic, _ := typ.Underlying().(*types.Basic)
+	isString := basic != nil && basic.Info()&types.IsString != 0
+	if !isString && codegen.LookupMethod(typ, "String") == nil {
+		return fmt.Errorf("type %s must either have a string underlying type or a String method", name)
+	}
+	if isString {
+		seen := make(map[string]*types.Const) // value => first constant
+		for _, c := range consts {
+			v := constant.StringVal(c.Val())
+			if prev, ok := seen[v]; ok {
+				return fmt.Errorf("constants %s and %s of type %s have the same value %q; mark all but one of them //codegen:noparse", prev.Name(), c.Name(), name, v)
+			}
+			seen[v] = c
+		}
+	}
+
+	it.Import("", "fmt")
+	fmt.Fprintf(buf, "// Parse%s returns the %s whose string representation is s.\n", name, name)
+	fmt.Fprintf(buf, "// It returns an error if s is not a known %s value.\n", name)
+	fmt.Fprintf(buf, "func Parse%s(s string) (%s, error) {\n", name, name)
+	writef := func(format string, args ...any) {
+		fmt.Fprintf(buf, "\t"+format+"\n", args...)
+	}
+	writef("switch s {")
+	for _, c := range consts {
+		if isString {
+			writef("case string(%s):", c.Name())
+		} else {
+			writef("case %s.String():", c.Name())
+		}
+		writef("\treturn %s, nil", c.Name())
+	}
+	writef("}")
+	writef("var zero %s", name)
+	writef("return zero, fmt.Errorf(\"unknown %s %%q\", s)", name)
+	fmt.Fprintf(buf, "}\n\n")
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+package main
+
+import (
+	"bytes"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"tailscale.com/cmd/enumparser/enumparserex"
+	"tailscale.com/util/codegen"
+)
+
+func TestGolden(t *testing.T) {
+	pkg, namedTypes, err := codegen.LoadTypes("", "./enumparserex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	it := codegen.NewImportTracker(pkg.Types)
+	buf := new(bytes.Buffer)
+	for _, typeName := range []string{"Protocol", "Level"} {
+		typ, ok := namedTypes[typeName].(*types.Named)
+		if !ok {
+			t.Fatalf("could not find type %s", typeName)
+		}
+		if err := gen(buf, it, pkg, typ); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "enumparserex_parse.go")
+	if err := codegen.WritePackageFile("tailscale.com/cmd/enumparser", pkg, path, it, buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join("enumparserex", "enumparserex_parse.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(want), string(got)); diff != "" {
+		t.Errorf("generated code mismatch; run go generate ./cmd/enumparser/enumparserex (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseProtocol(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    enumparserex.Protocol
+		wantErr bool
+	}{
+		{in: "http", want: enumparserex.ProtocolHTTP},
+		{in: "tcp", want: enumparserex.ProtocolTCP},
+		{in: "udp", wantErr: true},
+		{in: "HTTP", wantErr: true},
+		{in: "", wantErr: true}, // ProtocolUnset is marked noparse
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := enumparserex.ParseProtocol(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProtocol(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseProtocol(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    enumparserex.Level
+		wantErr bool
+	}{
+		{in: "debug", want: enumparserex.LevelDebug},
+		{in: "info", want: enumparserex.LevelInfo},
+		{in: "error", want: enumparserex.LevelError},
+		{in: "warn", wantErr: true},
+		{in: "Level(3)", wantErr: true}, // numLevels is marked noparse
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := enumparserex.ParseLevel(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLevel(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLevel(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenErrors(t *testing.T) {
+	pkg, namedTypes, err := codegen.LoadTypes("", "./enumparserex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A type without constants cannot be parsed.
+	typ := types.NewNamed(types.NewTypeName(0, pkg.Types, "Empty", nil), types.Typ[types.String], nil)
+	if err := gen(new(bytes.Buffer), codegen.NewImportTracker(pkg.Types), pkg, typ); err == nil {
+		t.Error("gen succeeded for a type without constants")
+	}
+
+	// Constants that share a value would generate duplicate cases.
+	typ, ok := namedTypes["Color"].(*types.Named)
+	if !ok {
+		t.Fatal("could not find type Color")
+	}
+	err = gen(new(bytes.Buffer), codegen.NewImportTracker(pkg.Types), pkg, typ)
+	if err == nil || !strings.Contains(err.Error(), "ColorGray and ColorGrey") {
+		t.Errorf("gen for constants with the same value: got error %v, want one naming both", err)
+	}
+}
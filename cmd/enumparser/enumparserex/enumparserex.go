@@ -0,0 +1,51 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:generate go run tailscale.com/cmd/enumparser -type Protocol,Level
+
+// Package enumparserex is an example package for the enumparser tool.
+package enumparserex
+
+import "fmt"
+
+// Protocol is an enum with a string underlying type.
+type Protocol string
+
+const (
+	ProtocolHTTP Protocol = "http"
+	ProtocolTCP  Protocol = "tcp"
+
+	// ProtocolUnset is the zero value and is not a valid input.
+	ProtocolUnset Protocol = "" //codegen:noparse
+)
+
+// Color is an enum whose constants share a value, which enumparser rejects.
+type Color string
+
+const (
+	ColorGray Color = "gray"
+	ColorGrey Color = "gray"
+)
+
+// Level is an enum with a String method.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelError
+
+	numLevels //codegen:noparse
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
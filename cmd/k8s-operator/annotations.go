@@ -0,0 +1,235 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !plan9
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"tailscale.com/util/mak"
+	"tailscale.com/util/set"
+)
+
+const (
+	// annotationDomain is the prefix of the annotation names declared in
+	// this package.
+	annotationDomain = "tailscale.com/"
+
+	reasonUnknownAnnotation = "UnknownAnnotation"
+
+	// maxAnnotationTypoDistance is the maximum edit distance between an
+	// unknown annotation and a known one for the unknown one to be reported
+	// as a likely typo of the known one.
+	maxAnnotationTypoDistance = 3
+)
+
+// annotationPrefix is the prefix of the annotations that users set to
+// configure the operator, in place of annotationDomain. It is set with
+// OPERATOR_ANNOTATION_PREFIX, for forks that use their own domain. Annotations
+// that the operator sets for its own bookkeeping, and annotations of Tailscale
+// Services, always use annotationDomain. The zero value is annotationDomain.
+type annotationPrefix string
+
+// validateAnnotationPrefix returns an error if p can not be used as an
+// annotationPrefix.
+func validateAnnotationPrefix(p string) error {
+	if !strings.HasSuffix(p, "/") {
+		return errors.New(`must end with "/"`)
+	}
+	if errs := validation.IsQualifiedName(p + "proxy-group"); len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (p annotationPrefix) String() string {
+	if p == "" {
+		return annotationDomain
+	}
+	return string(p)
+}
+
+// key returns the key of the annotation name, with annotationDomain replaced
+// by p. Names in other domains are returned as is.
+func (p annotationPrefix) key(name string) string {
+	if rest, ok := strings.CutPrefix(name, annotationDomain); ok {
+		return p.String() + rest
+	}
+	return name
+}
+
+// lookup returns the value of the annotation name in annots, with
+// annotationDomain replaced by p, and whether it is set. All reads of user-set
+// annotations go through it, so that they honor the configured prefix.
+func (p annotationPrefix) lookup(annots map[string]string, name string) (string, bool) {
+	v, ok := annots[p.key(name)]
+	return v, ok
+}
+
+// get is like lookup, but returns only the value, which is empty if the
+// annotation is not set.
+func (p annotationPrefix) get(annots map[string]string, name string) string {
+	v, _ := p.lookup(annots, name)
+	return v
+}
+
+// knownAnnotations is the set of annotations in the tailscale.com/ domain
+// that the operator reads or sets on Ingresses and Services.
+var knownAnnotations = set.Of(
+	AnnotationExpose,
+	AnnotationTags,
+	AnnotationHostname,
+	AnnotationTailnetTargetIP,
+	annotationTailnetTargetIPOld,
+	AnnotationTailnetTargetFQDN,
+	AnnotationProxyGroup,
+	AnnotationFunnel,
+	AnnotationExperimentalForwardClusterTrafficViaL7IngresProxy,
+	LabelAnnotationProxyClass,
+	annotationAccessLog,
+	annotationAutoApprovers,
+	annotationBackendDialFamily,
+	annotationBackendProbe,
+	annotationBackendSelector,
+	annotationBackendRetries,
+	annotationCertRenewalOwner,
+	annotationCertWait,
+	annotationClientHTTPVersion,
+	annotationDefaultResponse,
+	annotationFunnelGuard,
+	annotationHTTPBackend,
+	annotationHTTPEndpoint,
+	annotationHTTPMode,
+	annotationMaxConnections,
+	annotationPortAliases,
+	annotationPriority,
+	annotationReadyGate,
+	annotationReplicas,
+	annotationResponseHeaders,
+	annotationServiceDescription,
+	annotationServicePersistence,
+	annotationStandaloneService,
+	annotationTCPKeepAliveIdle,
+	annotationTCPKeepAliveInterval,
+	annotationTLSSecret,
+	annotationTrustBundleSecret,
+	annotationTSMagicDNSName,
+	annotationWaitingForDependency,
+	annotationWaitingForDNS,
+)
+
+// annotationWarnings tracks the unknown annotations that each object was last
+// warned about, so that a warning Event is emitted when an object's unknown
+// annotations change, rather than on every reconcile. It is kept in memory
+// only, so the warnings are emitted once more after the operator restarts.
+// The zero value is ready to use.
+type annotationWarnings struct {
+	mu     sync.Mutex
+	warned map[types.UID]string // joined unknown annotation keys last warned about
+}
+
+// warn emits a warning Event on obj for each of its annotations with prefix p
+// that the operator does not know, unless obj was last warned about the same
+// ones. These are most often misspellings of a known annotation, which would
+// otherwise be silently ignored, so the closest known annotation is suggested
+// if there is one. It does not fail the reconcile.
+func (w *annotationWarnings) warn(recorder record.EventRecorder, p annotationPrefix, obj client.Object) {
+	unknown := p.unknownAnnotations(obj.GetAnnotations())
+	joined := strings.Join(unknown, ",")
+	w.mu.Lock()
+	last, ok := w.warned[obj.GetUID()]
+	if len(unknown) == 0 {
+		delete(w.warned, obj.GetUID())
+	} else {
+		mak.Set(&w.warned, obj.GetUID(), joined)
+	}
+	w.mu.Unlock()
+	if ok && last == joined {
+		return
+	}
+	for _, k := range unknown {
+		msg := fmt.Sprintf("unknown annotation %q is ignored", k)
+		if s := p.closestKnownAnnotation(k); s != "" {
+			msg += fmt.Sprintf(", did you mean %q?", s)
+		}
+		recorder.Event(obj, corev1.EventTypeWarning, reasonUnknownAnnotation, msg)
+	}
+}
+
+// forget stops tracking the object with the given UID, once it is no longer
+// managed by the operator.
+func (w *annotationWarnings) forget(uid types.UID) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.warned, uid)
+}
+
+// unknownAnnotations returns the sorted keys of annots with prefix p that are
+// not in knownAnnotations.
+func (p annotationPrefix) unknownAnnotations(annots map[string]string) []string {
+	known := p.knownAnnotationKeys()
+	var unknown []string
+	for k := range annots {
+		if strings.HasPrefix(k, p.String()) && !slices.Contains(known, k) {
+			unknown = append(unknown, k)
+		}
+	}
+	slices.Sort(unknown)
+	return unknown
+}
+
+// knownAnnotationKeys returns the sorted keys of knownAnnotations, with
+// prefix p.
+func (p annotationPrefix) knownAnnotationKeys() []string {
+	var keys []string
+	for name := range knownAnnotations {
+		keys = append(keys, p.key(name))
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// closestKnownAnnotation returns the known annotation with the smallest edit
+// distance to key, or "" if none is within maxAnnotationTypoDistance. Ties
+// are broken alphabetically, so that the result is stable.
+func (p annotationPrefix) closestKnownAnnotation(key string) string {
+	best, bestDist := "", maxAnnotationTypoDistance+1
+	for _, k := range p.knownAnnotationKeys() {
+		if d := editDistance(key, k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b, in bytes.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range len(a) {
+		cur[0] = i + 1
+		for j := range len(b) {
+			cost := 1
+			if a[i] == b[j] {
+				cost = 0
+			}
+			cur[j+1] = min(prev[j+1]+1, cur[j]+1, prev[j]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
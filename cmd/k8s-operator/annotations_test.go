@@ -0,0 +1,183 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !plan9
+
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"tailscale.com/util/set"
+)
+
+func TestValidateAnnotationPrefix(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		wantErr bool
+	}{
+		{prefix: "tailscale.com/"},
+		{prefix: "example.com/"},
+		{prefix: "ingress.example.com/"},
+		{prefix: "example.com", wantErr: true},
+		{prefix: "/", wantErr: true},
+		{prefix: "Example_com/", wantErr: true},
+		{prefix: "example.com/x/", wantErr: true},
+	}
+	for _, tt := range tests {
+		err := validateAnnotationPrefix(tt.prefix)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateAnnotationPrefix(%q) = %v, want error: %v", tt.prefix, err, tt.wantErr)
+		}
+	}
+}
+
+func TestAnnotationPrefix(t *testing.T) {
+	annots := map[string]string{
+		"example.com/proxy-group": "test-pg",
+		"tailscale.com/tags":      "tag:ignored",
+		"example.com/proxy-grup":  "typo",
+	}
+
+	// By default, annotations in the tailscale.com/ domain are read.
+	var def annotationPrefix
+	if got, ok := def.lookup(annots, AnnotationTags); !ok || got != "tag:ignored" {
+		t.Errorf("lookup(%s) = %q, %v; want %q, true", AnnotationTags, got, ok, "tag:ignored")
+	}
+
+	p := annotationPrefix("example.com/")
+	if got, ok := p.lookup(annots, AnnotationProxyGroup); !ok || got != "test-pg" {
+		t.Errorf("lookup(%s) = %q, %v; want %q, true", AnnotationProxyGroup, got, ok, "test-pg")
+	}
+	if got, ok := p.lookup(annots, AnnotationTags); ok {
+		t.Errorf("lookup(%s) = %q, want unset as it is in the tailscale.com/ domain", AnnotationTags, got)
+	}
+	if got, want := p.key(annotationHTTPEndpoint), "example.com/http-endpoint"; got != want {
+		t.Errorf("key(%s) = %q, want %q", annotationHTTPEndpoint, got, want)
+	}
+	if got, want := p.key(ingressClassDefaultAnnotation), ingressClassDefaultAnnotation; got != want {
+		t.Errorf("key(%s) = %q, want it unchanged", ingressClassDefaultAnnotation, got)
+	}
+
+	// Unknown annotations are reported with the configured prefix.
+	if got, want := p.unknownAnnotations(annots), []string{"example.com/proxy-grup"}; !slices.Equal(got, want) {
+		t.Errorf("unknownAnnotations() = %q, want %q", got, want)
+	}
+	if got, want := p.closestKnownAnnotation("example.com/proxy-grup"), "example.com/proxy-group"; got != want {
+		t.Errorf("closestKnownAnnotation() = %q, want %q", got, want)
+	}
+}
+
+// notUserAnnotations are the annotation constants in the tailscale.com/
+// domain that are deliberately not in knownAnnotations, because they are
+// never set on Ingresses or Services.
+var notUserAnnotations = set.Of(
+	"annotationDomain",                      // the domain itself
+	"annotationCertsExpiring",               // set on cert Secrets
+	"annotationManagedServices",             // set on serve config ConfigMaps
+	"ownerAnnotation",                       // set on Tailscale Services
+	"podAnnotationLastSetClusterIP",         // set on proxy Pods
+	"podAnnotationLastSetClusterDNSName",    // set on proxy Pods
+	"podAnnotationLastSetTailnetTargetIP",   // set on proxy Pods
+	"podAnnotationLastSetTailnetTargetFQDN", // set on proxy Pods
+)
+
+// parsePackage parses the non-test Go files of the package, and returns them
+// along with the values of the string constants they declare, by name.
+func parsePackage(t *testing.T) (*token.FileSet, []*ast.File, map[string]string) {
+	t.Helper()
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	consts := map[string]string{} // name => value of string constants
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed = append(parsed, f)
+		ast.Inspect(f, func(n ast.Node) bool {
+			vs, ok := n.(*ast.ValueSpec)
+			if !ok {
+				return true
+			}
+			for i, id := range vs.Names {
+				if i >= len(vs.Values) {
+					break
+				}
+				if lit, ok := vs.Values[i].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					if v, err := strconv.Unquote(lit.Value); err == nil {
+						consts[id.Name] = v
+					}
+				}
+			}
+			return true
+		})
+	}
+	return fset, parsed, consts
+}
+
+// TestKnownAnnotationsComplete checks that every annotation constant in the
+// tailscale.com/ domain is either in knownAnnotations or in
+// notUserAnnotations, so that a newly added annotation is not reported as
+// unknown on the objects it is set on.
+func TestKnownAnnotationsComplete(t *testing.T) {
+	_, _, consts := parsePackage(t)
+	for name, v := range consts {
+		if !strings.HasPrefix(v, annotationDomain) || !strings.Contains(strings.ToLower(name), "annotation") {
+			continue
+		}
+		if knownAnnotations.Contains(v) == notUserAnnotations.Contains(name) {
+			t.Errorf("annotation %s (%q) must be in exactly one of knownAnnotations and notUserAnnotations", name, v)
+		}
+	}
+	for name := range notUserAnnotations {
+		if _, ok := consts[name]; !ok {
+			t.Errorf("notUserAnnotations lists %s, which is not declared", name)
+		}
+	}
+}
+
+// TestKnownAnnotations checks that every annotation in the tailscale.com/
+// domain that the operator reads through an annotationPrefix is in
+// knownAnnotations, so that it is not reported as unknown.
+func TestKnownAnnotations(t *testing.T) {
+	fset, parsed, consts := parsePackage(t)
+	for _, f := range parsed {
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || !slices.Contains([]string{"key", "lookup", "get"}, sel.Sel.Name) {
+				return true
+			}
+			id, ok := call.Args[len(call.Args)-1].(*ast.Ident)
+			if !ok {
+				return true
+			}
+			v, ok := consts[id.Name]
+			if !ok || !strings.HasPrefix(v, annotationDomain) {
+				return true
+			}
+			if !knownAnnotations.Contains(v) {
+				t.Errorf("%s: annotation %s (%q) is read but not in knownAnnotations", fset.Position(call.Pos()), id.Name, v)
+			}
+			return true
+		})
+	}
+}
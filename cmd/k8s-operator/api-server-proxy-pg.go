@@ -55,6 +55,9 @@ type KubeAPIServerTSServiceReconciler struct {
 	lc          localClient
 	defaultTags []string
 	operatorID  string // stableID of the operator's Tailscale device
+	// noAutoDeleteServices, if set, retains stale Tailscale Services
+	// rather than deleting them.
+	noAutoDeleteServices bool
 
 	clock tstime.Clock
 }
@@ -81,6 +84,7 @@ func (r *KubeAPIServerTSServiceReconciler) Reconcile(ctx context.Context, req re
 	if markedForDeletion(pg) {
 		logger.Debugf("ProxyGroup is being deleted, ensuring any created resources are cleaned up")
 		if err = r.maybeCleanup(ctx, serviceName, pg, logger); err != nil && strings.Contains(err.Error(), optimisticLockErrorMsg) {
+			ownerRefChanges.WithLabelValues(reconcilerKubeAPIServer, ownerRefResultConflict).Inc()
 			logger.Infof("optimistic lock error, retrying: %s", err)
 			return res, nil
 		}
@@ -91,6 +95,7 @@ func (r *KubeAPIServerTSServiceReconciler) Reconcile(ctx context.Context, req re
 	err = r.maybeProvision(ctx, serviceName, pg, logger)
 	if err != nil {
 		if strings.Contains(err.Error(), optimisticLockErrorMsg) {
+			ownerRefChanges.WithLabelValues(reconcilerKubeAPIServer, ownerRefResultConflict).Inc()
 			logger.Infof("optimistic lock error, retrying: %s", err)
 			return reconcile.Result{}, nil
 		}
@@ -108,7 +113,7 @@ func (r *KubeAPIServerTSServiceReconciler) maybeProvision(ctx context.Context, s
 	var dnsName string
 	oldPGStatus := pg.Status.DeepCopy()
 	defer func() {
-		podsAdvertising, podsErr := numberPodsAdvertising(ctx, r.Client, r.tsNamespace, pg.Name, serviceName)
+		podsAdvertising, podsErr := numberPodsAdvertising(ctx, r.Client, r.tsNamespace, pg.Name, serviceName, logger)
 		if podsErr != nil {
 			err = errors.Join(err, fmt.Errorf("failed to get number of advertised Pods: %w", podsErr))
 			// Continue, updating the status with the best available information.
@@ -201,6 +206,9 @@ func (r *KubeAPIServerTSServiceReconciler) maybeProvision(ctx context.Context, s
 		if err := r.tsClient.CreateOrUpdateVIPService(ctx, tsSvc); err != nil {
 			return fmt.Errorf("error creating Tailscale Service: %w", err)
 		}
+		if !hasOwnerRef(existingTSSvc, r.operatorID) {
+			ownerRefChanges.WithLabelValues(reconcilerKubeAPIServer, ownerRefResultAdd).Inc()
+		}
 	}
 
 	// 3. Ensure that TLS Secret and RBAC exists.
@@ -209,7 +217,7 @@ func (r *KubeAPIServerTSServiceReconciler) maybeProvision(ctx context.Context, s
 		return fmt.Errorf("error determining DNS name base: %w", err)
 	}
 	dnsName = serviceName.WithoutPrefix() + "." + tcd
-	if err = r.ensureCertResources(ctx, pg, dnsName); err != nil {
+	if err = r.ensureCertResources(ctx, pg, dnsName, logger); err != nil {
 		return fmt.Errorf("error ensuring cert resources: %w", err)
 	}
 
@@ -244,9 +252,13 @@ func (r *KubeAPIServerTSServiceReconciler) maybeCleanup(ctx context.Context, ser
 		}
 	}()
 
-	if _, err = cleanupTailscaleService(ctx, r.tsClient, serviceName, r.operatorID, logger); err != nil {
+	_, retained, err := cleanupTailscaleService(ctx, r.tsClient, serviceName, r.operatorID, "", r.noAutoDeleteServices, reconcilerKubeAPIServer, logger)
+	if err != nil {
 		return fmt.Errorf("error deleting Tailscale Service: %w", err)
 	}
+	if retained {
+		r.recorder.Eventf(pg, corev1.EventTypeWarning, warningTailscaleServiceRetained, msgTailscaleServiceRetained, serviceName)
+	}
 
 	if err = cleanupCertResources(ctx, r.Client, r.lc, r.tsNamespace, pg.Name, serviceName); err != nil {
 		return fmt.Errorf("failed to clean up cert resources: %w", err)
@@ -284,9 +296,16 @@ func (r *KubeAPIServerTSServiceReconciler) maybeDeleteStaleServices(ctx context.
 			continue
 		}
 
-		logger.Infof("Deleting Tailscale Service %s", svc.Name)
-		if err := r.tsClient.DeleteVIPService(ctx, svc.Name); err != nil && !isErrorTailscaleServiceNotFound(err) {
-			return fmt.Errorf("error deleting Tailscale Service %s: %w", svc.Name, err)
+		if r.noAutoDeleteServices {
+			if err := retainTailscaleService(ctx, r.tsClient, &svc, logger); err != nil {
+				return fmt.Errorf("error removing owner references from Tailscale Service %s: %w", svc.Name, err)
+			}
+			r.recorder.Eventf(pg, corev1.EventTypeWarning, warningTailscaleServiceRetained, msgTailscaleServiceRetained, svc.Name)
+		} else {
+			logger.Infof("Deleting Tailscale Service %s", svc.Name)
+			if err := r.tsClient.DeleteVIPService(ctx, svc.Name); err != nil && !isErrorTailscaleServiceNotFound(err) {
+				return fmt.Errorf("error deleting Tailscale Service %s: %w", svc.Name, err)
+			}
 		}
 
 		if err = cleanupCertResources(ctx, r.Client, r.lc, r.tsNamespace, pg.Name, svc.Name); err != nil {
@@ -309,11 +328,9 @@ func (r *KubeAPIServerTSServiceReconciler) deleteFinalizer(ctx context.Context,
 	return nil
 }
 
-func (r *KubeAPIServerTSServiceReconciler) ensureCertResources(ctx context.Context, pg *tsapi.ProxyGroup, domain string) error {
+func (r *KubeAPIServerTSServiceReconciler) ensureCertResources(ctx context.Context, pg *tsapi.ProxyGroup, domain string, logger *zap.SugaredLogger) error {
 	secret := certSecret(pg.Name, r.tsNamespace, domain, pg)
-	if _, err := createOrUpdate(ctx, r.Client, r.tsNamespace, secret, func(s *corev1.Secret) {
-		s.Labels = secret.Labels
-	}); err != nil {
+	if err := ensureCertSecret(ctx, r.Client, secret, nil, logger); err != nil {
 		return fmt.Errorf("failed to create or update Secret %s: %w", secret.Name, err)
 	}
 	role := certSecretRole(pg.Name, r.tsNamespace, domain)
@@ -343,7 +360,7 @@ func (r *KubeAPIServerTSServiceReconciler) maybeAdvertiseServices(ctx context.Co
 
 	// Only advertise a Tailscale Service once the TLS certs required for
 	// serving it are available.
-	shouldBeAdvertised, err := hasCerts(ctx, r.Client, r.lc, r.tsNamespace, serviceName)
+	shouldBeAdvertised, err := hasCerts(ctx, r.Client, r.lc, r.tsNamespace, pg.Name, serviceName)
 	if err != nil {
 		return fmt.Errorf("error checking TLS credentials provisioned for Tailscale Service %q: %w", serviceName, err)
 	}
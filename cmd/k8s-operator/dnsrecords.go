@@ -52,7 +52,8 @@ type dnsRecordsReconciler struct {
 	client.Client
 	tsNamespace           string // namespace in which we provision tailscale resources
 	logger                *zap.SugaredLogger
-	isDefaultLoadBalancer bool // true if operator is the default ingress controller in this cluster
+	isDefaultLoadBalancer bool             // true if operator is the default ingress controller in this cluster
+	annotationPrefix      annotationPrefix // prefix of user-set annotations
 }
 
 // Reconcile takes a reconcile.Request for a Service fronting a
@@ -294,7 +295,7 @@ func (dnsRR *dnsRecordsReconciler) fqdnForDNSRecord(ctx context.Context, proxySv
 		} else if err != nil {
 			return "", err
 		}
-		return svc.Annotations[AnnotationTailnetTargetFQDN], nil
+		return dnsRR.annotationPrefix.get(svc.Annotations, AnnotationTailnetTargetFQDN), nil
 	}
 	return "", nil
 }
@@ -342,7 +343,7 @@ func (dnsRR *dnsRecordsReconciler) isSvcForFQDNEgressProxy(ctx context.Context,
 		return false, err
 	}
 	annots := parentSvc.Annotations
-	return annots != nil && annots[AnnotationTailnetTargetFQDN] != "", nil
+	return dnsRR.annotationPrefix.get(annots, AnnotationTailnetTargetFQDN) != "", nil
 }
 
 // isProxyGroupEgressService reports whether the Service is a ClusterIP Service
@@ -388,7 +389,7 @@ func (dnsRR *dnsRecordsReconciler) parentSvcTargetsFQDN(ctx context.Context, svc
 		return false
 	}
 
-	return parentSvc.Annotations[AnnotationTailnetTargetFQDN] != ""
+	return dnsRR.annotationPrefix.get(parentSvc.Annotations, AnnotationTailnetTargetFQDN) != ""
 }
 
 // getTargetIPs returns the IPv4 and IPv6 addresses that should be used for DNS records
@@ -40,6 +40,8 @@ type egressSvcsReadinessReconciler struct {
 	logger      *zap.SugaredLogger
 	clock       tstime.Clock
 	tsNamespace string
+
+	annotationPrefix annotationPrefix // prefix of user-set annotations
 }
 
 // Reconcile reconciles an ExternalName Service that defines a tailnet target to be exposed on a ProxyGroup and sets the
@@ -70,7 +72,7 @@ func (esrr *egressSvcsReadinessReconciler) Reconcile(ctx context.Context, req re
 		}
 	}()
 
-	crl := egressSvcChildResourceLabels(svc)
+	crl := egressSvcChildResourceLabels(svc, esrr.annotationPrefix)
 	eps, err := getSingleObject[discoveryv1.EndpointSlice](ctx, esrr.Client, esrr.tsNamespace, crl)
 	if err != nil {
 		err = fmt.Errorf("error getting EndpointSlice: %w", err)
@@ -86,7 +88,7 @@ func (esrr *egressSvcsReadinessReconciler) Reconcile(ctx context.Context, req re
 	}
 	pg := &tsapi.ProxyGroup{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: svc.Annotations[AnnotationProxyGroup],
+			Name: esrr.annotationPrefix.get(svc.Annotations, AnnotationProxyGroup),
 		},
 	}
 	err = esrr.Get(ctx, client.ObjectKeyFromObject(pg), pg)
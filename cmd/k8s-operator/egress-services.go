@@ -75,6 +75,8 @@ type egressSvcsReconciler struct {
 	clock       tstime.Clock
 	tsNamespace string
 
+	annotationPrefix annotationPrefix // prefix of user-set annotations
+
 	mu   sync.Mutex           // protects following
 	svcs set.Slice[types.UID] // UIDs of all currently managed egress Services for ProxyGroup
 }
@@ -141,7 +143,7 @@ func (esr *egressSvcsReconciler) Reconcile(ctx context.Context, req reconcile.Re
 		svc.Finalizers = append(svc.Finalizers, FinalizerName)
 		if err := esr.updateSvcSpec(ctx, svc); err != nil {
 			err := fmt.Errorf("failed to add finalizer: %w", err)
-			r := svcConfiguredReason(svc, false, lg)
+			r := svcConfiguredReason(svc, false, lg, esr.annotationPrefix)
 			tsoperator.SetServiceCondition(svc, tsapi.EgressSvcConfigured, metav1.ConditionFalse, r, err.Error(), esr.clock, lg)
 			return res, err
 		}
@@ -153,7 +155,7 @@ func (esr *egressSvcsReconciler) Reconcile(ctx context.Context, req reconcile.Re
 
 	if err := esr.maybeCleanupProxyGroupConfig(ctx, svc, lg); err != nil {
 		err = fmt.Errorf("cleaning up resources for previous ProxyGroup failed: %w", err)
-		r := svcConfiguredReason(svc, false, lg)
+		r := svcConfiguredReason(svc, false, lg, esr.annotationPrefix)
 		tsoperator.SetServiceCondition(svc, tsapi.EgressSvcConfigured, metav1.ConditionFalse, r, err.Error(), esr.clock, lg)
 		return res, err
 	}
@@ -170,7 +172,7 @@ func (esr *egressSvcsReconciler) Reconcile(ctx context.Context, req reconcile.Re
 }
 
 func (esr *egressSvcsReconciler) maybeProvision(ctx context.Context, svc *corev1.Service, lg *zap.SugaredLogger) (err error) {
-	r := svcConfiguredReason(svc, false, lg)
+	r := svcConfiguredReason(svc, false, lg, esr.annotationPrefix)
 	st := metav1.ConditionFalse
 	defer func() {
 		msg := r
@@ -180,7 +182,7 @@ func (esr *egressSvcsReconciler) maybeProvision(ctx context.Context, svc *corev1
 		tsoperator.SetServiceCondition(svc, tsapi.EgressSvcConfigured, st, r, msg, esr.clock, lg)
 	}()
 
-	crl := egressSvcChildResourceLabels(svc)
+	crl := egressSvcChildResourceLabels(svc, esr.annotationPrefix)
 	clusterIPSvc, err := getSingleObject[corev1.Service](ctx, esr.Client, esr.tsNamespace, crl)
 	if err != nil {
 		err = fmt.Errorf("error retrieving ClusterIP Service: %w", err)
@@ -189,10 +191,10 @@ func (esr *egressSvcsReconciler) maybeProvision(ctx context.Context, svc *corev1
 	if clusterIPSvc == nil {
 		clusterIPSvc = esr.clusterIPSvcForEgress(crl)
 	}
-	upToDate := svcConfigurationUpToDate(svc, lg)
+	upToDate := svcConfigurationUpToDate(svc, lg, esr.annotationPrefix)
 	provisioned := true
 	if !upToDate {
-		if clusterIPSvc, provisioned, err = esr.provision(ctx, svc.Annotations[AnnotationProxyGroup], svc, clusterIPSvc, lg); err != nil {
+		if clusterIPSvc, provisioned, err = esr.provision(ctx, esr.annotationPrefix.get(svc.Annotations, AnnotationProxyGroup), svc, clusterIPSvc, lg); err != nil {
 			return err
 		}
 	}
@@ -212,7 +214,7 @@ func (esr *egressSvcsReconciler) maybeProvision(ctx context.Context, svc *corev1
 			return err
 		}
 	}
-	r = svcConfiguredReason(svc, true, lg)
+	r = svcConfiguredReason(svc, true, lg, esr.annotationPrefix)
 	st = metav1.ConditionTrue
 	return nil
 }
@@ -315,7 +317,7 @@ func (esr *egressSvcsReconciler) provision(ctx context.Context, proxyGroupName s
 		}
 	}
 
-	crl := egressSvcEpsLabels(svc, clusterIPSvc)
+	crl := egressSvcEpsLabels(svc, clusterIPSvc, esr.annotationPrefix)
 	// TODO(irbekrm): support IPv6, but need to investigate how kube proxy
 	// sets up Service -> Pod routing when IPv6 is involved.
 	eps := &discoveryv1.EndpointSlice{
@@ -348,7 +350,7 @@ func (esr *egressSvcsReconciler) provision(ctx context.Context, proxyGroupName s
 	}
 	tailnetSvc := tailnetSvcName(svc)
 	gotCfg := (*cfgs)[tailnetSvc]
-	wantsCfg := egressSvcCfg(svc, clusterIPSvc, esr.tsNamespace, lg)
+	wantsCfg := egressSvcCfg(svc, clusterIPSvc, esr.tsNamespace, lg, esr.annotationPrefix)
 	if !reflect.DeepEqual(gotCfg, wantsCfg) {
 		lg.Debugf("updating egress services ConfigMap %s", cm.Name)
 		mak.Set(cfgs, tailnetSvc, wantsCfg)
@@ -379,7 +381,7 @@ func (esr *egressSvcsReconciler) maybeCleanup(ctx context.Context, svc *corev1.S
 		&corev1.Service{},
 		&discoveryv1.EndpointSlice{},
 	}
-	crl := egressSvcChildResourceLabels(svc)
+	crl := egressSvcChildResourceLabels(svc, esr.annotationPrefix)
 	for _, typ := range types {
 		if err := esr.DeleteAllOf(ctx, typ, client.InNamespace(esr.tsNamespace), client.MatchingLabels(crl)); err != nil {
 			return fmt.Errorf("error deleting %s: %w", typ, err)
@@ -403,7 +405,7 @@ func (esr *egressSvcsReconciler) maybeCleanup(ctx context.Context, svc *corev1.S
 }
 
 func (esr *egressSvcsReconciler) maybeCleanupProxyGroupConfig(ctx context.Context, svc *corev1.Service, lg *zap.SugaredLogger) error {
-	wantsProxyGroup := svc.Annotations[AnnotationProxyGroup]
+	wantsProxyGroup := esr.annotationPrefix.get(svc.Annotations, AnnotationProxyGroup)
 	cond := tsoperator.GetServiceCondition(svc, tsapi.EgressSvcConfigured)
 	if cond == nil {
 		return nil
@@ -463,7 +465,7 @@ func (esr *egressSvcsReconciler) clusterIPSvcForEgress(crl map[string]string) *c
 }
 
 func (esr *egressSvcsReconciler) ensureEgressSvcCfgDeleted(ctx context.Context, svc *corev1.Service, logger *zap.SugaredLogger) error {
-	crl := egressSvcChildResourceLabels(svc)
+	crl := egressSvcChildResourceLabels(svc, esr.annotationPrefix)
 	cmName := pgEgressCMName(crl[labelProxyGroup])
 	cm := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
@@ -506,7 +508,7 @@ func (esr *egressSvcsReconciler) ensureEgressSvcCfgDeleted(ctx context.Context,
 }
 
 func (esr *egressSvcsReconciler) validateClusterResources(ctx context.Context, svc *corev1.Service, lg *zap.SugaredLogger) (bool, error) {
-	proxyGroupName := svc.Annotations[AnnotationProxyGroup]
+	proxyGroupName := esr.annotationPrefix.get(svc.Annotations, AnnotationProxyGroup)
 	pg := &tsapi.ProxyGroup{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: proxyGroupName,
@@ -523,7 +525,7 @@ func (esr *egressSvcsReconciler) validateClusterResources(ctx context.Context, s
 		tsoperator.RemoveServiceCondition(svc, tsapi.EgressSvcConfigured)
 		return false, err
 	}
-	if violations := validateEgressService(svc, pg); len(violations) > 0 {
+	if violations := validateEgressService(svc, pg, esr.annotationPrefix); len(violations) > 0 {
 		msg := fmt.Sprintf("invalid egress Service: %s", strings.Join(violations, ", "))
 		esr.recorder.Event(svc, corev1.EventTypeWarning, "INVALIDSERVICE", msg)
 		lg.Info(msg)
@@ -541,9 +543,9 @@ func (esr *egressSvcsReconciler) validateClusterResources(ctx context.Context, s
 	return true, nil
 }
 
-func egressSvcCfg(externalNameSvc, clusterIPSvc *corev1.Service, ns string, lg *zap.SugaredLogger) egressservices.Config {
+func egressSvcCfg(externalNameSvc, clusterIPSvc *corev1.Service, ns string, lg *zap.SugaredLogger, prefix annotationPrefix) egressservices.Config {
 	d := retrieveClusterDomain(ns, lg)
-	tt := tailnetTargetFromSvc(externalNameSvc)
+	tt := tailnetTargetFromSvc(externalNameSvc, prefix)
 	hep := healthCheckForSvc(clusterIPSvc, d)
 	cfg := egressservices.Config{
 		TailnetTarget:       tt,
@@ -559,12 +561,12 @@ func egressSvcCfg(externalNameSvc, clusterIPSvc *corev1.Service, ns string, lg *
 	return cfg
 }
 
-func validateEgressService(svc *corev1.Service, pg *tsapi.ProxyGroup) []string {
-	violations := validateService(svc)
+func validateEgressService(svc *corev1.Service, pg *tsapi.ProxyGroup, prefix annotationPrefix) []string {
+	violations := validateService(svc, prefix)
 
 	// We check that only one of these two is set in the earlier validateService function.
-	if svc.Annotations[AnnotationTailnetTargetFQDN] == "" && svc.Annotations[AnnotationTailnetTargetIP] == "" {
-		violations = append(violations, fmt.Sprintf("egress Service for ProxyGroup must have one of %s, %s annotations set", AnnotationTailnetTargetFQDN, AnnotationTailnetTargetIP))
+	if prefix.get(svc.Annotations, AnnotationTailnetTargetFQDN) == "" && prefix.get(svc.Annotations, AnnotationTailnetTargetIP) == "" {
+		violations = append(violations, fmt.Sprintf("egress Service for ProxyGroup must have one of %s, %s annotations set", prefix.key(AnnotationTailnetTargetFQDN), prefix.key(AnnotationTailnetTargetIP)))
 	}
 	if len(svc.Spec.Ports) == 0 {
 		violations = append(violations, "egress Service for ProxyGroup must have at least one target Port specified")
@@ -617,14 +619,14 @@ func unusedPort(usedPorts sets.Set[int32]) int32 {
 // tailnetTargetFromSvc returns a tailnet target for the given egress Service.
 // Service must contain exactly one of tailscale.com/tailnet-ip,
 // tailscale.com/tailnet-fqdn annotations.
-func tailnetTargetFromSvc(svc *corev1.Service) egressservices.TailnetTarget {
-	if fqdn := svc.Annotations[AnnotationTailnetTargetFQDN]; fqdn != "" {
+func tailnetTargetFromSvc(svc *corev1.Service, prefix annotationPrefix) egressservices.TailnetTarget {
+	if fqdn := prefix.get(svc.Annotations, AnnotationTailnetTargetFQDN); fqdn != "" {
 		return egressservices.TailnetTarget{
 			FQDN: fqdn,
 		}
 	}
 	return egressservices.TailnetTarget{
-		IP: svc.Annotations[AnnotationTailnetTargetIP],
+		IP: prefix.get(svc.Annotations, AnnotationTailnetTargetIP),
 	}
 }
 
@@ -637,13 +639,13 @@ func portMap(p corev1.ServicePort) egressservices.PortMap {
 	}
 }
 
-func isEgressSvcForProxyGroup(obj client.Object) bool {
+func isEgressSvcForProxyGroup(obj client.Object, prefix annotationPrefix) bool {
 	s, ok := obj.(*corev1.Service)
 	if !ok {
 		return false
 	}
 	annots := s.ObjectMeta.Annotations
-	return annots[AnnotationProxyGroup] != "" && (annots[AnnotationTailnetTargetFQDN] != "" || annots[AnnotationTailnetTargetIP] != "")
+	return prefix.get(annots, AnnotationProxyGroup) != "" && (prefix.get(annots, AnnotationTailnetTargetFQDN) != "" || prefix.get(annots, AnnotationTailnetTargetIP) != "")
 }
 
 // egressSvcConfig returns a ConfigMap that contains egress services configuration for the provided ProxyGroup as well
@@ -678,20 +680,20 @@ func egressSvcsConfigs(ctx context.Context, cl client.Client, proxyGroupName, ts
 // resource names (ProxyGroup, Service). Maximum allowed label length is 63
 // chars whilst the maximum allowed resource name length is 253 chars, so we
 // should probably validate and truncate (?) the names is they are too long.
-func egressSvcChildResourceLabels(svc *corev1.Service) map[string]string {
+func egressSvcChildResourceLabels(svc *corev1.Service, prefix annotationPrefix) map[string]string {
 	return map[string]string{
 		kubetypes.LabelManaged: "true",
 		LabelParentType:        "svc",
 		LabelParentName:        svc.Name,
 		LabelParentNamespace:   svc.Namespace,
-		labelProxyGroup:        svc.Annotations[AnnotationProxyGroup],
+		labelProxyGroup:        prefix.get(svc.Annotations, AnnotationProxyGroup),
 		labelSvcType:           typeEgress,
 	}
 }
 
 // egressEpsLabels returns labels to be added to an EndpointSlice created for an egress service.
-func egressSvcEpsLabels(extNSvc, clusterIPSvc *corev1.Service) map[string]string {
-	lbels := egressSvcChildResourceLabels(extNSvc)
+func egressSvcEpsLabels(extNSvc, clusterIPSvc *corev1.Service, prefix annotationPrefix) map[string]string {
+	lbels := egressSvcChildResourceLabels(extNSvc, prefix)
 	// Adding this label is what makes kube proxy set up rules to route traffic sent to the clusterIP Service to the
 	// endpoints defined on this EndpointSlice.
 	// https://kubernetes.io/docs/concepts/services-networking/endpoint-slices/#ownership
@@ -702,7 +704,7 @@ func egressSvcEpsLabels(extNSvc, clusterIPSvc *corev1.Service) map[string]string
 	return lbels
 }
 
-func svcConfigurationUpToDate(svc *corev1.Service, lg *zap.SugaredLogger) bool {
+func svcConfigurationUpToDate(svc *corev1.Service, lg *zap.SugaredLogger, prefix annotationPrefix) bool {
 	cond := tsoperator.GetServiceCondition(svc, tsapi.EgressSvcConfigured)
 	if cond == nil {
 		return false
@@ -710,7 +712,7 @@ func svcConfigurationUpToDate(svc *corev1.Service, lg *zap.SugaredLogger) bool {
 	if cond.Status != metav1.ConditionTrue {
 		return false
 	}
-	wantsReadyReason := svcConfiguredReason(svc, true, lg)
+	wantsReadyReason := svcConfiguredReason(svc, true, lg, prefix)
 	return strings.EqualFold(wantsReadyReason, cond.Reason)
 }
 
@@ -736,19 +738,19 @@ type cfg struct {
 	ProxyGroup    string                       `json:"proxyGroup"`
 }
 
-func svcConfiguredReason(svc *corev1.Service, configured bool, lg *zap.SugaredLogger) string {
+func svcConfiguredReason(svc *corev1.Service, configured bool, lg *zap.SugaredLogger, prefix annotationPrefix) string {
 	var r string
 	if configured {
 		r = "ConfiguredFor:"
 	} else {
 		r = fmt.Sprintf("ConfigurationFailed:%s", r)
 	}
-	r += fmt.Sprintf("ProxyGroup:%s", svc.Annotations[AnnotationProxyGroup])
-	tt := tailnetTargetFromSvc(svc)
+	r += fmt.Sprintf("ProxyGroup:%s", prefix.get(svc.Annotations, AnnotationProxyGroup))
+	tt := tailnetTargetFromSvc(svc, prefix)
 	s := cfg{
 		Ports:         svc.Spec.Ports,
 		TailnetTarget: tt,
-		ProxyGroup:    svc.Annotations[AnnotationProxyGroup],
+		ProxyGroup:    prefix.get(svc.Annotations, AnnotationProxyGroup),
 	}
 	r += fmt.Sprintf(":Config:%s", cfgHash(s, lg))
 	return r
@@ -140,7 +140,7 @@ func validateReadyService(t *testing.T, fc client.WithWatch, esr *egressSvcsReco
 	expectEqual(t, fc, endpointSlice(name, svc, clusterSvc))
 	// Verify that ConfigMap contains configuration for the new egress service.
 	mustHaveConfigForSvc(t, fc, svc, clusterSvc, cm, zl)
-	r := svcConfiguredReason(svc, true, zl.Sugar())
+	r := svcConfiguredReason(svc, true, zl.Sugar(), annotationDomain)
 	// Verify that the user-created ExternalName Service has Configured set to true and ExternalName pointing to the
 	// CluterIP Service.
 	svc.Status.Conditions = []metav1.Condition{
@@ -165,7 +165,7 @@ func condition(typ tsapi.ConditionType, st metav1.ConditionStatus, r, msg string
 
 func findGenNameForEgressSvcResources(t *testing.T, client client.Client, svc *corev1.Service) string {
 	t.Helper()
-	labels := egressSvcChildResourceLabels(svc)
+	labels := egressSvcChildResourceLabels(svc, annotationDomain)
 	s, err := getSingleObject[corev1.Service](context.Background(), client, "operator-ns", labels)
 	if err != nil {
 		t.Fatalf("finding ClusterIP Service for ExternalName Service %s: %v", svc.Name, err)
@@ -177,7 +177,7 @@ func findGenNameForEgressSvcResources(t *testing.T, client client.Client, svc *c
 }
 
 func clusterIPSvc(name string, extNSvc *corev1.Service) *corev1.Service {
-	labels := egressSvcChildResourceLabels(extNSvc)
+	labels := egressSvcChildResourceLabels(extNSvc, annotationDomain)
 	ports := make([]corev1.ServicePort, len(extNSvc.Spec.Ports))
 	for i, port := range extNSvc.Spec.Ports {
 		ports[i] = corev1.ServicePort{ // Copy the port to avoid modifying the original.
@@ -223,7 +223,7 @@ func mustGetClusterIPSvc(t *testing.T, cl client.Client, name string) *corev1.Se
 }
 
 func endpointSlice(name string, extNSvc, clusterIPSvc *corev1.Service) *discoveryv1.EndpointSlice {
-	labels := egressSvcChildResourceLabels(extNSvc)
+	labels := egressSvcChildResourceLabels(extNSvc, annotationDomain)
 	labels[discoveryv1.LabelManagedBy] = "tailscale.com"
 	labels[discoveryv1.LabelServiceName] = name
 	return &discoveryv1.EndpointSlice{
@@ -251,7 +251,7 @@ func portsForEndpointSlice(svc *corev1.Service) []discoveryv1.EndpointPort {
 
 func mustHaveConfigForSvc(t *testing.T, cl client.Client, extNSvc, clusterIPSvc *corev1.Service, cm *corev1.ConfigMap, lg *zap.Logger) {
 	t.Helper()
-	wantsCfg := egressSvcCfg(extNSvc, clusterIPSvc, clusterIPSvc.Namespace, lg.Sugar())
+	wantsCfg := egressSvcCfg(extNSvc, clusterIPSvc, clusterIPSvc.Namespace, lg.Sugar(), annotationDomain)
 	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(cm), cm); err != nil {
 		t.Fatalf("Error retrieving ConfigMap: %v", err)
 	}
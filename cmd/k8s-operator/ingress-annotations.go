@@ -0,0 +1,447 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !plan9
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Annotations that configure HA Ingresses, i.e. Ingresses exposed on a
+// ProxyGroup. Annotations that are shared with Ingresses exposed on a
+// standalone proxy are declared in ingress.go.
+const (
+	// annotationHTTPEndpoint can be used to configure the Ingress to expose an HTTP endpoint to tailnet (as
+	// well as the default HTTPS endpoint).
+	annotationHTTPEndpoint = "tailscale.com/http-endpoint"
+	// annotationHTTPMode can be used to configure how the HTTP endpoint
+	// handles requests. It can be set to "serve" (default), to serve the
+	// Ingress backends over HTTP, or "redirect", to redirect all requests to
+	// the HTTPS endpoint with a 301. It has no effect unless the HTTP endpoint
+	// is enabled.
+	annotationHTTPMode = "tailscale.com/http-mode"
+	httpModeServe      = "serve"
+	httpModeRedirect   = "redirect"
+	// annotationHTTPBackend can be set to "<service>:<port>" to serve the
+	// HTTP endpoint from a different backend Service than the HTTPS
+	// endpoint, e.g. an ACME responder. port can be a port number or name.
+	// All paths on the HTTP endpoint are proxied to the Service. It has no
+	// effect unless the HTTP endpoint is enabled and can not be combined
+	// with the "redirect" HTTP mode.
+	annotationHTTPBackend = "tailscale.com/http-backend"
+	// annotationServicePersistence can be used to configure whether the
+	// Tailscale Service should outlive the ProxyGroup replicas advertising it
+	// ("persistent", default) or be removed when they disconnect
+	// ("ephemeral").
+	annotationServicePersistence = "tailscale.com/service-persistence"
+	servicePersistent            = "persistent"
+	serviceEphemeral             = "ephemeral"
+	// annotationCertWait can be set to "false" to advertise the Tailscale
+	// Service and report the HTTPS endpoint in the Ingress status before the
+	// TLS cert has been issued. Until then, HTTPS requests fail or are served
+	// a temporary cert. Defaults to "true", i.e. the HTTPS endpoint is only
+	// made available once the cert is in place.
+	annotationCertWait = "tailscale.com/cert-wait"
+	// annotationPriority is reserved for setting the priority with which the
+	// control plane assigns VIPs to Tailscale Services that share a pool. The
+	// Tailscale Services API has no way to set it, so Ingresses that set it
+	// are rejected rather than having it silently ignored.
+	annotationPriority = "tailscale.com/priority"
+	// annotationAutoApprovers is reserved for setting who can approve the
+	// Tailscale Service's advertisement. Auto-approvers can only be set in
+	// the autoApprovers.services section of the tailnet policy file, not
+	// through the Tailscale Services API, so Ingresses that set it are
+	// rejected rather than having it silently ignored.
+	annotationAutoApprovers = "tailscale.com/auto-approvers"
+	// annotationReplicas can be set to a comma-separated list of ProxyGroup
+	// replica indices to advertise the Ingress's Tailscale Service only from
+	// those replicas. By default, all replicas advertise it.
+	annotationReplicas = "tailscale.com/replicas"
+	// annotationPortAliases can be set to a comma-separated list of ports on
+	// which the Ingress' HTTPS endpoint is also served, such as a legacy
+	// 8443, with the same handlers and TLS settings as on port 443. The
+	// ports are added to the Tailscale Service. Ports 443 and 80 are
+	// reserved for the HTTPS and HTTP endpoints.
+	annotationPortAliases = "tailscale.com/port-aliases"
+	// annotationTLSSecret can be set to the name of a kubernetes.io/tls
+	// Secret in the operator's namespace that contains a TLS cert for the
+	// Ingress' MagicDNS name issued out-of-band. The operator copies the
+	// cert to the ProxyGroup's TLS Secret and ProxyGroup Pods only get read
+	// access to it, so that they serve the cert rather than requesting one.
+	// The Secret must be in the operator's namespace as that is the only
+	// namespace the operator can read Secrets from.
+	annotationTLSSecret = "tailscale.com/tls-secret"
+	// annotationTrustBundleSecret can be set to the name of a Secret in the
+	// operator's namespace that contains a PEM-encoded CA bundle under the
+	// ca.crt key. Clients of the Ingress must then present a TLS client
+	// certificate signed by one of the CAs to connect. It can not be
+	// combined with the HTTP endpoint, as client certificates can only be
+	// verified over HTTPS.
+	annotationTrustBundleSecret = "tailscale.com/trust-bundle-secret"
+	// trustBundleKey is the key of the CA bundle in a trust bundle Secret.
+	trustBundleKey = "ca.crt"
+	// annotationReadyGate can be set to "configmap/<name>" or
+	// "secret/<name>" to reference a ConfigMap or Secret in the operator's
+	// namespace that must exist before the Ingress' Tailscale Service is
+	// advertised and the Ingress is marked ready in its status, such as
+	// config that its backends need. Until it exists, and again if it is
+	// deleted, the Tailscale Service is not advertised and the Ingress
+	// status is cleared.
+	annotationReadyGate = "tailscale.com/ready-gate"
+	readyGateConfigMap  = "configmap"
+	readyGateSecret     = "secret"
+	// annotationBackendProbe can be set to "true" to check that the Ingress'
+	// backends accept TCP connections from within the cluster before the
+	// Ingress is marked ready in its status. If a backend is unreachable, a
+	// warning Event with reason BackendUnreachable is emitted and the probe
+	// is retried after backendProbeRetryInterval. Defaults to "false", as
+	// probing adds a connection per backend to every reconcile.
+	annotationBackendProbe    = "tailscale.com/backend-probe"
+	reasonBackendUnreachable  = "BackendUnreachable"
+	backendProbeTimeout       = 5 * time.Second
+	backendProbeRetryInterval = 30 * time.Second
+	// annotationBackendSelector can be set to a label selector, such as
+	// "app=web,tier!=canary", to proxy requests for each path directly to
+	// the ready Pods in the Ingress' namespace that match it, rather than to
+	// the path's backend Service. Requests are load balanced round-robin
+	// across the Pods. The backend's Service port number is used as the
+	// Pods' port, and a port name is resolved against the Pods' container
+	// ports. Pods are not watched, so the set of Pods is refreshed every
+	// backendSelectorResyncInterval.
+	annotationBackendSelector     = "tailscale.com/backend-selector"
+	reasonNoBackendPods           = "NoBackendPods"
+	backendSelectorResyncInterval = 30 * time.Second
+)
+
+// ingressAnnotation validates an annotation of an HA Ingress.
+type ingressAnnotation struct {
+	name string
+	// validate returns an error if the value of the annotation on ing is
+	// invalid. It is only called if the annotation is set.
+	validate func(ing *networkingv1.Ingress, prefix annotationPrefix) error
+}
+
+// ingressAnnotations are the annotations of HA Ingresses whose values are
+// validated without reading other resources. Annotations that reference
+// other resources, and combinations of annotations (see incompatibleModes),
+// are validated in validateIngress. To validate a new annotation, add it
+// here.
+var ingressAnnotations = []ingressAnnotation{
+	oneOf(annotationHTTPMode, httpModeServe, httpModeRedirect),
+	parsedBy(annotationDefaultResponse, defaultResponseHandler),
+	{name: AnnotationFunnel, validate: validateFunnel},
+	{name: annotationStandaloneService, validate: validateStandaloneService},
+	parsedBy(annotationHTTPBackend, httpBackend),
+	parsedBy(annotationMaxConnections, maxConnections),
+	parsedBy(annotationResponseHeaders, responseHeaders),
+	{name: annotationBackendRetries, validate: func(ing *networkingv1.Ingress, prefix annotationPrefix) error {
+		_, _, err := backendRetries(ing, prefix)
+		return err
+	}},
+	parsedBy(annotationAccessLog, accessLogEnabled),
+	tcpKeepAliveAnnotation(annotationTCPKeepAliveIdle),
+	tcpKeepAliveAnnotation(annotationTCPKeepAliveInterval),
+	parsedBy(annotationClientHTTPVersion, clientHTTP1Only),
+	parsedBy(annotationBackendDialFamily, backendDialFamily),
+	parsedBy(annotationBackendSelector, backendSelector),
+	// TODO: pass the priority through to the Tailscale Service once the
+	// Tailscale Services API supports it. Until then it is rejected rather
+	// than silently ignored.
+	unsupported(annotationPriority, "Tailscale Service priorities are not yet supported by the Tailscale API"),
+	unsupported(annotationAutoApprovers, "auto-approvers of Tailscale Services can only be set in the autoApprovers.services section of the tailnet policy file"),
+	// TODO: pass ephemeral through to the Tailscale Service once the
+	// Tailscale Services API supports it. Until then it is rejected rather
+	// than silently ignored.
+	{name: annotationServicePersistence, validate: validateServicePersistence},
+	oneOf(annotationCertWait, "true", "false"),
+	oneOf(annotationBackendProbe, "true", "false"),
+	parsedBy(annotationPortAliases, portAliases),
+	parsedBy(annotationReplicas, pinnedReplicas),
+	{name: annotationReadyGate, validate: validateReadyGate},
+}
+
+// validateIngressAnnotations returns an error for each annotation in
+// ingressAnnotations that is set to an invalid value on ing.
+func validateIngressAnnotations(ing *networkingv1.Ingress, prefix annotationPrefix) error {
+	var errs []error
+	for _, a := range ingressAnnotations {
+		if _, ok := prefix.lookup(ing.Annotations, a.name); !ok {
+			continue
+		}
+		if err := a.validate(ing, prefix); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// oneOf returns an ingressAnnotation for the annotation name that must be set
+// to either a or b.
+func oneOf(name, a, b string) ingressAnnotation {
+	return ingressAnnotation{name: name, validate: func(ing *networkingv1.Ingress, prefix annotationPrefix) error {
+		if v := prefix.get(ing.Annotations, name); v != a && v != b {
+			return fmt.Errorf("Ingress has invalid %s annotation %q: must be %q or %q", prefix.key(name), v, a, b)
+		}
+		return nil
+	}}
+}
+
+// parsedBy returns an ingressAnnotation for the annotation name that is valid
+// if parse, which returns the annotation's parsed value, succeeds.
+func parsedBy[T any](name string, parse func(*networkingv1.Ingress, annotationPrefix) (T, error)) ingressAnnotation {
+	return ingressAnnotation{name: name, validate: func(ing *networkingv1.Ingress, prefix annotationPrefix) error {
+		_, err := parse(ing, prefix)
+		return err
+	}}
+}
+
+// unsupported returns an ingressAnnotation for the annotation name that is
+// reserved for a setting that can not be applied, for the given reason. It
+// is rejected rather than silently ignored.
+func unsupported(name, reason string) ingressAnnotation {
+	return ingressAnnotation{name: name, validate: func(ing *networkingv1.Ingress, prefix annotationPrefix) error {
+		return fmt.Errorf("Ingress has %s annotation %q: %s", prefix.key(name), prefix.get(ing.Annotations, name), reason)
+	}}
+}
+
+// validateServicePersistence validates the tailscale.com/service-persistence
+// annotation.
+func validateServicePersistence(ing *networkingv1.Ingress, prefix annotationPrefix) error {
+	switch p := prefix.get(ing.Annotations, annotationServicePersistence); p {
+	case servicePersistent:
+		return nil
+	case serviceEphemeral:
+		return fmt.Errorf("Ingress has %s annotation %q: ephemeral Tailscale Services are not yet supported by the Tailscale API", prefix.key(annotationServicePersistence), p)
+	default:
+		return fmt.Errorf("Ingress has invalid %s annotation %q: must be %q or %q", prefix.key(annotationServicePersistence), p, servicePersistent, serviceEphemeral)
+	}
+}
+
+// validateReadyGate validates the tailscale.com/ready-gate annotation. The
+// resource it references may not exist (yet).
+func validateReadyGate(ing *networkingv1.Ingress, prefix annotationPrefix) error {
+	v := prefix.get(ing.Annotations, annotationReadyGate)
+	if _, _, err := parseReadyGate(v); err != nil {
+		return fmt.Errorf("Ingress has invalid %s annotation %q: %w", prefix.key(annotationReadyGate), v, err)
+	}
+	return nil
+}
+
+// tcpKeepAliveAnnotation returns an ingressAnnotation for the TCP keep-alive
+// annotation name.
+func tcpKeepAliveAnnotation(name string) ingressAnnotation {
+	return parsedBy(name, func(ing *networkingv1.Ingress, prefix annotationPrefix) (time.Duration, error) {
+		return tcpKeepAliveDuration(ing, prefix, name)
+	})
+}
+
+// parseReadyGate parses the value of a tailscale.com/ready-gate annotation
+// into the kind of the resource it references, readyGateConfigMap or
+// readyGateSecret, and its name.
+func parseReadyGate(v string) (kind, name string, err error) {
+	kind, name, ok := strings.Cut(v, "/")
+	if !ok || (kind != readyGateConfigMap && kind != readyGateSecret) {
+		return "", "", errors.New("must be of the form configmap/<name> or secret/<name>")
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return "", "", fmt.Errorf("invalid name %q: %s", name, strings.Join(errs, "; "))
+	}
+	return kind, name, nil
+}
+
+// isHTTPEndpointEnabled returns true if the Ingress has been configured to expose an HTTP endpoint to tailnet.
+func isHTTPEndpointEnabled(ing *networkingv1.Ingress, prefix annotationPrefix) bool {
+	if ing == nil {
+		return false
+	}
+	return prefix.get(ing.Annotations, annotationHTTPEndpoint) == "enabled"
+}
+
+// shouldWaitForCert returns true unless the Ingress has opted out of waiting
+// for its TLS cert to be issued before exposing the HTTPS endpoint.
+func shouldWaitForCert(ing *networkingv1.Ingress, prefix annotationPrefix) bool {
+	return prefix.get(ing.Annotations, annotationCertWait) != "false"
+}
+
+// shouldProbeBackends returns true if the Ingress has requested that its
+// backends are probed before it is marked ready.
+func shouldProbeBackends(ing *networkingv1.Ingress, prefix annotationPrefix) bool {
+	return prefix.get(ing.Annotations, annotationBackendProbe) == "true"
+}
+
+// backendSelector returns the label selector configured by the
+// tailscale.com/backend-selector annotation, or nil if the Ingress' backends
+// are proxied to via their Services.
+func backendSelector(ing *networkingv1.Ingress, prefix annotationPrefix) (labels.Selector, error) {
+	v, ok := prefix.lookup(ing.Annotations, annotationBackendSelector)
+	if !ok {
+		return nil, nil
+	}
+	if strings.TrimSpace(v) == "" {
+		return nil, fmt.Errorf("Ingress has invalid %s annotation %q: must not be empty", prefix.key(annotationBackendSelector), v)
+	}
+	sel, err := labels.Parse(v)
+	if err != nil {
+		return nil, fmt.Errorf("Ingress has invalid %s annotation %q: %w", prefix.key(annotationBackendSelector), v, err)
+	}
+	return sel, nil
+}
+
+// isHTTPRedirectEnabled returns true if the Ingress has been configured to
+// redirect requests to its HTTP endpoint to HTTPS.
+func isHTTPRedirectEnabled(ing *networkingv1.Ingress, prefix annotationPrefix) bool {
+	if ing == nil {
+		return false
+	}
+	return prefix.get(ing.Annotations, annotationHTTPMode) == httpModeRedirect
+}
+
+// httpBackend returns the backend for the HTTP endpoint configured by the
+// tailscale.com/http-backend annotation, or nil if the HTTP endpoint is served
+// from the same backends as the HTTPS endpoint.
+func httpBackend(ing *networkingv1.Ingress, prefix annotationPrefix) (*networkingv1.IngressBackend, error) {
+	v, ok := prefix.lookup(ing.Annotations, annotationHTTPBackend)
+	if !ok {
+		return nil, nil
+	}
+	name, port, ok := strings.Cut(v, ":")
+	if !ok || name == "" || port == "" {
+		return nil, fmt.Errorf("Ingress has invalid %s annotation %q: must be of the form <service>:<port>", prefix.key(annotationHTTPBackend), v)
+	}
+	b := &networkingv1.IngressBackend{
+		Service: &networkingv1.IngressServiceBackend{Name: name},
+	}
+	if n, err := strconv.ParseInt(port, 10, 32); err == nil {
+		if n <= 0 || n > 65535 {
+			return nil, fmt.Errorf("Ingress has invalid %s annotation %q: port must be between 1 and 65535", prefix.key(annotationHTTPBackend), v)
+		}
+		b.Service.Port.Number = int32(n)
+	} else {
+		b.Service.Port.Name = port
+	}
+	return b, nil
+}
+
+// ingressMode is a mode of an HA Ingress that is enabled by an annotation,
+// optionally only with a specific value.
+type ingressMode struct {
+	annotation string
+	// value is the value of the annotation that enables the mode. If it is
+	// empty, the mode is enabled by any value.
+	value string
+}
+
+// enabled reports whether the mode is enabled for ing.
+func (m ingressMode) enabled(ing *networkingv1.Ingress, prefix annotationPrefix) bool {
+	v, ok := prefix.lookup(ing.Annotations, m.annotation)
+	return ok && (m.value == "" || v == m.value)
+}
+
+// has describes the mode as the object of "Ingress has", such as
+// `tailscale.com/http-mode annotation "redirect"`.
+func (m ingressMode) has(prefix annotationPrefix) string {
+	if m.value == "" {
+		return prefix.key(m.annotation) + " annotation"
+	}
+	return fmt.Sprintf("%s annotation %q", prefix.key(m.annotation), m.value)
+}
+
+// is describes the mode as a clause, such as
+// `tailscale.com/http-mode annotation is "redirect"`.
+func (m ingressMode) is(prefix annotationPrefix) string {
+	if m.value == "" {
+		return prefix.key(m.annotation) + " annotation is set"
+	}
+	return fmt.Sprintf("%s annotation is %q", prefix.key(m.annotation), m.value)
+}
+
+// incompatibleModes are the pairs of modes that can not be combined on an HA
+// Ingress, with the reason why. To reject a new combination, add it here.
+var incompatibleModes = []struct {
+	a, b   ingressMode
+	reason string
+}{
+	{
+		a:      ingressMode{annotation: annotationHTTPBackend},
+		b:      ingressMode{annotation: annotationHTTPMode, value: httpModeRedirect},
+		reason: "HTTP requests can not both be redirected and served from a backend",
+	},
+	{
+		a:      ingressMode{annotation: annotationCertRenewalOwner, value: "true"},
+		b:      ingressMode{annotation: annotationTLSSecret},
+		reason: "an Ingress with an externally managed TLS cert does not share the ProxyGroup's consolidated cert Secret, so it can not be its renewal owner",
+	},
+	{
+		a:      ingressMode{annotation: annotationTrustBundleSecret},
+		b:      ingressMode{annotation: annotationHTTPEndpoint, value: "enabled"},
+		reason: "client certificates can only be verified over HTTPS, so the HTTP endpoint would serve the Ingress without them",
+	},
+}
+
+// validateModeCombinations returns an error for each pair of incompatible
+// modes that ing combines.
+func validateModeCombinations(ing *networkingv1.Ingress, prefix annotationPrefix) error {
+	var errs []error
+	for _, c := range incompatibleModes {
+		if c.a.enabled(ing, prefix) && c.b.enabled(ing, prefix) {
+			errs = append(errs, fmt.Errorf("Ingress has %s, but %s: %s", c.a.has(prefix), c.b.is(prefix), c.reason))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// pinnedReplicas returns the ProxyGroup replica indices listed in the
+// Ingress's tailscale.com/replicas annotation, or nil if the annotation is not
+// set.
+func pinnedReplicas(ing *networkingv1.Ingress, prefix annotationPrefix) ([]int32, error) {
+	v, ok := prefix.lookup(ing.Annotations, annotationReplicas)
+	if !ok {
+		return nil, nil
+	}
+	replicas := []int32{}
+	for _, f := range strings.Split(v, ",") {
+		i, err := strconv.ParseInt(strings.TrimSpace(f), 10, 32)
+		if err != nil || i < 0 {
+			return nil, fmt.Errorf("Ingress has invalid %s annotation %q: must be a comma-separated list of ProxyGroup replica indices", prefix.key(annotationReplicas), v)
+		}
+		replicas = append(replicas, int32(i))
+	}
+	return replicas, nil
+}
+
+// portAliases returns the ports listed in the Ingress'
+// tailscale.com/port-aliases annotation in ascending order, or nil if the
+// annotation is not set.
+func portAliases(ing *networkingv1.Ingress, prefix annotationPrefix) ([]uint16, error) {
+	v, ok := prefix.lookup(ing.Annotations, annotationPortAliases)
+	if !ok {
+		return nil, nil
+	}
+	var ports []uint16
+	for _, f := range strings.Split(v, ",") {
+		p, err := strconv.ParseUint(strings.TrimSpace(f), 10, 16)
+		if err != nil || p == 0 {
+			return nil, fmt.Errorf("Ingress has invalid %s annotation %q: must be a comma-separated list of port numbers", prefix.key(annotationPortAliases), v)
+		}
+		if p == 443 || p == 80 {
+			return nil, fmt.Errorf("Ingress has invalid %s annotation %q: port %d is reserved for the HTTPS and HTTP endpoints", prefix.key(annotationPortAliases), v, p)
+		}
+		if slices.Contains(ports, uint16(p)) {
+			return nil, fmt.Errorf("Ingress has invalid %s annotation %q: port %d is listed more than once", prefix.key(annotationPortAliases), v, p)
+		}
+		ports = append(ports, uint16(p))
+	}
+	slices.Sort(ports)
+	return ports, nil
+}
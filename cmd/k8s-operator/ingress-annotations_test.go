@@ -0,0 +1,77 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !plan9
+
+package main
+
+import (
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"tailscale.com/util/set"
+)
+
+func TestIngressAnnotations(t *testing.T) {
+	seen := make(set.Set[string])
+	for _, a := range ingressAnnotations {
+		if !knownAnnotations.Contains(a.name) {
+			t.Errorf("annotation %s is validated but not in knownAnnotations", a.name)
+		}
+		if seen.Contains(a.name) {
+			t.Errorf("annotation %s is listed more than once", a.name)
+		}
+		seen.Add(a.name)
+	}
+}
+
+func TestValidateIngressAnnotations(t *testing.T) {
+	tests := []struct {
+		name    string
+		annots  map[string]string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			annots: map[string]string{
+				"example.com/http-mode":   "redirect",
+				"example.com/cert-wait":   "false",
+				"example.com/replicas":    "0,1",
+				"tailscale.com/cert-wait": "ignored",
+			},
+		},
+		{
+			name:    "one_of",
+			annots:  map[string]string{"example.com/backend-probe": "yes"},
+			wantErr: `Ingress has invalid example.com/backend-probe annotation "yes": must be "true" or "false"`,
+		},
+		{
+			name:    "unsupported",
+			annots:  map[string]string{"example.com/priority": "10"},
+			wantErr: `Ingress has example.com/priority annotation "10": Tailscale Service priorities are not yet supported by the Tailscale API`,
+		},
+		{
+			name: "multiple_invalid",
+			annots: map[string]string{
+				"example.com/service-persistence": "ephemeral",
+				"example.com/max-connections":     "-1",
+			},
+			wantErr: "Ingress has invalid example.com/max-connections annotation \"-1\": must be a positive integer\n" +
+				`Ingress has example.com/service-persistence annotation "ephemeral": ephemeral Tailscale Services are not yet supported by the Tailscale API`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ing := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annots}}
+			err := validateIngressAnnotations(ing, annotationPrefix("example.com/"))
+			var got string
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tt.wantErr {
+				t.Errorf("validateIngressAnnotations() = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
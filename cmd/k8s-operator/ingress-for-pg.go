@@ -6,28 +6,43 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"maps"
 	"math/rand/v2"
+	"net"
 	"net/http"
+	"net/netip"
+	"net/url"
 	"reflect"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
+	"golang.org/x/net/dns/dnsmessage"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"tailscale.com/internal/client/tailscale"
@@ -36,7 +51,10 @@
 	tsoperator "tailscale.com/k8s-operator"
 	tsapi "tailscale.com/k8s-operator/apis/v1alpha1"
 	"tailscale.com/kube/kubetypes"
+	"tailscale.com/net/netx"
 	"tailscale.com/tailcfg"
+	"tailscale.com/tstime"
+	"tailscale.com/types/dnstype"
 	"tailscale.com/util/clientmetric"
 	"tailscale.com/util/dnsname"
 	"tailscale.com/util/mak"
@@ -50,20 +68,136 @@
 	FinalizerNamePG = "tailscale.com/ingress-pg-finalizer"
 
 	indexIngressProxyGroup = ".metadata.annotations.ingress-proxy-group"
-	// annotationHTTPEndpoint can be used to configure the Ingress to expose an HTTP endpoint to tailnet (as
-	// well as the default HTTPS endpoint).
-	annotationHTTPEndpoint = "tailscale.com/http-endpoint"
+	indexIngressTLSSecret  = ".metadata.annotations.ingress-tls-secret"
+	// indexIngressReadyGate indexes HA Ingresses by the names of the
+	// ConfigMaps referenced by their tailscale.com/ready-gate annotation.
+	indexIngressReadyGate = ".metadata.annotations.ingress-ready-gate"
+	// reasonAdvertisingReplicasChanged is the reason of the Normal Event
+	// emitted for an HA Ingress when the set of ProxyGroup replicas that
+	// advertise its Tailscale Service changes. The Ingress status is a core
+	// type with no room for this, and the operator does not write to the
+	// Ingress's spec or metadata for it, as those are owned by the user.
+	reasonAdvertisingReplicasChanged = "AdvertisingReplicasChanged"
+	// maxServiceNameLength is the maximum length of a Tailscale Service name
+	// without the "svc:" prefix. The name is used as the first label of the
+	// MagicDNS name, so it is limited to the length of a DNS label.
+	maxServiceNameLength = 63
+	// annotationWaitingForDependency is set by the operator on HA Ingresses
+	// to the value of their tailscale.com/ready-gate annotation while the
+	// referenced resource does not exist. The Ingress status is a core type
+	// with no conditions, so the WaitingForDependency condition is exposed
+	// as this annotation. The annotation is removed once the resource
+	// exists.
+	annotationWaitingForDependency = "tailscale.com/waiting-for-dependency"
+	reasonWaitingForDependency     = "WaitingForDependency"
+	// annotationWaitingForDNS is set by the operator on HA Ingresses whose
+	// Tailscale Service is advertised but whose TLS cert has not been issued
+	// while their DNS name does not resolve on the tailnet yet, to the DNS
+	// name. The ProxyGroup Pods cannot get a cert for the name until it
+	// resolves, so the Ingress is reconciled every dnsPropagationRetryInterval
+	// until then. As for WaitingForDependency, the WaitingForDNS condition is
+	// exposed as this annotation. It is removed once the name resolves.
+	annotationWaitingForDNS     = "tailscale.com/waiting-for-dns"
+	reasonWaitingForDNS         = "WaitingForDNS"
+	dnsPropagationRetryInterval = 2 * time.Minute
+	// annotationManagedServices is set by the operator on a ProxyGroup's
+	// ingress serve config ConfigMap to a comma-separated list of the
+	// Tailscale Services in the serve config that the operator manages.
+	// Other entries, for example added by another controller or an admin,
+	// are never modified or removed by the operator. If the annotation is
+	// not set, as on ConfigMaps last written by older operator versions,
+	// all entries are assumed to be managed.
+	annotationManagedServices = "tailscale.com/managed-services"
+	// annotationCertRenewalOwner can be set to "true" on an HA Ingress to
+	// make it the renewal owner of the consolidated cert Secret that it
+	// shares with the other Ingresses on its ProxyGroup. If several
+	// Ingresses set it, or none does, the one with the lowest UID is
+	// elected. Only the renewal owner checks the certs in the Secret for
+	// renewal; the other Ingresses read the result, so that they do not all
+	// repeat the check. The operator records the renewal owner on the Secret
+	// under the same annotation, as the Ingress' namespace/name. It can not
+	// be combined with tailscale.com/tls-secret.
+	annotationCertRenewalOwner = "tailscale.com/cert-renewal-owner"
+	// annotationCertsExpiring is set by the operator on a consolidated cert
+	// Secret to a JSON object that maps the domains of the certs in it that
+	// expire within certExpiryWarning to their expiry times. It is the
+	// result of the renewal owner's check.
+	annotationCertsExpiring = "tailscale.com/certs-expiring"
 
 	labelDomain              = "tailscale.com/domain"
 	msgFeatureFlagNotEnabled = "Tailscale Service feature flag is not enabled for this tailnet, skipping provisioning. " +
 		"Please contact Tailscale support through https://tailscale.com/contact/support to enable the feature flag, then recreate the operator's Pod."
 
 	warningTailscaleServiceFeatureFlagNotEnabled = "TailscaleServiceFeatureFlagNotEnabled"
-	managedTSServiceComment                      = "This Tailscale Service is managed by the Tailscale Kubernetes Operator, do not modify"
+	warningTailscaleServiceRetained              = "TailscaleServiceRetained"
+	msgTailscaleServiceRetained                  = "Tailscale Service %s was not deleted as automatic deletion of Tailscale Services is disabled for this operator; delete it manually once no longer needed"
+	warningWrongProxyGroupType                   = "WrongProxyGroupType"
+
+	// Reasons of the Normal Events emitted for audit if emitAuditEvents is
+	// set.
+	reasonAuditTailscaleServiceCreated = "TailscaleServiceCreated"
+	reasonAuditTailscaleServiceUpdated = "TailscaleServiceUpdated"
+	reasonAuditTailscaleServiceDeleted = "TailscaleServiceDeleted"
+	reasonAuditOwnerRefAdded           = "OwnerReferenceAdded"
+	reasonAuditOwnerRefRemoved         = "OwnerReferenceRemoved"
+	reasonAuditCertIssued              = "CertIssued"
+
+	managedTSServiceComment = "This Tailscale Service is managed by the Tailscale Kubernetes Operator, do not modify"
+	// annotationServiceDescription can be set on HA Ingresses and Services
+	// to a description of the Tailscale Service, for tailnet admins. It is
+	// prepended to the Tailscale Service's comment.
+	annotationServiceDescription = "tailscale.com/service-description"
 )
 
 var gaugePGIngressResources = clientmetric.NewGauge(kubetypes.MetricIngressPGResourceCount)
 
+const (
+	ownerRefResultAdd    = "add"    // this operator's owner reference was added to a Tailscale Service
+	ownerRefResultRemove = "remove" // this operator's owner reference was removed from a Tailscale Service
+	// ownerRefResultConflict is a reconcile that failed, and is retried,
+	// because a resource it wrote was modified concurrently.
+	ownerRefResultConflict = "conflict"
+)
+
+const (
+	reconcilerIngressPG     = "ingress-pg-reconciler"
+	reconcilerServicePG     = "service-pg-reconciler"
+	reconcilerKubeAPIServer = "kube-apiserver-ts-service-reconciler"
+)
+
+// ownerRefChanges counts the changes that the HA reconcilers make to the
+// owner references of Tailscale Services, and their conflict retries. As
+// Tailscale Services can be shared with operators in other clusters, and
+// ProxyGroup configs between reconcilers, these help to diagnose contention.
+// They are served on the controller-runtime metrics endpoint, with the
+// reconcile metrics.
+var ownerRefChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "k8s_operator_owner_ref_changes_total",
+	Help: "Changes to the owner references of Tailscale Services, by reconciler and result",
+}, []string{"reconciler", "result"}) // result is one of the ownerRefResult* constants
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(ownerRefChanges)
+}
+
+// errProxyGroupNotReady is returned by maybeProvision if the Ingress's
+// ProxyGroup does not exist or is not ready yet.
+var errProxyGroupNotReady = errors.New("ProxyGroup is not ready")
+
+// errBackendUnreachable is returned by maybeProvision if the Ingress has
+// requested a backend probe and a backend could not be connected to.
+var errBackendUnreachable = errors.New("Ingress backend is unreachable")
+
+// errWaitingForDependency is returned by maybeProvision if the resource
+// referenced by the Ingress' ready gate does not exist. The Ingress is
+// reconciled again once it is created.
+var errWaitingForDependency = errors.New("Ingress ready gate does not exist")
+
+// errWaitingForDNS is returned by maybeProvision if the TLS cert for the
+// Ingress has not been issued and its DNS name does not resolve on the
+// tailnet yet. The Ingress status is updated before it is returned.
+var errWaitingForDNS = errors.New("Ingress DNS name does not resolve")
+
 // HAIngressReconciler is a controller that reconciles Tailscale Ingresses
 // should be exposed on an ingress ProxyGroup (in HA mode).
 type HAIngressReconciler struct {
@@ -75,14 +209,110 @@ type HAIngressReconciler struct {
 	tsnetServer      tsnetServer
 	tsNamespace      string
 	lc               localClient
-	defaultTags      []string
-	operatorID       string // stableID of the operator's Tailscale device
+	defaultTags      []string // default tags for HTTP-terminating Tailscale Services
+	operatorID       string   // stableID of the operator's Tailscale device
 	ingressClassName string
+	// previousOperatorID, if set, is the stable ID that the operator's
+	// Tailscale device had before it was re-created. Owner references
+	// under it are rewritten to operatorID on reconcile.
+	previousOperatorID string
+	// managedServiceTag, if set, is added to the tags of all Tailscale
+	// Services, regardless of the default or per-Ingress tags.
+	managedServiceTag string
+	// ownerAnnotationBudget, if positive, is the maximum size in bytes of
+	// the owner annotation on Tailscale Services.
+	ownerAnnotationBudget int
+	// ingressClassController is the expected spec.controller of the
+	// IngressClass.
+	ingressClassController string
+	watchNamespaces        []string // if non-empty, only Ingresses in these namespaces are managed
+	clock                  tstime.Clock
+	// certExpiryWarning, if positive, is how long before its TLS cert
+	// expires that an Ingress gets a warning Event about it.
+	certExpiryWarning time.Duration
+	// serviceNameStrategy determines the Tailscale Service names of
+	// Ingresses without a TLS host.
+	serviceNameStrategy serviceNameStrategy
+	// annotationPrefix is the prefix of the annotations that configure
+	// Ingresses.
+	annotationPrefix annotationPrefix
+	// annotationWarnings tracks the unknown annotations that Ingresses were
+	// warned about.
+	annotationWarnings annotationWarnings
+	// noAutoDeleteServices, if set, prevents the reconciler from deleting
+	// Tailscale Services that are no longer used; they are left in place
+	// without owner references for a human to delete.
+	noAutoDeleteServices bool
+	// proxyGroupNotReadyRequeue, if positive, is how long to wait before
+	// reconciling an Ingress again if its ProxyGroup is not ready. Otherwise
+	// the Ingress is only reconciled again once the ProxyGroup changes.
+	proxyGroupNotReadyRequeue time.Duration
+	// backendDialer, if set, is used to probe the backends of Ingresses
+	// with the tailscale.com/backend-probe annotation. Otherwise they are
+	// dialed directly. It is overridden in tests.
+	backendDialer netx.DialFunc
+	// createGracePeriod, if positive, is how long an Ingress must remain
+	// unchanged before a Tailscale Service is created for it, so that rapid
+	// edits, such as while GitOps tooling converges, are coalesced.
+	createGracePeriod time.Duration
+	// consolidateCertSecrets, if set, makes the reconciler store the TLS
+	// certs of all Ingresses on a ProxyGroup in a single Secret, rather
+	// than a Secret per domain, to reduce the number of watched objects.
+	// Ingresses with an externally managed cert keep a per-domain Secret.
+	consolidateCertSecrets bool
+	// emitAuditEvents, if set, makes the reconciler emit a Normal Event,
+	// with one of the reasonAudit* reasons, for each Tailscale Service it
+	// creates, updates or deletes, each owner reference it adds or removes,
+	// and each TLS cert that is issued.
+	emitAuditEvents bool
+	// podReader, if set, is used to list the Pods matched by the
+	// tailscale.com/backend-selector annotation of Ingresses. Otherwise the
+	// Client is used. The Client's cache only holds Pods in the operator's
+	// namespace, so the operator sets it to an uncached reader.
+	podReader client.Reader
+	// pendingWrites, if set, tracks the updates to the ProxyGroup replicas'
+	// config Secrets, so that they are completed on shutdown.
+	pendingWrites *pendingWrites
+	// clusterName, if set, identifies the operator's cluster in the
+	// provenance in Tailscale Service comments.
+	clusterName string
+	// noServiceProvenance, if set, leaves out the provenance of Tailscale
+	// Services, i.e. the Ingress and cluster, from their comments.
+	noServiceProvenance bool
 
 	mu sync.Mutex // protects following
 	// managedIngresses is a set of all ingress resources that we're currently
 	// managing. This is only used for metrics.
 	managedIngresses set.Slice[types.UID]
+	// pendingCreates tracks the Ingresses whose Tailscale Service creation
+	// is deferred by createGracePeriod. Entries are removed once the
+	// creation proceeds, or by maybeCleanup once the Ingress is deleted or
+	// no longer exposed on a ProxyGroup. It is not persisted, so the grace
+	// period restarts for pending Ingresses after the operator restarts.
+	pendingCreates map[types.NamespacedName]pendingCreate
+	// advertisingReplicas is the comma-separated list of the ProxyGroup
+	// replicas advertising each Ingress's Tailscale Service that was last
+	// reported in an Event. It is not persisted, so the current list is
+	// reported again after the operator restarts.
+	advertisingReplicas map[types.UID]string
+}
+
+// pendingCreate is an Ingress whose Tailscale Service creation is deferred
+// until it has been unchanged for the creation grace period.
+type pendingCreate struct {
+	uid             types.UID
+	resourceVersion string
+	since           time.Time // when the Ingress was first seen at resourceVersion
+}
+
+// createDeferredError is returned by maybeProvision if the creation of the
+// Tailscale Service for an Ingress is deferred by the creation grace period.
+type createDeferredError struct {
+	remaining time.Duration
+}
+
+func (e createDeferredError) Error() string {
+	return fmt.Sprintf("Tailscale Service creation deferred for %v", e.remaining)
 }
 
 // Reconcile reconciles Ingresses that should be exposed over Tailscale in HA
@@ -111,6 +341,7 @@ func (r *HAIngressReconciler) Reconcile(ctx context.Context, req reconcile.Reque
 	if apierrors.IsNotFound(err) {
 		// Request object not found, could have been deleted after reconcile request.
 		logger.Debugf("Ingress not found, assuming it was deleted")
+		r.forgetPendingCreate(req.NamespacedName)
 		return res, nil
 	} else if err != nil {
 		return res, fmt.Errorf("failed to get Ingress: %w", err)
@@ -119,7 +350,7 @@ func (r *HAIngressReconciler) Reconcile(ctx context.Context, req reconcile.Reque
 	// hostname is the name of the Tailscale Service that will be created
 	// for this Ingress as well as the first label in the MagicDNS name of
 	// the Ingress.
-	hostname := hostnameForIngress(ing)
+	hostname := r.serviceNameStrategy.hostnameForIngress(ing)
 	logger = logger.With("hostname", hostname)
 
 	// needsRequeue is set to true if the underlying Tailscale Service has
@@ -133,11 +364,37 @@ func (r *HAIngressReconciler) Reconcile(ctx context.Context, req reconcile.Reque
 	} else {
 		needsRequeue, err = r.maybeProvision(ctx, hostname, ing, logger)
 	}
+	if errors.Is(err, errProxyGroupNotReady) {
+		if r.proxyGroupNotReadyRequeue > 0 {
+			res = reconcile.Result{RequeueAfter: r.proxyGroupNotReadyRequeue}
+		}
+		return res, nil
+	}
+	if errors.Is(err, errBackendUnreachable) {
+		return reconcile.Result{RequeueAfter: backendProbeRetryInterval}, nil
+	}
+	if errors.Is(err, errWaitingForDependency) {
+		return res, nil
+	}
+	if errors.Is(err, errWaitingForDNS) {
+		return reconcile.Result{RequeueAfter: dnsPropagationRetryInterval}, nil
+	}
+	var deferred createDeferredError
+	if errors.As(err, &deferred) {
+		return reconcile.Result{RequeueAfter: deferred.remaining}, nil
+	}
+	if apierrors.IsConflict(err) {
+		ownerRefChanges.WithLabelValues(reconcilerIngressPG, ownerRefResultConflict).Inc()
+	}
 	if err != nil {
 		return res, err
 	}
 	if needsRequeue {
 		res = reconcile.Result{RequeueAfter: requeueInterval()}
+	} else if _, ok := r.annotationPrefix.lookup(ing.Annotations, annotationBackendSelector); ok && ing.DeletionTimestamp.IsZero() && r.shouldExpose(ing) {
+		// Pods are not watched, so pick up changes to the Pods matching
+		// the backend selector periodically.
+		res = reconcile.Result{RequeueAfter: backendSelectorResyncInterval}
 	}
 	return res, nil
 }
@@ -159,29 +416,44 @@ func (r *HAIngressReconciler) maybeProvision(ctx context.Context, hostname strin
 		return false, fmt.Errorf("error getting Tailscale Service %q: %w", hostname, err)
 	}
 
-	if err := validateIngressClass(ctx, r.Client, r.ingressClassName); err != nil {
+	if err := validateIngressClass(ctx, r.Client, r.ingressClassName, r.ingressClassController); err != nil {
 		logger.Infof("error validating tailscale IngressClass: %v.", err)
 		return false, nil
 	}
+	r.annotationWarnings.warn(r.recorder, r.annotationPrefix, ing)
 	// Get and validate ProxyGroup readiness
-	pgName := ing.Annotations[AnnotationProxyGroup]
+	pgName := r.annotationPrefix.get(ing.Annotations, AnnotationProxyGroup)
 	if pgName == "" {
 		logger.Infof("[unexpected] no ProxyGroup annotation, skipping Tailscale Service provisioning")
 		return false, nil
 	}
 	logger = logger.With("ProxyGroup", pgName)
+	if slices.Contains(ing.Finalizers, FinalizerName) {
+		// The Ingress was previously exposed via a standalone proxy. Wait
+		// for the Ingress reconciler to clean up the proxy, whose device
+		// has the same DNS name as the Tailscale Service would have.
+		// Removing the finalizer triggers another reconcile.
+		logger.Infof("Ingress is being switched from a standalone proxy to a ProxyGroup, waiting for the standalone proxy to be cleaned up")
+		return false, nil
+	}
 
 	pg := &tsapi.ProxyGroup{}
 	if err := r.Get(ctx, client.ObjectKey{Name: pgName}, pg); err != nil {
 		if apierrors.IsNotFound(err) {
 			logger.Infof("ProxyGroup does not exist")
-			return false, nil
+			return false, errProxyGroupNotReady
 		}
 		return false, fmt.Errorf("getting ProxyGroup %q: %w", pgName, err)
 	}
+	// The ProxyGroup may have been recreated with a different type after
+	// the Ingress was exposed on it. This is checked before readiness, as a
+	// ProxyGroup of the wrong type never becomes ready to serve the Ingress.
+	if pg.Spec.Type != tsapi.ProxyGroupTypeIngress {
+		return r.cleanupWrongProxyGroupType(ctx, hostname, ing, pg, logger)
+	}
 	if !tsoperator.ProxyGroupAvailable(pg) {
 		logger.Infof("ProxyGroup is not (yet) ready")
-		return false, nil
+		return false, errProxyGroupNotReady
 	}
 
 	// Validate Ingress configuration
@@ -191,24 +463,27 @@ func (r *HAIngressReconciler) maybeProvision(ctx context.Context, hostname strin
 		return false, nil
 	}
 
+	// Defer the creation of a new Tailscale Service until the Ingress has
+	// settled. Nothing is created or modified until then, so that an
+	// Ingress deleted in the meantime leaves nothing behind.
+	if existingTSSvc == nil {
+		if remaining := r.createGraceRemaining(ing); remaining > 0 {
+			logger.Debugf("deferring Tailscale Service creation for %v until the Ingress has settled", remaining)
+			return false, createDeferredError{remaining: remaining}
+		}
+	}
+	r.forgetPendingCreate(client.ObjectKeyFromObject(ing))
+
+	if err := ensureFunnelGuard(ctx, r.Client, ing, r.annotationPrefix); err != nil {
+		return false, err
+	}
+
 	if !IsHTTPSEnabledOnTailnet(r.tsnetServer) {
 		r.recorder.Event(ing, corev1.EventTypeWarning, "HTTPSNotEnabled", "HTTPS is not enabled on the tailnet; ingress may not work")
 	}
 
-	if !slices.Contains(ing.Finalizers, FinalizerNamePG) {
-		// This log line is printed exactly once during initial provisioning,
-		// because once the finalizer is in place this block gets skipped. So,
-		// this is a nice place to tell the operator that the high level,
-		// multi-reconcile operation is underway.
-		logger.Infof("exposing Ingress over tailscale")
-		ing.Finalizers = append(ing.Finalizers, FinalizerNamePG)
-		if err := r.Update(ctx, ing); err != nil {
-			return false, fmt.Errorf("failed to add finalizer: %w", err)
-		}
-		r.mu.Lock()
-		r.managedIngresses.Add(ing.UID)
-		gaugePGIngressResources.Set(int64(r.managedIngresses.Len()))
-		r.mu.Unlock()
+	if err := r.ensureFinalizer(ctx, ing, logger); err != nil {
+		return false, err
 	}
 
 	// 1. Ensure that if Ingress' hostname has changed, any Tailscale Service
@@ -235,7 +510,16 @@ func (r *HAIngressReconciler) maybeProvision(ctx context.Context, hostname strin
 	// This checks and ensures that Tailscale Service's owner references are updated
 	// for this Ingress and errors if that is not possible (i.e. because it
 	// appears that the Tailscale Service has been created by a non-operator actor).
-	updatedAnnotations, err := ownerAnnotations(r.operatorID, existingTSSvc)
+	ref := OwnerRef{
+		OperatorID: r.operatorID,
+		Tags:       withManagedServiceTag(tailscaleServiceTags(ing, r.defaultTags, r.annotationPrefix), r.managedServiceTag),
+		HTTP:       isHTTPEndpointEnabled(ing, r.annotationPrefix),
+	}
+	ownedTSSvc, err := migrateOwnerRefs(existingTSSvc, r.previousOperatorID, r.operatorID, logger)
+	if err != nil {
+		return false, fmt.Errorf("error migrating owner references of Tailscale Service %q: %w", serviceName, err)
+	}
+	updatedAnnotations, err := ownerAnnotations(ref, ownedTSSvc, r.ownerAnnotationBudget, logger)
 	if err != nil {
 		const instr = "To proceed, you can either manually delete the existing Tailscale Service or choose a different MagicDNS name at `.spec.tls.hosts[0] in the Ingress definition"
 		msg := fmt.Sprintf("error ensuring ownership of Tailscale Service %s: %v. %s", hostname, err, instr)
@@ -243,90 +527,318 @@ func (r *HAIngressReconciler) maybeProvision(ctx context.Context, hostname strin
 		r.recorder.Event(ing, corev1.EventTypeWarning, "InvalidTailscaleService", msg)
 		return false, nil
 	}
+	o, err := parseOwnerAnnotation(&tailscale.VIPService{Annotations: updatedAnnotations})
+	if err != nil {
+		return false, err
+	}
+	// The Tailscale Service may be shared with Ingresses in other clusters
+	// that disagree on whether to expose an HTTP endpoint. It is exposed if
+	// any of them requests it, so that all owners converge on the same
+	// state rather than overwriting each other's.
+	httpEndpoint := ownersWantHTTP(o)
+	if httpEndpoint && !ref.HTTP {
+		msg := fmt.Sprintf("HTTP endpoint is not enabled for this Ingress, but Tailscale Service %s is exposed over HTTP as it is requested by an Ingress in another cluster sharing it", serviceName)
+		logger.Info(msg)
+		r.recorder.Event(ing, corev1.EventTypeWarning, "ConflictingHTTPEndpoint", msg)
+	}
 	// 3. Ensure that TLS Secret and RBAC exists
 	tcd, err := tailnetCertDomain(ctx, r.lc)
 	if err != nil {
 		return false, fmt.Errorf("error determining DNS name base: %w", err)
 	}
+	// validateTLSHost ensures that a FQDN TLS host is exactly this name,
+	// and bare hostnames get the MagicDNS suffix appended.
 	dnsName := hostname + "." + tcd
-	if err := r.ensureCertResources(ctx, pg, dnsName, ing); err != nil {
+	if err := r.ensureCertResources(ctx, pg, dnsName, ing, logger); err != nil {
 		return false, fmt.Errorf("error ensuring cert resources: %w", err)
 	}
+	if err := r.warnIfCertExpiring(ctx, pg.Name, dnsName, ing, logger); err != nil {
+		return false, err
+	}
 
 	// 4. Ensure that the serve config for the ProxyGroup contains the Tailscale Service.
+	ingCfg, aliases, ok, err := r.ensureServeConfig(ctx, ing, pg, serviceName, dnsName, httpEndpoint, logger)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	// 5. Ensure that the Tailscale Service exists and is up to date.
+	if err := r.ensureTailscaleService(ctx, ing, serviceName, existingTSSvc, ownedTSSvc, o, updatedAnnotations, httpEndpoint, aliases, logger); err != nil {
+		return false, err
+	}
+
+	// 6. If the Ingress has a ready gate, wait for the resource it
+	// references to exist before advertising the Tailscale Service.
+	ready, err := r.readyGateExists(ctx, ing)
+	if err != nil {
+		return false, err
+	}
+	if !ready {
+		if err := r.waitForDependency(ctx, ing, pg.Name, serviceName, logger); err != nil {
+			return false, err
+		}
+		return svcsChanged, errWaitingForDependency
+	}
+	if err := r.maybeUpdateWaitingForDependency(ctx, ing, "", logger); err != nil {
+		return false, err
+	}
+
+	// 7. Update tailscaled's AdvertiseServices config, which should add the Tailscale Service
+	// IPs to the ProxyGroup Pods' AllowedIPs in the next netmap update if approved.
+	mode := advertisementMode(ing, httpEndpoint, r.annotationPrefix)
+	pinned, err := pinnedReplicas(ing, r.annotationPrefix)
+	if err != nil {
+		return false, err // should have been caught by validateIngress
+	}
+	if err = r.maybeUpdateAdvertiseServicesConfig(ctx, pg.Name, serviceName, mode, pinned, logger); err != nil {
+		return false, fmt.Errorf("failed to update tailscaled config: %w", err)
+	}
+
+	// 8. If requested, check that the backends are reachable before
+	// marking the Ingress ready. The Ingress status is left as is until the
+	// probe succeeds.
+	if shouldProbeBackends(ing, r.annotationPrefix) {
+		if err := r.probeBackends(ctx, ingCfg); err != nil {
+			msg := fmt.Sprintf("not updating Ingress status: %v. Retrying in %v", err, backendProbeRetryInterval)
+			logger.Info(msg)
+			r.recorder.Event(ing, corev1.EventTypeWarning, reasonBackendUnreachable, msg)
+			return svcsChanged, errBackendUnreachable
+		}
+	}
+
+	// 9. Update Ingress status if ProxyGroup Pods are ready.
+	waitingForDNS, err := r.updateIngressStatus(ctx, ing, pg.Name, serviceName, dnsName, httpEndpoint, aliases, logger)
+	if err != nil {
+		return false, err
+	}
+	if waitingForDNS {
+		return svcsChanged, errWaitingForDNS
+	}
+	return svcsChanged, nil
+}
+
+// ensureFinalizer adds the HA Ingress finalizer to the Ingress, if it does not
+// have it yet, and starts counting it as managed.
+func (r *HAIngressReconciler) ensureFinalizer(ctx context.Context, ing *networkingv1.Ingress, logger *zap.SugaredLogger) error {
+	if slices.Contains(ing.Finalizers, FinalizerNamePG) {
+		return nil
+	}
+	// This log line is printed exactly once during initial provisioning,
+	// because once the finalizer is in place this is skipped. So, this is a
+	// nice place to tell the operator that the high level, multi-reconcile
+	// operation is underway.
+	logger.Infof("exposing Ingress over tailscale")
+	ing.Finalizers = append(ing.Finalizers, FinalizerNamePG)
+	if err := r.Update(ctx, ing); err != nil {
+		return fmt.Errorf("failed to add finalizer: %w", err)
+	}
+	r.mu.Lock()
+	r.managedIngresses.Add(ing.UID)
+	gaugePGIngressResources.Set(int64(r.managedIngresses.Len()))
+	r.mu.Unlock()
+	return nil
+}
+
+// advertisementMode returns how the Tailscale Service of the Ingress is to be
+// advertised, given whether it has an HTTP endpoint.
+func advertisementMode(ing *networkingv1.Ingress, httpEndpoint bool, prefix annotationPrefix) serviceAdvertisementMode {
+	switch {
+	case httpEndpoint:
+		return serviceAdvertisementHTTPAndHTTPS
+	case !shouldWaitForCert(ing, prefix):
+		return serviceAdvertisementHTTPSWithoutCert
+	}
+	return serviceAdvertisementHTTPS
+}
+
+// ensureServeConfig ensures that the serve config of the ProxyGroup pg contains
+// the config of the Tailscale Service serviceName for the Ingress, served on
+// dnsName, and returns it along with the Ingress' port aliases. It returns
+// false if the serve config can not be updated, after emitting a warning Event
+// on the Ingress that explains why.
+func (r *HAIngressReconciler) ensureServeConfig(ctx context.Context, ing *networkingv1.Ingress, pg *tsapi.ProxyGroup, serviceName tailcfg.ServiceName, dnsName string, httpEndpoint bool, logger *zap.SugaredLogger) (_ *ipn.ServiceConfig, aliases []uint16, ok bool, _ error) {
+	pgName := pg.Name
 	cm, cfg, err := r.proxyGroupServeConfig(ctx, pgName)
 	if err != nil {
-		return false, fmt.Errorf("error getting Ingress serve config: %w", err)
+		return nil, nil, false, fmt.Errorf("error getting Ingress serve config: %w", err)
 	}
 	if cm == nil {
-		logger.Infof("no Ingress serve config ConfigMap found, unable to update serve config. Ensure that ProxyGroup is healthy.")
-		return svcsChanged, nil
+		// The ProxyGroup reconciler creates the ConfigMap, but it may not
+		// have done so yet, for example if the ProxyGroup and the Ingress
+		// were created at the same time.
+		logger.Infof("no Ingress serve config ConfigMap found, creating it")
+		if cm, cfg, err = r.createProxyGroupServeConfig(ctx, pg); err != nil {
+			return nil, nil, false, fmt.Errorf("error creating Ingress serve config: %w", err)
+		}
+	}
+	managed := managedServices(cm, cfg)
+	if cfg.Services[serviceName] != nil && !managed.Contains(serviceName) {
+		logger.Infof("serve config already contains Tailscale Service %q that is not managed by the operator, skipping", serviceName)
+		r.recorder.Eventf(ing, corev1.EventTypeWarning, "ServeConfigConflict", "Serve config for ProxyGroup %s already contains Tailscale Service %s that is not managed by the operator; remove it or choose a different hostname", pgName, serviceName)
+		return nil, nil, false, nil
+	}
+	managed.Add(serviceName)
+	ingCfg, aliases, err := r.serviceConfig(ctx, ing, dnsName, httpEndpoint, logger)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	// Replicas that are too old would silently ignore the fields of the
+	// serve config that they do not know, so it is not applied until they
+	// are upgraded.
+	if need := ingCfg.RequiredCapVer(); need > 0 {
+		capvers, err := replicaCapVers(ctx, r.Client, r.tsNamespace, pgName, logger)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if err := checkProxyCapVers(need, capvers); err != nil {
+			logger.Infof("not updating serve config: %v", err)
+			r.recorder.Event(ing, corev1.EventTypeWarning, reasonProxyTooOld, err.Error())
+			return nil, nil, false, nil
+		}
+	}
+
+	var gotCfg *ipn.ServiceConfig
+	if cfg != nil && cfg.Services != nil {
+		gotCfg = cfg.Services[serviceName]
+	}
+	if !reflect.DeepEqual(gotCfg, ingCfg) || cm.Annotations[annotationManagedServices] != managedServicesValue(managed) {
+		// The serve config entry for this Tailscale Service is owned by
+		// this Ingress, so any difference (including manual edits to the
+		// ConfigMap) is overwritten. Entries for other Tailscale Services
+		// are left as is.
+		if gotCfg != nil {
+			logger.Infof("Serve config for Tailscale Service %q differs from desired state, updating", serviceName)
+		} else {
+			logger.Infof("Updating serve config")
+		}
+		mak.Set(&cfg.Services, serviceName, ingCfg)
+		cfgBytes, err := json.Marshal(cfg)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("error marshaling serve config: %w", err)
+		}
+		mak.Set(&cm.BinaryData, serveConfigKey, cfgBytes)
+		mak.Set(&cm.Annotations, annotationManagedServices, managedServicesValue(managed))
+		if err := applyConfigMap(ctx, r.Client, haIngressFieldManager, cm, serveConfigKey, annotationManagedServices); err != nil {
+			return nil, nil, false, fmt.Errorf("error updating serve config: %w", err)
+		}
 	}
+	return ingCfg, aliases, true, nil
+}
+
+// serviceConfig returns the serve config of the Tailscale Service for the
+// Ingress, served on dnsName, and the Ingress' port aliases.
+func (r *HAIngressReconciler) serviceConfig(ctx context.Context, ing *networkingv1.Ingress, dnsName string, httpEndpoint bool, logger *zap.SugaredLogger) (_ *ipn.ServiceConfig, aliases []uint16, _ error) {
 	ep := ipn.HostPort(fmt.Sprintf("%s:443", dnsName))
-	handlers, err := handlersForIngress(ctx, ing, r.Client, r.recorder, dnsName, logger)
+	proxyHandler := func(b *networkingv1.IngressBackend, path string) *ipn.HTTPHandler {
+		return proxyHandlerForBackend(ctx, ing, r.Client, r.recorder, b, path, r.annotationPrefix)
+	}
+	if sel, _ := backendSelector(ing, r.annotationPrefix); sel != nil { // validated in validateIngress
+		pods, err := r.backendPods(ctx, ing.Namespace, sel)
+		if err != nil {
+			return nil, nil, err
+		}
+		proxyHandler = func(b *networkingv1.IngressBackend, path string) *ipn.HTTPHandler {
+			return proxyHandlerForPods(ing, r.recorder, pods, b, path, r.annotationPrefix)
+		}
+	}
+	handlers, err := handlersForIngressBackends(ing, r.recorder, dnsName, logger, proxyHandler, r.annotationPrefix)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get handlers for Ingress: %w", err)
+	}
+	clientCAs, err := r.trustBundle(ctx, ing)
 	if err != nil {
-		return false, fmt.Errorf("failed to get handlers for Ingress: %w", err)
+		return nil, nil, err
 	}
+	keepAliveIdle, keepAliveInterval, _ := tcpKeepAlive(ing, r.annotationPrefix) // validated in validateIngress
+	accessLog, _ := accessLogEnabled(ing, r.annotationPrefix)                    // validated in validateIngress
+	http1Only, _ := clientHTTP1Only(ing, r.annotationPrefix)                     // validated in validateIngress
 	ingCfg := &ipn.ServiceConfig{
 		TCP: map[uint16]*ipn.TCPPortHandler{
 			443: {
-				HTTPS: true,
+				HTTPS:             true,
+				KeepAliveIdle:     keepAliveIdle,
+				KeepAliveInterval: keepAliveInterval,
+				HTTP1Only:         http1Only,
 			},
 		},
 		Web: map[ipn.HostPort]*ipn.WebServerConfig{
 			ep: {
-				Handlers: handlers,
+				Handlers:  handlers,
+				AccessLog: accessLog,
+				ClientCAs: clientCAs,
 			},
 		},
 	}
 
+	// Mirror the HTTPS endpoint on any port aliases.
+	aliases, _ = portAliases(ing, r.annotationPrefix) // validated in validateIngress
+	for _, p := range aliases {
+		ingCfg.TCP[p] = ingCfg.TCP[443].Clone()
+		ingCfg.Web[ipn.HostPort(fmt.Sprintf("%s:%d", dnsName, p))] = ingCfg.Web[ep].Clone()
+	}
+
 	// Add HTTP endpoint if configured.
-	if isHTTPEndpointEnabled(ing) {
+	if httpEndpoint {
 		logger.Infof("exposing Ingress over HTTP")
 		epHTTP := ipn.HostPort(fmt.Sprintf("%s:80", dnsName))
 		ingCfg.TCP[80] = &ipn.TCPPortHandler{
-			HTTP: true,
-		}
-		ingCfg.Web[epHTTP] = &ipn.WebServerConfig{
-			Handlers: handlers,
+			HTTP:              true,
+			KeepAliveIdle:     keepAliveIdle,
+			KeepAliveInterval: keepAliveInterval,
 		}
-	}
-
-	var gotCfg *ipn.ServiceConfig
-	if cfg != nil && cfg.Services != nil {
-		gotCfg = cfg.Services[serviceName]
-	}
-	if !reflect.DeepEqual(gotCfg, ingCfg) {
-		logger.Infof("Updating serve config")
-		mak.Set(&cfg.Services, serviceName, ingCfg)
-		cfgBytes, err := json.Marshal(cfg)
-		if err != nil {
-			return false, fmt.Errorf("error marshaling serve config: %w", err)
+		httpHandlers := handlers
+		// An Ingress that requires client certificates can not enable the
+		// HTTP endpoint (see incompatibleModes), but an Ingress in another
+		// cluster sharing the Tailscale Service can. Its HTTP requests are
+		// then redirected, as client certificates can only be verified over
+		// HTTPS.
+		if isHTTPRedirectEnabled(ing, r.annotationPrefix) || clientCAs != "" {
+			logger.Infof("redirecting HTTP requests to HTTPS")
+			httpHandlers = map[string]*ipn.HTTPHandler{
+				"/": {Redirect: fmt.Sprintf("301:https://%s${REQUEST_URI}", dnsName)},
+			}
+		} else if b, err := httpBackend(ing, r.annotationPrefix); err == nil && b != nil {
+			logger.Infof("serving HTTP requests from backend Service %q", b.Service.Name)
+			httpHandlers = handlersForHTTPBackend(ctx, ing, r.Client, r.recorder, b, r.annotationPrefix)
 		}
-		mak.Set(&cm.BinaryData, serveConfigKey, cfgBytes)
-		if err := r.Update(ctx, cm); err != nil {
-			return false, fmt.Errorf("error updating serve config: %w", err)
+		ingCfg.Web[epHTTP] = &ipn.WebServerConfig{
+			Handlers:  httpHandlers,
+			AccessLog: accessLog,
 		}
 	}
+	return ingCfg, aliases, nil
+}
 
-	// 4. Ensure that the Tailscale Service exists and is up to date.
-	tags := r.defaultTags
-	if tstr, ok := ing.Annotations[AnnotationTags]; ok {
-		tags = strings.Split(tstr, ",")
-	}
-
+// ensureTailscaleService ensures that the Tailscale Service serviceName for
+// the Ingress exists and is up to date. existingTSSvc is the Tailscale Service
+// as last read, or nil if it does not exist, and ownedTSSvc is the same with
+// owner references migrated from a previous operator ID. updatedAnnotations
+// are its annotations with this operator's owner reference, parsed into o.
+func (r *HAIngressReconciler) ensureTailscaleService(ctx context.Context, ing *networkingv1.Ingress, serviceName tailcfg.ServiceName, existingTSSvc, ownedTSSvc *tailscale.VIPService, o *ownerAnnotationValue, updatedAnnotations map[string]string, httpEndpoint bool, aliases []uint16, logger *zap.SugaredLogger) error {
 	tsSvcPorts := []string{"tcp:443"} // always 443 for Ingress
-	if isHTTPEndpointEnabled(ing) {
+	if httpEndpoint {
 		tsSvcPorts = append(tsSvcPorts, "tcp:80")
 	}
+	for _, p := range aliases {
+		tsSvcPorts = append(tsSvcPorts, fmt.Sprintf("tcp:%d", p))
+	}
 
 	tsSvc := &tailscale.VIPService{
 		Name:        serviceName,
-		Tags:        tags,
 		Ports:       tsSvcPorts,
-		Comment:     managedTSServiceComment,
+		Comment:     tailscaleServiceComment("Ingress", ing, r.clusterName, r.noServiceProvenance, r.annotationPrefix),
 		Annotations: updatedAnnotations,
 	}
+	if existingTSSvc != nil && len(o.OwnerRefs) > 1 {
+		// The Tailscale Service is shared with operators in other
+		// clusters, whose provenance differs, so keep its comment rather
+		// than overwriting each other's.
+		tsSvc.Comment = existingTSSvc.Comment
+	}
+	// The Tailscale Service may be shared with operators in other clusters
+	// that have different default tags, so tag it with the tags of all owners.
+	tsSvc.Tags = ownerTags(o)
 	if existingTSSvc != nil {
 		tsSvc.Addrs = existingTSSvc.Addrs
 	}
@@ -336,27 +848,48 @@ func (r *HAIngressReconciler) maybeProvision(ctx context.Context, hostname strin
 	if existingTSSvc == nil ||
 		!reflect.DeepEqual(tsSvc.Tags, existingTSSvc.Tags) ||
 		!reflect.DeepEqual(tsSvc.Ports, existingTSSvc.Ports) ||
+		tsSvc.Comment != existingTSSvc.Comment ||
 		!ownersAreSetAndEqual(tsSvc, existingTSSvc) {
 		logger.Infof("Ensuring Tailscale Service exists and is up to date")
 		if err := r.tsClient.CreateOrUpdateVIPService(ctx, tsSvc); err != nil {
-			return false, fmt.Errorf("error creating Tailscale Service: %w", err)
+			return fmt.Errorf("error creating Tailscale Service: %w", err)
+		}
+		if existingTSSvc == nil {
+			r.auditEventf(ing, reasonAuditTailscaleServiceCreated, "Created Tailscale Service %s", serviceName)
+		} else {
+			r.auditEventf(ing, reasonAuditTailscaleServiceUpdated, "Updated Tailscale Service %s", serviceName)
+		}
+		if !hasOwnerRef(ownedTSSvc, r.operatorID) {
+			ownerRefChanges.WithLabelValues(reconcilerIngressPG, ownerRefResultAdd).Inc()
+			r.auditEventf(ing, reasonAuditOwnerRefAdded, "Added owner reference of operator %s to Tailscale Service %s", r.operatorID, serviceName)
 		}
 	}
+	return nil
+}
 
-	// 5. Update tailscaled's AdvertiseServices config, which should add the Tailscale Service
-	// IPs to the ProxyGroup Pods' AllowedIPs in the next netmap update if approved.
-	mode := serviceAdvertisementHTTPS
-	if isHTTPEndpointEnabled(ing) {
-		mode = serviceAdvertisementHTTPAndHTTPS
-	}
-	if err = r.maybeUpdateAdvertiseServicesConfig(ctx, pg.Name, serviceName, mode, logger); err != nil {
-		return false, fmt.Errorf("failed to update tailscaled config: %w", err)
+// updateIngressStatus updates the status of the Ingress to report the ports
+// on which the Tailscale Service serviceName is served on dnsName by the
+// replicas of the ProxyGroup pgName that advertise it. It reports whether the
+// Ingress is waiting for dnsName to resolve on the tailnet before its TLS
+// cert can be issued.
+func (r *HAIngressReconciler) updateIngressStatus(ctx context.Context, ing *networkingv1.Ingress, pgName string, serviceName tailcfg.ServiceName, dnsName string, httpEndpoint bool, aliases []uint16, logger *zap.SugaredLogger) (waitingForDNS bool, _ error) {
+	replicas, err := replicasAdvertising(ctx, r.Client, r.tsNamespace, pgName, serviceName, logger)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if any Pods are configured: %w", err)
 	}
+	r.maybeReportAdvertisingReplicas(ing, pgName, serviceName, replicas, logger)
+	count := len(replicas)
 
-	// 6. Update Ingress status if ProxyGroup Pods are ready.
-	count, err := numberPodsAdvertising(ctx, r.Client, r.tsNamespace, pg.Name, serviceName)
+	hasCerts, err := hasCerts(ctx, r.Client, r.lc, r.tsNamespace, pgName, serviceName)
 	if err != nil {
-		return false, fmt.Errorf("failed to check if any Pods are configured: %w", err)
+		return false, fmt.Errorf("error checking TLS credentials provisioned for Ingress: %w", err)
+	}
+	// If the cert has not been issued although the Tailscale Service is
+	// advertised, issuance may be failing as the DNS name of the Tailscale
+	// Service does not resolve on the tailnet yet.
+	waitingForDNS = count > 0 && !hasCerts && !r.dnsResolves(ctx, dnsName, logger)
+	if err := r.maybeUpdateWaitingForDNS(ctx, ing, waitingForDNS, dnsName, logger); err != nil {
+		return false, err
 	}
 
 	oldStatus := ing.Status.DeepCopy()
@@ -366,18 +899,26 @@ func (r *HAIngressReconciler) maybeProvision(ctx context.Context, hostname strin
 		ing.Status.LoadBalancer.Ingress = nil
 	default:
 		var ports []networkingv1.IngressPortStatus
-		hasCerts, err := hasCerts(ctx, r.Client, r.lc, r.tsNamespace, serviceName)
-		if err != nil {
-			return false, fmt.Errorf("error checking TLS credentials provisioned for Ingress: %w", err)
+		// If TLS certs have not been issued (yet), do not set port 443,
+		// unless the Ingress opted out of waiting for them.
+		// Ingresses that do not wait for their cert advertise port 443
+		// before it is issued, so no audit Event is emitted for them.
+		if hasCerts && !hasPort443(oldStatus) {
+			r.auditEventf(ing, reasonAuditCertIssued, "TLS cert issued for %s", dnsName)
 		}
-		// If TLS certs have not been issued (yet), do not set port 443.
-		if hasCerts {
+		if hasCerts || !shouldWaitForCert(ing, r.annotationPrefix) {
 			ports = append(ports, networkingv1.IngressPortStatus{
 				Protocol: "TCP",
 				Port:     443,
 			})
+			for _, p := range aliases {
+				ports = append(ports, networkingv1.IngressPortStatus{
+					Protocol: "TCP",
+					Port:     int32(p),
+				})
+			}
 		}
-		if isHTTPEndpointEnabled(ing) {
+		if httpEndpoint {
 			ports = append(ports, networkingv1.IngressPortStatus{
 				Protocol: "TCP",
 				Port:     80,
@@ -395,21 +936,19 @@ func (r *HAIngressReconciler) maybeProvision(ctx context.Context, hostname strin
 			},
 		}
 	}
-	if apiequality.Semantic.DeepEqual(oldStatus, &ing.Status) {
-		return svcsChanged, nil
-	}
-
-	const prefix = "Updating Ingress status"
-	if count == 0 {
-		logger.Infof("%s. No Pods are advertising Tailscale Service yet", prefix)
-	} else {
-		logger.Infof("%s. %d Pod(s) advertising Tailscale Service", prefix, count)
-	}
+	if !apiequality.Semantic.DeepEqual(oldStatus, &ing.Status) {
+		const prefix = "Updating Ingress status"
+		if count == 0 {
+			logger.Infof("%s. No Pods are advertising Tailscale Service yet", prefix)
+		} else {
+			logger.Infof("%s. %d Pod(s) advertising Tailscale Service", prefix, count)
+		}
 
-	if err := r.Status().Update(ctx, ing); err != nil {
-		return false, fmt.Errorf("failed to update Ingress status: %w", err)
+		if err := r.Status().Update(ctx, ing); err != nil {
+			return false, fmt.Errorf("failed to update Ingress status: %w", err)
+		}
 	}
-	return svcsChanged, nil
+	return waitingForDNS, nil
 }
 
 // maybeCleanupProxyGroup ensures that any Tailscale Services that are
@@ -433,13 +972,18 @@ func (r *HAIngressReconciler) maybeCleanupProxyGroup(ctx context.Context, proxyG
 	if err := r.List(ctx, ingList); err != nil {
 		return false, fmt.Errorf("listing Ingresses: %w", err)
 	}
+	managed := managedServices(cm, cfg)
 	serveConfigChanged := false
 	// For each Tailscale Service in serve config...
 	for tsSvcName := range cfg.Services {
+		if !managed.Contains(tsSvcName) {
+			// Added by another controller or an admin, leave it alone.
+			continue
+		}
 		// ...check if there is currently an Ingress with this hostname
 		found := false
 		for _, i := range ingList.Items {
-			ingressHostname := hostnameForIngress(&i)
+			ingressHostname := r.serviceNameStrategy.hostnameForIngress(&i)
 			if ingressHostname == tsSvcName.WithoutPrefix() {
 				found = true
 				break
@@ -448,28 +992,28 @@ func (r *HAIngressReconciler) maybeCleanupProxyGroup(ctx context.Context, proxyG
 
 		if !found {
 			logger.Infof("Tailscale Service %q is not owned by any Ingress, cleaning up", tsSvcName)
+			// If the Tailscale Service has already been deleted, tsService
+			// is nil and only the cluster resources are cleaned up.
 			tsService, err := r.tsClient.GetVIPService(ctx, tsSvcName)
-			if isErrorTailscaleServiceNotFound(err) {
-				return false, nil
-			}
-			if err != nil {
+			if err != nil && !isErrorTailscaleServiceNotFound(err) {
 				return false, fmt.Errorf("getting Tailscale Service %q: %w", tsSvcName, err)
 			}
 
 			// Delete the Tailscale Service from control if necessary.
-			svcsChanged, err = r.cleanupTailscaleService(ctx, tsService, logger)
+			svcsChanged, _, err = r.cleanupTailscaleService(ctx, tsService, cm, logger)
 			if err != nil {
 				return false, fmt.Errorf("deleting Tailscale Service %q: %w", tsSvcName, err)
 			}
 
 			// Make sure the Tailscale Service is not advertised in tailscaled or serve config.
-			if err = r.maybeUpdateAdvertiseServicesConfig(ctx, proxyGroupName, tsSvcName, serviceAdvertisementOff, logger); err != nil {
+			if err = r.maybeUpdateAdvertiseServicesConfig(ctx, proxyGroupName, tsSvcName, serviceAdvertisementOff, nil, logger); err != nil {
 				return false, fmt.Errorf("failed to update tailscaled config services: %w", err)
 			}
 			_, ok := cfg.Services[tsSvcName]
 			if ok {
 				logger.Infof("Removing Tailscale Service %q from serve config", tsSvcName)
 				delete(cfg.Services, tsSvcName)
+				managed.Delete(tsSvcName)
 				serveConfigChanged = true
 			}
 			if err := cleanupCertResources(ctx, r.Client, r.lc, r.tsNamespace, proxyGroupName, tsSvcName); err != nil {
@@ -484,7 +1028,8 @@ func (r *HAIngressReconciler) maybeCleanupProxyGroup(ctx context.Context, proxyG
 			return false, fmt.Errorf("marshaling serve config: %w", err)
 		}
 		mak.Set(&cm.BinaryData, serveConfigKey, cfgBytes)
-		if err := r.Update(ctx, cm); err != nil {
+		mak.Set(&cm.Annotations, annotationManagedServices, managedServicesValue(managed))
+		if err := applyConfigMap(ctx, r.Client, haIngressFieldManager, cm, serveConfigKey, annotationManagedServices); err != nil {
 			return false, fmt.Errorf("updating serve config: %w", err)
 		}
 	}
@@ -495,8 +1040,19 @@ func (r *HAIngressReconciler) maybeCleanupProxyGroup(ctx context.Context, proxyG
 // Ingress is being deleted or is unexposed. The cleanup is safe for a multi-cluster setup- the Tailscale Service is only
 // deleted if it does not contain any other owner references. If it does the cleanup only removes the owner reference
 // corresponding to this Ingress.
+//
+// Resources are cleaned up in a fixed order: the Tailscale Service is first unadvertised by the ProxyGroup, then this
+// operator's owner reference is removed from it, then its cert Secret and then the RBAC for the Secret are deleted.
+// The Tailscale Service is removed from the ProxyGroup's serve config last. As cleanup is skipped for Tailscale
+// Services that are not in the serve config, this ensures that if any step fails, all steps are retried, each of
+// which is a no-op if it has already been done.
+//
+// If the ProxyGroup has been deleted before the Ingress, its serve and tailscaled configs are garbage collected
+// along with it, so updating them is best effort and does not block the removal of the Ingress' finalizer.
 func (r *HAIngressReconciler) maybeCleanup(ctx context.Context, hostname string, ing *networkingv1.Ingress, logger *zap.SugaredLogger) (svcChanged bool, err error) {
 	logger.Debugf("Ensuring any resources for Ingress are cleaned up")
+	r.forgetPendingCreate(client.ObjectKeyFromObject(ing))
+	r.annotationWarnings.forget(ing.UID)
 	ix := slices.Index(ing.Finalizers, FinalizerNamePG)
 	if ix < 0 {
 		logger.Debugf("no finalizer, nothing to do")
@@ -518,10 +1074,26 @@ func (r *HAIngressReconciler) maybeCleanup(ctx context.Context, hostname string,
 	}()
 
 	// 1. Check if there is a Tailscale Service associated with this Ingress.
-	pg := ing.Annotations[AnnotationProxyGroup]
+	pg := r.annotationPrefix.get(ing.Annotations, AnnotationProxyGroup)
+	if pg == "" {
+		// The ProxyGroup annotation has been removed, for example to switch
+		// the Ingress to a standalone proxy. Find the ProxyGroup that it was
+		// exposed on, so that its serve and tailscaled configs get cleaned up.
+		if pg, err = r.proxyGroupForService(ctx, serviceName); err != nil {
+			return false, fmt.Errorf("error finding ProxyGroup for Tailscale Service %q: %w", serviceName, err)
+		}
+	}
+	pgGone, err := r.proxyGroupGone(ctx, pg)
+	if err != nil {
+		return false, err
+	}
 	cm, cfg, err := r.proxyGroupServeConfig(ctx, pg)
 	if err != nil {
-		return false, fmt.Errorf("error getting ProxyGroup serve config: %w", err)
+		if !pgGone {
+			return false, fmt.Errorf("error getting ProxyGroup serve config: %w", err)
+		}
+		logger.Infof("ProxyGroup %q is gone, ignoring its serve config: %v", pg, err)
+		cm, cfg = nil, nil
 	}
 
 	// Tailscale Service is always first added to serve config and only then created in the Tailscale API, so if it is not
@@ -531,36 +1103,113 @@ func (r *HAIngressReconciler) maybeCleanup(ctx context.Context, hostname string,
 	if cfg != nil && cfg.Services != nil && cfg.Services[serviceName] == nil {
 		return false, nil
 	}
+	// The entry was not added by this operator, so the Ingress was never
+	// exposed; leave it alone.
+	if cfg != nil && !managedServices(cm, cfg).Contains(serviceName) {
+		return false, nil
+	}
 
-	// 2. Clean up the Tailscale Service resources.
-	svcChanged, err = r.cleanupTailscaleService(ctx, svc, logger)
+	// If the serve config does not exist, the user probably deleted the
+	// ProxyGroup, along with its tailscaled config.
+	pgExists := cfg != nil && cfg.Services != nil
+
+	// 2. Unadvertise the Tailscale Service in tailscaled config, so that
+	// the ProxyGroup stops serving it before its resources are removed.
+	if pgExists {
+		if err := r.maybeUpdateAdvertiseServicesConfig(ctx, pg, serviceName, serviceAdvertisementOff, nil, logger); err != nil {
+			if !pgGone {
+				return false, fmt.Errorf("failed to update tailscaled config services: %w", err)
+			}
+			logger.Infof("ProxyGroup %q is gone, not unadvertising Tailscale Service %q in its tailscaled configs: %v", pg, serviceName, err)
+		}
+	}
+
+	// 3. Clean up the Tailscale Service resources.
+	svcChanged, retained, err := r.cleanupTailscaleService(ctx, svc, ing, logger)
 	if err != nil {
 		return false, fmt.Errorf("error deleting Tailscale Service: %w", err)
 	}
+	if retained {
+		r.recorder.Eventf(ing, corev1.EventTypeWarning, warningTailscaleServiceRetained, msgTailscaleServiceRetained, serviceName)
+	}
 
-	// 3. Clean up any cluster resources
+	// 4. Clean up the cert Secret and its RBAC.
 	if err := cleanupCertResources(ctx, r.Client, r.lc, r.tsNamespace, pg, serviceName); err != nil {
 		return false, fmt.Errorf("failed to clean up cert resources: %w", err)
 	}
 
-	if cfg == nil || cfg.Services == nil { // user probably deleted the ProxyGroup
+	if !pgExists {
 		return svcChanged, nil
 	}
 
-	// 4. Unadvertise the Tailscale Service in tailscaled config.
-	if err = r.maybeUpdateAdvertiseServicesConfig(ctx, pg, serviceName, serviceAdvertisementOff, logger); err != nil {
-		return false, fmt.Errorf("failed to update tailscaled config services: %w", err)
-	}
-
 	// 5. Remove the Tailscale Service from the serve config for the ProxyGroup.
 	logger.Infof("Removing TailscaleService %q from serve config for ProxyGroup %q", hostname, pg)
+	managed := managedServices(cm, cfg)
 	delete(cfg.Services, serviceName)
+	managed.Delete(serviceName)
 	cfgBytes, err := json.Marshal(cfg)
 	if err != nil {
 		return false, fmt.Errorf("error marshaling serve config: %w", err)
 	}
 	mak.Set(&cm.BinaryData, serveConfigKey, cfgBytes)
-	return svcChanged, r.Update(ctx, cm)
+	mak.Set(&cm.Annotations, annotationManagedServices, managedServicesValue(managed))
+	if err := applyConfigMap(ctx, r.Client, haIngressFieldManager, cm, serveConfigKey, annotationManagedServices); err != nil {
+		if !pgGone {
+			return svcChanged, err
+		}
+		logger.Infof("ProxyGroup %q is gone, not removing Tailscale Service %q from its serve config: %v", pg, serviceName, err)
+	}
+	return svcChanged, nil
+}
+
+// proxyGroupGone reports whether ProxyGroup pgName has been deleted or is
+// being deleted, or pgName is empty as no ProxyGroup was found.
+func (r *HAIngressReconciler) proxyGroupGone(ctx context.Context, pgName string) (bool, error) {
+	if pgName == "" {
+		return true, nil
+	}
+	pg := &tsapi.ProxyGroup{}
+	err := r.Get(ctx, client.ObjectKey{Name: pgName}, pg)
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get ProxyGroup %q: %w", pgName, err)
+	}
+	return !pg.DeletionTimestamp.IsZero(), nil
+}
+
+// cleanupWrongProxyGroupType is called for an Ingress whose ProxyGroup is not
+// of type ingress. It emits a warning Event, and if the Ingress was exposed on
+// the ProxyGroup before its type changed, it removes the Tailscale Service from
+// the ProxyGroup's tailscaled configs, cleans up the Ingress' resources as for
+// a deleted Ingress and resets its status.
+func (r *HAIngressReconciler) cleanupWrongProxyGroupType(ctx context.Context, hostname string, ing *networkingv1.Ingress, pg *tsapi.ProxyGroup, logger *zap.SugaredLogger) (svcChanged bool, err error) {
+	msg := fmt.Sprintf("ProxyGroup %q is of type %q but must be of type %q", pg.Name, pg.Spec.Type, tsapi.ProxyGroupTypeIngress)
+	logger.Infof("invalid Ingress configuration: %s", msg)
+	r.recorder.Event(ing, corev1.EventTypeWarning, warningWrongProxyGroupType, msg)
+	if !slices.Contains(ing.Finalizers, FinalizerNamePG) {
+		return false, nil
+	}
+
+	// The serve config of a ProxyGroup that was recreated with a different
+	// type may be gone, in which case maybeCleanup does not unadvertise the
+	// Tailscale Service, so do that first.
+	serviceName := tailcfg.ServiceName("svc:" + hostname)
+	if err := r.maybeUpdateAdvertiseServicesConfig(ctx, pg.Name, serviceName, serviceAdvertisementOff, nil, logger); err != nil {
+		return false, fmt.Errorf("failed to update tailscaled config services: %w", err)
+	}
+	if svcChanged, err = r.maybeCleanup(ctx, hostname, ing, logger); err != nil {
+		return false, err
+	}
+
+	if len(ing.Status.LoadBalancer.Ingress) != 0 {
+		ing.Status.LoadBalancer.Ingress = nil
+		if err := r.Status().Update(ctx, ing); err != nil {
+			return false, fmt.Errorf("failed to update Ingress status: %w", err)
+		}
+	}
+	return svcChanged, nil
 }
 
 func (r *HAIngressReconciler) deleteFinalizer(ctx context.Context, ing *networkingv1.Ingress, logger *zap.SugaredLogger) error {
@@ -573,6 +1222,8 @@ func (r *HAIngressReconciler) deleteFinalizer(ctx context.Context, ing *networki
 		return nil
 	}
 	logger.Debug("ensure %q finalizer is removed", FinalizerNamePG)
+	delete(ing.Annotations, annotationWaitingForDependency)
+	delete(ing.Annotations, annotationWaitingForDNS)
 
 	if err := r.Update(ctx, ing); err != nil {
 		return fmt.Errorf("failed to remove finalizer %q: %w", FinalizerNamePG, err)
@@ -580,6 +1231,7 @@ func (r *HAIngressReconciler) deleteFinalizer(ctx context.Context, ing *networki
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.managedIngresses.Remove(ing.UID)
+	delete(r.advertisingReplicas, ing.UID)
 	gaugePGIngressResources.Set(int64(r.managedIngresses.Len()))
 	return nil
 }
@@ -596,12 +1248,13 @@ func (r *HAIngressReconciler) proxyGroupServeConfig(ctx context.Context, pg stri
 			Namespace: r.tsNamespace,
 		},
 	}
-	if err := r.Get(ctx, client.ObjectKeyFromObject(cm), cm); err != nil && !apierrors.IsNotFound(err) {
-		return nil, nil, fmt.Errorf("error retrieving ingress serve config ConfigMap %s: %v", name, err)
-	}
-	if apierrors.IsNotFound(err) {
+	err = r.Get(ctx, client.ObjectKeyFromObject(cm), cm)
+	if apierrors.IsNotFound(err) { // ProxyGroup resources have not been created (yet)
 		return nil, nil, nil
 	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error retrieving ingress serve config ConfigMap %s: %v", name, err)
+	}
 	cfg = &ipn.ServeConfig{}
 	if len(cm.BinaryData[serveConfigKey]) != 0 {
 		if err := json.Unmarshal(cm.BinaryData[serveConfigKey], cfg); err != nil {
@@ -611,8 +1264,75 @@ func (r *HAIngressReconciler) proxyGroupServeConfig(ctx context.Context, pg stri
 	return cm, cfg, nil
 }
 
+// managedServices returns the Tailscale Services in the serve config cfg,
+// read from the ConfigMap cm, that are managed by the operator.
+func managedServices(cm *corev1.ConfigMap, cfg *ipn.ServeConfig) set.Set[tailcfg.ServiceName] {
+	managed := make(set.Set[tailcfg.ServiceName])
+	v, ok := cm.Annotations[annotationManagedServices]
+	if !ok {
+		for svc := range cfg.Services {
+			managed.Add(svc)
+		}
+		return managed
+	}
+	for _, svc := range strings.Split(v, ",") {
+		if svc != "" {
+			managed.Add(tailcfg.ServiceName(svc))
+		}
+	}
+	return managed
+}
+
+// managedServicesValue returns the value of the annotationManagedServices
+// annotation for the managed Tailscale Services.
+func managedServicesValue(managed set.Set[tailcfg.ServiceName]) string {
+	names := make([]string, 0, len(managed))
+	for svc := range managed {
+		names = append(names, svc.String())
+	}
+	slices.Sort(names)
+	return strings.Join(names, ",")
+}
+
+// proxyGroupForService returns the name of the ingress ProxyGroup whose serve
+// config contains the Tailscale Service, or an empty string if there is none.
+func (r *HAIngressReconciler) proxyGroupForService(ctx context.Context, serviceName tailcfg.ServiceName) (string, error) {
+	pgList := &tsapi.ProxyGroupList{}
+	if err := r.List(ctx, pgList); err != nil {
+		return "", fmt.Errorf("listing ProxyGroups: %w", err)
+	}
+	for _, pg := range pgList.Items {
+		if pg.Spec.Type != tsapi.ProxyGroupTypeIngress {
+			continue
+		}
+		_, cfg, err := r.proxyGroupServeConfig(ctx, pg.Name)
+		if err != nil {
+			return "", err
+		}
+		if cfg != nil && cfg.Services[serviceName] != nil {
+			return pg.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// createProxyGroupServeConfig creates the ProxyGroup's ingress serve config
+// ConfigMap with an empty serve config, in the same way as the ProxyGroup
+// reconciler, and returns it.
+func (r *HAIngressReconciler) createProxyGroupServeConfig(ctx context.Context, pg *tsapi.ProxyGroup) (*corev1.ConfigMap, *ipn.ServeConfig, error) {
+	cm := pgIngressCM(pg, r.tsNamespace)
+	cm.BinaryData = map[string][]byte{
+		serveConfigKey: []byte(`{"Services":{}}`),
+	}
+	if err := r.Create(ctx, cm); err != nil {
+		return nil, nil, fmt.Errorf("error creating ConfigMap %s: %w", cm.Name, err)
+	}
+	return cm, &ipn.ServeConfig{}, nil
+}
+
 type localClient interface {
 	StatusWithoutPeers(ctx context.Context) (*ipnstate.Status, error)
+	QueryDNS(ctx context.Context, name string, queryType string) ([]byte, []*dnstype.Resolver, error)
 }
 
 // tailnetCertDomain returns the base domain (TCD) of the current tailnet.
@@ -625,38 +1345,368 @@ func tailnetCertDomain(ctx context.Context, lc localClient) (string, error) {
 }
 
 // shouldExpose returns true if the Ingress should be exposed over Tailscale in HA mode (on a ProxyGroup).
+// If the operator is configured to only watch a set of namespaces, Ingresses
+// in other namespaces are never exposed, regardless of their IngressClass.
 func (r *HAIngressReconciler) shouldExpose(ing *networkingv1.Ingress) bool {
 	isTSIngress := ing != nil &&
 		ing.Spec.IngressClassName != nil &&
 		*ing.Spec.IngressClassName == r.ingressClassName
-	pgAnnot := ing.Annotations[AnnotationProxyGroup]
-	return isTSIngress && pgAnnot != ""
+	if !isTSIngress {
+		return false
+	}
+	if len(r.watchNamespaces) > 0 && !slices.Contains(r.watchNamespaces, ing.Namespace) {
+		return false
+	}
+	pgAnnot := r.annotationPrefix.get(ing.Annotations, AnnotationProxyGroup)
+	return pgAnnot != ""
 }
 
-// validateIngress validates that the Ingress is properly configured.
-// Currently validates:
-// - Any tags provided via tailscale.com/tags annotation are valid Tailscale ACL tags
-// - The derived hostname is a valid DNS label
-// - The referenced ProxyGroup exists and is of type 'ingress'
-// - Ingress' TLS block is invalid
-func (r *HAIngressReconciler) validateIngress(ctx context.Context, ing *networkingv1.Ingress, pg *tsapi.ProxyGroup) error {
-	var errs []error
+// serviceNameStrategy determines how the Tailscale Service name, and thus the
+// MagicDNS hostname, of an HA Ingress is derived if the Ingress does not set
+// one explicitly via the host in its TLS block. Derived names depend only on
+// the Ingress' metadata, so they do not change between reconciles. The zero
+// value is the default strategy.
+type serviceNameStrategy struct {
+	kind   string // one of the serviceNameStrategy* constants, empty for default
+	prefix string // set for serviceNameStrategyPrefix
+
+	// hostnameTemplate, if set, derives the hostname instead of kind. It is
+	// executed with the Ingress' metav1.ObjectMeta.
+	hostnameTemplate *template.Template
+}
 
-	// Validate tags if present
-	violations := tagViolations(ing)
-	if len(violations) > 0 {
-		errs = append(errs, fmt.Errorf("Ingress contains invalid tags: %v", strings.Join(violations, ",")))
-	}
+const (
+	// serviceNameStrategyDefault derives <namespace>-<name>-ingress.
+	serviceNameStrategyDefault = "default"
+	// serviceNameStrategyStripSuffix derives <namespace>-<name>.
+	serviceNameStrategyStripSuffix = "strip-suffix"
+	// serviceNameStrategyHash derives ingress-<hash>, where hash is a hash
+	// of the namespace and name, to not reveal them in the tailnet.
+	serviceNameStrategyHash = "hash"
+	// serviceNameStrategyPrefix, configured as "prefix:<prefix>", derives
+	// <prefix>-<name>.
+	serviceNameStrategyPrefix = "prefix"
+)
 
-	// Validate TLS configuration
-	if len(ing.Spec.TLS) > 0 && (len(ing.Spec.TLS) > 1 || len(ing.Spec.TLS[0].Hosts) > 1) {
-		errs = append(errs, fmt.Errorf("Ingress contains invalid TLS block %v: only a single TLS entry with a single host is allowed", ing.Spec.TLS))
+// parseServiceNameStrategy parses the value of the
+// OPERATOR_SERVICE_NAME_STRATEGY environment variable.
+func parseServiceNameStrategy(s string) (serviceNameStrategy, error) {
+	switch s {
+	case "", serviceNameStrategyDefault:
+		return serviceNameStrategy{}, nil
+	case serviceNameStrategyStripSuffix, serviceNameStrategyHash:
+		return serviceNameStrategy{kind: s}, nil
+	}
+	if prefix, ok := strings.CutPrefix(s, serviceNameStrategyPrefix+":"); ok {
+		if err := dnsname.ValidLabel(prefix); err != nil {
+			return serviceNameStrategy{}, fmt.Errorf("invalid prefix %q: %w", prefix, err)
+		}
+		return serviceNameStrategy{kind: serviceNameStrategyPrefix, prefix: prefix}, nil
 	}
+	return serviceNameStrategy{}, fmt.Errorf("unknown strategy %q: must be %q, %q, %q or %q", s, serviceNameStrategyDefault, serviceNameStrategyStripSuffix, serviceNameStrategyHash, serviceNameStrategyPrefix+":<prefix>")
+}
 
-	// Validate that the hostname will be a valid DNS label
-	hostname := hostnameForIngress(ing)
-	if err := dnsname.ValidLabel(hostname); err != nil {
-		errs = append(errs, fmt.Errorf("invalid hostname %q: %w. Ensure that the hostname is a valid DNS label", hostname, err))
+// parseHostnameTemplate parses the value of the OPERATOR_HOSTNAME_TEMPLATE
+// environment variable, a Go template such as "{{.Namespace}}-{{.Name}}"
+// that derives the hostname from an Ingress' metadata. Referring to a label
+// or annotation that an Ingress does not have is an error for that Ingress.
+func parseHostnameTemplate(s string) (*template.Template, error) {
+	return template.New("hostname").Option("missingkey=error").Parse(s)
+}
+
+// renderHostname returns the hostname derived by the hostname template for an
+// Ingress.
+func (s serviceNameStrategy) renderHostname(ing *networkingv1.Ingress) (string, error) {
+	var buf strings.Builder
+	if err := s.hostnameTemplate.Execute(&buf, &ing.ObjectMeta); err != nil {
+		return "", fmt.Errorf("error rendering hostname template for Ingress: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// hostnameForIngress returns the hostname, and thus the Tailscale Service
+// name, for an HA Ingress. The hostname from the Ingress' TLS block takes
+// precedence over the strategy.
+func (s serviceNameStrategy) hostnameForIngress(ing *networkingv1.Ingress) string {
+	if len(ing.Spec.TLS) > 0 && len(ing.Spec.TLS[0].Hosts) > 0 {
+		return hostnameForIngress(ing)
+	}
+	if s.hostnameTemplate != nil {
+		// An Ingress that the template fails for is rejected by
+		// validateIngress, but still needs a name to clean up by.
+		if h, err := s.renderHostname(ing); err == nil {
+			return h
+		}
+		return hostnameForIngress(ing)
+	}
+	switch s.kind {
+	case serviceNameStrategyStripSuffix:
+		return ing.Namespace + "-" + ing.Name
+	case serviceNameStrategyHash:
+		h := sha256.Sum256([]byte(ing.Namespace + "/" + ing.Name))
+		return "ingress-" + hex.EncodeToString(h[:8])
+	case serviceNameStrategyPrefix:
+		return s.prefix + "-" + ing.Name
+	}
+	return hostnameForIngress(ing)
+}
+
+// trustBundle returns the CA bundle in the Secret referenced by the Ingress'
+// tailscale.com/trust-bundle-secret annotation, or an empty string if it has
+// none.
+func (r *HAIngressReconciler) trustBundle(ctx context.Context, ing *networkingv1.Ingress) (string, error) {
+	name, ok := r.annotationPrefix.lookup(ing.Annotations, annotationTrustBundleSecret)
+	if !ok {
+		return "", nil
+	}
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: r.tsNamespace, Name: name}, secret); apierrors.IsNotFound(err) {
+		return "", fmt.Errorf("Ingress has %s annotation %q, but Secret %s/%s does not exist. The Secret must be in the operator's namespace", r.annotationPrefix.key(annotationTrustBundleSecret), name, r.tsNamespace, name)
+	} else if err != nil {
+		return "", fmt.Errorf("error getting Secret %s/%s: %w", r.tsNamespace, name, err)
+	}
+	return string(secret.Data[trustBundleKey]), nil
+}
+
+// readyGateExists reports whether the ConfigMap or Secret referenced by the
+// Ingress' tailscale.com/ready-gate annotation exists in the operator's
+// namespace. It returns true if the Ingress has no ready gate.
+func (r *HAIngressReconciler) readyGateExists(ctx context.Context, ing *networkingv1.Ingress) (bool, error) {
+	v, ok := r.annotationPrefix.lookup(ing.Annotations, annotationReadyGate)
+	if !ok {
+		return true, nil
+	}
+	kind, name, err := parseReadyGate(v)
+	if err != nil {
+		return false, err // should have been caught by validateIngress
+	}
+	var obj client.Object = &corev1.ConfigMap{}
+	if kind == readyGateSecret {
+		obj = &corev1.Secret{}
+	}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: r.tsNamespace, Name: name}, obj); apierrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("error getting %s %s/%s: %w", kind, r.tsNamespace, name, err)
+	}
+	return true, nil
+}
+
+// waitForDependency ensures that the Tailscale Service of an Ingress whose
+// ready gate does not exist is not advertised and that the Ingress is not
+// marked ready, and sets the tailscale.com/waiting-for-dependency annotation.
+func (r *HAIngressReconciler) waitForDependency(ctx context.Context, ing *networkingv1.Ingress, pgName string, serviceName tailcfg.ServiceName, logger *zap.SugaredLogger) error {
+	if err := r.maybeUpdateAdvertiseServicesConfig(ctx, pgName, serviceName, serviceAdvertisementOff, nil, logger); err != nil {
+		return fmt.Errorf("failed to update tailscaled config: %w", err)
+	}
+	gate := r.annotationPrefix.get(ing.Annotations, annotationReadyGate)
+	if ing.Annotations[annotationWaitingForDependency] != gate {
+		msg := fmt.Sprintf("waiting for %s in namespace %s to exist before advertising the Tailscale Service", gate, r.tsNamespace)
+		logger.Info(msg)
+		r.recorder.Event(ing, corev1.EventTypeNormal, reasonWaitingForDependency, msg)
+	}
+	if err := r.maybeUpdateWaitingForDependency(ctx, ing, gate, logger); err != nil {
+		return err
+	}
+	if len(ing.Status.LoadBalancer.Ingress) == 0 {
+		return nil
+	}
+	logger.Infof("Clearing Ingress status until the ready gate exists")
+	ing.Status.LoadBalancer.Ingress = nil
+	if err := r.Status().Update(ctx, ing); err != nil {
+		return fmt.Errorf("failed to update Ingress status: %w", err)
+	}
+	return nil
+}
+
+// maybeUpdateWaitingForDependency ensures that the Ingress'
+// tailscale.com/waiting-for-dependency annotation is set to gate, or removed if
+// gate is empty.
+func (r *HAIngressReconciler) maybeUpdateWaitingForDependency(ctx context.Context, ing *networkingv1.Ingress, gate string, logger *zap.SugaredLogger) error {
+	if ing.Annotations[annotationWaitingForDependency] == gate {
+		return nil
+	}
+	if gate == "" {
+		delete(ing.Annotations, annotationWaitingForDependency)
+	} else {
+		mak.Set(&ing.Annotations, annotationWaitingForDependency, gate)
+	}
+	logger.Debugf("updating waiting for dependency to %q", gate)
+	if err := r.Update(ctx, ing); err != nil {
+		return fmt.Errorf("failed to update %s annotation: %w", annotationWaitingForDependency, err)
+	}
+	return nil
+}
+
+// dnsResolves reports whether name resolves on the tailnet, as seen by the
+// operator's tailscaled. If the name cannot be queried, it is assumed to
+// resolve, so that a failing query does not hold up the Ingress.
+func (r *HAIngressReconciler) dnsResolves(ctx context.Context, name string, logger *zap.SugaredLogger) bool {
+	b, _, err := r.lc.QueryDNS(ctx, name, "A")
+	if err != nil {
+		logger.Debugf("error querying DNS for %s, assuming it resolves: %v", name, err)
+		return true
+	}
+	var p dnsmessage.Parser
+	h, err := p.Start(b)
+	if err != nil {
+		logger.Debugf("error parsing DNS response for %s, assuming it resolves: %v", name, err)
+		return true
+	}
+	if h.RCode != dnsmessage.RCodeSuccess {
+		return false
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		logger.Debugf("error parsing DNS response for %s, assuming it resolves: %v", name, err)
+		return true
+	}
+	answers, err := p.AllAnswers()
+	if err != nil {
+		logger.Debugf("error parsing DNS response for %s, assuming it resolves: %v", name, err)
+		return true
+	}
+	return len(answers) > 0
+}
+
+// maybeUpdateWaitingForDNS ensures that the Ingress' tailscale.com/waiting-for-dns
+// annotation is set to dnsName if waiting is true, or removed otherwise. A
+// Normal Event is emitted when the Ingress starts waiting.
+func (r *HAIngressReconciler) maybeUpdateWaitingForDNS(ctx context.Context, ing *networkingv1.Ingress, waiting bool, dnsName string, logger *zap.SugaredLogger) error {
+	want := ""
+	if waiting {
+		want = dnsName
+	}
+	if ing.Annotations[annotationWaitingForDNS] == want {
+		return nil
+	}
+	if want == "" {
+		logger.Infof("%s resolves on the tailnet, no longer waiting for DNS", dnsName)
+		delete(ing.Annotations, annotationWaitingForDNS)
+	} else {
+		msg := fmt.Sprintf("TLS cert for %s cannot be issued until the name resolves on the tailnet; checking again every %v", dnsName, dnsPropagationRetryInterval)
+		logger.Info(msg)
+		r.recorder.Event(ing, corev1.EventTypeNormal, reasonWaitingForDNS, msg)
+		mak.Set(&ing.Annotations, annotationWaitingForDNS, want)
+	}
+	if err := r.Update(ctx, ing); err != nil {
+		return fmt.Errorf("failed to update %s annotation: %w", annotationWaitingForDNS, err)
+	}
+	return nil
+}
+
+// validateTLSSecret validates that the Secret referenced by the Ingress'
+// tailscale.com/tls-secret annotation, if any, exists and contains a TLS cert
+// and key.
+func (r *HAIngressReconciler) validateTLSSecret(ctx context.Context, ing *networkingv1.Ingress) error {
+	name, ok := r.annotationPrefix.lookup(ing.Annotations, annotationTLSSecret)
+	if !ok {
+		return nil
+	}
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: r.tsNamespace, Name: name}, secret); apierrors.IsNotFound(err) {
+		return fmt.Errorf("Ingress has %s annotation %q, but Secret %s/%s does not exist. The Secret must be in the operator's namespace", r.annotationPrefix.key(annotationTLSSecret), name, r.tsNamespace, name)
+	} else if err != nil {
+		return fmt.Errorf("error getting Secret %s/%s: %w", r.tsNamespace, name, err)
+	}
+	if len(secret.Data[corev1.TLSCertKey]) == 0 || len(secret.Data[corev1.TLSPrivateKeyKey]) == 0 {
+		return fmt.Errorf("Secret %s/%s referenced by the Ingress' %s annotation must contain non-empty %s and %s", r.tsNamespace, name, r.annotationPrefix.key(annotationTLSSecret), corev1.TLSCertKey, corev1.TLSPrivateKeyKey)
+	}
+	return nil
+}
+
+// serviceNameTooLongError returns an error for an Ingress whose hostname, and
+// thus Tailscale Service name, exceeds maxServiceNameLength, with guidance on
+// how to shorten it.
+func serviceNameTooLongError(ing *networkingv1.Ingress, hostname string) error {
+	svcName := "svc:" + hostname
+	if len(ing.Spec.TLS) > 0 && len(ing.Spec.TLS[0].Hosts) > 0 {
+		return fmt.Errorf("Tailscale Service name %q is too long: the hostname from the Ingress' TLS block must be at most %d characters, but is %d. Set a shorter host in the TLS block", svcName, maxServiceNameLength, len(hostname))
+	}
+	return fmt.Errorf("Tailscale Service name %q derived for the Ingress is too long: the hostname must be at most %d characters, but is %d. Set a shorter hostname explicitly via the host in the Ingress' TLS block, or shorten the Ingress' name or namespace", svcName, maxServiceNameLength, len(hostname))
+}
+
+// validateIngress validates that the Ingress is properly configured.
+// Currently validates:
+// - Any tags provided via tailscale.com/tags annotation are valid Tailscale ACL tags
+// - The derived hostname is a valid DNS label that is not too long to be used as a Tailscale Service name
+// - The referenced ProxyGroup exists and is of type 'ingress'
+// - Ingress' TLS block is invalid
+// - Ingress' TLS host is neither a bare hostname nor a FQDN in the tailnet's MagicDNS domain
+// - Ingress' TLS host is an IP address
+// - The Ingress does not combine modes that are incompatible, see incompatibleModes
+// - The Ingress' annotations have valid values, see ingressAnnotations
+// - The resources referenced by the Ingress' annotations exist and are valid
+func (r *HAIngressReconciler) validateIngress(ctx context.Context, ing *networkingv1.Ingress, pg *tsapi.ProxyGroup) error {
+	var errs []error
+
+	// Validate tags if present
+	violations := tagViolations(ing, r.annotationPrefix)
+	if len(violations) > 0 {
+		errs = append(errs, fmt.Errorf("Ingress contains invalid tags: %v", strings.Join(violations, ",")))
+	}
+
+	// Validate TLS configuration
+	if len(ing.Spec.TLS) > 0 && (len(ing.Spec.TLS) > 1 || len(ing.Spec.TLS[0].Hosts) > 1) {
+		errs = append(errs, fmt.Errorf("Ingress contains invalid TLS block %v: only a single TLS entry with a single host is allowed", ing.Spec.TLS))
+	} else if len(ing.Spec.TLS) == 1 && len(ing.Spec.TLS[0].Hosts) == 1 {
+		if err := r.validateTLSHost(ctx, ing.Spec.TLS[0].Hosts[0]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Validate that no incompatible modes are combined
+	if err := validateModeCombinations(ing, r.annotationPrefix); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Validate the values of annotations, see ingressAnnotations
+	if err := validateIngressAnnotations(ing, r.annotationPrefix); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Validate the backend ports
+	if err := validateBackendPorts(ing); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Validate the backend for the HTTP endpoint
+	if err := r.validateHTTPBackend(ctx, ing); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Validate that the pinned replicas exist
+	if pinned, err := pinnedReplicas(ing, r.annotationPrefix); err == nil {
+		for _, i := range pinned {
+			if i >= pgReplicas(pg) {
+				errs = append(errs, fmt.Errorf("Ingress has invalid %s annotation: replica %d does not exist, ProxyGroup %q has %d replicas", r.annotationPrefix.key(annotationReplicas), i, pg.Name, pgReplicas(pg)))
+			}
+		}
+	}
+
+	// Validate the externally managed TLS Secret
+	if err := r.validateTLSSecret(ctx, ing); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Validate the trust bundle Secret
+	if bundle, err := r.trustBundle(ctx, ing); err != nil {
+		errs = append(errs, err)
+	} else if _, ok := r.annotationPrefix.lookup(ing.Annotations, annotationTrustBundleSecret); ok && !x509.NewCertPool().AppendCertsFromPEM([]byte(bundle)) {
+		errs = append(errs, fmt.Errorf("Secret %s/%s referenced by the Ingress' %s annotation must contain PEM-encoded CA certificates in %s", r.tsNamespace, r.annotationPrefix.get(ing.Annotations, annotationTrustBundleSecret), r.annotationPrefix.key(annotationTrustBundleSecret), trustBundleKey))
+	}
+
+	// Validate that the hostname will be a valid DNS label. Hostnames derived
+	// from IP literals in the TLS block have already been rejected above.
+	hostname := r.serviceNameStrategy.hostnameForIngress(ing)
+	if r.serviceNameStrategy.hostnameTemplate != nil && (len(ing.Spec.TLS) == 0 || len(ing.Spec.TLS[0].Hosts) == 0) {
+		if _, err := r.serviceNameStrategy.renderHostname(ing); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(hostname) > maxServiceNameLength {
+		errs = append(errs, serviceNameTooLongError(ing, hostname))
+	} else if err := dnsname.ValidLabel(hostname); err != nil && !hasIPTLSHost(ing) {
+		errs = append(errs, fmt.Errorf("invalid hostname %q: %w. Ensure that the hostname is a valid DNS label", hostname, err))
 	}
 
 	// Validate ProxyGroup type
@@ -677,28 +1727,103 @@ func (r *HAIngressReconciler) validateIngress(ctx context.Context, ing *networki
 		return errors.Join(errs...)
 	}
 	for _, i := range ingList.Items {
-		if r.shouldExpose(&i) && hostnameForIngress(&i) == hostname && i.UID != ing.UID {
+		if r.shouldExpose(&i) && r.serviceNameStrategy.hostnameForIngress(&i) == hostname && i.UID != ing.UID {
 			errs = append(errs, fmt.Errorf("found duplicate Ingress %q for hostname %q - multiple Ingresses for the same hostname in the same cluster are not allowed", client.ObjectKeyFromObject(&i), hostname))
 		}
 	}
 	return errors.Join(errs...)
 }
 
+// createGraceRemaining returns how much longer the creation of the Tailscale
+// Service for ing must be deferred by r.createGracePeriod. The grace period
+// starts when the Ingress is first seen, and restarts whenever it changes, so
+// that rapid edits are coalesced into a single creation. It returns 0 once
+// the Ingress has been unchanged for the whole grace period.
+func (r *HAIngressReconciler) createGraceRemaining(ing *networkingv1.Ingress) time.Duration {
+	if r.createGracePeriod <= 0 {
+		return 0
+	}
+	key := client.ObjectKeyFromObject(ing)
+	now := r.clock.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.pendingCreates[key]
+	if !ok || p.uid != ing.UID || p.resourceVersion != ing.ResourceVersion {
+		p = pendingCreate{uid: ing.UID, resourceVersion: ing.ResourceVersion, since: now}
+		mak.Set(&r.pendingCreates, key, p)
+	}
+	return max(p.since.Add(r.createGracePeriod).Sub(now), 0)
+}
+
+// forgetPendingCreate stops tracking the Ingress by the given name for the
+// creation grace period, once its Tailscale Service is being created, or the
+// Ingress no longer needs one.
+func (r *HAIngressReconciler) forgetPendingCreate(key types.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pendingCreates, key)
+}
+
+// validateTLSHost validates the host in an Ingress' TLS block. It must either
+// be a bare hostname, such as "my-svc", which gets the tailnet's MagicDNS
+// suffix appended, or a FQDN directly in the tailnet's MagicDNS domain, such
+// as "my-svc.tailnet-xyz.ts.net", which is used as is. Other FQDNs are
+// rejected, as the Ingress can only be served on a MagicDNS name.
+func (r *HAIngressReconciler) validateTLSHost(ctx context.Context, host string) error {
+	if isIPHost(host) {
+		return fmt.Errorf("Ingress contains invalid TLS host %q: IP addresses are not supported, as Tailscale Services are only served on DNS names; use a bare hostname, such as %q, instead", host, "my-svc")
+	}
+	label, _, isFQDN := strings.Cut(host, ".")
+	if !isFQDN {
+		// Bare hostnames are validated along with derived ones.
+		return nil
+	}
+	tcd, err := tailnetCertDomain(ctx, r.lc)
+	if err != nil {
+		return fmt.Errorf("[unexpected] error determining the tailnet's MagicDNS suffix: %w", err)
+	}
+	if host != label+"."+tcd {
+		return fmt.Errorf("Ingress contains invalid TLS host %q: must be a bare hostname, such as %q, or a FQDN in the tailnet's MagicDNS domain %q, such as %q", host, label, tcd, label+"."+tcd)
+	}
+	return nil
+}
+
+// isIPHost reports whether host is an IPv4 or IPv6 literal, optionally
+// enclosed in square brackets, rather than a DNS name.
+func isIPHost(host string) bool {
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		host = host[1 : len(host)-1]
+	}
+	_, err := netip.ParseAddr(host)
+	return err == nil
+}
+
+// hasIPTLSHost reports whether the Ingress' TLS block has an IP literal as its
+// first host.
+func hasIPTLSHost(ing *networkingv1.Ingress) bool {
+	return len(ing.Spec.TLS) > 0 && len(ing.Spec.TLS[0].Hosts) > 0 && isIPHost(ing.Spec.TLS[0].Hosts[0])
+}
+
 // cleanupTailscaleService deletes any Tailscale Service by the provided name if it is not owned by operator instances other than this one.
 // If a Tailscale Service is found, but contains other owner references, only removes this operator's owner reference.
 // If a Tailscale Service by the given name is not found or does not contain this operator's owner reference, do nothing.
-// It returns true if an existing Tailscale Service was updated to remove owner reference, as well as any error that occurred.
-func (r *HAIngressReconciler) cleanupTailscaleService(ctx context.Context, svc *tailscale.VIPService, logger *zap.SugaredLogger) (updated bool, _ error) {
+// If automatic deletion of Tailscale Services is disabled, the last owner reference is removed instead of deleting the
+// Tailscale Service.
+// Audit Events for the changes made are emitted on obj.
+// It returns whether an existing Tailscale Service was updated to remove owner reference, whether it was retained
+// rather than deleted, as well as any error that occurred.
+func (r *HAIngressReconciler) cleanupTailscaleService(ctx context.Context, svc *tailscale.VIPService, obj client.Object, logger *zap.SugaredLogger) (updated, retained bool, _ error) {
 	if svc == nil {
-		return false, nil
+		return false, false, nil
 	}
 	o, err := parseOwnerAnnotation(svc)
 	if err != nil {
-		return false, fmt.Errorf("error parsing Tailscale Service's owner annotation")
+		return false, false, fmt.Errorf("error parsing Tailscale Service's owner annotation")
 	}
 	if o == nil || len(o.OwnerRefs) == 0 {
-		return false, nil
+		return false, false, nil
 	}
+	o.OwnerRefs, _ = migrateOwnerRefList(o.OwnerRefs, r.previousOperatorID, r.operatorID)
 	// Comparing with the operatorID only means that we will not be able to
 	// clean up Tailscale Service in cases where the operator was deleted from the
 	// cluster before deleting the Ingress. Perhaps the comparison could be
@@ -707,45 +1832,314 @@ func (r *HAIngressReconciler) cleanupTailscaleService(ctx context.Context, svc *
 		return or.OperatorID == r.operatorID
 	})
 	if ix == -1 {
-		return false, nil
+		return false, false, nil
 	}
 	if len(o.OwnerRefs) == 1 {
+		if r.noAutoDeleteServices {
+			if err := retainTailscaleService(ctx, r.tsClient, svc, logger); err != nil {
+				return true, true, err
+			}
+			ownerRefChanges.WithLabelValues(reconcilerIngressPG, ownerRefResultRemove).Inc()
+			r.auditOwnerRefRemoved(obj, svc.Name)
+			return true, true, nil
+		}
 		logger.Infof("Deleting Tailscale Service %q", svc.Name)
 		if err = r.tsClient.DeleteVIPService(ctx, svc.Name); err != nil && !isErrorTailscaleServiceNotFound(err) {
-			return false, err
+			return false, false, err
 		}
-
-		return false, nil
+		ownerRefChanges.WithLabelValues(reconcilerIngressPG, ownerRefResultRemove).Inc()
+		r.auditEventf(obj, reasonAuditTailscaleServiceDeleted, "Deleted Tailscale Service %s", svc.Name)
+		return false, false, nil
 	}
 
 	o.OwnerRefs = slices.Delete(o.OwnerRefs, ix, ix+1)
+	if tags := ownerTags(o); len(tags) > 0 {
+		svc.Tags = tags
+	}
+	if !ownersWantHTTP(o) {
+		svc.Ports = slices.DeleteFunc(svc.Ports, func(p string) bool { return p == "tcp:80" })
+	}
 	logger.Infof("Creating/Updating Tailscale Service %q", svc.Name)
 	json, err := json.Marshal(o)
 	if err != nil {
-		return false, fmt.Errorf("error marshalling updated Tailscale Service owner reference: %w", err)
+		return false, false, fmt.Errorf("error marshalling updated Tailscale Service owner reference: %w", err)
 	}
 	svc.Annotations[ownerAnnotation] = string(json)
-	return true, r.tsClient.CreateOrUpdateVIPService(ctx, svc)
+	if err := r.tsClient.CreateOrUpdateVIPService(ctx, svc); err != nil {
+		return true, false, err
+	}
+	ownerRefChanges.WithLabelValues(reconcilerIngressPG, ownerRefResultRemove).Inc()
+	r.auditOwnerRefRemoved(obj, svc.Name)
+	return true, false, nil
+}
+
+// auditEventf emits a Normal Event on obj recording a change made by the
+// reconciler, if audit Events are enabled.
+func (r *HAIngressReconciler) auditEventf(obj client.Object, reason, format string, args ...any) {
+	if !r.emitAuditEvents {
+		return
+	}
+	r.recorder.Eventf(obj, corev1.EventTypeNormal, reason, format, args...)
+}
+
+// auditOwnerRefRemoved emits an audit Event on obj for the removal of this
+// operator instance's owner reference from the Tailscale Service svcName.
+func (r *HAIngressReconciler) auditOwnerRefRemoved(obj client.Object, svcName tailcfg.ServiceName) {
+	r.auditEventf(obj, reasonAuditOwnerRefRemoved, "Removed owner reference of operator %s from Tailscale Service %s", r.operatorID, svcName)
 }
 
-// isHTTPEndpointEnabled returns true if the Ingress has been configured to expose an HTTP endpoint to tailnet.
-func isHTTPEndpointEnabled(ing *networkingv1.Ingress) bool {
-	if ing == nil {
+// hasPort443 reports whether the Ingress status st advertises port 443.
+func hasPort443(st *networkingv1.IngressStatus) bool {
+	for _, lb := range st.LoadBalancer.Ingress {
+		for _, p := range lb.Ports {
+			if p.Port == 443 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasOwnerRef reports whether svc has an owner reference of the operator
+// instance operatorID.
+func hasOwnerRef(svc *tailscale.VIPService, operatorID string) bool {
+	if svc == nil {
 		return false
 	}
-	return ing.Annotations[annotationHTTPEndpoint] == "enabled"
+	o, err := parseOwnerAnnotation(svc)
+	if err != nil || o == nil {
+		return false
+	}
+	return slices.ContainsFunc(o.OwnerRefs, func(or OwnerRef) bool {
+		return or.OperatorID == operatorID
+	})
+}
+
+// probeBackends checks that each backend that cfg proxies to accepts TCP
+// connections. It returns an error listing the backends that do not.
+func (r *HAIngressReconciler) probeBackends(ctx context.Context, cfg *ipn.ServiceConfig) error {
+	dial := r.backendDialer
+	if dial == nil {
+		dial = new(net.Dialer).DialContext
+	}
+	var errs []error
+	seen := make(set.Set[string])
+	for _, ep := range slices.Sorted(maps.Keys(cfg.Web)) {
+		handlers := cfg.Web[ep].Handlers
+		for _, path := range slices.Sorted(maps.Keys(handlers)) {
+			if handlers[path].Proxy == "" {
+				continue
+			}
+			for _, target := range append([]string{handlers[path].Proxy}, handlers[path].ProxyTargets...) {
+				u, err := url.Parse(target)
+				if err != nil {
+					return fmt.Errorf("[unexpected] invalid proxy target %q: %w", target, err)
+				}
+				if seen.Contains(u.Host) {
+					continue
+				}
+				seen.Add(u.Host)
+				dialCtx, cancel := context.WithTimeout(ctx, backendProbeTimeout)
+				conn, err := dial(dialCtx, "tcp", u.Host)
+				cancel()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("backend %s for path %q is unreachable: %w", u.Host, path, err))
+					continue
+				}
+				conn.Close()
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// backendPods returns the Pods in namespace ns that match sel and are ready
+// to serve requests.
+func (r *HAIngressReconciler) backendPods(ctx context.Context, ns string, sel labels.Selector) ([]corev1.Pod, error) {
+	var cl client.Reader = r.Client
+	if r.podReader != nil {
+		cl = r.podReader
+	}
+	var podList corev1.PodList
+	if err := cl.List(ctx, &podList, client.InNamespace(ns), client.MatchingLabelsSelector{Selector: sel}); err != nil {
+		return nil, fmt.Errorf("error listing backend Pods: %w", err)
+	}
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if !pod.DeletionTimestamp.IsZero() || pod.Status.PodIP == "" {
+			continue
+		}
+		if slices.ContainsFunc(pod.Status.Conditions, func(c corev1.PodCondition) bool {
+			return c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue
+		}) {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// proxyHandlerForPods returns the handler that proxies requests for path to
+// the pods selected by the tailscale.com/backend-selector annotation, on the
+// port of the Service backend b. It returns nil, after emitting a warning
+// Event, if none of the pods serve the port.
+func proxyHandlerForPods(ing *networkingv1.Ingress, rec record.EventRecorder, pods []corev1.Pod, b *networkingv1.IngressBackend, path string, prefix annotationPrefix) *ipn.HTTPHandler {
+	if b == nil {
+		return nil
+	}
+	if b.Service == nil {
+		rec.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressBackend", "backend for path %q is missing service", path)
+		return nil
+	}
+	var targets []string
+	for _, pod := range pods {
+		port := b.Service.Port.Number
+		if b.Service.Port.Name != "" {
+			port = containerPort(&pod, b.Service.Port.Name)
+		}
+		if port <= 0 || port > 65535 {
+			continue
+		}
+		proto := "http://"
+		if port == 443 || b.Service.Port.Name == "https" {
+			proto = "https+insecure://"
+		}
+		targets = append(targets, proto+net.JoinHostPort(pod.Status.PodIP, fmt.Sprint(port))+path)
+	}
+	if len(targets) == 0 {
+		port := b.Service.Port.Name
+		if port == "" {
+			port = fmt.Sprint(b.Service.Port.Number)
+		}
+		rec.Eventf(ing, corev1.EventTypeWarning, reasonNoBackendPods, "no ready Pods matching %s annotation %q serve port %s for path %q", prefix.key(annotationBackendSelector), prefix.get(ing.Annotations, annotationBackendSelector), port, path)
+		return nil
+	}
+	// Sort the targets so that the serve config only changes if the set
+	// of Pods does.
+	slices.Sort(targets)
+	h := &ipn.HTTPHandler{Proxy: targets[0]}
+	if len(targets) > 1 {
+		h.ProxyTargets = targets[1:]
+	}
+	return h
+}
+
+// containerPort returns the number of the container port of pod with the
+// given name, or 0 if it has none.
+func containerPort(pod *corev1.Pod, name string) int32 {
+	for _, c := range pod.Spec.Containers {
+		for _, p := range c.Ports {
+			if p.Name == name {
+				return p.ContainerPort
+			}
+		}
+	}
+	return 0
+}
+
+// handlersForHTTPBackend returns the handlers for an HTTP endpoint that is
+// served from the backend b, configured by the tailscale.com/http-backend
+// annotation. Like the HTTPS endpoint's handlers, they respect the Ingress'
+// connection limit, backend retries and response headers.
+func handlersForHTTPBackend(ctx context.Context, ing *networkingv1.Ingress, cl client.Client, rec record.EventRecorder, b *networkingv1.IngressBackend, prefix annotationPrefix) map[string]*ipn.HTTPHandler {
+	h := proxyHandlerForBackend(ctx, ing, cl, rec, b, "/", prefix)
+	if h == nil {
+		return nil
+	}
+	// Invalid values have already been reported when building the HTTPS
+	// endpoint's handlers.
+	h.MaxConns, _ = maxConnections(ing, prefix)
+	h.BackendRetries, h.RetryStatusCodes, _ = backendRetries(ing, prefix)
+	if respHeaders, _ := responseHeaders(ing, prefix); len(respHeaders) > 0 {
+		h.ResponseHeaders = respHeaders
+	}
+	return map[string]*ipn.HTTPHandler{"/": h}
+}
+
+// validateHTTPBackend validates the tailscale.com/http-backend annotation. If
+// it is set, the Services backing both the HTTP and the HTTPS endpoint must
+// exist.
+//
+// The Services are read through r.Client, like in proxyHandlerForBackend, so
+// they come from the manager's cache rather than the API server. The cache is
+// kept up to date by the reconciler's Service watch, which also reconciles
+// the Ingress when one of them is created or deleted, so they are checked on
+// every reconcile rather than only when the Ingress changes.
+func (r *HAIngressReconciler) validateHTTPBackend(ctx context.Context, ing *networkingv1.Ingress) error {
+	b, err := httpBackend(ing, r.annotationPrefix)
+	if err != nil || b == nil {
+		// Invalid values are rejected by validateIngressAnnotations.
+		return nil
+	}
+	if isHTTPRedirectEnabled(ing, r.annotationPrefix) {
+		// Rejected by validateModeCombinations.
+		return nil
+	}
+	backends := []*networkingv1.IngressBackend{b, ing.Spec.DefaultBackend}
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, p := range rule.HTTP.Paths {
+			backends = append(backends, &p.Backend)
+		}
+	}
+	var errs []error
+	seen := make(set.Set[string])
+	for _, b := range backends {
+		if b == nil || b.Service == nil || seen.Contains(b.Service.Name) {
+			continue
+		}
+		seen.Add(b.Service.Name)
+		err := r.Get(ctx, types.NamespacedName{Namespace: ing.Namespace, Name: b.Service.Name}, &corev1.Service{})
+		if apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("Ingress backend Service %q does not exist", b.Service.Name))
+		} else if err != nil {
+			errs = append(errs, fmt.Errorf("[unexpected] error getting Ingress backend Service %q: %w", b.Service.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateBackendPorts validates the port numbers of the Ingress' Service
+// backends. Backends that refer to a Service port by name are resolved when
+// building the serve config.
+func validateBackendPorts(ing *networkingv1.Ingress) error {
+	var errs []error
+	check := func(b *networkingv1.IngressBackend, path string) {
+		if b == nil || b.Service == nil || b.Service.Port.Name != "" {
+			return
+		}
+		if n := b.Service.Port.Number; n <= 0 || n > 65535 {
+			errs = append(errs, fmt.Errorf("Ingress backend for path %q has invalid port %d for Service %q: port must be between 1 and 65535", path, n, b.Service.Name))
+		}
+	}
+	check(ing.Spec.DefaultBackend, "/")
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, p := range rule.HTTP.Paths {
+			check(&p.Backend, cmp.Or(p.Path, "/"))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // serviceAdvertisementMode describes the desired state of a Tailscale Service.
 type serviceAdvertisementMode int
 
 const (
-	serviceAdvertisementOff          serviceAdvertisementMode = iota // Should not be advertised
-	serviceAdvertisementHTTPS                                        // Port 443 should be advertised
-	serviceAdvertisementHTTPAndHTTPS                                 // Both ports 80 and 443 should be advertised
+	serviceAdvertisementOff              serviceAdvertisementMode = iota // Should not be advertised
+	serviceAdvertisementHTTPS                                            // Port 443 should be advertised
+	serviceAdvertisementHTTPAndHTTPS                                     // Both ports 80 and 443 should be advertised
+	serviceAdvertisementHTTPSWithoutCert                                 // Port 443 should be advertised, even if the TLS cert is not yet issued
 )
 
-func (a *HAIngressReconciler) maybeUpdateAdvertiseServicesConfig(ctx context.Context, pgName string, serviceName tailcfg.ServiceName, mode serviceAdvertisementMode, logger *zap.SugaredLogger) (err error) {
+// maybeUpdateAdvertiseServicesConfig ensures that the ProxyGroup replicas'
+// tailscaled configs advertise the Tailscale Service if required by mode. If
+// replicas is non-nil, only the replicas with those indices advertise it and it
+// is removed from all others.
+func (a *HAIngressReconciler) maybeUpdateAdvertiseServicesConfig(ctx context.Context, pgName string, serviceName tailcfg.ServiceName, mode serviceAdvertisementMode, replicas []int32, logger *zap.SugaredLogger) (err error) {
 	// Get all config Secrets for this ProxyGroup.
 	secrets := &corev1.SecretList{}
 	if err := a.List(ctx, secrets, client.InNamespace(a.tsNamespace), client.MatchingLabels(pgSecretLabels(pgName, kubetypes.LabelSecretTypeConfig))); err != nil {
@@ -759,15 +2153,27 @@ func (a *HAIngressReconciler) maybeUpdateAdvertiseServicesConfig(ctx context.Con
 	// to a backend that is not able to serve HTTPS.
 	// The only exception is Ingresses with an HTTP endpoint enabled - if an
 	// Ingress has an HTTP endpoint enabled, it will be advertised even if the
-	// TLS cert is not yet provisioned.
-	hasCert, err := hasCerts(ctx, a.Client, a.lc, a.tsNamespace, serviceName)
+	// TLS cert is not yet provisioned. Ingresses can also opt out of waiting
+	// for the cert with the cert-wait annotation.
+	hasCert, err := hasCerts(ctx, a.Client, a.lc, a.tsNamespace, pgName, serviceName)
 	if err != nil {
 		return fmt.Errorf("error checking TLS credentials provisioned for service %q: %w", serviceName, err)
 	}
 	shouldBeAdvertised := (mode == serviceAdvertisementHTTPAndHTTPS) ||
+		(mode == serviceAdvertisementHTTPSWithoutCert) ||
 		(mode == serviceAdvertisementHTTPS && hasCert) // if we only expose port 443 and don't have certs (yet), do not advertise
 
+	var changed []*corev1.Secret
 	for _, secret := range secrets.Items {
+		replicaShouldAdvertise := shouldBeAdvertised
+		if replicas != nil {
+			var ordinal int32
+			if _, err := fmt.Sscanf(secret.Name, pgName+"-%d-config", &ordinal); err != nil {
+				return fmt.Errorf("unexpected ProxyGroup %q config Secret name %q: %w", pgName, secret.Name, err)
+			}
+			replicaShouldAdvertise = shouldBeAdvertised && slices.Contains(replicas, ordinal)
+		}
+
 		var updated bool
 		for fileName, confB := range secret.Data {
 			var conf ipn.ConfigVAlpha
@@ -779,13 +2185,13 @@ func (a *HAIngressReconciler) maybeUpdateAdvertiseServicesConfig(ctx context.Con
 			idx := slices.Index(conf.AdvertiseServices, serviceName.String())
 			isAdvertised := idx >= 0
 			switch {
-			case isAdvertised == shouldBeAdvertised:
+			case isAdvertised == replicaShouldAdvertise:
 				// Already up to date.
 				continue
 			case isAdvertised:
 				// Needs to be removed.
 				conf.AdvertiseServices = slices.Delete(conf.AdvertiseServices, idx, idx+1)
-			case shouldBeAdvertised:
+			case replicaShouldAdvertise:
 				// Needs to be added.
 				conf.AdvertiseServices = append(conf.AdvertiseServices, serviceName.String())
 			}
@@ -800,37 +2206,108 @@ func (a *HAIngressReconciler) maybeUpdateAdvertiseServicesConfig(ctx context.Con
 		}
 
 		if updated {
-			if err := a.Update(ctx, &secret); err != nil {
-				return fmt.Errorf("error updating ProxyGroup config Secret: %w", err)
-			}
+			changed = append(changed, &secret)
 		}
 	}
 
-	return nil
+	// Update all replicas together, so that they advertise the same
+	// Tailscale Services even if the operator shuts down meanwhile.
+	return a.pendingWrites.applyConfigSecrets(ctx, a.Client, haIngressFieldManager, changed)
+}
+
+func numberPodsAdvertising(ctx context.Context, cl client.Client, tsNamespace, pgName string, serviceName tailcfg.ServiceName, logger *zap.SugaredLogger) (int, error) {
+	replicas, err := replicasAdvertising(ctx, cl, tsNamespace, pgName, serviceName, logger)
+	if err != nil {
+		return 0, err
+	}
+	return len(replicas), nil
 }
 
-func numberPodsAdvertising(ctx context.Context, cl client.Client, tsNamespace, pgName string, serviceName tailcfg.ServiceName) (int, error) {
+// replicasAdvertising returns the sorted indices of the ProxyGroup replicas
+// whose state Secrets show that they are currently advertising the given
+// Tailscale Service.
+func replicasAdvertising(ctx context.Context, cl client.Client, tsNamespace, pgName string, serviceName tailcfg.ServiceName, logger *zap.SugaredLogger) ([]int, error) {
 	// Get all state Secrets for this ProxyGroup.
 	secrets := &corev1.SecretList{}
 	if err := cl.List(ctx, secrets, client.InNamespace(tsNamespace), client.MatchingLabels(pgSecretLabels(pgName, kubetypes.LabelSecretTypeState))); err != nil {
-		return 0, fmt.Errorf("failed to list ProxyGroup %q state Secrets: %w", pgName, err)
+		return nil, fmt.Errorf("failed to list ProxyGroup %q state Secrets: %w", pgName, err)
 	}
 
-	var count int
+	var replicas []int
 	for _, secret := range secrets.Items {
+		var ordinal int
+		if _, err := fmt.Sscanf(secret.Name, pgName+"-%d", &ordinal); err != nil {
+			// Not a replica's state Secret, though it has the labels
+			// of one.
+			logger.Debugf("skipping ProxyGroup %q state Secret with unexpected name %q", pgName, secret.Name)
+			continue
+		}
 		prefs, ok, err := getDevicePrefs(&secret)
 		if err != nil {
-			return 0, fmt.Errorf("error getting node metadata: %w", err)
+			return nil, fmt.Errorf("error getting node metadata: %w", err)
 		}
 		if !ok {
 			continue
 		}
 		if slices.Contains(prefs.AdvertiseServices, serviceName.String()) {
-			count++
+			replicas = append(replicas, ordinal)
 		}
 	}
+	slices.Sort(replicas)
+
+	return replicas, nil
+}
+
+// replicaCapVers returns the capability versions of the ProxyGroup's replicas,
+// as reported in their state Secrets. It is negative for replicas whose
+// current Pod has not reported it.
+func replicaCapVers(ctx context.Context, cl client.Client, tsNamespace, pgName string, logger *zap.SugaredLogger) ([]tailcfg.CapabilityVersion, error) {
+	secrets := &corev1.SecretList{}
+	if err := cl.List(ctx, secrets, client.InNamespace(tsNamespace), client.MatchingLabels(pgSecretLabels(pgName, kubetypes.LabelSecretTypeState))); err != nil {
+		return nil, fmt.Errorf("failed to list ProxyGroup %q state Secrets: %w", pgName, err)
+	}
 
-	return count, nil
+	var capvers []tailcfg.CapabilityVersion
+	for _, secret := range secrets.Items {
+		// A replica's Pod has the same name as its state Secret.
+		podUID := ""
+		pod := &corev1.Pod{}
+		if err := cl.Get(ctx, client.ObjectKey{Namespace: tsNamespace, Name: secret.Name}, pod); err == nil {
+			podUID = string(pod.UID)
+		} else if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get Pod %q: %w", secret.Name, err)
+		}
+		capvers = append(capvers, proxyCapVer(&secret, podUID, logger))
+	}
+	return capvers, nil
+}
+
+// maybeReportAdvertisingReplicas emits a Normal Event for the Ingress if the
+// ProxyGroup replicas advertising its Tailscale Service differ from those last
+// reported for it. An Ingress not yet reported on is treated as advertised by
+// no replicas, so that newly exposed Ingresses don't get an Event before any
+// replica has picked up the Tailscale Service.
+func (r *HAIngressReconciler) maybeReportAdvertisingReplicas(ing *networkingv1.Ingress, pgName string, serviceName tailcfg.ServiceName, replicas []int, logger *zap.SugaredLogger) {
+	var indices []string
+	for _, i := range replicas {
+		indices = append(indices, strconv.Itoa(i))
+	}
+	want := strings.Join(indices, ",")
+
+	r.mu.Lock()
+	if r.advertisingReplicas[ing.UID] == want {
+		r.mu.Unlock()
+		return
+	}
+	mak.Set(&r.advertisingReplicas, ing.UID, want)
+	r.mu.Unlock()
+
+	logger.Debugf("advertising replicas changed to %q", want)
+	if want == "" {
+		r.recorder.Eventf(ing, corev1.EventTypeNormal, reasonAdvertisingReplicasChanged, "Tailscale Service %s is not advertised by any replicas of ProxyGroup %s", serviceName, pgName)
+		return
+	}
+	r.recorder.Eventf(ing, corev1.EventTypeNormal, reasonAdvertisingReplicasChanged, "Tailscale Service %s is advertised by replicas %s of ProxyGroup %s", serviceName, want, pgName)
 }
 
 const ownerAnnotation = "tailscale.com/owner-references"
@@ -848,6 +2325,15 @@ type OwnerRef struct {
 	// OperatorID is the stable ID of the operator's Tailscale device.
 	OperatorID string    `json:"operatorID,omitempty"`
 	Resource   *Resource `json:"resource,omitempty"` // optional, used to identify the ProxyGroup that owns this Tailscale Service.
+	// Tags are the ACL tags that this operator instance wants set on the
+	// Tailscale Service. The Tailscale Service is tagged with the union of
+	// the tags of all its owners, so that operators with different default
+	// tags converge on the same set rather than overwriting each other's.
+	Tags []string `json:"tags,omitempty"`
+	// HTTP is whether this operator instance's Ingress requests an HTTP
+	// endpoint. The Tailscale Service is exposed over HTTP if any of its
+	// owners requests it.
+	HTTP bool `json:"http,omitempty"`
 }
 
 type Resource struct {
@@ -856,23 +2342,53 @@ type Resource struct {
 	UID  string `json:"uid,omitempty"`  // UID of the ProxyGroup that owns this Tailscale Service.
 }
 
+// tailscaleServiceComment returns the comment for the Tailscale Service of
+// obj, an HA Ingress or Service of the given kind. It starts with the
+// description from obj's tailscale.com/service-description annotation, if
+// any. Unless noProvenance is set, it ends with the provenance of the
+// Tailscale Service, so that tailnet admins can trace it back to obj without
+// access to the cluster.
+func tailscaleServiceComment(kind string, obj client.Object, clusterName string, noProvenance bool, prefix annotationPrefix) string {
+	var b strings.Builder
+	if desc := strings.TrimSpace(prefix.get(obj.GetAnnotations(), annotationServiceDescription)); desc != "" {
+		b.WriteString(desc)
+		b.WriteString("\n\n")
+	}
+	b.WriteString(managedTSServiceComment)
+	if !noProvenance {
+		fmt.Fprintf(&b, "\nSource: %s %s/%s", kind, obj.GetNamespace(), obj.GetName())
+		if clusterName != "" {
+			fmt.Fprintf(&b, " in cluster %s", clusterName)
+		}
+	}
+	return b.String()
+}
+
 // ownerAnnotations returns the updated annotations required to ensure this
-// instance of the operator is included as an owner. If the Tailscale Service is not
+// instance of the operator, identified by ref.OperatorID, is included as an
+// owner with the tags and HTTP setting of ref. If the Tailscale Service is not
 // nil, but does not contain an owner reference we return an error as this likely means
 // that the Service was created by somthing other than a Tailscale
 // Kubernetes operator.
-func ownerAnnotations(operatorID string, svc *tailscale.VIPService) (map[string]string, error) {
-	ref := OwnerRef{
-		OperatorID: operatorID,
-	}
+//
+// TODO: owner references are added and removed with a full GET and PUT of the
+// Tailscale Service. A targeted patch of just the owner annotation would be
+// cheaper for Tailscale Services with many owners, but the Tailscale Services
+// API currently only supports whole-object PUTs (which must also carry any
+// auto-allocated addresses) and has no conditional update to detect
+// concurrent writers.
+//
+// If budget is positive and the owner annotation would be larger than budget
+// bytes, the owner references are compacted, and an error is returned if they
+// still do not fit, rather than making an update that the API would reject.
+func ownerAnnotations(ref OwnerRef, svc *tailscale.VIPService, budget int, logger *zap.SugaredLogger) (map[string]string, error) {
 	if svc == nil {
-		c := ownerAnnotationValue{OwnerRefs: []OwnerRef{ref}}
-		json, err := json.Marshal(c)
+		json, err := fitOwnerAnnotation(&ownerAnnotationValue{OwnerRefs: []OwnerRef{ref}}, budget, logger)
 		if err != nil {
-			return nil, fmt.Errorf("[unexpected] unable to marshal Tailscale Service's owner annotation contents: %w, please report this", err)
+			return nil, err
 		}
 		return map[string]string{
-			ownerAnnotation: string(json),
+			ownerAnnotation: json,
 		}, nil
 	}
 	o, err := parseOwnerAnnotation(svc)
@@ -882,26 +2398,148 @@ func ownerAnnotations(operatorID string, svc *tailscale.VIPService) (map[string]
 	if o == nil || len(o.OwnerRefs) == 0 {
 		return nil, fmt.Errorf("Tailscale Service %s exists, but does not contain owner annotation with owner references; not proceeding as this is likely a resource created by something other than the Tailscale Kubernetes operator", svc.Name)
 	}
-	if slices.Contains(o.OwnerRefs, ref) { // up to date
+	ix := slices.IndexFunc(o.OwnerRefs, func(or OwnerRef) bool {
+		return or.OperatorID == ref.OperatorID && or.Resource == nil
+	})
+	switch {
+	case ix != -1 && slices.Equal(o.OwnerRefs[ix].Tags, ref.Tags) && o.OwnerRefs[ix].HTTP == ref.HTTP: // up to date
 		return svc.Annotations, nil
-	}
-	if o.OwnerRefs[0].Resource != nil {
+	case ix != -1:
+		o.OwnerRefs[ix].Tags = ref.Tags
+		o.OwnerRefs[ix].HTTP = ref.HTTP
+	case o.OwnerRefs[0].Resource != nil:
 		return nil, fmt.Errorf("Tailscale Service %s is owned by another resource: %#v; cannot be reused for an Ingress", svc.Name, o.OwnerRefs[0].Resource)
+	default:
+		o.OwnerRefs = append(o.OwnerRefs, ref)
 	}
-	o.OwnerRefs = append(o.OwnerRefs, ref)
-	json, err := json.Marshal(o)
+	json, err := fitOwnerAnnotation(o, budget, logger)
 	if err != nil {
-		return nil, fmt.Errorf("error marshalling updated owner references: %w", err)
+		return nil, err
 	}
 
 	newAnnots := make(map[string]string, len(svc.Annotations)+1)
 	for k, v := range svc.Annotations {
 		newAnnots[k] = v
 	}
-	newAnnots[ownerAnnotation] = string(json)
+	newAnnots[ownerAnnotation] = json
 	return newAnnots, nil
 }
 
+// migrateOwnerRefs returns svc, or if it has owner references of the
+// operator instance previously identified by previousID, a copy of svc with
+// those rewritten to operatorID. This lets an operator whose Tailscale device
+// was re-created, and so has a new stable ID, keep managing the Tailscale
+// Services it created, rather than treating them as owned by another
+// operator instance.
+func migrateOwnerRefs(svc *tailscale.VIPService, previousID, operatorID string, logger *zap.SugaredLogger) (*tailscale.VIPService, error) {
+	if svc == nil || previousID == "" || previousID == operatorID {
+		return svc, nil
+	}
+	o, err := parseOwnerAnnotation(svc)
+	if err != nil || o == nil {
+		return svc, err
+	}
+	var changed bool
+	if o.OwnerRefs, changed = migrateOwnerRefList(o.OwnerRefs, previousID, operatorID); !changed {
+		return svc, nil
+	}
+	b, err := json.Marshal(o)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling owner references: %w", err)
+	}
+	logger.Infof("migrating owner references of Tailscale Service %q from previous operator ID %q to %q", svc.Name, previousID, operatorID)
+	migrated := *svc
+	migrated.Annotations = maps.Clone(svc.Annotations)
+	migrated.Annotations[ownerAnnotation] = string(b)
+	return &migrated, nil
+}
+
+// migrateOwnerRefList rewrites the operator ID of the owner references in
+// refs that have previousID to operatorID. An owner reference under
+// previousID is dropped instead if refs already contains one under operatorID,
+// so that the operator is not listed as an owner twice. It reports whether
+// refs was changed.
+func migrateOwnerRefList(refs []OwnerRef, previousID, operatorID string) ([]OwnerRef, bool) {
+	if previousID == "" || previousID == operatorID {
+		return refs, false
+	}
+	isOwn := func(id string) func(OwnerRef) bool {
+		return func(or OwnerRef) bool { return or.OperatorID == id && or.Resource == nil }
+	}
+	if !slices.ContainsFunc(refs, isOwn(previousID)) {
+		return refs, false
+	}
+	refs = slices.Clone(refs)
+	if !slices.ContainsFunc(refs, isOwn(operatorID)) {
+		ix := slices.IndexFunc(refs, isOwn(previousID))
+		refs[ix].OperatorID = operatorID
+	}
+	return slices.DeleteFunc(refs, isOwn(previousID)), true
+}
+
+// fitOwnerAnnotation returns o marshalled as the value of the owner
+// annotation. If budget is positive and the value is larger than budget
+// bytes, the owner references are compacted first, and an error is returned
+// if the value is still too large.
+func fitOwnerAnnotation(o *ownerAnnotationValue, budget int, logger *zap.SugaredLogger) (string, error) {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling owner references: %w", err)
+	}
+	if budget <= 0 || len(b) <= budget {
+		return string(b), nil
+	}
+	size := len(b)
+	o.OwnerRefs = compactOwnerRefs(o.OwnerRefs)
+	if b, err = json.Marshal(o); err != nil {
+		return "", fmt.Errorf("error marshalling owner references: %w", err)
+	}
+	logger.Warnf("owner annotation of %d bytes exceeds the budget of %d bytes, compacted it to %d bytes", size, budget, len(b))
+	if len(b) > budget {
+		return "", fmt.Errorf("owner annotation with %d owner references would be %d bytes, exceeding the budget of %d bytes; remove the owner references of operators that no longer exist or increase OPERATOR_OWNER_ANNOTATION_BUDGET", len(o.OwnerRefs), len(b), budget)
+	}
+	return string(b), nil
+}
+
+// compactOwnerRefs merges the owner references of the same operator instance
+// and removes duplicate tags and references without an operator ID, to reduce
+// the size of the owner annotation. An operator only writes one owner
+// reference for itself, but concurrent writers can leave more behind.
+func compactOwnerRefs(refs []OwnerRef) []OwnerRef {
+	var out []OwnerRef
+	operatorRef := make(map[string]int) // operator ID to index in out
+	for _, r := range refs {
+		if r.Resource != nil {
+			out = append(out, r)
+			continue
+		}
+		if r.OperatorID == "" {
+			continue
+		}
+		if i, ok := operatorRef[r.OperatorID]; ok {
+			out[i].Tags = append(out[i].Tags, r.Tags...)
+			out[i].HTTP = out[i].HTTP || r.HTTP
+			continue
+		}
+		operatorRef[r.OperatorID] = len(out)
+		r.Tags = slices.Clone(r.Tags)
+		out = append(out, r)
+	}
+	for i := range out {
+		// Remove duplicate tags, keeping the order of the remaining ones so
+		// that the operator's own reference stays up to date.
+		seen := make(set.Set[string])
+		out[i].Tags = slices.DeleteFunc(out[i].Tags, func(tag string) bool {
+			if seen.Contains(tag) {
+				return true
+			}
+			seen.Add(tag)
+			return false
+		})
+	}
+	return out
+}
+
 // parseOwnerAnnotation returns nil if no valid owner found.
 func parseOwnerAnnotation(tsSvc *tailscale.VIPService) (*ownerAnnotationValue, error) {
 	if tsSvc.Annotations == nil || tsSvc.Annotations[ownerAnnotation] == "" {
@@ -914,6 +2552,26 @@ func parseOwnerAnnotation(tsSvc *tailscale.VIPService) (*ownerAnnotationValue, e
 	return o, nil
 }
 
+// ownerTags returns the sorted union of the tags of all owners in o. Owner
+// references written by operator versions that did not record tags contribute
+// none.
+func ownerTags(o *ownerAnnotationValue) []string {
+	if o == nil {
+		return nil
+	}
+	var tags []string
+	for _, or := range o.OwnerRefs {
+		tags = append(tags, or.Tags...)
+	}
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
+
+// ownersWantHTTP reports whether any owner in o requests an HTTP endpoint.
+func ownersWantHTTP(o *ownerAnnotationValue) bool {
+	return o != nil && slices.ContainsFunc(o.OwnerRefs, func(or OwnerRef) bool { return or.HTTP })
+}
+
 func ownersAreSetAndEqual(a, b *tailscale.VIPService) bool {
 	return a != nil && b != nil &&
 		a.Annotations != nil && b.Annotations != nil &&
@@ -924,25 +2582,67 @@ func ownersAreSetAndEqual(a, b *tailscale.VIPService) bool {
 
 // ensureCertResources ensures that the TLS Secret for an HA Ingress and RBAC
 // resources that allow proxies to manage the Secret are created.
+// If the Ingress has a tailscale.com/tls-secret annotation, the cert from the
+// referenced Secret is copied to the TLS Secret and proxies are only allowed to
+// read it, so that they do not request a cert of their own.
+// If r.consolidateCertSecrets is set, the cert is stored in the ProxyGroup's
+// consolidated cert Secret instead, unless it is externally managed. A cert
+// that was stored in the other kind of Secret before is moved, so that
+// switching between the two does not make the proxies issue new certs.
 // Note that Tailscale Service's name validation matches Kubernetes
 // resource name validation, so we can be certain that the Tailscale Service name
 // (domain) is a valid Kubernetes resource name.
 // https://github.com/tailscale/tailscale/blob/8b1e7f646ee4730ad06c9b70c13e7861b964949b/util/dnsname/dnsname.go#L99
 // https://kubernetes.io/docs/concepts/overview/working-with-objects/names/#dns-subdomain-names
-func (r *HAIngressReconciler) ensureCertResources(ctx context.Context, pg *tsapi.ProxyGroup, domain string, ing *networkingv1.Ingress) error {
+func (r *HAIngressReconciler) ensureCertResources(ctx context.Context, pg *tsapi.ProxyGroup, domain string, ing *networkingv1.Ingress, logger *zap.SugaredLogger) error {
 	secret := certSecret(pg.Name, r.tsNamespace, domain, ing)
-	if _, err := createOrUpdate(ctx, r.Client, r.tsNamespace, secret, func(s *corev1.Secret) {
-		// Labels might have changed if the Ingress has been updated to use a
-		// different ProxyGroup.
-		s.Labels = secret.Labels
-	}); err != nil {
-		return fmt.Errorf("failed to create or update Secret %s: %w", secret.Name, err)
-	}
 	role := certSecretRole(pg.Name, r.tsNamespace, domain)
+	extName, external := r.annotationPrefix.lookup(ing.Annotations, annotationTLSSecret)
+	if external {
+		ext := &corev1.Secret{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: r.tsNamespace, Name: extName}, ext); err != nil {
+			return fmt.Errorf("failed to get TLS Secret %s: %w", extName, err)
+		}
+		secret.Data[corev1.TLSCertKey] = ext.Data[corev1.TLSCertKey]
+		secret.Data[corev1.TLSPrivateKeyKey] = ext.Data[corev1.TLSPrivateKeyKey]
+		role.Rules[0].Verbs = []string{"get", "list"}
+	}
+	if r.consolidateCertSecrets && !external {
+		name := consolidatedCertSecretName(pg.Name)
+		role.Rules[0].ResourceNames = []string{name}
+		if err := r.ensureConsolidatedCert(ctx, pg.Name, domain, logger); err != nil {
+			return fmt.Errorf("failed to create or update Secret %s: %w", name, err)
+		}
+	} else {
+		var moved bool
+		if !external {
+			cert, key, err := consolidatedCert(ctx, r.Client, r.tsNamespace, pg.Name, domain)
+			if err != nil {
+				return err
+			}
+			if len(cert) > 0 && len(key) > 0 {
+				secret.Data[corev1.TLSCertKey] = cert
+				secret.Data[corev1.TLSPrivateKeyKey] = key
+				moved = true
+			}
+		}
+		if err := ensureCertSecret(ctx, r.Client, secret, func(s *corev1.Secret) {
+			if external || moved && !hasTLSData(s.Data, corev1.TLSCertKey, corev1.TLSPrivateKeyKey) {
+				s.Data = secret.Data
+			}
+		}, logger); err != nil {
+			return fmt.Errorf("failed to create or update Secret %s: %w", secret.Name, err)
+		}
+		if err := removeConsolidatedCert(ctx, r.Client, r.tsNamespace, pg.Name, domain); err != nil {
+			return err
+		}
+	}
 	if _, err := createOrUpdate(ctx, r.Client, r.tsNamespace, role, func(r *rbacv1.Role) {
 		// Labels might have changed if the Ingress has been updated to use a
-		// different ProxyGroup.
+		// different ProxyGroup. Rules change if the Ingress has started or
+		// stopped using an externally managed cert.
 		r.Labels = role.Labels
+		r.Rules = role.Rules
 	}); err != nil {
 		return fmt.Errorf("failed to create or update Role %s: %w", role.Name, err)
 	}
@@ -958,6 +2658,45 @@ func (r *HAIngressReconciler) ensureCertResources(ctx context.Context, pg *tsapi
 	return nil
 }
 
+// ensureCertSecret creates secret, the TLS Secret for a domain or the
+// consolidated cert Secret of a ProxyGroup, or updates the existing one with
+// update, which may be nil.
+//
+// The labels of an existing Secret are reset to those of secret. They might
+// have changed if the parent has been updated to use a different ProxyGroup,
+// or have been edited, and the Secret watches rely on them to map events on
+// the Secret back to its parent. An existing Secret that is not labelled as
+// managed by the operator is left as is.
+//
+// The Secret is only updated if that changes it, so that reconciles that do
+// not affect the cert, such as toggling the HTTP endpoint, do not churn it.
+func ensureCertSecret(ctx context.Context, cl client.Client, secret *corev1.Secret, update func(*corev1.Secret), logger *zap.SugaredLogger) error {
+	existing := &corev1.Secret{}
+	err := cl.Get(ctx, client.ObjectKeyFromObject(secret), existing)
+	if apierrors.IsNotFound(err) {
+		return cl.Create(ctx, secret)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get object: %w", err)
+	}
+	if existing.Labels[kubetypes.LabelManaged] != "true" {
+		logger.Infof("TLS Secret %s/%s is not managed by the operator, not updating it", existing.Namespace, existing.Name)
+		return nil
+	}
+	orig := existing.DeepCopy()
+	if !maps.Equal(existing.Labels, secret.Labels) {
+		logger.Debugf("updating labels of TLS Secret %s/%s from %v to %v", existing.Namespace, existing.Name, existing.Labels, secret.Labels)
+		existing.Labels = secret.Labels
+	}
+	if update != nil {
+		update(existing)
+	}
+	if apiequality.Semantic.DeepEqual(orig, existing) {
+		return nil
+	}
+	return cl.Update(ctx, existing)
+}
+
 // cleanupCertResources ensures that the TLS Secret and associated RBAC
 // resources that allow proxies to read/write to the Secret are deleted.
 func cleanupCertResources(ctx context.Context, cl client.Client, lc localClient, tsNamespace, pgName string, serviceName tailcfg.ServiceName) error {
@@ -965,16 +2704,28 @@ func cleanupCertResources(ctx context.Context, cl client.Client, lc localClient,
 	if err != nil {
 		return fmt.Errorf("error getting DNS name for Tailscale Service %s: %w", serviceName, err)
 	}
+	return cleanupCertResourcesForDomain(ctx, cl, tsNamespace, pgName, domainName)
+}
+
+// cleanupCertResourcesForDomain deletes the TLS Secret and associated RBAC
+// resources for the provided domain name, and removes its cert from the
+// ProxyGroup's consolidated cert Secret. The cert is removed before the RBAC
+// that grants ProxyGroup replicas access to it, so that no replica is denied
+// access to a cert Secret that still exists.
+func cleanupCertResourcesForDomain(ctx context.Context, cl client.Client, tsNamespace, pgName, domainName string) error {
 	labels := certResourceLabels(pgName, domainName)
+	if err := cl.DeleteAllOf(ctx, &corev1.Secret{}, client.InNamespace(tsNamespace), client.MatchingLabels(labels)); err != nil {
+		return fmt.Errorf("error deleting Secret for domain name %s: %w", domainName, err)
+	}
+	if err := removeConsolidatedCert(ctx, cl, tsNamespace, pgName, domainName); err != nil {
+		return err
+	}
 	if err := cl.DeleteAllOf(ctx, &rbacv1.RoleBinding{}, client.InNamespace(tsNamespace), client.MatchingLabels(labels)); err != nil {
 		return fmt.Errorf("error deleting RoleBinding for domain name %s: %w", domainName, err)
 	}
 	if err := cl.DeleteAllOf(ctx, &rbacv1.Role{}, client.InNamespace(tsNamespace), client.MatchingLabels(labels)); err != nil {
 		return fmt.Errorf("error deleting Role for domain name %s: %w", domainName, err)
 	}
-	if err := cl.DeleteAllOf(ctx, &corev1.Secret{}, client.InNamespace(tsNamespace), client.MatchingLabels(labels)); err != nil {
-		return fmt.Errorf("error deleting Secret for domain name %s: %w", domainName, err)
-	}
 	return nil
 }
 
@@ -987,6 +2738,186 @@ func requeueInterval() time.Duration {
 	return time.Duration(rand.N(5)+5) * time.Minute
 }
 
+// warnIfCertExpiring emits a warning Event for ing if the TLS cert for domain
+// expires within r.certExpiryWarning. ProxyGroup Pods renew certs well before
+// they expire, so a cert this close to expiry means that renewal is failing.
+// It does nothing if the cert has not been issued yet or cannot be parsed.
+// If the cert is stored in the ProxyGroup's consolidated cert Secret, the
+// result of the check by the Secret's renewal owner is used.
+func (r *HAIngressReconciler) warnIfCertExpiring(ctx context.Context, pgName, domain string, ing *networkingv1.Ingress, logger *zap.SugaredLogger) error {
+	if r.certExpiryWarning <= 0 {
+		return nil
+	}
+	warn := func(notAfter time.Time) {
+		msg := fmt.Sprintf("TLS certificate for %s expires at %s and has not been renewed; check the logs of ProxyGroup %s Pods for cert renewal errors", domain, notAfter.UTC().Format(time.RFC3339), pgName)
+		logger.Warn(msg)
+		r.recorder.Event(ing, corev1.EventTypeWarning, "CertificateExpiringSoon", msg)
+	}
+	secrets := &corev1.SecretList{}
+	if err := r.List(ctx, secrets, client.InNamespace(r.tsNamespace), client.MatchingLabels(certResourceLabels(pgName, domain))); err != nil {
+		return fmt.Errorf("failed to list TLS Secrets: %w", err)
+	}
+	var certs [][]byte
+	for _, secret := range secrets.Items {
+		if isTLSSecret(&secret) {
+			certs = append(certs, secret.Data[corev1.TLSCertKey])
+		}
+	}
+	if _, external := r.annotationPrefix.lookup(ing.Annotations, annotationTLSSecret); r.consolidateCertSecrets && !external {
+		expiring, err := r.consolidatedCertsExpiring(ctx, pgName, ing, logger)
+		if err != nil {
+			return err
+		}
+		if notAfter, ok := expiring[domain]; ok {
+			warn(notAfter)
+		}
+	} else {
+		cert, _, err := consolidatedCert(ctx, r.Client, r.tsNamespace, pgName, domain)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, cert)
+	}
+	for _, cert := range certs {
+		if len(cert) == 0 {
+			continue
+		}
+		notAfter, err := certNotAfter(cert)
+		if err != nil {
+			logger.Debugf("unable to determine expiry of TLS cert for %s: %v", domain, err)
+			continue
+		}
+		if notAfter.Sub(r.clock.Now()) < r.certExpiryWarning {
+			warn(notAfter)
+		}
+	}
+	return nil
+}
+
+// consolidatedCertsExpiring returns the expiry times of the certs in the
+// consolidated cert Secret of ProxyGroup pgName that expire within
+// r.certExpiryWarning, by domain. The Secret is shared by the ProxyGroup's
+// Ingresses, so the certs are only checked if ing is the Secret's renewal
+// owner, which records the result on the Secret. Otherwise the result last
+// recorded by the renewal owner is returned. The elected renewal owner is
+// recorded on the Secret too.
+func (r *HAIngressReconciler) consolidatedCertsExpiring(ctx context.Context, pgName string, ing *networkingv1.Ingress, logger *zap.SugaredLogger) (map[string]time.Time, error) {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: r.tsNamespace, Name: consolidatedCertSecretName(pgName)}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get consolidated TLS Secret: %w", err)
+	}
+	if secret.Labels[kubetypes.LabelManaged] != "true" {
+		return nil, nil
+	}
+	owner, err := r.certRenewalOwner(ctx, pgName)
+	if err != nil {
+		return nil, err
+	}
+	orig := secret.DeepCopy()
+	if owner == nil {
+		delete(secret.Annotations, annotationCertRenewalOwner)
+	} else {
+		mak.Set(&secret.Annotations, annotationCertRenewalOwner, client.ObjectKeyFromObject(owner).String())
+	}
+	if owner != nil && owner.UID == ing.UID {
+		expiring := make(map[string]time.Time)
+		for k, cert := range secret.Data {
+			domain, ok := strings.CutSuffix(k, ".crt")
+			if !ok || len(cert) == 0 {
+				continue
+			}
+			notAfter, err := certNotAfter(cert)
+			if err != nil {
+				logger.Debugf("unable to determine expiry of TLS cert for %s: %v", domain, err)
+				continue
+			}
+			if notAfter.Sub(r.clock.Now()) < r.certExpiryWarning {
+				expiring[domain] = notAfter
+			}
+		}
+		if len(expiring) == 0 {
+			delete(secret.Annotations, annotationCertsExpiring)
+		} else {
+			b, err := json.Marshal(expiring)
+			if err != nil {
+				return nil, fmt.Errorf("error marshalling expiring certs: %w", err)
+			}
+			secret.Annotations[annotationCertsExpiring] = string(b)
+		}
+	}
+	if !apiequality.Semantic.DeepEqual(orig, secret) {
+		logger.Debugf("updating cert renewal owner %q and expiring certs %q of Secret %s", secret.Annotations[annotationCertRenewalOwner], secret.Annotations[annotationCertsExpiring], secret.Name)
+		if err := r.Update(ctx, secret); err != nil {
+			return nil, fmt.Errorf("failed to update cert renewal annotations of Secret %s: %w", secret.Name, err)
+		}
+	}
+	v := secret.Annotations[annotationCertsExpiring]
+	if v == "" {
+		return nil, nil
+	}
+	var expiring map[string]time.Time
+	if err := json.Unmarshal([]byte(v), &expiring); err != nil {
+		return nil, fmt.Errorf("Secret %s has invalid %s annotation %q: %w", secret.Name, annotationCertsExpiring, v, err)
+	}
+	return expiring, nil
+}
+
+// certRenewalOwner returns the renewal owner of the consolidated cert Secret
+// of ProxyGroup pgName: of the Ingresses that store their cert in it, the one
+// with the lowest UID of those that set the tailscale.com/cert-renewal-owner
+// annotation to "true", or else of all of them. The election only depends on
+// the Ingresses, so that all reconciles agree on it. It returns nil if there
+// is no such Ingress.
+func (r *HAIngressReconciler) certRenewalOwner(ctx context.Context, pgName string) (*networkingv1.Ingress, error) {
+	ingList := &networkingv1.IngressList{}
+	if err := r.List(ctx, ingList); err != nil {
+		return nil, fmt.Errorf("failed to list Ingresses: %w", err)
+	}
+	var owner *networkingv1.Ingress
+	var ownerOverride bool
+	for i := range ingList.Items {
+		ing := &ingList.Items[i]
+		if !r.shouldExpose(ing) || r.annotationPrefix.get(ing.Annotations, AnnotationProxyGroup) != pgName || ing.DeletionTimestamp != nil {
+			continue
+		}
+		if _, external := r.annotationPrefix.lookup(ing.Annotations, annotationTLSSecret); external {
+			continue
+		}
+		override := r.annotationPrefix.get(ing.Annotations, annotationCertRenewalOwner) == "true"
+		switch {
+		case owner == nil, override && !ownerOverride:
+		case override == ownerOverride && ing.UID < owner.UID:
+		default:
+			continue
+		}
+		owner, ownerOverride = ing, override
+	}
+	return owner, nil
+}
+
+// certNotAfter returns the expiry time of the first certificate in the
+// PEM-encoded certPEM.
+func certNotAfter(certPEM []byte) (time.Time, error) {
+	for {
+		var block *pem.Block
+		block, certPEM = pem.Decode(certPEM)
+		if block == nil {
+			return time.Time{}, errors.New("no certificate found")
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return cert.NotAfter, nil
+	}
+}
+
 // certSecretRole creates a Role that will allow proxies to manage the TLS
 // Secret for the given domain. Domain must be a valid Kubernetes resource name.
 func certSecretRole(pgName, namespace, domain string) *rbacv1.Role {
@@ -1067,6 +2998,136 @@ func certSecret(pgName, namespace, domain string, parent client.Object) *corev1.
 	}
 }
 
+// consolidatedCertSecretName returns the name of the Secret that stores the
+// TLS certs of all domains of ProxyGroup pgName if cert Secrets are
+// consolidated.
+func consolidatedCertSecretName(pgName string) string {
+	return pgName + kubetypes.ProxyGroupCertsSecretSuffix
+}
+
+// consolidatedCertKeys returns the keys of the TLS cert and private key for
+// domain in a consolidated cert Secret. They are the same as in a proxy's
+// state Secret.
+func consolidatedCertKeys(domain string) (certKey, keyKey string) {
+	return domain + ".crt", domain + ".key"
+}
+
+// consolidatedCertSecret creates a Secret that will store the TLS
+// certificates and private keys of all domains of a ProxyGroup, keyed by
+// domain. It is used instead of a Secret per domain, as created by
+// certSecret, if cert Secrets are consolidated.
+func consolidatedCertSecret(pgName, namespace string) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      consolidatedCertSecretName(pgName),
+			Namespace: namespace,
+			Labels: map[string]string{
+				kubetypes.LabelManaged:    "true",
+				kubetypes.LabelSecretType: kubetypes.LabelSecretTypeCerts,
+				labelProxyGroup:           pgName,
+			},
+		},
+		Data: map[string][]byte{},
+		Type: corev1.SecretTypeOpaque,
+	}
+}
+
+// ensureConsolidatedCert ensures that the consolidated cert Secret of
+// ProxyGroup pgName has entries for domain, for the proxies to store its cert
+// in. A cert in a per-domain Secret for domain, as created while cert Secrets
+// were not consolidated, is moved to the consolidated Secret.
+func (r *HAIngressReconciler) ensureConsolidatedCert(ctx context.Context, pgName, domain string, logger *zap.SugaredLogger) error {
+	certKey, keyKey := consolidatedCertKeys(domain)
+	secret := consolidatedCertSecret(pgName, r.tsNamespace)
+	secret.Data[certKey], secret.Data[keyKey] = nil, nil
+
+	perDomain := &corev1.Secret{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: r.tsNamespace, Name: domain}, perDomain)
+	switch {
+	case apierrors.IsNotFound(err):
+		perDomain = nil
+	case err != nil:
+		return fmt.Errorf("failed to get TLS Secret %s: %w", domain, err)
+	case perDomain.Labels[kubetypes.LabelManaged] != "true":
+		perDomain = nil
+	default:
+		secret.Data[certKey] = perDomain.Data[corev1.TLSCertKey]
+		secret.Data[keyKey] = perDomain.Data[corev1.TLSPrivateKeyKey]
+	}
+
+	if err := ensureCertSecret(ctx, r.Client, secret, func(s *corev1.Secret) {
+		if !hasTLSData(s.Data, certKey, keyKey) {
+			mak.Set(&s.Data, certKey, secret.Data[certKey])
+			mak.Set(&s.Data, keyKey, secret.Data[keyKey])
+		}
+	}, logger); err != nil {
+		return err
+	}
+	if perDomain != nil {
+		logger.Infof("moving TLS cert for %s to Secret %s", domain, secret.Name)
+		if err := r.Delete(ctx, perDomain); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete TLS Secret %s: %w", domain, err)
+		}
+	}
+	return nil
+}
+
+// consolidatedCert returns the TLS cert and private key for domain from the
+// consolidated cert Secret of ProxyGroup pgName. They are nil if there is no
+// such Secret or it has no cert for domain.
+func consolidatedCert(ctx context.Context, cl client.Client, namespace, pgName, domain string) (cert, key []byte, err error) {
+	secret := &corev1.Secret{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: consolidatedCertSecretName(pgName)}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to get consolidated TLS Secret: %w", err)
+	}
+	certKey, keyKey := consolidatedCertKeys(domain)
+	return secret.Data[certKey], secret.Data[keyKey], nil
+}
+
+// removeConsolidatedCert removes the entries for domain from the consolidated
+// cert Secret of ProxyGroup pgName, if any, and deletes the Secret once it
+// has none left.
+func removeConsolidatedCert(ctx context.Context, cl client.Client, namespace, pgName, domain string) error {
+	secret := &corev1.Secret{}
+	if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: consolidatedCertSecretName(pgName)}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get consolidated TLS Secret: %w", err)
+	}
+	certKey, keyKey := consolidatedCertKeys(domain)
+	_, hasCert := secret.Data[certKey]
+	_, hasKey := secret.Data[keyKey]
+	if secret.Labels[kubetypes.LabelManaged] != "true" || !hasCert && !hasKey {
+		return nil
+	}
+	delete(secret.Data, certKey)
+	delete(secret.Data, keyKey)
+	if len(secret.Data) == 0 {
+		if err := cl.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete consolidated TLS Secret %s: %w", secret.Name, err)
+		}
+		return nil
+	}
+	if err := cl.Update(ctx, secret); err != nil {
+		return fmt.Errorf("failed to remove TLS cert for %s from Secret %s: %w", domain, secret.Name, err)
+	}
+	return nil
+}
+
+// hasTLSData reports whether data has a non-empty TLS cert and key at certKey
+// and keyKey.
+func hasTLSData(data map[string][]byte, certKey, keyKey string) bool {
+	return len(data[certKey]) > 0 && len(data[keyKey]) > 0
+}
+
 func certResourceLabels(pgName, domain string) map[string]string {
 	return map[string]string{
 		kubetypes.LabelManaged: "true",
@@ -1085,8 +3146,10 @@ func dnsNameForService(ctx context.Context, lc localClient, svc tailcfg.ServiceN
 	return s + "." + tcd, nil
 }
 
-// hasCerts checks if the TLS Secret for the given service has non-zero cert and key data.
-func hasCerts(ctx context.Context, cl client.Client, lc localClient, ns string, svc tailcfg.ServiceName) (bool, error) {
+// hasCerts checks if the TLS Secret for the given service, or else the
+// consolidated cert Secret of ProxyGroup pgName, has non-zero cert and key
+// data for it.
+func hasCerts(ctx context.Context, cl client.Client, lc localClient, ns, pgName string, svc tailcfg.ServiceName) (bool, error) {
 	domain, err := dnsNameForService(ctx, lc, svc)
 	if err != nil {
 		return false, fmt.Errorf("failed to get DNS name for service: %w", err)
@@ -1098,15 +3161,13 @@ func hasCerts(ctx context.Context, cl client.Client, lc localClient, ns string,
 	}, secret)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
-			return false, nil
+			cert, key, err := consolidatedCert(ctx, cl, ns, pgName, domain)
+			return len(cert) > 0 && len(key) > 0, err
 		}
 		return false, fmt.Errorf("failed to get TLS Secret: %w", err)
 	}
 
-	cert := secret.Data[corev1.TLSCertKey]
-	key := secret.Data[corev1.TLSPrivateKeyKey]
-
-	return len(cert) > 0 && len(key) > 0, nil
+	return hasTLSData(secret.Data, corev1.TLSCertKey, corev1.TLSPrivateKeyKey), nil
 }
 
 func isErrorTailscaleServiceNotFound(err error) bool {
@@ -1115,12 +3176,12 @@ func isErrorTailscaleServiceNotFound(err error) bool {
 	return ok && errResp.Status == http.StatusNotFound
 }
 
-func tagViolations(obj client.Object) []string {
+func tagViolations(obj client.Object, prefix annotationPrefix) []string {
 	var violations []string
 	if obj == nil {
 		return nil
 	}
-	tags, ok := obj.GetAnnotations()[AnnotationTags]
+	tags, ok := prefix.lookup(obj.GetAnnotations(), AnnotationTags)
 	if !ok {
 		return nil
 	}
@@ -1133,3 +3194,23 @@ func tagViolations(obj client.Object) []string {
 	}
 	return violations
 }
+
+// tailscaleServiceTags returns the ACL tags that should be set on the Tailscale
+// Service for obj. Tags set via the tailscale.com/tags annotation take
+// precedence over defaultTags, which the operator configures separately for
+// HTTP-terminating (Ingress) and TCP passthrough (Service) backends.
+func tailscaleServiceTags(obj client.Object, defaultTags []string, prefix annotationPrefix) []string {
+	if tstr := prefix.get(obj.GetAnnotations(), AnnotationTags); tstr != "" {
+		return strings.Split(tstr, ",")
+	}
+	return defaultTags
+}
+
+// withManagedServiceTag returns tags with managedTag added, unless it is empty
+// or already present. It does not modify tags.
+func withManagedServiceTag(tags []string, managedTag string) []string {
+	if managedTag == "" || slices.Contains(tags, managedTag) {
+		return tags
+	}
+	return append(slices.Clip(tags), managedTag)
+}
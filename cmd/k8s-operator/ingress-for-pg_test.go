@@ -7,24 +7,39 @@
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"maps"
+	"math/big"
+	"net"
 	"reflect"
 	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"tailscale.com/internal/client/tailscale"
 	"tailscale.com/ipn"
@@ -33,7 +48,9 @@
 	tsapi "tailscale.com/k8s-operator/apis/v1alpha1"
 	"tailscale.com/kube/kubetypes"
 	"tailscale.com/tailcfg"
+	"tailscale.com/tstest"
 	"tailscale.com/types/ptr"
+	"tailscale.com/util/mak"
 )
 
 func TestIngressPGReconciler(t *testing.T) {
@@ -127,12 +144,17 @@ func TestIngressPGReconciler(t *testing.T) {
 				},
 			},
 			TLS: []networkingv1.IngressTLS{
-				{Hosts: []string{"my-other-svc.tailnetxyz.ts.net"}},
+				{Hosts: []string{"my-other-svc.ts.net"}},
 			},
 		},
 	}
 	mustCreate(t, fc, ing2)
 
+	// Someone sets another key of the serve config ConfigMap by hand.
+	mustUpdate(t, fc, "operator-ns", pgIngressCMName("test-pg"), func(cm *corev1.ConfigMap) {
+		mak.Set(&cm.Data, "manual", "edit")
+	})
+
 	// Verify second Ingress reconciliation
 	expectReconciled(t, ingPGR, "default", "my-other-ingress")
 	populateTLSSecret(t, fc, "test-pg", "my-other-svc.ts.net")
@@ -151,6 +173,15 @@ func TestIngressPGReconciler(t *testing.T) {
 
 	verifyTailscaledConfig(t, fc, "test-pg", []string{"svc:my-svc", "svc:my-other-svc"})
 
+	// Updating the serve config left the manually set key as is.
+	pgCM := &corev1.ConfigMap{}
+	if err := fc.Get(t.Context(), types.NamespacedName{Name: pgIngressCMName("test-pg"), Namespace: "operator-ns"}, pgCM); err != nil {
+		t.Fatalf("getting ConfigMap: %v", err)
+	}
+	if got := pgCM.Data["manual"]; got != "edit" {
+		t.Errorf("manually set ConfigMap key = %q, want %q", got, "edit")
+	}
+
 	// Delete second Ingress
 	if err := fc.Delete(t.Context(), ing2); err != nil {
 		t.Fatalf("deleting second Ingress: %v", err)
@@ -223,6 +254,11 @@ func TestIngressPGReconciler(t *testing.T) {
 	if len(cfg.Services) > 0 {
 		t.Error("serve config not cleaned up")
 	}
+	// Once its last Tailscale Service is removed, the serve config must
+	// not retain empty Services, TCP or Web objects.
+	if got := string(cm.BinaryData[serveConfigKey]); got != "{}" {
+		t.Errorf("serve config after cleanup = %s, want {}", got)
+	}
 	verifyTailscaledConfig(t, fc, "test-pg-second", nil)
 
 	// Add verification that cert resources were cleaned up
@@ -252,7 +288,7 @@ func TestIngressPGReconciler(t *testing.T) {
 				},
 			},
 			TLS: []networkingv1.IngressTLS{
-				{Hosts: []string{"my-other-svc.tailnetxyz.ts.net"}},
+				{Hosts: []string{"my-other-svc.ts.net"}},
 			},
 		},
 	}
@@ -330,186 +366,141 @@ func TestIngressPGReconciler_UpdateIngressHostname(t *testing.T) {
 	}
 }
 
-func TestValidateIngress(t *testing.T) {
-	baseIngress := &networkingv1.Ingress{
+func TestIngressPGReconciler_ProtocolDefaultTags(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
+	// Operator configured with PROXY_HTTP_TAGS=tag:k8s-http.
+	ingPGR.defaultTags = []string{"tag:k8s-http"}
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "test-ingress",
 			Namespace: "default",
 			UID:       types.UID("1234-UID"),
 			Annotations: map[string]string{
-				AnnotationProxyGroup: "test-pg",
+				"tailscale.com/proxy-group": "test-pg",
 			},
 		},
 		Spec: networkingv1.IngressSpec{
 			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
 			TLS: []networkingv1.IngressTLS{
-				{Hosts: []string{"test"}},
+				{Hosts: []string{"my-svc"}},
 			},
 		},
 	}
+	mustCreate(t, fc, ing)
 
-	readyProxyGroup := &tsapi.ProxyGroup{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:       "test-pg",
-			Generation: 1,
-		},
-		Spec: tsapi.ProxyGroupSpec{
-			Type: tsapi.ProxyGroupTypeIngress,
-		},
-		Status: tsapi.ProxyGroupStatus{
-			Conditions: []metav1.Condition{
-				{
-					Type:               string(tsapi.ProxyGroupAvailable),
-					Status:             metav1.ConditionTrue,
-					ObservedGeneration: 1,
-				},
-			},
-		},
+	verifyTags := func(t *testing.T, wantTags []string) {
+		t.Helper()
+		tsSvc, err := ft.GetVIPService(t.Context(), "svc:my-svc")
+		if err != nil {
+			t.Fatalf("getting Tailscale Service: %v", err)
+		}
+		if !slices.Equal(tsSvc.Tags, wantTags) {
+			t.Errorf("incorrect Tailscale Service tags: got %v, want %v", tsSvc.Tags, wantTags)
+		}
 	}
 
-	tests := []struct {
-		name         string
-		ing          *networkingv1.Ingress
-		pg           *tsapi.ProxyGroup
-		existingIngs []networkingv1.Ingress
-		wantErr      string
-	}{
-		{
-			name: "valid_ingress_with_hostname",
-			ing: &networkingv1.Ingress{
-				ObjectMeta: baseIngress.ObjectMeta,
-				Spec: networkingv1.IngressSpec{
-					TLS: []networkingv1.IngressTLS{
-						{Hosts: []string{"test.example.com"}},
-					},
-				},
-			},
-			pg: readyProxyGroup,
-		},
-		{
-			name: "valid_ingress_with_default_hostname",
-			ing:  baseIngress,
-			pg:   readyProxyGroup,
-		},
-		{
-			name: "invalid_tags",
-			ing: &networkingv1.Ingress{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      baseIngress.Name,
-					Namespace: baseIngress.Namespace,
-					Annotations: map[string]string{
-						AnnotationTags: "tag:invalid!",
-					},
-				},
-			},
-			pg:      readyProxyGroup,
-			wantErr: "Ingress contains invalid tags: invalid tag \"tag:invalid!\": tag names can only contain numbers, letters, or dashes",
-		},
-		{
-			name: "multiple_TLS_entries",
-			ing: &networkingv1.Ingress{
-				ObjectMeta: baseIngress.ObjectMeta,
-				Spec: networkingv1.IngressSpec{
-					TLS: []networkingv1.IngressTLS{
-						{Hosts: []string{"test1.example.com"}},
-						{Hosts: []string{"test2.example.com"}},
-					},
-				},
+	// No custom tags, HTTP defaults apply.
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTags(t, []string{"tag:k8s-http"})
+
+	// Custom tags override the HTTP defaults.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		ing.Annotations["tailscale.com/tags"] = "tag:custom"
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTags(t, []string{"tag:custom"})
+
+	// Removing custom tags falls back to the HTTP defaults.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		delete(ing.Annotations, "tailscale.com/tags")
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTags(t, []string{"tag:k8s-http"})
+}
+
+func TestIngressPGReconciler_ManagedServiceTag(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
+	// Operator configured with OPERATOR_MANAGED_SERVICE_TAG=tag:k8s-operator.
+	ingPGR.managedServiceTag = "tag:k8s-operator"
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
 			},
-			pg:      readyProxyGroup,
-			wantErr: "Ingress contains invalid TLS block [{[test1.example.com] } {[test2.example.com] }]: only a single TLS entry with a single host is allowed",
 		},
-		{
-			name: "multiple_hosts_in_TLS_entry",
-			ing: &networkingv1.Ingress{
-				ObjectMeta: baseIngress.ObjectMeta,
-				Spec: networkingv1.IngressSpec{
-					TLS: []networkingv1.IngressTLS{
-						{Hosts: []string{"test1.example.com", "test2.example.com"}},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
 					},
 				},
 			},
-			pg:      readyProxyGroup,
-			wantErr: "Ingress contains invalid TLS block [{[test1.example.com test2.example.com] }]: only a single TLS entry with a single host is allowed",
-		},
-		{
-			name: "wrong_proxy_group_type",
-			ing:  baseIngress,
-			pg: &tsapi.ProxyGroup{
-				ObjectMeta: readyProxyGroup.ObjectMeta,
-				Spec: tsapi.ProxyGroupSpec{
-					Type: tsapi.ProxyGroupType("foo"),
-				},
-				Status: readyProxyGroup.Status,
-			},
-			wantErr: "ProxyGroup \"test-pg\" is of type \"foo\" but must be of type \"ingress\"",
-		},
-		{
-			name: "proxy_group_not_ready",
-			ing:  baseIngress,
-			pg: &tsapi.ProxyGroup{
-				ObjectMeta: readyProxyGroup.ObjectMeta,
-				Spec:       readyProxyGroup.Spec,
-				Status: tsapi.ProxyGroupStatus{
-					Conditions: []metav1.Condition{
-						{
-							Type:               string(tsapi.ProxyGroupAvailable),
-							Status:             metav1.ConditionFalse,
-							ObservedGeneration: 1,
-						},
-					},
-				},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
 			},
-			wantErr: "ProxyGroup \"test-pg\" is not ready",
-		},
-		{
-			name: "duplicate_hostname",
-			ing:  baseIngress,
-			pg:   readyProxyGroup,
-			existingIngs: []networkingv1.Ingress{{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "existing-ingress",
-					Namespace: "default",
-					Annotations: map[string]string{
-						AnnotationProxyGroup: "test-pg",
-					},
-				},
-				Spec: networkingv1.IngressSpec{
-					IngressClassName: ptr.To("tailscale"),
-					TLS: []networkingv1.IngressTLS{
-						{Hosts: []string{"test"}},
-					},
-				},
-			}},
-			wantErr: `found duplicate Ingress "default/existing-ingress" for hostname "test" - multiple Ingresses for the same hostname in the same cluster are not allowed`,
 		},
 	}
+	mustCreate(t, fc, ing)
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			fc := fake.NewClientBuilder().
-				WithScheme(tsapi.GlobalScheme).
-				WithObjects(tt.ing).
-				WithLists(&networkingv1.IngressList{Items: tt.existingIngs}).
-				Build()
-
-			r := &HAIngressReconciler{Client: fc}
-			if tt.ing.Spec.IngressClassName != nil {
-				r.ingressClassName = *tt.ing.Spec.IngressClassName
-			}
+	verifyTags := func(t *testing.T, wantTags []string) {
+		t.Helper()
+		tsSvc, err := ft.GetVIPService(t.Context(), "svc:my-svc")
+		if err != nil {
+			t.Fatalf("getting Tailscale Service: %v", err)
+		}
+		if !slices.Equal(tsSvc.Tags, wantTags) {
+			t.Errorf("incorrect Tailscale Service tags: got %v, want %v", tsSvc.Tags, wantTags)
+		}
+	}
 
-			err := r.validateIngress(context.Background(), tt.ing, tt.pg)
-			if (err == nil && tt.wantErr != "") || (err != nil && err.Error() != tt.wantErr) {
-				t.Errorf("validateIngress() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
+	// The managed tag is added to the default tags.
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTags(t, []string{"tag:k8s", "tag:k8s-operator"})
+	if !slices.Equal(ingPGR.defaultTags, []string{"tag:k8s"}) {
+		t.Errorf("default tags were modified: %v", ingPGR.defaultTags)
 	}
+
+	// The managed tag is kept if custom tags override the defaults.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		ing.Annotations["tailscale.com/tags"] = "tag:custom"
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTags(t, []string{"tag:custom", "tag:k8s-operator"})
+
+	// The managed tag is not duplicated if it is also a custom tag.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		ing.Annotations["tailscale.com/tags"] = "tag:k8s-operator,tag:custom"
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTags(t, []string{"tag:custom", "tag:k8s-operator"})
 }
 
-func TestIngressPGReconciler_HTTPEndpoint(t *testing.T) {
-	ingPGR, fc, ft := setupIngressTest(t)
+func TestIngressPGReconciler_MissingServeConfig(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+	// The ProxyGroup reconciler has not created the serve config ConfigMap
+	// yet.
+	if err := fc.Delete(t.Context(), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test-pg-ingress-config", Namespace: "operator-ns"}}); err != nil {
+		t.Fatal(err)
+	}
 
-	// Create test Ingress with HTTP endpoint enabled
 	ing := &networkingv1.Ingress{
 		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
 		ObjectMeta: metav1.ObjectMeta{
@@ -517,8 +508,7 @@ func TestIngressPGReconciler_HTTPEndpoint(t *testing.T) {
 			Namespace: "default",
 			UID:       types.UID("1234-UID"),
 			Annotations: map[string]string{
-				"tailscale.com/proxy-group":   "test-pg",
-				"tailscale.com/http-endpoint": "enabled",
+				"tailscale.com/proxy-group": "test-pg",
 			},
 		},
 		Spec: networkingv1.IngressSpec{
@@ -536,93 +526,4610 @@ func TestIngressPGReconciler_HTTPEndpoint(t *testing.T) {
 			},
 		},
 	}
-	if err := fc.Create(context.Background(), ing); err != nil {
+	mustCreate(t, fc, ing)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+
+	// The ConfigMap is created as the ProxyGroup reconciler would and the
+	// Ingress is added to it.
+	cm := &corev1.ConfigMap{}
+	if err := fc.Get(t.Context(), types.NamespacedName{Namespace: "operator-ns", Name: "test-pg-ingress-config"}, cm); err != nil {
+		t.Fatalf("getting serve config ConfigMap: %v", err)
+	}
+	pg := &tsapi.ProxyGroup{}
+	if err := fc.Get(t.Context(), types.NamespacedName{Name: "test-pg"}, pg); err != nil {
 		t.Fatal(err)
 	}
+	want := pgIngressCM(pg, "operator-ns")
+	if !reflect.DeepEqual(cm.Labels, want.Labels) || !reflect.DeepEqual(cm.OwnerReferences, want.OwnerReferences) {
+		t.Errorf("unexpected ConfigMap metadata: got labels %v, owner references %v; want %v, %v", cm.Labels, cm.OwnerReferences, want.Labels, want.OwnerReferences)
+	}
+	verifyServeConfig(t, fc, "svc:my-svc", false)
+}
 
-	// Verify initial reconciliation with HTTP enabled
-	expectReconciled(t, ingPGR, "default", "test-ingress")
-	populateTLSSecret(t, fc, "test-pg", "my-svc.ts.net")
-	expectReconciled(t, ingPGR, "default", "test-ingress")
-	verifyTailscaleService(t, ft, "svc:my-svc", []string{"tcp:80", "tcp:443"})
-	verifyServeConfig(t, fc, "svc:my-svc", true)
+func TestIngressPGReconciler_ModeSwitch(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
+	ingR := &IngressReconciler{
+		Client:                 fc,
+		ingressClassName:       "tailscale",
+		ingressClassController: tailscaleIngressControllerName,
+		ssr: &tailscaleSTSReconciler{
+			Client:            fc,
+			tsClient:          ft,
+			tsnetServer:       &fakeTSNetServer{certDomains: []string{"foo.com"}},
+			defaultTags:       []string{"tag:k8s"},
+			operatorNamespace: "operator-ns",
+			proxyImage:        "tailscale/tailscale",
+		},
+		recorder: record.NewFakeRecorder(10),
+		logger:   ingPGR.logger,
+	}
 
-	// Verify Ingress status
-	ing = &networkingv1.Ingress{}
-	if err := fc.Get(context.Background(), types.NamespacedName{
-		Name:      "test-ingress",
-		Namespace: "default",
-	}, ing); err != nil {
+	// 1. The Ingress is exposed via a standalone proxy.
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ingress())
+	expectReconciled(t, ingR, "default", "test")
+	_, shortName := findGenName(t, fc, "default", "test", "ingress")
+	ing := &networkingv1.Ingress{}
+	if err := fc.Get(t.Context(), types.NamespacedName{Namespace: "default", Name: "test"}, ing); err != nil {
 		t.Fatal(err)
 	}
+	if !slices.Contains(ing.Finalizers, FinalizerName) {
+		t.Fatalf("standalone Ingress finalizer not set: %v", ing.Finalizers)
+	}
 
-	// Status will be empty until the Tailscale Service shows up in prefs.
-	if !reflect.DeepEqual(ing.Status.LoadBalancer.Ingress, []networkingv1.IngressLoadBalancerIngress(nil)) {
-		t.Errorf("incorrect Ingress status: got %v, want empty",
-			ing.Status.LoadBalancer.Ingress)
+	// 2. The Ingress is switched to the ProxyGroup. The Tailscale Service
+	// is not created until the standalone proxy has been cleaned up.
+	mustUpdate(t, fc, "default", "test", func(ing *networkingv1.Ingress) {
+		mak.Set(&ing.Annotations, AnnotationProxyGroup, "test-pg")
+	})
+	expectReconciled(t, ingPGR, "default", "test")
+	if err := fc.Get(t.Context(), types.NamespacedName{Namespace: "default", Name: "test"}, ing); err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(ing.Finalizers, FinalizerNamePG) {
+		t.Fatalf("HA Ingress provisioned before standalone proxy was cleaned up")
 	}
+	expectReconciled(t, ingR, "default", "test")
+	expectReconciled(t, ingR, "default", "test") // deleting Ingress STS requires two reconciles
+	expectMissing[appsv1.StatefulSet](t, fc, "operator-ns", shortName)
+	expectMissing[corev1.Service](t, fc, "operator-ns", shortName)
 
-	// Add the Tailscale Service to prefs to have the Ingress recognised as ready.
-	mustCreate(t, fc, &corev1.Secret{
+	expectReconciled(t, ingPGR, "default", "test")
+	populateTLSSecret(t, fc, "test-pg", "default-test.ts.net")
+	expectReconciled(t, ingPGR, "default", "test")
+	if err := fc.Get(t.Context(), types.NamespacedName{Namespace: "default", Name: "test"}, ing); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ing.Finalizers, []string{FinalizerNamePG}) {
+		t.Errorf("unexpected finalizers after switching to ProxyGroup: %v", ing.Finalizers)
+	}
+	verifyServeConfig(t, fc, "svc:default-test", false)
+	verifyTailscaleService(t, ft, "svc:default-test", []string{"tcp:443"})
+	verifyTailscaledConfig(t, fc, "test-pg", []string{"svc:default-test"})
+
+	// 3. The Ingress is switched back to a standalone proxy. The ProxyGroup
+	// stops advertising the Tailscale Service before the proxy is created.
+	mustUpdate(t, fc, "default", "test", func(ing *networkingv1.Ingress) {
+		delete(ing.Annotations, AnnotationProxyGroup)
+	})
+	expectReconciled(t, ingR, "default", "test")
+	if err := fc.Get(t.Context(), types.NamespacedName{Namespace: "default", Name: "test"}, ing); err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(ing.Finalizers, FinalizerName) {
+		t.Fatalf("standalone proxy provisioned before ProxyGroup resources were cleaned up")
+	}
+	expectReconciled(t, ingPGR, "default", "test")
+	verifyTailscaledConfig(t, fc, "test-pg", nil)
+	_, cfg, err := ingPGR.proxyGroupServeConfig(t.Context(), "test-pg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Services) != 0 {
+		t.Errorf("serve config still contains Tailscale Services: %v", cfg.Services)
+	}
+	if _, err := ft.GetVIPService(t.Context(), "svc:default-test"); !isErrorTailscaleServiceNotFound(err) {
+		t.Errorf("Tailscale Service not deleted, GetVIPService error: %v", err)
+	}
+
+	expectReconciled(t, ingR, "default", "test")
+	findGenName(t, fc, "default", "test", "ingress")
+}
+
+func TestIngressPGReconciler_ForeignServeConfigEntries(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
+	fr := record.NewFakeRecorder(10)
+	ingPGR.recorder = fr
+
+	// Another controller has added a Tailscale Service to the serve config
+	// of a ProxyGroup created by this operator version.
+	foreign := &ipn.ServiceConfig{
+		TCP: map[uint16]*ipn.TCPPortHandler{443: {TCPForward: "10.0.0.1:443"}},
+	}
+	mustUpdate(t, fc, "operator-ns", "test-pg-ingress-config", func(cm *corev1.ConfigMap) {
+		cfgBytes, err := json.Marshal(&ipn.ServeConfig{
+			Services: map[tailcfg.ServiceName]*ipn.ServiceConfig{"svc:foreign": foreign},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cm.BinaryData["serve-config.json"] = cfgBytes
+		mak.Set(&cm.Annotations, annotationManagedServices, "")
+	})
+	if err := ft.CreateOrUpdateVIPService(t.Context(), &tailscale.VIPService{Name: "svc:foreign"}); err != nil {
+		t.Fatal(err)
+	}
+
+	expectServeConfig := func(t *testing.T, wantManaged string, wantServices ...tailcfg.ServiceName) {
+		t.Helper()
+		cm := &corev1.ConfigMap{}
+		if err := fc.Get(t.Context(), types.NamespacedName{Namespace: "operator-ns", Name: "test-pg-ingress-config"}, cm); err != nil {
+			t.Fatal(err)
+		}
+		if got := cm.Annotations[annotationManagedServices]; got != wantManaged {
+			t.Errorf("managed services annotation = %q, want %q", got, wantManaged)
+		}
+		_, cfg, err := ingPGR.proxyGroupServeConfig(t.Context(), "test-pg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := slices.Sorted(maps.Keys(cfg.Services)); !slices.Equal(got, wantServices) {
+			t.Errorf("serve config Services = %v, want %v", got, wantServices)
+		}
+		if !reflect.DeepEqual(cfg.Services["svc:foreign"], foreign) {
+			t.Errorf("foreign serve config entry modified: %+v", cfg.Services["svc:foreign"])
+		}
+	}
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectServeConfig(t, "svc:my-svc", "svc:foreign", "svc:my-svc")
+
+	// Changing the hostname cleans up the operator's previous entry only.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		ing.Spec.TLS[0].Hosts = []string{"my-other-svc"}
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectServeConfig(t, "svc:my-other-svc", "svc:foreign", "svc:my-other-svc")
+
+	// The operator does not take over the foreign entry, even if the
+	// foreign Tailscale Service, which it would refuse to take over, does
+	// not exist (yet).
+	if err := ft.DeleteVIPService(t.Context(), "svc:foreign"); err != nil {
+		t.Fatal(err)
+	}
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		ing.Spec.TLS[0].Hosts = []string{"foreign"}
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectServeConfig(t, "", "svc:foreign")
+	expectEvents(t, fr, []string{
+		"Warning ServeConfigConflict Serve config for ProxyGroup test-pg already contains Tailscale Service svc:foreign that is not managed by the operator; remove it or choose a different hostname",
+	})
+
+	// Deleting the Ingress leaves the foreign entry in place.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		ing.Spec.TLS[0].Hosts = []string{"my-svc"}
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectServeConfig(t, "svc:my-svc", "svc:foreign", "svc:my-svc")
+	if err := fc.Delete(t.Context(), ing); err != nil {
+		t.Fatalf("deleting Ingress: %v", err)
+	}
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectServeConfig(t, "", "svc:foreign")
+}
+
+func TestIngressPGReconciler_ServeConfigDrift(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, ing)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+
+	serveConfig := func(t *testing.T) *ipn.ServeConfig {
+		t.Helper()
+		cm := &corev1.ConfigMap{}
+		if err := fc.Get(t.Context(), types.NamespacedName{Namespace: "operator-ns", Name: "test-pg-ingress-config"}, cm); err != nil {
+			t.Fatal(err)
+		}
+		cfg := &ipn.ServeConfig{}
+		if err := json.Unmarshal(cm.BinaryData["serve-config.json"], cfg); err != nil {
+			t.Fatal(err)
+		}
+		return cfg
+	}
+	wantCfg := serveConfig(t).Services["svc:my-svc"]
+	if wantCfg == nil {
+		t.Fatal("Tailscale Service svc:my-svc not found in serve config")
+	}
+	unrelatedCfg := &ipn.ServiceConfig{
+		TCP: map[uint16]*ipn.TCPPortHandler{
+			443: {TCPForward: "10.0.0.1:443"},
+		},
+	}
+
+	// Manually edit the managed entry and add an entry that the operator
+	// does not manage.
+	mustUpdate(t, fc, "operator-ns", "test-pg-ingress-config", func(cm *corev1.ConfigMap) {
+		cfg := &ipn.ServeConfig{}
+		if err := json.Unmarshal(cm.BinaryData["serve-config.json"], cfg); err != nil {
+			t.Fatal(err)
+		}
+		cfg.Services["svc:my-svc"].TCP[443].HTTPS = false
+		cfg.Services["svc:my-svc"].TCP[8443] = &ipn.TCPPortHandler{TCPForward: "10.0.0.2:8443"}
+		cfg.Services["svc:unrelated"] = unrelatedCfg
+		b, err := json.Marshal(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cm.BinaryData["serve-config.json"] = b
+	})
+
+	// The managed entry is corrected, the unrelated entry is left alone.
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	gotCfg := serveConfig(t)
+	if diff := cmp.Diff(wantCfg, gotCfg.Services["svc:my-svc"]); diff != "" {
+		t.Errorf("unexpected serve config for svc:my-svc (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(unrelatedCfg, gotCfg.Services["svc:unrelated"]); diff != "" {
+		t.Errorf("unexpected serve config for svc:unrelated (-want +got):\n%s", diff)
+	}
+}
+
+func TestIngressPGReconciler_WatchNamespaces(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
+	ingPGR.watchNamespaces = []string{"default"}
+
+	ingress := func(ns, host string) *networkingv1.Ingress {
+		return &networkingv1.Ingress{
+			TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-ingress",
+				Namespace: ns,
+				UID:       types.UID(ns + "-UID"),
+				Annotations: map[string]string{
+					"tailscale.com/proxy-group": "test-pg",
+				},
+			},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: ptr.To("tailscale"),
+				DefaultBackend: &networkingv1.IngressBackend{
+					Service: &networkingv1.IngressServiceBackend{
+						Name: "test",
+						Port: networkingv1.ServiceBackendPort{
+							Number: 8080,
+						},
+					},
+				},
+				TLS: []networkingv1.IngressTLS{
+					{Hosts: []string{host}},
+				},
+			},
+		}
+	}
+
+	// Ingress in a namespace that is not watched is skipped, even though it
+	// has the tailscale IngressClass.
+	mustCreate(t, fc, ingress("other", "other-svc"))
+	expectReconciled(t, ingPGR, "other", "test-ingress")
+	ing := &networkingv1.Ingress{}
+	if err := fc.Get(t.Context(), types.NamespacedName{Namespace: "other", Name: "test-ingress"}, ing); err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(ing.Finalizers, FinalizerNamePG) {
+		t.Errorf("Ingress in non-watched namespace has finalizer %q", FinalizerNamePG)
+	}
+	if _, err := ft.GetVIPService(t.Context(), "svc:other-svc"); !isErrorTailscaleServiceNotFound(err) {
+		t.Errorf("expected Tailscale Service svc:other-svc to not exist, got err %v", err)
+	}
+
+	// Ingress in a watched namespace is exposed.
+	mustCreate(t, fc, ingress("default", "my-svc"))
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyServeConfig(t, fc, "svc:my-svc", false)
+	verifyTailscaleService(t, ft, "svc:my-svc", []string{"tcp:443"})
+}
+
+func TestValidateIngress(t *testing.T) {
+	baseIngress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				AnnotationProxyGroup: "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"test"}},
+			},
+		},
+	}
+
+	readyProxyGroup := &tsapi.ProxyGroup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-pg",
+			Generation: 1,
+		},
+		Spec: tsapi.ProxyGroupSpec{
+			Type: tsapi.ProxyGroupTypeIngress,
+		},
+		Status: tsapi.ProxyGroupStatus{
+			Conditions: []metav1.Condition{
+				{
+					Type:               string(tsapi.ProxyGroupAvailable),
+					Status:             metav1.ConditionTrue,
+					ObservedGeneration: 1,
+				},
+			},
+		},
+	}
+
+	// certOnlySecret is an externally managed TLS Secret without a key.
+	certOnlySecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cert-only",
+			Namespace: "operator-ns",
+		},
+		Data: map[string][]byte{
+			corev1.TLSCertKey: []byte("fake-cert"),
+		},
+	}
+
+	tests := []struct {
+		name         string
+		ing          *networkingv1.Ingress
+		pg           *tsapi.ProxyGroup
+		existingIngs []networkingv1.Ingress
+		wantErr      string
+	}{
+		{
+			name: "valid_ingress_with_hostname",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: baseIngress.ObjectMeta,
+				Spec: networkingv1.IngressSpec{
+					TLS: []networkingv1.IngressTLS{
+						{Hosts: []string{"test"}},
+					},
+				},
+			},
+			pg: readyProxyGroup,
+		},
+		{
+			name: "valid_ingress_with_magicdns_fqdn",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: baseIngress.ObjectMeta,
+				Spec: networkingv1.IngressSpec{
+					TLS: []networkingv1.IngressTLS{
+						{Hosts: []string{"test.ts.net"}},
+					},
+				},
+			},
+			pg: readyProxyGroup,
+		},
+		{
+			name: "foreign_fqdn",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: baseIngress.ObjectMeta,
+				Spec: networkingv1.IngressSpec{
+					TLS: []networkingv1.IngressTLS{
+						{Hosts: []string{"test.example.com"}},
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: `Ingress contains invalid TLS host "test.example.com": must be a bare hostname, such as "test", or a FQDN in the tailnet's MagicDNS domain "ts.net", such as "test.ts.net"`,
+		},
+		{
+			name: "subdomain_of_magicdns_domain",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: baseIngress.ObjectMeta,
+				Spec: networkingv1.IngressSpec{
+					TLS: []networkingv1.IngressTLS{
+						{Hosts: []string{"test.foo.ts.net"}},
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: `Ingress contains invalid TLS host "test.foo.ts.net": must be a bare hostname, such as "test", or a FQDN in the tailnet's MagicDNS domain "ts.net", such as "test.ts.net"`,
+		},
+		{
+			name: "valid_ingress_with_default_hostname",
+			ing:  baseIngress,
+			pg:   readyProxyGroup,
+		},
+		{
+			name: "invalid_http_mode",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationHTTPMode: "foo",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has invalid tailscale.com/http-mode annotation \"foo\": must be \"serve\" or \"redirect\"",
+		},
+		{
+			name: "invalid_default_response",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationDefaultResponse: "redirect:/login",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has invalid tailscale.com/default-response annotation \"redirect:/login\": redirect target must be an absolute URL",
+		},
+		{
+			name: "unsupported_priority",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationPriority: "10",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has tailscale.com/priority annotation \"10\": Tailscale Service priorities are not yet supported by the Tailscale API",
+		},
+		{
+			name: "unsupported_auto_approvers",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationAutoApprovers: "group:eng",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has tailscale.com/auto-approvers annotation \"group:eng\": auto-approvers of Tailscale Services can only be set in the autoApprovers.services section of the tailnet policy file",
+		},
+		{
+			name: "invalid_response_headers",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationResponseHeaders: `{"Bad Header":"x"}`,
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: `Ingress has invalid tailscale.com/response-headers annotation "{\"Bad Header\":\"x\"}": invalid header name "Bad Header"`,
+		},
+		{
+			name: "invalid_access_log",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationAccessLog: "yes",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: `Ingress has invalid tailscale.com/access-log annotation "yes": must be "true" or "false"`,
+		},
+		{
+			name: "backend_retries_out_of_bounds",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationBackendRetries: "11",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: `Ingress has invalid tailscale.com/backend-retries annotation "11": the number of retries must be an integer between 1 and 10`,
+		},
+		{
+			name: "backend_retries_invalid_status_code",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationBackendRetries: "3:503,200",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: `Ingress has invalid tailscale.com/backend-retries annotation "3:503,200": status code "200" must be an HTTP error status code between 400 and 599`,
+		},
+		{
+			name: "backend_port_zero",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: baseIngress.ObjectMeta,
+				Spec: networkingv1.IngressSpec{
+					DefaultBackend: &networkingv1.IngressBackend{
+						Service: &networkingv1.IngressServiceBackend{Name: "test"},
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: `Ingress backend for path "/" has invalid port 0 for Service "test": port must be between 1 and 65535`,
+		},
+		{
+			name: "backend_port_out_of_range",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: baseIngress.ObjectMeta,
+				Spec: networkingv1.IngressSpec{
+					Rules: []networkingv1.IngressRule{{
+						IngressRuleValue: networkingv1.IngressRuleValue{
+							HTTP: &networkingv1.HTTPIngressRuleValue{
+								Paths: []networkingv1.HTTPIngressPath{{
+									Path: "/api",
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "test",
+											Port: networkingv1.ServiceBackendPort{Number: 70000},
+										},
+									},
+								}},
+							},
+						},
+					}},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: `Ingress backend for path "/api" has invalid port 70000 for Service "test": port must be between 1 and 65535`,
+		},
+		{
+			name: "invalid_http_backend",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationHTTPBackend: "acme",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: `Ingress has invalid tailscale.com/http-backend annotation "acme": must be of the form <service>:<port>`,
+		},
+		{
+			name: "http_backend_with_redirect",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationHTTPBackend: "acme:80",
+						annotationHTTPMode:    httpModeRedirect,
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: `Ingress has tailscale.com/http-backend annotation, but tailscale.com/http-mode annotation is "redirect": HTTP requests can not both be redirected and served from a backend`,
+		},
+		{
+			name: "http_backend_missing_services",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationHTTPBackend: "acme:80",
+					},
+				},
+				Spec: networkingv1.IngressSpec{
+					DefaultBackend: &networkingv1.IngressBackend{
+						Service: &networkingv1.IngressServiceBackend{
+							Name: "app",
+							Port: networkingv1.ServiceBackendPort{Number: 8080},
+						},
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress backend Service \"acme\" does not exist\nIngress backend Service \"app\" does not exist",
+		},
+		{
+			name: "funnel_enabled_when_marked_never",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						AnnotationFunnel:      "true",
+						annotationFunnelGuard: funnelNever,
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has tailscale.com/funnel annotation \"true\", but has been marked as never to be exposed over Funnel; remove the tailscale.com/funnel-guard annotation to allow it",
+		},
+		{
+			name: "invalid_max_connections",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationMaxConnections: "0",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has invalid tailscale.com/max-connections annotation \"0\": must be a positive integer",
+		},
+		{
+			name: "empty_backend_selector",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationBackendSelector: "",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has invalid tailscale.com/backend-selector annotation \"\": must not be empty",
+		},
+		{
+			name: "invalid_backend_selector",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationBackendSelector: "app=(web",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has invalid tailscale.com/backend-selector annotation \"app=(web\": unable to parse requirement: found '(', expected: identifier",
+		},
+		{
+			name: "invalid_tcp_keepalive_idle",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationTCPKeepAliveIdle: "forever",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has invalid tailscale.com/tcp-keepalive-idle annotation \"forever\": must be a duration of at least 1s",
+		},
+		{
+			name: "tcp_keepalive_interval_too_short",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationTCPKeepAliveInterval: "500ms",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has invalid tailscale.com/tcp-keepalive-interval annotation \"500ms\": must be a duration of at least 1s",
+		},
+		{
+			name: "invalid_client_http_version",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationClientHTTPVersion: "h3",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has invalid tailscale.com/client-http-version annotation \"h3\": must be \"h2\" or \"http1\"",
+		},
+		{
+			name: "invalid_backend_dial_family",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationBackendDialFamily: "ipv5",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has invalid tailscale.com/backend-dial-family annotation \"ipv5\": must be \"ipv4\" or \"ipv6\"",
+		},
+		{
+			name: "invalid_service_persistence",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationServicePersistence: "sometimes",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has invalid tailscale.com/service-persistence annotation \"sometimes\": must be \"persistent\" or \"ephemeral\"",
+		},
+		{
+			name: "invalid_cert_wait",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationCertWait: "no",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has invalid tailscale.com/cert-wait annotation \"no\": must be \"true\" or \"false\"",
+		},
+		{
+			name: "tls_host_too_long",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: baseIngress.ObjectMeta,
+				Spec: networkingv1.IngressSpec{
+					TLS: []networkingv1.IngressTLS{
+						{Hosts: []string{strings.Repeat("a", 64)}},
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Tailscale Service name \"svc:" + strings.Repeat("a", 64) + "\" is too long: the hostname from the Ingress' TLS block must be at most 63 characters, but is 64. Set a shorter host in the TLS block",
+		},
+		{
+			name: "tls_host_ipv4",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: baseIngress.ObjectMeta,
+				Spec: networkingv1.IngressSpec{
+					TLS: []networkingv1.IngressTLS{
+						{Hosts: []string{"100.64.0.1"}},
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: `Ingress contains invalid TLS host "100.64.0.1": IP addresses are not supported, as Tailscale Services are only served on DNS names; use a bare hostname, such as "my-svc", instead`,
+		},
+		{
+			name: "tls_host_ipv6",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: baseIngress.ObjectMeta,
+				Spec: networkingv1.IngressSpec{
+					TLS: []networkingv1.IngressTLS{
+						{Hosts: []string{"fd7a:115c:a1e0::1"}},
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: `Ingress contains invalid TLS host "fd7a:115c:a1e0::1": IP addresses are not supported, as Tailscale Services are only served on DNS names; use a bare hostname, such as "my-svc", instead`,
+		},
+		{
+			name: "tls_host_ipv6_bracketed",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: baseIngress.ObjectMeta,
+				Spec: networkingv1.IngressSpec{
+					TLS: []networkingv1.IngressTLS{
+						{Hosts: []string{"[fd7a:115c:a1e0::1]"}},
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: `Ingress contains invalid TLS host "[fd7a:115c:a1e0::1]": IP addresses are not supported, as Tailscale Services are only served on DNS names; use a bare hostname, such as "my-svc", instead`,
+		},
+		{
+			name: "derived_hostname_too_long",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      strings.Repeat("a", 50),
+					Namespace: baseIngress.Namespace,
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Tailscale Service name \"svc:default-" + strings.Repeat("a", 50) + "-ingress\" derived for the Ingress is too long: the hostname must be at most 63 characters, but is 66. Set a shorter hostname explicitly via the host in the Ingress' TLS block, or shorten the Ingress' name or namespace",
+		},
+		{
+			name: "tls_secret_missing",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationTLSSecret: "missing",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has tailscale.com/tls-secret annotation \"missing\", but Secret operator-ns/missing does not exist. The Secret must be in the operator's namespace",
+		},
+		{
+			name: "tls_secret_without_key",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationTLSSecret: "cert-only",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Secret operator-ns/cert-only referenced by the Ingress' tailscale.com/tls-secret annotation must contain non-empty tls.crt and tls.key",
+		},
+		{
+			name: "cert_renewal_owner_with_tls_secret",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationCertRenewalOwner: "true",
+						annotationTLSSecret:        "cert-only",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has tailscale.com/cert-renewal-owner annotation \"true\", but tailscale.com/tls-secret annotation is set: an Ingress with an externally managed TLS cert does not share the ProxyGroup's consolidated cert Secret, so it can not be its renewal owner\nSecret operator-ns/cert-only referenced by the Ingress' tailscale.com/tls-secret annotation must contain non-empty tls.crt and tls.key",
+		},
+		{
+			name: "trust_bundle_secret_missing",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationTrustBundleSecret: "missing",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has tailscale.com/trust-bundle-secret annotation \"missing\", but Secret operator-ns/missing does not exist. The Secret must be in the operator's namespace",
+		},
+		{
+			name: "trust_bundle_secret_without_pem",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationTrustBundleSecret: "cert-only",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Secret operator-ns/cert-only referenced by the Ingress' tailscale.com/trust-bundle-secret annotation must contain PEM-encoded CA certificates in ca.crt",
+		},
+		{
+			name: "trust_bundle_secret_with_http_endpoint",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationTrustBundleSecret: "cert-only",
+						annotationHTTPEndpoint:      "enabled",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has tailscale.com/trust-bundle-secret annotation, but tailscale.com/http-endpoint annotation is \"enabled\": client certificates can only be verified over HTTPS, so the HTTP endpoint would serve the Ingress without them\nSecret operator-ns/cert-only referenced by the Ingress' tailscale.com/trust-bundle-secret annotation must contain PEM-encoded CA certificates in ca.crt",
+		},
+		{
+			name: "invalid_ready_gate_kind",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationReadyGate: "deployment/app",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has invalid tailscale.com/ready-gate annotation \"deployment/app\": must be of the form configmap/<name> or secret/<name>",
+		},
+		{
+			name: "invalid_ready_gate_name",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationReadyGate: "configmap/App_Config",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has invalid tailscale.com/ready-gate annotation \"configmap/App_Config\": invalid name \"App_Config\": a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')",
+		},
+		{
+			name: "ready_gate_missing",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationReadyGate: "secret/missing",
+					},
+				},
+			},
+			pg: readyProxyGroup,
+		},
+		{
+			name: "invalid_port_aliases",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationPortAliases: "8443,https",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has invalid tailscale.com/port-aliases annotation \"8443,https\": must be a comma-separated list of port numbers",
+		},
+		{
+			name: "port_alias_reserved",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationPortAliases: "80",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has invalid tailscale.com/port-aliases annotation \"80\": port 80 is reserved for the HTTPS and HTTP endpoints",
+		},
+		{
+			name: "duplicate_port_aliases",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationPortAliases: "8443, 8443",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has invalid tailscale.com/port-aliases annotation \"8443, 8443\": port 8443 is listed more than once",
+		},
+		{
+			name: "invalid_replicas",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationReplicas: "0,a",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has invalid tailscale.com/replicas annotation \"0,a\": must be a comma-separated list of ProxyGroup replica indices",
+		},
+		{
+			name: "replica_out_of_range",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationReplicas: "1,2",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has invalid tailscale.com/replicas annotation: replica 2 does not exist, ProxyGroup \"test-pg\" has 2 replicas",
+		},
+		{
+			name: "ephemeral_service_unsupported",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationServicePersistence: "ephemeral",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress has tailscale.com/service-persistence annotation \"ephemeral\": ephemeral Tailscale Services are not yet supported by the Tailscale API",
+		},
+		{
+			name: "persistent_service",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						annotationServicePersistence: "persistent",
+					},
+				},
+			},
+			pg: readyProxyGroup,
+		},
+		{
+			name: "invalid_tags",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      baseIngress.Name,
+					Namespace: baseIngress.Namespace,
+					Annotations: map[string]string{
+						AnnotationTags: "tag:invalid!",
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress contains invalid tags: invalid tag \"tag:invalid!\": tag names can only contain numbers, letters, or dashes",
+		},
+		{
+			name: "multiple_TLS_entries",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: baseIngress.ObjectMeta,
+				Spec: networkingv1.IngressSpec{
+					TLS: []networkingv1.IngressTLS{
+						{Hosts: []string{"test1.example.com"}},
+						{Hosts: []string{"test2.example.com"}},
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress contains invalid TLS block [{[test1.example.com] } {[test2.example.com] }]: only a single TLS entry with a single host is allowed",
+		},
+		{
+			name: "multiple_hosts_in_TLS_entry",
+			ing: &networkingv1.Ingress{
+				ObjectMeta: baseIngress.ObjectMeta,
+				Spec: networkingv1.IngressSpec{
+					TLS: []networkingv1.IngressTLS{
+						{Hosts: []string{"test1.example.com", "test2.example.com"}},
+					},
+				},
+			},
+			pg:      readyProxyGroup,
+			wantErr: "Ingress contains invalid TLS block [{[test1.example.com test2.example.com] }]: only a single TLS entry with a single host is allowed",
+		},
+		{
+			name: "wrong_proxy_group_type",
+			ing:  baseIngress,
+			pg: &tsapi.ProxyGroup{
+				ObjectMeta: readyProxyGroup.ObjectMeta,
+				Spec: tsapi.ProxyGroupSpec{
+					Type: tsapi.ProxyGroupType("foo"),
+				},
+				Status: readyProxyGroup.Status,
+			},
+			wantErr: "ProxyGroup \"test-pg\" is of type \"foo\" but must be of type \"ingress\"",
+		},
+		{
+			name: "proxy_group_not_ready",
+			ing:  baseIngress,
+			pg: &tsapi.ProxyGroup{
+				ObjectMeta: readyProxyGroup.ObjectMeta,
+				Spec:       readyProxyGroup.Spec,
+				Status: tsapi.ProxyGroupStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:               string(tsapi.ProxyGroupAvailable),
+							Status:             metav1.ConditionFalse,
+							ObservedGeneration: 1,
+						},
+					},
+				},
+			},
+			wantErr: "ProxyGroup \"test-pg\" is not ready",
+		},
+		{
+			name: "duplicate_hostname",
+			ing:  baseIngress,
+			pg:   readyProxyGroup,
+			existingIngs: []networkingv1.Ingress{{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "existing-ingress",
+					Namespace: "default",
+					Annotations: map[string]string{
+						AnnotationProxyGroup: "test-pg",
+					},
+				},
+				Spec: networkingv1.IngressSpec{
+					IngressClassName: ptr.To("tailscale"),
+					TLS: []networkingv1.IngressTLS{
+						{Hosts: []string{"test"}},
+					},
+				},
+			}},
+			wantErr: `found duplicate Ingress "default/existing-ingress" for hostname "test" - multiple Ingresses for the same hostname in the same cluster are not allowed`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc := fake.NewClientBuilder().
+				WithScheme(tsapi.GlobalScheme).
+				WithInterceptorFuncs(interceptor.Funcs{Patch: applyAsMergePatch}).
+				WithObjects(tt.ing, certOnlySecret).
+				WithLists(&networkingv1.IngressList{Items: tt.existingIngs}).
+				Build()
+
+			r := &HAIngressReconciler{
+				Client:      fc,
+				tsNamespace: "operator-ns",
+				lc: &fakeLocalClient{
+					status: &ipnstate.Status{
+						CurrentTailnet: &ipnstate.TailnetStatus{
+							MagicDNSSuffix: "ts.net",
+						},
+					},
+				},
+			}
+			if tt.ing.Spec.IngressClassName != nil {
+				r.ingressClassName = *tt.ing.Spec.IngressClassName
+			}
+
+			err := r.validateIngress(context.Background(), tt.ing, tt.pg)
+			if (err == nil && tt.wantErr != "") || (err != nil && err.Error() != tt.wantErr) {
+				t.Errorf("validateIngress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIngressPGReconciler_HTTPEndpoint(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
+
+	// Create test Ingress with HTTP endpoint enabled
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group":   "test-pg",
+				"tailscale.com/http-endpoint": "enabled",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	if err := fc.Create(context.Background(), ing); err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify initial reconciliation with HTTP enabled
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	populateTLSSecret(t, fc, "test-pg", "my-svc.ts.net")
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTailscaleService(t, ft, "svc:my-svc", []string{"tcp:80", "tcp:443"})
+	verifyServeConfig(t, fc, "svc:my-svc", true)
+
+	// Verify Ingress status
+	ing = &networkingv1.Ingress{}
+	if err := fc.Get(context.Background(), types.NamespacedName{
+		Name:      "test-ingress",
+		Namespace: "default",
+	}, ing); err != nil {
+		t.Fatal(err)
+	}
+
+	// Status will be empty until the Tailscale Service shows up in prefs.
+	if !reflect.DeepEqual(ing.Status.LoadBalancer.Ingress, []networkingv1.IngressLoadBalancerIngress(nil)) {
+		t.Errorf("incorrect Ingress status: got %v, want empty",
+			ing.Status.LoadBalancer.Ingress)
+	}
+
+	// Add the Tailscale Service to prefs to have the Ingress recognised as ready.
+	mustCreate(t, fc, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pg-0",
+			Namespace: "operator-ns",
+			Labels:    pgSecretLabels("test-pg", kubetypes.LabelSecretTypeState),
+		},
+		Data: map[string][]byte{
+			"_current-profile": []byte("profile-foo"),
+			"profile-foo":      []byte(`{"AdvertiseServices":["svc:my-svc"],"Config":{"NodeID":"node-foo"}}`),
+		},
+	})
+
+	// Reconcile and re-fetch Ingress.
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	if err := fc.Get(context.Background(), client.ObjectKeyFromObject(ing), ing); err != nil {
+		t.Fatal(err)
+	}
+
+	wantStatus := []networkingv1.IngressPortStatus{
+		{Port: 443, Protocol: "TCP"},
+		{Port: 80, Protocol: "TCP"},
+	}
+	if !reflect.DeepEqual(ing.Status.LoadBalancer.Ingress[0].Ports, wantStatus) {
+		t.Errorf("incorrect status ports: got %v, want %v",
+			ing.Status.LoadBalancer.Ingress[0].Ports, wantStatus)
+	}
+
+	// The TLS Secret is left alone when the HTTP endpoint is toggled, as the
+	// HTTPS host and cert are unchanged.
+	certSecretVersion := func() string {
+		t.Helper()
+		secret := &corev1.Secret{}
+		if err := fc.Get(context.Background(), types.NamespacedName{Namespace: "operator-ns", Name: "my-svc.ts.net"}, secret); err != nil {
+			t.Fatalf("getting TLS Secret: %v", err)
+		}
+		return secret.ResourceVersion
+	}
+	wantVersion := certSecretVersion()
+
+	// Remove HTTP endpoint annotation
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		delete(ing.Annotations, "tailscale.com/http-endpoint")
+	})
+
+	// Verify reconciliation after removing HTTP
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTailscaleService(t, ft, "svc:my-svc", []string{"tcp:443"})
+	verifyServeConfig(t, fc, "svc:my-svc", false)
+	if got := certSecretVersion(); got != wantVersion {
+		t.Errorf("TLS Secret resourceVersion = %q after disabling the HTTP endpoint, want unchanged %q", got, wantVersion)
+	}
+
+	// Verify Ingress status
+	ing = &networkingv1.Ingress{}
+	if err := fc.Get(context.Background(), types.NamespacedName{
+		Name:      "test-ingress",
+		Namespace: "default",
+	}, ing); err != nil {
+		t.Fatal(err)
+	}
+
+	wantStatus = []networkingv1.IngressPortStatus{
+		{Port: 443, Protocol: "TCP"},
+	}
+	if !reflect.DeepEqual(ing.Status.LoadBalancer.Ingress[0].Ports, wantStatus) {
+		t.Errorf("incorrect status ports: got %v, want %v",
+			ing.Status.LoadBalancer.Ingress[0].Ports, wantStatus)
+	}
+
+	// Re-enable the HTTP endpoint.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		ing.Annotations["tailscale.com/http-endpoint"] = "enabled"
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTailscaleService(t, ft, "svc:my-svc", []string{"tcp:80", "tcp:443"})
+	verifyServeConfig(t, fc, "svc:my-svc", true)
+	if got := certSecretVersion(); got != wantVersion {
+		t.Errorf("TLS Secret resourceVersion = %q after re-enabling the HTTP endpoint, want unchanged %q", got, wantVersion)
+	}
+}
+
+func TestIngressPGReconciler_CertWait(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+				"tailscale.com/cert-wait":   "false",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, ing)
+
+	// The Tailscale Service is advertised without the TLS Secret being
+	// populated.
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTailscaleService(t, ft, "svc:my-svc", []string{"tcp:443"})
+	verifyTailscaledConfig(t, fc, "test-pg", []string{"svc:my-svc"})
+
+	// Once a replica advertises it, the Ingress is ready on port 443.
+	mustCreate(t, fc, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pg-0",
+			Namespace: "operator-ns",
+			Labels:    pgSecretLabels("test-pg", kubetypes.LabelSecretTypeState),
+		},
+		Data: map[string][]byte{
+			"_current-profile": []byte("profile-foo"),
+			"profile-foo":      []byte(`{"AdvertiseServices":["svc:my-svc"],"Config":{"NodeID":"node-foo"}}`),
+		},
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	if err := fc.Get(t.Context(), client.ObjectKeyFromObject(ing), ing); err != nil {
+		t.Fatal(err)
+	}
+	wantStatus := []networkingv1.IngressLoadBalancerIngress{
+		{
+			Hostname: "my-svc.ts.net",
+			Ports:    []networkingv1.IngressPortStatus{{Port: 443, Protocol: "TCP"}},
+		},
+	}
+	if !reflect.DeepEqual(ing.Status.LoadBalancer.Ingress, wantStatus) {
+		t.Errorf("incorrect Ingress status: got %v, want %v", ing.Status.LoadBalancer.Ingress, wantStatus)
+	}
+
+	// Waiting for the cert again stops advertising the Tailscale Service
+	// and removes port 443 from the status until the cert is issued.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		delete(ing.Annotations, annotationCertWait)
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTailscaledConfig(t, fc, "test-pg", nil)
+	if err := fc.Get(t.Context(), client.ObjectKeyFromObject(ing), ing); err != nil {
+		t.Fatal(err)
+	}
+	if ports := ing.Status.LoadBalancer.Ingress[0].Ports; len(ports) != 0 {
+		t.Errorf("incorrect status ports: got %v, want none", ports)
+	}
+}
+
+func TestIngressPGReconciler_NoAutoDeleteServices(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
+	ingPGR.noAutoDeleteServices = true
+	fr := record.NewFakeRecorder(10)
+	ingPGR.recorder = fr
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTailscaleService(t, ft, "svc:my-svc", []string{"tcp:443"})
+
+	// Deleting the last Ingress for the Tailscale Service leaves it in
+	// place, without owner references, for manual cleanup.
+	if err := fc.Delete(t.Context(), ing); err != nil {
+		t.Fatal(err)
+	}
+	expectRequeue(t, ingPGR, "default", "test-ingress")
+	if len(ft.deletedVIPServices) != 0 {
+		t.Errorf("DeleteVIPService called for %v, want no calls", ft.deletedVIPServices)
+	}
+	tsSvc, err := ft.GetVIPService(t.Context(), "svc:my-svc")
+	if err != nil {
+		t.Fatalf("getting Tailscale Service: %v", err)
+	}
+	if o, ok := tsSvc.Annotations[ownerAnnotation]; ok {
+		t.Errorf("Tailscale Service has owner annotation %q, want none", o)
+	}
+	expectEvents(t, fr, []string{"Warning TailscaleServiceRetained Tailscale Service svc:my-svc was not deleted as automatic deletion of Tailscale Services is disabled for this operator; delete it manually once no longer needed"})
+
+	// The Ingress is not blocked from being deleted.
+	if err := fc.Get(t.Context(), client.ObjectKeyFromObject(ing), ing); !apierrors.IsNotFound(err) {
+		t.Errorf("getting Ingress: got %v, want NotFound", err)
+	}
+}
+
+func TestIngressPGReconciler_CustomIngressClassController(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
+	const controller = "example.com/ingress"
+	mustUpdate(t, fc, "", "tailscale", func(ic *networkingv1.IngressClass) {
+		ic.Spec.Controller = controller
+	})
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+
+	// The IngressClass does not match the default controller name.
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	if _, err := ft.GetVIPService(t.Context(), "svc:my-svc"); !isErrorTailscaleServiceNotFound(err) {
+		t.Fatalf("getting Tailscale Service: got %v, want not found", err)
+	}
+
+	// It matches once the operator expects the customized controller name.
+	ingPGR.ingressClassController = controller
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTailscaleService(t, ft, "svc:my-svc", []string{"tcp:443"})
+}
+
+func TestIngressPGReconciler_ProxyGroupNotReadyRequeue(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
+	mustUpdateStatus(t, fc, "", "test-pg", func(pg *tsapi.ProxyGroup) {
+		pg.Status.Conditions = nil
+	})
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+
+	// By default, the Ingress is reconciled again when the ProxyGroup
+	// changes, rather than after a timeout.
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-ingress"}}
+	ingPGR.proxyGroupNotReadyRequeue = 42 * time.Second
+	res, err := ingPGR.Reconcile(t.Context(), req)
+	if err != nil {
+		t.Fatalf("Reconcile: unexpected error: %v", err)
+	}
+	if res.RequeueAfter != 42*time.Second {
+		t.Errorf("RequeueAfter = %v, want %v", res.RequeueAfter, 42*time.Second)
+	}
+
+	// The same applies if the ProxyGroup does not exist.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		ing.Annotations[AnnotationProxyGroup] = "missing-pg"
+	})
+	res, err = ingPGR.Reconcile(t.Context(), req)
+	if err != nil {
+		t.Fatalf("Reconcile: unexpected error: %v", err)
+	}
+	if res.RequeueAfter != 42*time.Second {
+		t.Errorf("RequeueAfter = %v, want %v", res.RequeueAfter, 42*time.Second)
+	}
+	if len(ft.vipServices) != 0 {
+		t.Errorf("Tailscale Services created for an Ingress without a ready ProxyGroup: %v", ft.vipServices)
+	}
+}
+
+func TestIngressPGReconciler_BackendProbe(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+	fr := record.NewFakeRecorder(10)
+	ingPGR.recorder = fr
+	var dialed []string
+	reachable := false
+	ingPGR.backendDialer = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		if !reachable {
+			return nil, errors.New("connection refused")
+		}
+		c1, c2 := net.Pipe()
+		c2.Close()
+		return c1, nil
+	}
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group":   "test-pg",
+				"tailscale.com/backend-probe": "true",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+	populateTLSSecret(t, fc, "test-pg", "my-svc.ts.net")
+	mustCreate(t, fc, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pg-0",
+			Namespace: "operator-ns",
+			Labels:    pgSecretLabels("test-pg", kubetypes.LabelSecretTypeState),
+		},
+		Data: map[string][]byte{
+			"_current-profile": []byte("profile-foo"),
+			"profile-foo":      []byte(`{"AdvertiseServices":["svc:my-svc"],"Config":{"NodeID":"node-foo"}}`),
+		},
+	})
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-ingress"}}
+	getStatus := func() []networkingv1.IngressLoadBalancerIngress {
+		t.Helper()
+		if err := fc.Get(t.Context(), client.ObjectKeyFromObject(ing), ing); err != nil {
+			t.Fatal(err)
+		}
+		return ing.Status.LoadBalancer.Ingress
+	}
+
+	// The backend is unreachable, so the Ingress is not marked ready and
+	// the probe is retried.
+	res, err := ingPGR.Reconcile(t.Context(), req)
+	if err != nil {
+		t.Fatalf("Reconcile: unexpected error: %v", err)
+	}
+	if res.RequeueAfter != backendProbeRetryInterval {
+		t.Errorf("RequeueAfter = %v, want %v", res.RequeueAfter, backendProbeRetryInterval)
+	}
+	if !slices.Equal(dialed, []string{"1.2.3.4:8080"}) {
+		t.Errorf("dialed %v, want [1.2.3.4:8080]", dialed)
+	}
+	var gotWarning bool
+	for len(fr.Events) > 0 {
+		if strings.HasPrefix(<-fr.Events, "Warning BackendUnreachable not updating Ingress status: backend 1.2.3.4:8080 for path \"/\" is unreachable: connection refused") {
+			gotWarning = true
+		}
+	}
+	if !gotWarning {
+		t.Error("no BackendUnreachable warning Event")
+	}
+	if st := getStatus(); st != nil {
+		t.Errorf("Ingress with unreachable backend marked ready: %v", st)
+	}
+
+	// Once the backend is reachable, the Ingress is marked ready.
+	reachable = true
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	wantStatus := []networkingv1.IngressLoadBalancerIngress{
+		{
+			Hostname: "my-svc.ts.net",
+			Ports:    []networkingv1.IngressPortStatus{{Port: 443, Protocol: "TCP"}},
+		},
+	}
+	if st := getStatus(); !reflect.DeepEqual(st, wantStatus) {
+		t.Errorf("incorrect Ingress status: got %v, want %v", st, wantStatus)
+	}
+
+	// Without the annotation, backends are not probed.
+	dialed = nil
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		delete(ing.Annotations, annotationBackendProbe)
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	if len(dialed) != 0 {
+		t.Errorf("backends probed without %s annotation: %v", annotationBackendProbe, dialed)
+	}
+}
+
+func TestIngressPGReconciler_ReadyGate(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+	fr := record.NewFakeRecorder(10)
+	ingPGR.recorder = fr
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+				"tailscale.com/ready-gate":  "configmap/app-config",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+	populateTLSSecret(t, fc, "test-pg", "my-svc.ts.net")
+	mustCreate(t, fc, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pg-0",
+			Namespace: "operator-ns",
+			Labels:    pgSecretLabels("test-pg", kubetypes.LabelSecretTypeState),
+		},
+		Data: map[string][]byte{
+			"_current-profile": []byte("profile-foo"),
+			"profile-foo":      []byte(`{"AdvertiseServices":["svc:my-svc"],"Config":{"NodeID":"node-foo"}}`),
+		},
+	})
+	getIngress := func() *networkingv1.Ingress {
+		t.Helper()
+		if err := fc.Get(t.Context(), client.ObjectKeyFromObject(ing), ing); err != nil {
+			t.Fatal(err)
+		}
+		return ing
+	}
+	waitingEvents := func() int {
+		var n int
+		for len(fr.Events) > 0 {
+			if strings.HasPrefix(<-fr.Events, "Normal WaitingForDependency ") {
+				n++
+			}
+		}
+		return n
+	}
+
+	// The tailscaled config is left as created by createPGResources until
+	// the Tailscale Service is first advertised.
+	expectConfigUnchanged := func() {
+		t.Helper()
+		expectEqual(t, fc, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pgConfigSecretName("test-pg", 0),
+				Namespace: "operator-ns",
+				Labels:    pgSecretLabels("test-pg", kubetypes.LabelSecretTypeConfig),
+			},
+			Data: map[string][]byte{
+				tsoperator.TailscaledConfigFileName(pgMinCapabilityVersion): []byte("{}"),
+			},
+		})
+	}
+
+	// The ConfigMap does not exist, so the Tailscale Service is not
+	// advertised and the Ingress is not marked ready.
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectConfigUnchanged()
+	if st := getIngress().Status.LoadBalancer.Ingress; st != nil {
+		t.Errorf("Ingress with missing ready gate marked ready: %v", st)
+	}
+	if got := ing.Annotations[annotationWaitingForDependency]; got != "configmap/app-config" {
+		t.Errorf("%s annotation = %q, want %q", annotationWaitingForDependency, got, "configmap/app-config")
+	}
+	var gotEvent bool
+	for len(fr.Events) > 0 {
+		if <-fr.Events == "Normal WaitingForDependency waiting for configmap/app-config in namespace operator-ns to exist before advertising the Tailscale Service" {
+			gotEvent = true
+		}
+	}
+	if !gotEvent {
+		t.Error("no WaitingForDependency Event")
+	}
+
+	// The Ingress stays not ready on subsequent reconciles, and the Event
+	// is not repeated.
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	if st := getIngress().Status.LoadBalancer.Ingress; st != nil {
+		t.Errorf("Ingress with missing ready gate marked ready: %v", st)
+	}
+	if n := waitingEvents(); n != 0 {
+		t.Errorf("got %d repeated WaitingForDependency Events, want none", n)
+	}
+
+	// A Secret of the same name does not satisfy the ready gate.
+	mustCreate(t, fc, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "operator-ns"},
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectConfigUnchanged()
+	if st := getIngress().Status.LoadBalancer.Ingress; st != nil {
+		t.Errorf("Ingress with missing ready gate marked ready: %v", st)
+	}
+
+	// Once the ConfigMap exists, the Tailscale Service is advertised and
+	// the Ingress is marked ready.
+	mustCreate(t, fc, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "operator-ns"},
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTailscaledConfig(t, fc, "test-pg", []string{"svc:my-svc"})
+	wantStatus := []networkingv1.IngressLoadBalancerIngress{
+		{
+			Hostname: "my-svc.ts.net",
+			Ports:    []networkingv1.IngressPortStatus{{Port: 443, Protocol: "TCP"}},
+		},
+	}
+	if st := getIngress().Status.LoadBalancer.Ingress; !reflect.DeepEqual(st, wantStatus) {
+		t.Errorf("incorrect Ingress status: got %v, want %v", st, wantStatus)
+	}
+	if _, ok := ing.Annotations[annotationWaitingForDependency]; ok {
+		t.Errorf("%s annotation not removed once the ready gate exists", annotationWaitingForDependency)
+	}
+
+	// If the ConfigMap is deleted, the Ingress is not ready again.
+	if err := fc.Delete(t.Context(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "operator-ns"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTailscaledConfig(t, fc, "test-pg", nil)
+	if st := getIngress().Status.LoadBalancer.Ingress; st != nil {
+		t.Errorf("Ingress with deleted ready gate still marked ready: %v", st)
+	}
+}
+
+func TestIngressPGReconciler_WaitingForDNS(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+	fr := record.NewFakeRecorder(10)
+	ingPGR.recorder = fr
+	lc := ingPGR.lc.(*fakeLocalClient)
+	lc.unresolvedNames = []string{"my-svc.ts.net"}
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+	mustCreate(t, fc, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pg-0",
+			Namespace: "operator-ns",
+			Labels:    pgSecretLabels("test-pg", kubetypes.LabelSecretTypeState),
+		},
+		Data: map[string][]byte{
+			"_current-profile": []byte("profile-foo"),
+			"profile-foo":      []byte(`{"AdvertiseServices":["svc:my-svc"],"Config":{"NodeID":"node-foo"}}`),
+		},
+	})
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-ingress"}}
+	getIngress := func() *networkingv1.Ingress {
+		t.Helper()
+		if err := fc.Get(t.Context(), client.ObjectKeyFromObject(ing), ing); err != nil {
+			t.Fatal(err)
+		}
+		return ing
+	}
+	waitingEvents := func() int {
+		var n int
+		for len(fr.Events) > 0 {
+			if strings.HasPrefix(<-fr.Events, "Normal WaitingForDNS ") {
+				n++
+			}
+		}
+		return n
+	}
+
+	// The replica advertises the Tailscale Service, but its DNS name does
+	// not resolve yet, so the cert cannot be issued. The Ingress waits for
+	// DNS and is reconciled again after the DNS propagation interval.
+	for range 2 {
+		res, err := ingPGR.Reconcile(t.Context(), req)
+		if err != nil {
+			t.Fatalf("Reconcile: unexpected error: %v", err)
+		}
+		if res.RequeueAfter != dnsPropagationRetryInterval {
+			t.Errorf("RequeueAfter = %v, want %v", res.RequeueAfter, dnsPropagationRetryInterval)
+		}
+	}
+	if got := getIngress().Annotations[annotationWaitingForDNS]; got != "my-svc.ts.net" {
+		t.Errorf("%s annotation = %q, want %q", annotationWaitingForDNS, got, "my-svc.ts.net")
+	}
+	// The Event is emitted once, not on every reconcile.
+	if n := waitingEvents(); n != 1 {
+		t.Errorf("got %d WaitingForDNS Events, want 1", n)
+	}
+
+	// Once the name resolves, the Ingress no longer waits for DNS, and is
+	// marked ready when the cert is issued.
+	lc.unresolvedNames = nil
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	if _, ok := getIngress().Annotations[annotationWaitingForDNS]; ok {
+		t.Errorf("%s annotation not removed once the name resolves", annotationWaitingForDNS)
+	}
+	populateTLSSecret(t, fc, "test-pg", "my-svc.ts.net")
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	wantStatus := []networkingv1.IngressLoadBalancerIngress{
+		{
+			Hostname: "my-svc.ts.net",
+			Ports:    []networkingv1.IngressPortStatus{{Port: 443, Protocol: "TCP"}},
+		},
+	}
+	if st := getIngress().Status.LoadBalancer.Ingress; !reflect.DeepEqual(st, wantStatus) {
+		t.Errorf("incorrect Ingress status: got %v, want %v", st, wantStatus)
+	}
+	if n := waitingEvents(); n != 0 {
+		t.Errorf("got %d WaitingForDNS Events after the name resolved, want none", n)
+	}
+}
+
+func TestIngressPGReconciler_BackendSelector(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+	fr := record.NewFakeRecorder(10)
+	ingPGR.recorder = fr
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group":      "test-pg",
+				"tailscale.com/backend-selector": "app=web",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	pod := func(name, ip, app string, ready bool) *corev1.Pod {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{"app": app},
+			},
+			Status: corev1.PodStatus{
+				PodIP:      ip,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+			},
+		}
+	}
+	mustCreate(t, fc, pod("web-b", "10.1.0.3", "web", true))
+	mustCreate(t, fc, pod("web-a", "10.1.0.2", "web", true))
+	mustCreate(t, fc, pod("web-c", "10.1.0.4", "web", false))
+	mustCreate(t, fc, pod("db", "10.1.0.5", "db", true))
+	mustCreate(t, fc, ing)
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-ingress"}}
+	reconcileAndGetHandler := func() *ipn.HTTPHandler {
+		t.Helper()
+		res, err := ingPGR.Reconcile(t.Context(), req)
+		if err != nil {
+			t.Fatalf("Reconcile: unexpected error: %v", err)
+		}
+		// Pods are not watched, so the Ingress is reconciled periodically.
+		if res.RequeueAfter != backendSelectorResyncInterval {
+			t.Errorf("RequeueAfter = %v, want %v", res.RequeueAfter, backendSelectorResyncInterval)
+		}
+		_, cfg, err := ingPGR.proxyGroupServeConfig(t.Context(), "test-pg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		svc := cfg.Services["svc:my-svc"]
+		if svc == nil {
+			t.Fatal("serve config does not contain Tailscale Service svc:my-svc")
+		}
+		return svc.Web["my-svc.ts.net:443"].Handlers["/"]
+	}
+
+	// Requests are load balanced across the ready Pods matching the
+	// selector, in a stable order.
+	want := &ipn.HTTPHandler{
+		Proxy:        "http://10.1.0.2:8080/",
+		ProxyTargets: []string{"http://10.1.0.3:8080/"},
+	}
+	if diff := cmp.Diff(want, reconcileAndGetHandler()); diff != "" {
+		t.Errorf("unexpected handler (-want +got):\n%s", diff)
+	}
+
+	// A Pod that becomes ready is added on the next reconcile.
+	mustUpdateStatus(t, fc, "default", "web-c", func(p *corev1.Pod) {
+		p.Status.Conditions[0].Status = corev1.ConditionTrue
+	})
+	want.ProxyTargets = append(want.ProxyTargets, "http://10.1.0.4:8080/")
+	if diff := cmp.Diff(want, reconcileAndGetHandler()); diff != "" {
+		t.Errorf("unexpected handler (-want +got):\n%s", diff)
+	}
+
+	// If no Pods match, the path is not proxied and a warning is emitted.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		ing.Annotations["tailscale.com/backend-selector"] = "app=none"
+	})
+	if h := reconcileAndGetHandler(); h != nil {
+		t.Errorf("got handler %+v for path with no matching Pods, want none", h)
+	}
+	var found bool
+	for len(fr.Events) > 0 {
+		if strings.HasPrefix(<-fr.Events, "Warning "+reasonNoBackendPods+" ") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("no %s Event emitted", reasonNoBackendPods)
+	}
+}
+
+func TestIngressPGReconciler_ServiceComment(t *testing.T) {
+	tests := []struct {
+		name         string
+		description  string
+		noProvenance bool
+		want         string
+	}{
+		{
+			name: "provenance",
+			want: managedTSServiceComment + "\nSource: Ingress default/test-ingress in cluster prod-eu",
+		},
+		{
+			name:        "description_and_provenance",
+			description: "Internal wiki",
+			want:        "Internal wiki\n\n" + managedTSServiceComment + "\nSource: Ingress default/test-ingress in cluster prod-eu",
+		},
+		{
+			name:         "no_provenance",
+			description:  "Internal wiki",
+			noProvenance: true,
+			want:         "Internal wiki\n\n" + managedTSServiceComment,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingPGR, fc, ft := setupIngressTest(t)
+			ingPGR.clusterName = "prod-eu"
+			ingPGR.noServiceProvenance = tt.noProvenance
+
+			ing := &networkingv1.Ingress{
+				TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-ingress",
+					Namespace: "default",
+					UID:       types.UID("1234-UID"),
+					Annotations: map[string]string{
+						"tailscale.com/proxy-group": "test-pg",
+					},
+				},
+				Spec: networkingv1.IngressSpec{
+					IngressClassName: ptr.To("tailscale"),
+					DefaultBackend: &networkingv1.IngressBackend{
+						Service: &networkingv1.IngressServiceBackend{
+							Name: "test",
+							Port: networkingv1.ServiceBackendPort{
+								Number: 8080,
+							},
+						},
+					},
+					TLS: []networkingv1.IngressTLS{
+						{Hosts: []string{"my-svc"}},
+					},
+				},
+			}
+			if tt.description != "" {
+				ing.Annotations["tailscale.com/service-description"] = tt.description
+			}
+			mustCreate(t, fc, service())
+			mustCreate(t, fc, ing)
+			expectReconciled(t, ingPGR, "default", "test-ingress")
+
+			tsSvc, err := ft.GetVIPService(t.Context(), "svc:my-svc")
+			if err != nil {
+				t.Fatalf("getting Tailscale Service: %v", err)
+			}
+			if tsSvc.Comment != tt.want {
+				t.Errorf("Tailscale Service comment = %q, want %q", tsSvc.Comment, tt.want)
+			}
+		})
+	}
+}
+
+func TestHAIngressesFromReadyGate(t *testing.T) {
+	ing := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+				"tailscale.com/ready-gate":  "configmap/app-config",
+			},
+		},
+	}
+	fc := fake.NewClientBuilder().
+		WithScheme(tsapi.GlobalScheme).
+		WithInterceptorFuncs(interceptor.Funcs{Patch: applyAsMergePatch}).
+		WithObjects(ing).
+		WithIndex(new(networkingv1.Ingress), indexIngressTLSSecret, indexTLSSecretIngresses(annotationDomain)).
+		WithIndex(new(networkingv1.Ingress), indexIngressProxyGroup, indexPGIngresses(annotationDomain)).
+		WithIndex(new(networkingv1.Ingress), indexIngressReadyGate, indexReadyGateIngresses(annotationDomain)).
+		Build()
+	want := []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-ingress"}}}
+
+	// Events on the ConfigMap trigger a reconcile of the Ingress.
+	cmHandler := HAIngressesFromServeConfig(fc, zap.NewNop().Sugar())
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "operator-ns"}}
+	if got := cmHandler(t.Context(), cm); !reflect.DeepEqual(got, want) {
+		t.Errorf("ConfigMap handler = %v, want %v", got, want)
+	}
+	// Events on a Secret of the same name do not.
+	secretHandler := HAIngressesFromSecret(fc, zap.NewNop().Sugar())
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "operator-ns"}}
+	if got := secretHandler(t.Context(), secret); len(got) != 0 {
+		t.Errorf("Secret handler = %v, want none", got)
+	}
+
+	// Unless the ready gate is a Secret.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		ing.Annotations["tailscale.com/ready-gate"] = "secret/app-config"
+	})
+	if got := secretHandler(t.Context(), secret); !reflect.DeepEqual(got, want) {
+		t.Errorf("Secret handler = %v, want %v", got, want)
+	}
+	if got := cmHandler(t.Context(), cm); len(got) != 0 {
+		t.Errorf("ConfigMap handler = %v, want none", got)
+	}
+}
+
+func TestIngressPGReconciler_PortAliases(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group":  "test-pg",
+				"tailscale.com/port-aliases": "8443",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	populateTLSSecret(t, fc, "test-pg", "my-svc.ts.net")
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+
+	// The Tailscale Service has both the primary and the alias port, and the
+	// alias port is served like the primary one.
+	verifyTailscaleService(t, ft, "svc:my-svc", []string{"tcp:443", "tcp:8443"})
+	_, cfg, err := ingPGR.proxyGroupServeConfig(t.Context(), "test-pg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := cfg.Services["svc:my-svc"]
+	if svc == nil {
+		t.Fatal("serve config does not contain Tailscale Service svc:my-svc")
+	}
+	if got := slices.Sorted(maps.Keys(svc.TCP)); !slices.Equal(got, []uint16{443, 8443}) {
+		t.Errorf("serve config TCP ports = %v, want [443 8443]", got)
+	}
+	if !reflect.DeepEqual(svc.TCP[8443], svc.TCP[443]) {
+		t.Errorf("TCP handler for port 8443 = %+v, want the same as for port 443: %+v", svc.TCP[8443], svc.TCP[443])
+	}
+	primary, alias := svc.Web["my-svc.ts.net:443"], svc.Web["my-svc.ts.net:8443"]
+	if primary == nil || alias == nil {
+		t.Fatalf("serve config web endpoints = %v, want my-svc.ts.net:443 and my-svc.ts.net:8443", slices.Sorted(maps.Keys(svc.Web)))
+	}
+	if !reflect.DeepEqual(alias, primary) {
+		t.Errorf("web config for port 8443 = %+v, want the same as for port 443: %+v", alias, primary)
+	}
+	if got := primary.Handlers["/"].Proxy; got != "http://1.2.3.4:8080/" {
+		t.Errorf("handler proxies to %q, want %q", got, "http://1.2.3.4:8080/")
+	}
+
+	// Removing the annotation removes the alias port.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		delete(ing.Annotations, "tailscale.com/port-aliases")
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTailscaleService(t, ft, "svc:my-svc", []string{"tcp:443"})
+	verifyServeConfig(t, fc, "svc:my-svc", false)
+}
+
+func TestIngressPGReconciler_MaxConnections(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group":     "test-pg",
+				"tailscale.com/max-connections": "50",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+
+	expectMaxConns := func(t *testing.T, want int) {
+		t.Helper()
+		h := serveConfigHandler(t, fc, "my-svc.ts.net:443", "/")
+		if h.MaxConns != want {
+			t.Errorf("serve config MaxConns = %d, want %d", h.MaxConns, want)
+		}
+	}
+
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectMaxConns(t, 50)
+
+	// Removing the annotation removes the limit.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		delete(ing.Annotations, annotationMaxConnections)
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectMaxConns(t, 0)
+
+	// A limit is not applied while a replica is too old to enforce it.
+	mustCreate(t, fc, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pg-0", Namespace: "operator-ns", UID: "pod-uid"},
+	})
+	mustCreate(t, fc, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-pg-0",
+			Namespace: "operator-ns",
+			Labels:    pgSecretLabels("test-pg", kubetypes.LabelSecretTypeState),
+		},
+		Data: map[string][]byte{
+			kubetypes.KeyCapVer: []byte("131"),
+			kubetypes.KeyPodUID: []byte("pod-uid"),
+		},
+	})
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		mak.Set(&ing.Annotations, annotationMaxConnections, "20")
+	})
+	fr := ingPGR.recorder.(*record.FakeRecorder)
+	for len(fr.Events) > 0 {
+		<-fr.Events
+	}
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectMaxConns(t, 0)
+	expectEvents(t, fr, []string{
+		"Warning ProxyTooOld the Ingress requires proxies of capability version 132 or newer, but a proxy has capability version 131; upgrade the proxy image",
+	})
+
+	// It is applied once the replica is upgraded.
+	mustUpdate(t, fc, "operator-ns", "test-pg-0", func(s *corev1.Secret) {
+		s.Data[kubetypes.KeyCapVer] = []byte("132")
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectMaxConns(t, 20)
+}
+
+func TestIngressPGReconciler_BackendRetries(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group":     "test-pg",
+				"tailscale.com/backend-retries": "3:503, 429",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+
+	expectRetries := func(t *testing.T, wantRetries int, wantCodes []int) {
+		t.Helper()
+		h := serveConfigHandler(t, fc, "my-svc.ts.net:443", "/")
+		if h.BackendRetries != wantRetries || !slices.Equal(h.RetryStatusCodes, wantCodes) {
+			t.Errorf("serve config BackendRetries, RetryStatusCodes = %d, %v; want %d, %v", h.BackendRetries, h.RetryStatusCodes, wantRetries, wantCodes)
+		}
+	}
+
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectRetries(t, 3, []int{503, 429})
+
+	// Without status codes, the proxies' defaults are used.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		ing.Annotations[annotationBackendRetries] = "2"
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectRetries(t, 2, nil)
+
+	// Removing the annotation removes the retry configuration.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		delete(ing.Annotations, annotationBackendRetries)
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectRetries(t, 0, nil)
+}
+
+func TestIngressPGReconciler_CreateGracePeriod(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
+	cl := tstest.NewClock(tstest.ClockOpts{})
+	ingPGR.clock = cl
+	ingPGR.createGracePeriod = time.Minute
+
+	ingress := func(name, host string) *networkingv1.Ingress {
+		return &networkingv1.Ingress{
+			TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				UID:       types.UID(name + "-UID"),
+				Annotations: map[string]string{
+					"tailscale.com/proxy-group": "test-pg",
+				},
+			},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: ptr.To("tailscale"),
+				DefaultBackend: &networkingv1.IngressBackend{
+					Service: &networkingv1.IngressServiceBackend{
+						Name: "test",
+						Port: networkingv1.ServiceBackendPort{
+							Number: 8080,
+						},
+					},
+				},
+				TLS: []networkingv1.IngressTLS{
+					{Hosts: []string{host}},
+				},
+			},
+		}
+	}
+	expectNoVIPService := func(t *testing.T, name tailcfg.ServiceName) {
+		t.Helper()
+		if _, err := ft.GetVIPService(context.Background(), name); !isErrorTailscaleServiceNotFound(err) {
+			t.Fatalf("GetVIPService(%q) error = %v, want not found", name, err)
+		}
+	}
+	mustCreate(t, fc, service())
+
+	// An Ingress deleted within the grace period never gets a Tailscale
+	// Service.
+	mustCreate(t, fc, ingress("short-lived", "short-lived"))
+	expectRequeue(t, ingPGR, "default", "short-lived")
+	expectNoVIPService(t, "svc:short-lived")
+	ing := &networkingv1.Ingress{}
+	if err := fc.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "short-lived"}, ing); err != nil {
+		t.Fatal(err)
+	}
+	if len(ing.Finalizers) != 0 {
+		t.Fatalf("Ingress has finalizers %v during the grace period, want none", ing.Finalizers)
+	}
+	if err := fc.Delete(context.Background(), ing); err != nil {
+		t.Fatal(err)
+	}
+	cl.Advance(2 * time.Minute)
+	expectReconciled(t, ingPGR, "default", "short-lived")
+	expectNoVIPService(t, "svc:short-lived")
+	if n := len(ingPGR.pendingCreates); n != 0 {
+		t.Errorf("%d pending creates after the Ingress was deleted, want 0", n)
+	}
+
+	// An Ingress that moves to another IngressClass within the grace
+	// period is no longer tracked, and never gets a Tailscale Service.
+	mustCreate(t, fc, ingress("switched", "switched"))
+	expectRequeue(t, ingPGR, "default", "switched")
+	mustUpdate(t, fc, "default", "switched", func(ing *networkingv1.Ingress) {
+		ing.Spec.IngressClassName = ptr.To("nginx")
+	})
+	expectReconciled(t, ingPGR, "default", "switched")
+	if n := len(ingPGR.pendingCreates); n != 0 {
+		t.Errorf("%d pending creates after the Ingress moved to another IngressClass, want 0", n)
+	}
+	cl.Advance(2 * time.Minute)
+	expectReconciled(t, ingPGR, "default", "switched")
+	expectNoVIPService(t, "svc:switched")
+
+	// An update restarts the grace period, and the Tailscale Service is
+	// created once it elapses.
+	mustCreate(t, fc, ingress("test-ingress", "my-svc"))
+	expectRequeue(t, ingPGR, "default", "test-ingress")
+	cl.Advance(30 * time.Second)
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		ing.Labels = map[string]string{"foo": "bar"}
+	})
+	expectRequeue(t, ingPGR, "default", "test-ingress")
+	cl.Advance(45 * time.Second)
+	expectRequeue(t, ingPGR, "default", "test-ingress")
+	expectNoVIPService(t, "svc:my-svc")
+	cl.Advance(15 * time.Second)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	if _, err := ft.GetVIPService(context.Background(), "svc:my-svc"); err != nil {
+		t.Fatalf("GetVIPService after the grace period: %v", err)
+	}
+	if n := len(ingPGR.pendingCreates); n != 0 {
+		t.Errorf("%d pending creates after the Tailscale Service was created, want 0", n)
+	}
+}
+
+func TestIngressPGReconciler_TCPKeepAlive(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group":            "test-pg",
+				"tailscale.com/http-endpoint":          "enabled",
+				"tailscale.com/tcp-keepalive-idle":     "2m",
+				"tailscale.com/tcp-keepalive-interval": "15s",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+
+	expectKeepAlive := func(t *testing.T, wantIdle, wantInterval time.Duration) {
+		t.Helper()
+		_, cfg, err := ingPGR.proxyGroupServeConfig(t.Context(), "test-pg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		svc := cfg.Services["svc:my-svc"]
+		if svc == nil {
+			t.Fatal("Tailscale Service not found in serve config")
+		}
+		for _, port := range []uint16{443, 80} {
+			h := svc.TCP[port]
+			if h == nil {
+				t.Fatalf("no TCP handler for port %d", port)
+			}
+			if h.KeepAliveIdle != wantIdle || h.KeepAliveInterval != wantInterval {
+				t.Errorf("port %d: keep-alive idle %v, interval %v; want %v, %v", port, h.KeepAliveIdle, h.KeepAliveInterval, wantIdle, wantInterval)
+			}
+		}
+	}
+
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectKeepAlive(t, 2*time.Minute, 15*time.Second)
+
+	// Removing the annotations reverts to the system defaults.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		delete(ing.Annotations, annotationTCPKeepAliveIdle)
+		delete(ing.Annotations, annotationTCPKeepAliveInterval)
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectKeepAlive(t, 0, 0)
+}
+
+func TestIngressPGReconciler_ClientHTTPVersion(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group":         "test-pg",
+				"tailscale.com/client-http-version": "http1",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+
+	expectHTTP1Only := func(t *testing.T, want bool) {
+		t.Helper()
+		_, cfg, err := ingPGR.proxyGroupServeConfig(t.Context(), "test-pg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		svc := cfg.Services["svc:my-svc"]
+		if svc == nil {
+			t.Fatal("Tailscale Service not found in serve config")
+		}
+		h := svc.TCP[443]
+		if h == nil {
+			t.Fatal("no TCP handler for port 443")
+		}
+		if h.HTTP1Only != want {
+			t.Errorf("HTTP1Only = %v, want %v", h.HTTP1Only, want)
+		}
+	}
+
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectHTTP1Only(t, true)
+
+	// h2 offers HTTP/2 to clients again.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		ing.Annotations[annotationClientHTTPVersion] = clientHTTPVersionH2
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectHTTP1Only(t, false)
+}
+
+func TestIngressPGReconciler_ConsolidatedCertSecrets(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+	ingPGR.consolidateCertSecrets = true
+
+	newIngress := func(name, host string) *networkingv1.Ingress {
+		return &networkingv1.Ingress{
+			TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				UID:       types.UID(name + "-UID"),
+				Annotations: map[string]string{
+					"tailscale.com/proxy-group": "test-pg",
+				},
+			},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: ptr.To("tailscale"),
+				DefaultBackend: &networkingv1.IngressBackend{
+					Service: &networkingv1.IngressServiceBackend{
+						Name: "test",
+						Port: networkingv1.ServiceBackendPort{
+							Number: 8080,
+						},
+					},
+				},
+				TLS: []networkingv1.IngressTLS{
+					{Hosts: []string{host}},
+				},
+			},
+		}
+	}
+	mustCreate(t, fc, service())
+	// The first domain has a per-domain Secret from before cert Secrets were
+	// consolidated, whose cert is carried over.
+	populateTLSSecret(t, fc, "test-pg", "my-svc.ts.net")
+	ing := newIngress("test-ingress", "my-svc")
+	mustCreate(t, fc, ing)
+	ing2 := newIngress("my-other-ingress", "my-other-svc")
+	mustCreate(t, fc, ing2)
+
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectReconciled(t, ingPGR, "default", "my-other-ingress")
+
+	secret := consolidatedCertSecret("test-pg", "operator-ns")
+	secret.Data = map[string][]byte{
+		"my-svc.ts.net.crt":       []byte("fake-cert"),
+		"my-svc.ts.net.key":       []byte("fake-key"),
+		"my-other-svc.ts.net.crt": nil,
+		"my-other-svc.ts.net.key": nil,
+	}
+	expectEqual(t, fc, secret)
+	expectMissing[corev1.Secret](t, fc, "operator-ns", "my-svc.ts.net")
+	expectMissing[corev1.Secret](t, fc, "operator-ns", "my-other-svc.ts.net")
+	for _, domain := range []string{"my-svc.ts.net", "my-other-svc.ts.net"} {
+		role := certSecretRole("test-pg", "operator-ns", domain)
+		role.Rules[0].ResourceNames = []string{"test-pg-certs"}
+		expectEqual(t, fc, role)
+	}
+
+	// The cert is reported as issued from the consolidated Secret.
+	has, err := hasCerts(t.Context(), fc, ingPGR.lc, "operator-ns", "test-pg", "svc:my-svc")
+	if err != nil || !has {
+		t.Errorf("hasCerts(svc:my-svc) = %v, %v; want true, nil", has, err)
+	}
+
+	// Deleting an Ingress removes its domain from the Secret.
+	if err := fc.Delete(t.Context(), ing2); err != nil {
+		t.Fatalf("deleting Ingress: %v", err)
+	}
+	expectReconciled(t, ingPGR, "default", "my-other-ingress")
+	delete(secret.Data, "my-other-svc.ts.net.crt")
+	delete(secret.Data, "my-other-svc.ts.net.key")
+	expectEqual(t, fc, secret)
+
+	// Without consolidation, the cert is moved back to a per-domain Secret.
+	ingPGR.consolidateCertSecrets = false
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectMissing[corev1.Secret](t, fc, "operator-ns", "test-pg-certs")
+	perDomain := certSecret("test-pg", "operator-ns", "my-svc.ts.net", ing)
+	perDomain.Data = map[string][]byte{
+		corev1.TLSCertKey:       []byte("fake-cert"),
+		corev1.TLSPrivateKeyKey: []byte("fake-key"),
+	}
+	expectEqual(t, fc, perDomain)
+}
+
+func TestIngressPGReconciler_BackendDialFamily(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group":         "test-pg",
+				"tailscale.com/backend-dial-family": "ipv4",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	// A dual-stack Service whose primary IP family is IPv6.
+	svc := service()
+	svc.Spec.ClusterIP = "fd00::1"
+	svc.Spec.ClusterIPs = []string{"fd00::1", "10.0.0.1"}
+	mustCreate(t, fc, svc)
+	mustCreate(t, fc, ing)
+
+	expectProxy := func(t *testing.T, want string) {
+		t.Helper()
+		_, cfg, err := ingPGR.proxyGroupServeConfig(t.Context(), "test-pg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		svc := cfg.Services["svc:my-svc"]
+		if svc == nil {
+			t.Fatal("Tailscale Service not found in serve config")
+		}
+		web := svc.Web["my-svc.ts.net:443"]
+		if web == nil || web.Handlers["/"] == nil {
+			t.Fatal("no handler for / in serve config")
+		}
+		if got := web.Handlers["/"].Proxy; got != want {
+			t.Errorf("Proxy = %q, want %q", got, want)
+		}
+	}
+
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectProxy(t, "http://10.0.0.1:8080/")
+
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		ing.Annotations[annotationBackendDialFamily] = backendDialFamilyIPv6
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectProxy(t, "http://[fd00::1]:8080/")
+
+	// Without the annotation, the primary ClusterIP is used.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		delete(ing.Annotations, annotationBackendDialFamily)
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectProxy(t, "http://[fd00::1]:8080/")
+}
+
+func TestIngressPGReconciler_HTTPBackend(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group":   "test-pg",
+				"tailscale.com/http-endpoint": "enabled",
+				"tailscale.com/http-backend":  "acme:http",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "acme",
+			Namespace: "default",
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: "5.6.7.8",
+			Ports: []corev1.ServicePort{{
+				Port: 8089,
+				Name: "http",
+			}},
+		},
+	})
+	mustCreate(t, fc, ing)
+
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	if h := serveConfigHandler(t, fc, "my-svc.ts.net:443", "/"); h.Proxy != "http://1.2.3.4:8080/" {
+		t.Errorf("HTTPS proxy = %q, want %q", h.Proxy, "http://1.2.3.4:8080/")
+	}
+	if h := serveConfigHandler(t, fc, "my-svc.ts.net:80", "/"); h.Proxy != "http://5.6.7.8:8089/" {
+		t.Errorf("HTTP proxy = %q, want %q", h.Proxy, "http://5.6.7.8:8089/")
+	}
+
+	// Changes to the HTTP backend Service enqueue the Ingress.
+	acme := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "acme"}}
+	reqs := serviceHandlerForIngressPG(fc, ingPGR.logger, "tailscale", annotationDomain)(t.Context(), acme)
+	wantReqs := []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-ingress"}}}
+	if diff := cmp.Diff(wantReqs, reqs); diff != "" {
+		t.Errorf("unexpected reconcile requests for HTTP backend Service change (-want +got):\n%s", diff)
+	}
+
+	// Without the annotation, both endpoints are served from the same
+	// backend.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		delete(ing.Annotations, annotationHTTPBackend)
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	if h := serveConfigHandler(t, fc, "my-svc.ts.net:80", "/"); h.Proxy != "http://1.2.3.4:8080/" {
+		t.Errorf("HTTP proxy = %q, want %q", h.Proxy, "http://1.2.3.4:8080/")
+	}
+}
+
+func TestIngressPGReconciler_ServiceNameStrategy(t *testing.T) {
+	tests := []struct {
+		strategy string
+		tlsHost  string
+		want     tailcfg.ServiceName
+	}{
+		{strategy: "", want: "svc:default-test-ingress-ingress"},
+		{strategy: "default", want: "svc:default-test-ingress-ingress"},
+		{strategy: "strip-suffix", want: "svc:default-test-ingress"},
+		{strategy: "hash", want: "svc:ingress-936ba8489e4b3b47"},
+		{strategy: "prefix:team-a", want: "svc:team-a-test-ingress"},
+		{strategy: "prefix:team-a", tlsHost: "my-svc", want: "svc:my-svc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy+"/"+tt.tlsHost, func(t *testing.T) {
+			ingPGR, fc, ft := setupIngressTest(t)
+			s, err := parseServiceNameStrategy(tt.strategy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ingPGR.serviceNameStrategy = s
+
+			ing := &networkingv1.Ingress{
+				TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-ingress",
+					Namespace: "default",
+					UID:       types.UID("1234-UID"),
+					Annotations: map[string]string{
+						"tailscale.com/proxy-group": "test-pg",
+					},
+				},
+				Spec: networkingv1.IngressSpec{
+					IngressClassName: ptr.To("tailscale"),
+					DefaultBackend: &networkingv1.IngressBackend{
+						Service: &networkingv1.IngressServiceBackend{
+							Name: "test",
+							Port: networkingv1.ServiceBackendPort{
+								Number: 8080,
+							},
+						},
+					},
+				},
+			}
+			if tt.tlsHost != "" {
+				ing.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{tt.tlsHost}}}
+			}
+			mustCreate(t, fc, service())
+			mustCreate(t, fc, ing)
+
+			// Reconcile twice to check that the name is stable.
+			expectReconciled(t, ingPGR, "default", "test-ingress")
+			expectReconciled(t, ingPGR, "default", "test-ingress")
+			var got []tailcfg.ServiceName
+			for name := range ft.vipServices {
+				got = append(got, name)
+			}
+			if diff := cmp.Diff([]tailcfg.ServiceName{tt.want}, got); diff != "" {
+				t.Errorf("unexpected Tailscale Services (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIngressPGReconciler_HostnameTemplate(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
+	tmpl, err := parseHostnameTemplate("{{.Namespace}}-{{.Labels.team}}-{{.Name}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ingPGR.serviceNameStrategy = serviceNameStrategy{hostnameTemplate: tmpl}
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Labels:    map[string]string{"team": "infra"},
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTailscaleService(t, ft, "svc:default-infra-test-ingress", []string{"tcp:443"})
+	verifyServeConfig(t, fc, "svc:default-infra-test-ingress", false)
+
+	// A host in the TLS block takes precedence over the template.
+	pg := &tsapi.ProxyGroup{}
+	if err := fc.Get(t.Context(), types.NamespacedName{Name: "test-pg"}, pg); err != nil {
+		t.Fatal(err)
+	}
+	withTLS := ing.DeepCopy()
+	withTLS.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{"my-svc"}}}
+	if got := ingPGR.serviceNameStrategy.hostnameForIngress(withTLS); got != "my-svc" {
+		t.Errorf("hostnameForIngress with TLS host = %q, want %q", got, "my-svc")
+	}
+
+	// Ingresses that the template can not be rendered for, or renders an
+	// invalid hostname for, are rejected.
+	noLabel := ing.DeepCopy()
+	noLabel.Labels = nil
+	if err := ingPGR.validateIngress(t.Context(), noLabel, pg); err == nil || !strings.Contains(err.Error(), "error rendering hostname template for Ingress") {
+		t.Errorf("validateIngress without label: got error %v, want template error", err)
+	}
+	badLabel := ing.DeepCopy()
+	badLabel.Labels["team"] = "Not_A_Label"
+	if err := ingPGR.validateIngress(t.Context(), badLabel, pg); err == nil || !strings.Contains(err.Error(), `invalid hostname "default-Not_A_Label-test-ingress"`) {
+		t.Errorf("validateIngress with invalid label: got error %v, want invalid hostname error", err)
+	}
+}
+
+func TestParseServiceNameStrategy(t *testing.T) {
+	for _, s := range []string{"unknown", "prefix:", "prefix:Not_A_Label", "strip-suffix:x"} {
+		if _, err := parseServiceNameStrategy(s); err == nil {
+			t.Errorf("parseServiceNameStrategy(%q) succeeded, want error", s)
+		}
+	}
+	if _, err := parseHostnameTemplate("{{.Name"); err == nil {
+		t.Errorf("parseHostnameTemplate succeeded for unterminated action, want error")
+	}
+}
+
+func TestIngressPGReconciler_BackendServicePortChange(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Name: "http",
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	if got, want := serveConfigHandler(t, fc, "my-svc.ts.net:443", "/").Proxy, "http://1.2.3.4:8080/"; got != want {
+		t.Fatalf("proxy target = %q, want %q", got, want)
+	}
+
+	// Renumber the backend Service's port.
+	mustUpdate(t, fc, "default", "test", func(svc *corev1.Service) {
+		svc.Spec.Ports[0].Port = 9090
+	})
+
+	// The Service change enqueues the Ingress ...
+	svc := &corev1.Service{}
+	if err := fc.Get(t.Context(), types.NamespacedName{Namespace: "default", Name: "test"}, svc); err != nil {
+		t.Fatal(err)
+	}
+	reqs := serviceHandlerForIngressPG(fc, ingPGR.logger, "tailscale", annotationDomain)(t.Context(), svc)
+	wantReqs := []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-ingress"}}}
+	if diff := cmp.Diff(wantReqs, reqs); diff != "" {
+		t.Fatalf("unexpected reconcile requests for backend Service change (-want +got):\n%s", diff)
+	}
+
+	// ... and reconciling it updates the proxy target.
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	if got, want := serveConfigHandler(t, fc, "my-svc.ts.net:443", "/").Proxy, "http://1.2.3.4:9090/"; got != want {
+		t.Errorf("proxy target after port change = %q, want %q", got, want)
+	}
+
+	// Rename the backend Service's port, so that the Ingress refers to a
+	// port that no longer exists.
+	fr := record.NewFakeRecorder(10)
+	ingPGR.recorder = fr
+	mustUpdate(t, fc, "default", "test", func(svc *corev1.Service) {
+		svc.Spec.Ports[0].Name = "web"
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectEvents(t, fr, []string{`Warning BackendPortMissing backend for path "/" refers to port "http", which Service "test" does not have; update the Ingress to use one of the Service's named ports ["web"]`})
+
+	// The previously resolved port is no longer served.
+	cm := &corev1.ConfigMap{}
+	if err := fc.Get(t.Context(), types.NamespacedName{Namespace: "operator-ns", Name: "test-pg-ingress-config"}, cm); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ipn.ServeConfig{}
+	if err := json.Unmarshal(cm.BinaryData[serveConfigKey], cfg); err != nil {
+		t.Fatal(err)
+	}
+	if web := cfg.Services["svc:my-svc"].Web["my-svc.ts.net:443"]; web != nil && web.Handlers["/"] != nil {
+		t.Errorf("handler for renamed port still in serve config: %+v", web.Handlers["/"])
+	}
+}
+
+func TestIngressPGReconciler_ResponseHeaders(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group":      "test-pg",
+				"tailscale.com/response-headers": `{"Cache-Control":"no-store","X-Powered-By":""}`,
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			Rules: []networkingv1.IngressRule{{
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     "/api",
+						PathType: ptr.To(networkingv1.PathTypePrefix),
+						Backend: networkingv1.IngressBackend{
+							Service: &networkingv1.IngressServiceBackend{
+								Name: "test",
+								Port: networkingv1.ServiceBackendPort{Number: 8080},
+							},
+						},
+					}},
+				}},
+			}},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+
+	want := map[string]string{"Cache-Control": "no-store", "X-Powered-By": ""}
+	h := serveConfigHandler(t, fc, "my-svc.ts.net:443", "/api")
+	if diff := cmp.Diff(want, h.ResponseHeaders); diff != "" {
+		t.Errorf("unexpected response headers (-want +got):\n%s", diff)
+	}
+
+	// Removing the annotation removes the headers.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		delete(ing.Annotations, annotationResponseHeaders)
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	if h := serveConfigHandler(t, fc, "my-svc.ts.net:443", "/api"); h.ResponseHeaders != nil {
+		t.Errorf("response headers = %v, want none", h.ResponseHeaders)
+	}
+}
+
+func TestIngressPGReconciler_AccessLog(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group":   "test-pg",
+				"tailscale.com/access-log":    "true",
+				"tailscale.com/http-endpoint": "enabled",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{Number: 8080},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+
+	// Access logs are enabled for both the HTTPS and HTTP endpoints.
+	for _, host := range []ipn.HostPort{"my-svc.ts.net:443", "my-svc.ts.net:80"} {
+		if !serveConfigWeb(t, fc, host).AccessLog {
+			t.Errorf("access log not enabled for %s", host)
+		}
+	}
+
+	// Removing the annotation disables them.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		delete(ing.Annotations, annotationAccessLog)
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	for _, host := range []ipn.HostPort{"my-svc.ts.net:443", "my-svc.ts.net:80"} {
+		if serveConfigWeb(t, fc, host).AccessLog {
+			t.Errorf("access log enabled for %s after removing the annotation", host)
+		}
+	}
+}
+
+// serveConfigHandler returns the handler for mount on host in the
+// svc:my-svc serve config of the test-pg ProxyGroup.
+func serveConfigHandler(t *testing.T, fc client.Client, host ipn.HostPort, mount string) *ipn.HTTPHandler {
+	t.Helper()
+	web := serveConfigWeb(t, fc, host)
+	if web.Handlers[mount] == nil {
+		t.Fatalf("no handler for %s%s in serve config", host, mount)
+	}
+	return web.Handlers[mount]
+}
+
+// serveConfigWeb returns the web server config for host in the svc:my-svc
+// serve config of the test-pg ProxyGroup.
+func serveConfigWeb(t *testing.T, fc client.Client, host ipn.HostPort) *ipn.WebServerConfig {
+	t.Helper()
+	cm := &corev1.ConfigMap{}
+	if err := fc.Get(t.Context(), types.NamespacedName{Namespace: "operator-ns", Name: "test-pg-ingress-config"}, cm); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ipn.ServeConfig{}
+	if err := json.Unmarshal(cm.BinaryData[serveConfigKey], cfg); err != nil {
+		t.Fatal(err)
+	}
+	svc := cfg.Services["svc:my-svc"]
+	if svc == nil {
+		t.Fatal("Tailscale Service svc:my-svc not found in serve config")
+	}
+	web := svc.Web[host]
+	if web == nil {
+		t.Fatalf("no web server config for %s in serve config", host)
+	}
+	return web
+}
+
+func TestIngressPGReconciler_CertExpiryWarning(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+	clock := tstest.NewClock(tstest.ClockOpts{Start: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)})
+	ingPGR.clock = clock
+	ingPGR.certExpiryWarning = 7 * 24 * time.Hour
+	fr := record.NewFakeRecorder(10)
+	ingPGR.recorder = fr
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, ing)
+
+	setCert := func(notAfter time.Time) {
+		t.Helper()
+		mustUpdate(t, fc, "operator-ns", "my-svc.ts.net", func(s *corev1.Secret) {
+			s.Data = map[string][]byte{
+				corev1.TLSCertKey:       testCertPEM(t, "my-svc.ts.net", notAfter),
+				corev1.TLSPrivateKeyKey: []byte("fake-key"),
+			}
+		})
+	}
+	expectExpiryWarning := func(t *testing.T, want bool) {
+		t.Helper()
+		var got bool
+		for len(fr.Events) > 0 {
+			if strings.HasPrefix(<-fr.Events, "Warning CertificateExpiringSoon TLS certificate for my-svc.ts.net expires at") {
+				got = true
+			}
+		}
+		if got != want {
+			t.Errorf("got cert expiry warning %v, want %v", got, want)
+		}
+	}
+
+	// The cert has not been issued yet.
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectExpiryWarning(t, false)
+
+	setCert(clock.Now().Add(60 * 24 * time.Hour))
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectExpiryWarning(t, false)
+
+	// Time passes without the cert being renewed.
+	clock.Advance(55 * 24 * time.Hour)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectExpiryWarning(t, true)
+
+	// The cert is renewed.
+	setCert(clock.Now().Add(90 * 24 * time.Hour))
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectExpiryWarning(t, false)
+}
+
+func TestIngressPGReconciler_DeleteAfterProxyGroup(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	populateTLSSecret(t, fc, "test-pg", "my-svc.ts.net")
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTailscaleService(t, ft, "svc:my-svc", []string{"tcp:443"})
+	verifyTailscaledConfig(t, fc, "test-pg", []string{"svc:my-svc"})
+
+	// The ProxyGroup is deleted before the Ingress. Its serve and tailscaled
+	// configs are left behind until they are garbage collected, and can no
+	// longer be updated.
+	if err := fc.Delete(t.Context(), &tsapi.ProxyGroup{ObjectMeta: metav1.ObjectMeta{Name: "test-pg"}}); err != nil {
+		t.Fatalf("deleting ProxyGroup: %v", err)
+	}
+	ingPGR.Client = interceptor.NewClient(fc.(client.WithWatch), interceptor.Funcs{
+		Patch: func(ctx context.Context, cl client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if obj.GetName() == pgIngressCMName("test-pg") || obj.GetObjectKind().GroupVersionKind().Kind == "Secret" {
+				return errors.New("object is being garbage collected")
+			}
+			return cl.Patch(ctx, obj, patch, opts...)
+		},
+	})
+
+	// Deleting the Ingress still cleans up its Tailscale Service and cert
+	// resources, and removes its finalizer.
+	if err := fc.Delete(t.Context(), ing); err != nil {
+		t.Fatalf("deleting Ingress: %v", err)
+	}
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectMissing[networkingv1.Ingress](t, fc, "default", "test-ingress")
+	if _, err := ft.GetVIPService(t.Context(), "svc:my-svc"); !isErrorTailscaleServiceNotFound(err) {
+		t.Errorf("Tailscale Service not deleted, GetVIPService error: %v", err)
+	}
+	expectMissing[corev1.Secret](t, fc, "operator-ns", "my-svc.ts.net")
+	expectMissing[rbacv1.Role](t, fc, "operator-ns", "my-svc.ts.net")
+	expectMissing[rbacv1.RoleBinding](t, fc, "operator-ns", "my-svc.ts.net")
+}
+
+func TestValidateModeCombinations(t *testing.T) {
+	// annotate enables mode m on ing.
+	annotate := func(ing *networkingv1.Ingress, m ingressMode) {
+		v := m.value
+		if v == "" {
+			v = "some-value"
+		}
+		mak.Set(&ing.Annotations, m.annotation, v)
+	}
+	for _, c := range incompatibleModes {
+		t.Run(c.a.annotation+"+"+c.b.annotation, func(t *testing.T) {
+			for _, m := range []ingressMode{c.a, c.b} {
+				ing := &networkingv1.Ingress{}
+				annotate(ing, m)
+				if err := validateModeCombinations(ing, annotationDomain); err != nil {
+					t.Errorf("%s alone: got error %v, want nil", m.has(annotationDomain), err)
+				}
+			}
+			ing := &networkingv1.Ingress{}
+			annotate(ing, c.a)
+			annotate(ing, c.b)
+			err := validateModeCombinations(ing, annotationDomain)
+			if err == nil {
+				t.Fatalf("%s and %s: got nil error, want error", c.a.has(annotationDomain), c.b.has(annotationDomain))
+			}
+			if want := fmt.Sprintf("Ingress has %s, but %s: %s", c.a.has(annotationDomain), c.b.is(annotationDomain), c.reason); err.Error() != want {
+				t.Errorf("got error %q, want %q", err, want)
+			}
+		})
+	}
+}
+
+func TestIngressPGReconciler_CertRenewalOwner(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+	ingPGR.consolidateCertSecrets = true
+	clock := tstest.NewClock(tstest.ClockOpts{Start: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)})
+	ingPGR.clock = clock
+	ingPGR.certExpiryWarning = 7 * 24 * time.Hour
+	fr := record.NewFakeRecorder(10)
+	ingPGR.recorder = fr
+
+	newIngress := func(name, uid, host string) *networkingv1.Ingress {
+		return &networkingv1.Ingress{
+			TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				UID:       types.UID(uid),
+				Annotations: map[string]string{
+					"tailscale.com/proxy-group": "test-pg",
+				},
+			},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: ptr.To("tailscale"),
+				TLS: []networkingv1.IngressTLS{
+					{Hosts: []string{host}},
+				},
+			},
+		}
+	}
+	// The Ingresses share the ProxyGroup's consolidated cert Secret. The
+	// one created last has the lowest UID.
+	mustCreate(t, fc, newIngress("test-ingress", "2222-UID", "my-svc"))
+	mustCreate(t, fc, newIngress("my-other-ingress", "1111-UID", "my-other-svc"))
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectReconciled(t, ingPGR, "default", "my-other-ingress")
+
+	setCert := func(domain string, notAfter time.Time) {
+		t.Helper()
+		mustUpdate(t, fc, "operator-ns", "test-pg-certs", func(s *corev1.Secret) {
+			s.Data[domain+".crt"] = testCertPEM(t, domain, notAfter)
+			s.Data[domain+".key"] = []byte("fake-key")
+		})
+	}
+	// expectWarnings checks the domains that cert expiry warnings were
+	// emitted for since the last call.
+	expectWarnings := func(t *testing.T, want ...string) {
+		t.Helper()
+		var got []string
+		for len(fr.Events) > 0 {
+			e := <-fr.Events
+			if rest, ok := strings.CutPrefix(e, "Warning CertificateExpiringSoon TLS certificate for "); ok {
+				got = append(got, strings.Fields(rest)[0])
+			}
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("got cert expiry warnings for %v, want %v", got, want)
+		}
+	}
+	expectRenewal := func(t *testing.T, wantOwner, wantExpiring string) {
+		t.Helper()
+		secret := &corev1.Secret{}
+		if err := fc.Get(t.Context(), types.NamespacedName{Namespace: "operator-ns", Name: "test-pg-certs"}, secret); err != nil {
+			t.Fatal(err)
+		}
+		if got := secret.Annotations[annotationCertRenewalOwner]; got != wantOwner {
+			t.Errorf("renewal owner = %q, want %q", got, wantOwner)
+		}
+		if got := secret.Annotations[annotationCertsExpiring]; got != wantExpiring {
+			t.Errorf("expiring certs = %q, want %q", got, wantExpiring)
+		}
+	}
+
+	setCert("my-svc.ts.net", clock.Now().Add(60*24*time.Hour))
+	setCert("my-other-svc.ts.net", clock.Now().Add(60*24*time.Hour))
+	clock.Advance(55 * 24 * time.Hour)
+	expiring := `{"my-other-svc.ts.net":"2025-03-02T00:00:00Z","my-svc.ts.net":"2025-03-02T00:00:00Z"}`
+
+	// Only the Ingress with the lowest UID checks the certs of the shared
+	// Secret. Until it has, the other Ingress has no result to read.
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectWarnings(t)
+	expectRenewal(t, "default/my-other-ingress", "")
+	expectReconciled(t, ingPGR, "default", "my-other-ingress")
+	expectWarnings(t, "my-other-svc.ts.net")
+	expectRenewal(t, "default/my-other-ingress", expiring)
+
+	// The other Ingress reads the result of the renewal owner's check,
+	// rather than checking its cert itself, so a renewal is only reflected
+	// once the renewal owner checks again.
+	setCert("my-svc.ts.net", clock.Now().Add(90*24*time.Hour))
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectWarnings(t, "my-svc.ts.net")
+	expectRenewal(t, "default/my-other-ingress", expiring)
+
+	// An Ingress can be made the renewal owner, which then checks the certs.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		ing.Annotations["tailscale.com/cert-renewal-owner"] = "true"
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectWarnings(t)
+	expectRenewal(t, "default/test-ingress", `{"my-other-svc.ts.net":"2025-03-02T00:00:00Z"}`)
+	expectReconciled(t, ingPGR, "default", "my-other-ingress")
+	expectWarnings(t, "my-other-svc.ts.net")
+}
+
+// testCertPEM returns a PEM-encoded self-signed certificate for domain that
+// expires at notAfter.
+func testCertPEM(t *testing.T, domain string, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: domain},
+		DNSNames:     []string{domain},
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestIngressPGReconciler_HTTPRedirect(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group":   "test-pg",
+				"tailscale.com/http-endpoint": "enabled",
+				"tailscale.com/http-mode":     "redirect",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, ing)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTailscaleService(t, ft, "svc:my-svc", []string{"tcp:80", "tcp:443"})
+
+	httpHandlers := func(t *testing.T) map[string]*ipn.HTTPHandler {
+		t.Helper()
+		cm := &corev1.ConfigMap{}
+		if err := fc.Get(t.Context(), types.NamespacedName{Namespace: "operator-ns", Name: "test-pg-ingress-config"}, cm); err != nil {
+			t.Fatal(err)
+		}
+		cfg := &ipn.ServeConfig{}
+		if err := json.Unmarshal(cm.BinaryData["serve-config.json"], cfg); err != nil {
+			t.Fatal(err)
+		}
+		svc := cfg.Services["svc:my-svc"]
+		if svc == nil {
+			t.Fatal("Tailscale Service svc:my-svc not found in serve config")
+		}
+		if h := svc.TCP[80]; h == nil || !h.HTTP {
+			t.Fatalf("unexpected port 80 handler: %+v", h)
+		}
+		web := svc.Web["my-svc.ts.net:80"]
+		if web == nil {
+			t.Fatal("no web config for my-svc.ts.net:80")
+		}
+		return web.Handlers
+	}
+
+	want := map[string]*ipn.HTTPHandler{
+		"/": {Redirect: "301:https://my-svc.ts.net${REQUEST_URI}"},
+	}
+	if diff := cmp.Diff(want, httpHandlers(t)); diff != "" {
+		t.Errorf("unexpected HTTP handlers (-want +got):\n%s", diff)
+	}
+
+	// Switching back to serve mode removes the redirect.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		ing.Annotations["tailscale.com/http-mode"] = "serve"
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	for path, h := range httpHandlers(t) {
+		if h.Redirect != "" {
+			t.Errorf("unexpected redirect for path %q in serve mode: %q", path, h.Redirect)
+		}
+	}
+}
+
+func TestIngressPGReconciler_AdvertisingReplicas(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+	fr := record.NewFakeRecorder(10)
+	ingPGR.recorder = fr
+	mustUpdate(t, fc, "", "test-pg", func(pg *tsapi.ProxyGroup) {
+		pg.Spec.Replicas = ptr.To[int32](3)
+	})
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, ing)
+
+	stateSecret := func(i int32, advertised ...string) *corev1.Secret {
+		profile, err := json.Marshal(map[string]any{
+			"AdvertiseServices": advertised,
+			"Config":            map[string]any{"NodeID": fmt.Sprintf("node-%d", i)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pgStateSecretName("test-pg", i),
+				Namespace: "operator-ns",
+				Labels:    pgSecretLabels("test-pg", kubetypes.LabelSecretTypeState),
+			},
+			Data: map[string][]byte{
+				"_current-profile": []byte("profile-foo"),
+				"profile-foo":      profile,
+			},
+		}
+	}
+	// Replicas 0 and 2 advertise the Tailscale Service, replica 1 does not (yet).
+	mustCreate(t, fc, stateSecret(0, "svc:my-svc"))
+	mustCreate(t, fc, stateSecret(1))
+	mustCreate(t, fc, stateSecret(2, "svc:my-svc"))
+
+	// advertisingEvents returns the messages of the AdvertisingReplicasChanged
+	// Events emitted since it was last called.
+	advertisingEvents := func() []string {
+		var msgs []string
+		for len(fr.Events) > 0 {
+			if msg, ok := strings.CutPrefix(<-fr.Events, "Normal "+reasonAdvertisingReplicasChanged+" "); ok {
+				msgs = append(msgs, msg)
+			}
+		}
+		return msgs
+	}
+	expectAdvertisingEvents := func(t *testing.T, want ...string) {
+		t.Helper()
+		if diff := cmp.Diff(want, advertisingEvents()); diff != "" {
+			t.Errorf("unexpected %s Events (-want +got):\n%s", reasonAdvertisingReplicasChanged, diff)
+		}
+	}
+
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectAdvertisingEvents(t, "Tailscale Service svc:my-svc is advertised by replicas 0,2 of ProxyGroup test-pg")
+
+	// Reconciling again without changes emits no further Events.
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectAdvertisingEvents(t)
+
+	// The Ingress itself is left alone.
+	got := &networkingv1.Ingress{}
+	if err := fc.Get(t.Context(), types.NamespacedName{Namespace: "default", Name: "test-ingress"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Annotations["tailscale.com/advertising-replicas"] != "" {
+		t.Errorf("unexpected advertising replicas annotation on Ingress: %v", got.Annotations)
+	}
+
+	// Replica 1 starts advertising.
+	mustUpdate(t, fc, "operator-ns", "test-pg-1", func(s *corev1.Secret) {
+		s.Data["profile-foo"] = stateSecret(1, "svc:my-svc").Data["profile-foo"]
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectAdvertisingEvents(t, "Tailscale Service svc:my-svc is advertised by replicas 0,1,2 of ProxyGroup test-pg")
+
+	// A Secret with the state Secret labels, but not named after a replica,
+	// is skipped.
+	mustCreate(t, fc, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "unrelated",
+			Namespace: "operator-ns",
+			Labels:    pgSecretLabels("test-pg", kubetypes.LabelSecretTypeState),
+		},
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectAdvertisingEvents(t)
+
+	// No replicas advertise.
+	for i := range int32(3) {
+		mustUpdate(t, fc, "operator-ns", pgStateSecretName("test-pg", i), func(s *corev1.Secret) {
+			s.Data["profile-foo"] = stateSecret(i).Data["profile-foo"]
+		})
+	}
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectAdvertisingEvents(t, "Tailscale Service svc:my-svc is not advertised by any replicas of ProxyGroup test-pg")
+}
+
+func TestIngressPGReconciler_MultiReplicaConfigSecrets(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+	mustUpdate(t, fc, "", "test-pg", func(pg *tsapi.ProxyGroup) {
+		pg.Spec.Replicas = ptr.To[int32](3)
+	})
+	// createPGResources only creates the config Secret for replica 0.
+	for i := range int32(3) {
+		if i == 0 {
+			continue
+		}
+		mustCreate(t, fc, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pgConfigSecretName("test-pg", i),
+				Namespace: "operator-ns",
+				Labels:    pgSecretLabels("test-pg", kubetypes.LabelSecretTypeConfig),
+			},
+			Data: map[string][]byte{
+				tsoperator.TailscaledConfigFileName(pgMinCapabilityVersion): []byte("{}"),
+			},
+		})
+	}
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, ing)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	populateTLSSecret(t, fc, "test-pg", "my-svc.ts.net")
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+
+	for i := range int32(3) {
+		verifyTailscaledConfigReplica(t, fc, "test-pg", i, []string{"svc:my-svc"})
+	}
+
+	// Deleting the Ingress removes the advertisement from all replicas.
+	if err := fc.Delete(t.Context(), ing); err != nil {
+		t.Fatal(err)
+	}
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	for i := range int32(3) {
+		verifyTailscaledConfigReplica(t, fc, "test-pg", i, nil)
+	}
+}
+
+func TestIngressPGReconciler_PinnedReplicas(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+	mustUpdate(t, fc, "", "test-pg", func(pg *tsapi.ProxyGroup) {
+		pg.Spec.Replicas = ptr.To[int32](3)
+	})
+	// createPGResources only creates the config Secret for replica 0. The
+	// others are created as the operator would marshal them, so that replicas
+	// the Ingress is not pinned to can be verified as untouched.
+	for i := range int32(3) {
+		if i == 0 {
+			continue
+		}
+		mustCreate(t, fc, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pgConfigSecretName("test-pg", i),
+				Namespace: "operator-ns",
+				Labels:    pgSecretLabels("test-pg", kubetypes.LabelSecretTypeConfig),
+			},
+			Data: map[string][]byte{
+				tsoperator.TailscaledConfigFileName(pgMinCapabilityVersion): []byte(`{"Version":""}`),
+			},
+		})
+	}
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+				annotationReplicas:          "0,2",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, ing)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	populateTLSSecret(t, fc, "test-pg", "my-svc.ts.net")
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+
+	verifyTailscaledConfigReplica(t, fc, "test-pg", 0, []string{"svc:my-svc"})
+	verifyTailscaledConfigReplica(t, fc, "test-pg", 1, nil)
+	verifyTailscaledConfigReplica(t, fc, "test-pg", 2, []string{"svc:my-svc"})
+
+	// Changing the pinned replicas moves the advertisement.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		ing.Annotations[annotationReplicas] = "1"
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTailscaledConfigReplica(t, fc, "test-pg", 0, nil)
+	verifyTailscaledConfigReplica(t, fc, "test-pg", 1, []string{"svc:my-svc"})
+	verifyTailscaledConfigReplica(t, fc, "test-pg", 2, nil)
+
+	// Removing the annotation advertises the service from all replicas.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		delete(ing.Annotations, annotationReplicas)
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	for i := range int32(3) {
+		verifyTailscaledConfigReplica(t, fc, "test-pg", i, []string{"svc:my-svc"})
+	}
+}
+
+func TestIngressPGReconciler_ExternalTLSSecret(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-cert",
+			Namespace: "operator-ns",
+		},
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       []byte("fake-cert"),
+			corev1.TLSPrivateKeyKey: []byte("fake-key"),
+		},
+		Type: corev1.SecretTypeTLS,
+	})
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+				annotationTLSSecret:         "my-cert",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, ing)
+
+	// The cert is copied to the ProxyGroup's TLS Secret, so the Tailscale
+	// Service is advertised without waiting for the ProxyGroup Pods to
+	// request a cert, and the Pods are only allowed to read it.
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	withCert := func(cert, key string) func(*corev1.Secret) {
+		return func(s *corev1.Secret) {
+			s.Data = map[string][]byte{
+				corev1.TLSCertKey:       []byte(cert),
+				corev1.TLSPrivateKeyKey: []byte(key),
+			}
+		}
+	}
+	expectEqual(t, fc, certSecret("test-pg", "operator-ns", "my-svc.ts.net", ing), withCert("fake-cert", "fake-key"))
+	expectEqual(t, fc, certSecretRole("test-pg", "operator-ns", "my-svc.ts.net"), func(r *rbacv1.Role) {
+		r.Rules[0].Verbs = []string{"get", "list"}
+	})
+	verifyTailscaledConfig(t, fc, "test-pg", []string{"svc:my-svc"})
+
+	// A rotated cert is copied over.
+	mustUpdate(t, fc, "operator-ns", "my-cert", func(s *corev1.Secret) {
+		s.Data[corev1.TLSCertKey] = []byte("rotated-cert")
+		s.Data[corev1.TLSPrivateKeyKey] = []byte("rotated-key")
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectEqual(t, fc, certSecret("test-pg", "operator-ns", "my-svc.ts.net", ing), withCert("rotated-cert", "rotated-key"))
+
+	// Removing the annotation gives the ProxyGroup Pods write access again,
+	// so that they can renew the cert.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		delete(ing.Annotations, annotationTLSSecret)
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectEqual(t, fc, certSecretRole("test-pg", "operator-ns", "my-svc.ts.net"))
+}
+
+func TestIngressPGReconciler_AnnotationPrefix(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
+	ingPGR.annotationPrefix = "example.com/"
+	fr := record.NewFakeRecorder(10)
+	ingPGR.recorder = fr
+	mustCreate(t, fc, service())
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"example.com/proxy-group":   "test-pg",
+				"example.com/http-endpoint": "enabled",
+				"example.com/tags":          "tag:custom",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, ing)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	populateTLSSecret(t, fc, "test-pg", "my-svc.ts.net")
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+
+	// The Ingress is exposed on the ProxyGroup with the configuration from
+	// the annotations with the custom prefix.
+	verifyServeConfig(t, fc, "svc:my-svc", true)
+	tsSvc, err := ft.GetVIPService(t.Context(), "svc:my-svc")
+	if err != nil {
+		t.Fatalf("getting Tailscale Service: %v", err)
+	}
+	if want := []string{"tag:custom"}; !slices.Equal(tsSvc.Tags, want) {
+		t.Errorf("Tailscale Service tags = %v, want %v", tsSvc.Tags, want)
+	}
+	// No unknown annotation warnings are emitted for them.
+	select {
+	case e := <-fr.Events:
+		t.Errorf("unexpected event %q", e)
+	default:
+	}
+
+	// Annotations in the tailscale.com/ domain are ignored.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		delete(ing.Annotations, "example.com/http-endpoint")
+		ing.Annotations["tailscale.com/http-endpoint"] = "enabled"
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyServeConfig(t, fc, "svc:my-svc", false)
+}
+
+func TestIngressPGReconciler_AuditEvents(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			ingPGR, fc, _ := setupIngressTest(t)
+			fr := record.NewFakeRecorder(10)
+			ingPGR.recorder = fr
+			ingPGR.operatorID = "operator-1"
+			ingPGR.emitAuditEvents = enabled
+			mustCreate(t, fc, service())
+			ing := &networkingv1.Ingress{
+				TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-ingress",
+					Namespace: "default",
+					UID:       types.UID("1234-UID"),
+					Annotations: map[string]string{
+						"tailscale.com/proxy-group": "test-pg",
+					},
+				},
+				Spec: networkingv1.IngressSpec{
+					IngressClassName: ptr.To("tailscale"),
+					DefaultBackend: &networkingv1.IngressBackend{
+						Service: &networkingv1.IngressServiceBackend{
+							Name: "test",
+							Port: networkingv1.ServiceBackendPort{
+								Number: 8080,
+							},
+						},
+					},
+					TLS: []networkingv1.IngressTLS{
+						{Hosts: []string{"my-svc"}},
+					},
+				},
+			}
+
+			mustCreate(t, fc, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-pg-0",
+					Namespace: "operator-ns",
+					Labels:    pgSecretLabels("test-pg", kubetypes.LabelSecretTypeState),
+				},
+				Data: map[string][]byte{
+					"_current-profile": []byte("profile-foo"),
+					"profile-foo":      []byte(`{"AdvertiseServices":["svc:my-svc"],"Config":{"NodeID":"node-foo"}}`),
+				},
+			})
+
+			// expectAuditEvents checks the audit Events emitted since the
+			// last call, ignoring the Events that are emitted regardless
+			// of emitAuditEvents.
+			expectAuditEvents := func(want ...string) {
+				t.Helper()
+				var got []string
+				for len(fr.Events) > 0 {
+					e := <-fr.Events
+					if strings.HasPrefix(e, "Normal "+reasonAdvertisingReplicasChanged+" ") {
+						continue
+					}
+					got = append(got, e)
+				}
+				if !enabled {
+					want = nil
+				}
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("unexpected audit Events (-want +got):\n%s", diff)
+				}
+			}
+
+			// Creating the Ingress creates its Tailscale Service, and the
+			// cert is issued afterwards.
+			mustCreate(t, fc, ing)
+			expectReconciled(t, ingPGR, "default", "test-ingress")
+			populateTLSSecret(t, fc, "test-pg", "my-svc.ts.net")
+			expectReconciled(t, ingPGR, "default", "test-ingress")
+			expectAuditEvents(
+				"Normal TailscaleServiceCreated Created Tailscale Service svc:my-svc",
+				"Normal OwnerReferenceAdded Added owner reference of operator operator-1 to Tailscale Service svc:my-svc",
+				"Normal CertIssued TLS cert issued for my-svc.ts.net",
+			)
+
+			// Reconciling again without changes does not emit Events.
+			expectReconciled(t, ingPGR, "default", "test-ingress")
+			expectAuditEvents()
+
+			// Deleting the Ingress deletes its Tailscale Service.
+			if err := fc.Delete(t.Context(), ing); err != nil {
+				t.Fatalf("deleting Ingress: %v", err)
+			}
+			expectReconciled(t, ingPGR, "default", "test-ingress")
+			expectAuditEvents(
+				"Normal TailscaleServiceDeleted Deleted Tailscale Service svc:my-svc",
+			)
+		})
+	}
+}
+
+func TestIngressPGReconciler_TrustBundle(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+	caPEM := testCertPEM(t, "client-ca", time.Now().Add(time.Hour))
+	mustCreate(t, fc, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "client-ca",
+			Namespace: "operator-ns",
+		},
+		Data: map[string][]byte{
+			"ca.crt": caPEM,
+		},
+	})
+	mustCreate(t, fc, service())
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group":         "test-pg",
+				"tailscale.com/trust-bundle-secret": "client-ca",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, ing)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+
+	checkClientCAs := func(want []byte) {
+		t.Helper()
+		_, cfg, err := ingPGR.proxyGroupServeConfig(t.Context(), "test-pg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		svc := cfg.Services["svc:my-svc"]
+		if svc == nil {
+			t.Fatalf("Tailscale Service not in serve config")
+		}
+		if got := svc.Web["my-svc.ts.net:443"].ClientCAs; got != string(want) {
+			t.Errorf("ClientCAs of HTTPS endpoint = %q, want %q", got, want)
+		}
+	}
+	checkClientCAs(caPEM)
+
+	// A rotated bundle is copied to the serve config.
+	rotated := slices.Concat(caPEM, testCertPEM(t, "client-ca-2", time.Now().Add(time.Hour)))
+	mustUpdate(t, fc, "operator-ns", "client-ca", func(s *corev1.Secret) {
+		s.Data["ca.crt"] = rotated
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	checkClientCAs(rotated)
+
+	// Removing the annotation stops requiring client certificates.
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		delete(ing.Annotations, annotationTrustBundleSecret)
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	checkClientCAs(nil)
+}
+
+func TestIngressPGReconciler_TrustBundleSharedHTTPEndpoint(t *testing.T) {
+	// Ingresses in two clusters share one Tailscale Service. The first one
+	// requires client certificates, and the second one requests an HTTP
+	// endpoint.
+	ingPGR1, fc1, ft := setupIngressTest(t)
+	ingPGR1.operatorID = "operator-1"
+	ingPGR2, fc2, _ := setupIngressTest(t)
+	ingPGR2.operatorID = "operator-2"
+	ingPGR2.tsClient = ft
+	mustCreate(t, fc1, &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "test-pg-0",
+			Name:      "client-ca",
 			Namespace: "operator-ns",
-			Labels:    pgSecretLabels("test-pg", kubetypes.LabelSecretTypeState),
 		},
 		Data: map[string][]byte{
-			"_current-profile": []byte("profile-foo"),
-			"profile-foo":      []byte(`{"AdvertiseServices":["svc:my-svc"],"Config":{"NodeID":"node-foo"}}`),
+			"ca.crt": testCertPEM(t, "client-ca", time.Now().Add(time.Hour)),
 		},
 	})
 
-	// Reconcile and re-fetch Ingress.
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	ing1 := ing.DeepCopy()
+	ing1.Annotations[annotationTrustBundleSecret] = "client-ca"
+	mustCreate(t, fc1, ing1)
+	ing2 := ing.DeepCopy()
+	ing2.Annotations[annotationHTTPEndpoint] = "enabled"
+	mustCreate(t, fc2, ing2)
+
+	expectReconciled(t, ingPGR1, "default", "test-ingress")
+	expectReconciled(t, ingPGR2, "default", "test-ingress")
+	expectReconciled(t, ingPGR1, "default", "test-ingress")
+	verifyTailscaleService(t, ft, "svc:my-svc", []string{"tcp:443", "tcp:80"})
+
+	// The first cluster's proxies redirect HTTP requests to HTTPS rather
+	// than serve them without verifying client certificates.
+	_, cfg, err := ingPGR1.proxyGroupServeConfig(t.Context(), "test-pg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	web := cfg.Services["svc:my-svc"].Web["my-svc.ts.net:80"]
+	if web == nil {
+		t.Fatalf("HTTP endpoint not in serve config")
+	}
+	want := map[string]*ipn.HTTPHandler{
+		"/": {Redirect: "301:https://my-svc.ts.net${REQUEST_URI}"},
+	}
+	if diff := cmp.Diff(want, web.Handlers); diff != "" {
+		t.Errorf("HTTP endpoint handlers (-want +got):\n%s", diff)
+	}
+}
+
+func TestHAIngressesFromSecret_ExternalTLSSecret(t *testing.T) {
+	ing := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			Annotations: map[string]string{
+				AnnotationProxyGroup:        "test-pg",
+				annotationTLSSecret:         "my-cert",
+				annotationTrustBundleSecret: "my-ca",
+			},
+		},
+	}
+	fc := fake.NewClientBuilder().
+		WithScheme(tsapi.GlobalScheme).
+		WithInterceptorFuncs(interceptor.Funcs{Patch: applyAsMergePatch}).
+		WithObjects(ing).
+		WithIndex(new(networkingv1.Ingress), indexIngressTLSSecret, indexTLSSecretIngresses(annotationDomain)).
+		Build()
+	zl, err := zap.NewDevelopment()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := HAIngressesFromSecret(fc, zl.Sugar())
+
+	got := h(t.Context(), &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "my-cert", Namespace: "operator-ns"}})
+	want := []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-ingress"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := h(t.Context(), &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "my-ca", Namespace: "operator-ns"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v for trust bundle Secret, want %v", got, want)
+	}
+	if got := h(t.Context(), &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "operator-ns"}}); len(got) != 0 {
+		t.Errorf("got %v for unreferenced Secret, want none", got)
+	}
+}
+
+func TestIngressPGReconciler_CertSecretLabelDrift(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+	mustCreate(t, fc, service())
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, ing)
 	expectReconciled(t, ingPGR, "default", "test-ingress")
-	if err := fc.Get(context.Background(), client.ObjectKeyFromObject(ing), ing); err != nil {
+	expectEqual(t, fc, certSecret("test-pg", "operator-ns", "my-svc.ts.net", ing))
+
+	// A Secret with a stripped label is still mapped to its Ingress, and
+	// the Ingress reconcile restores the label.
+	mustUpdate(t, fc, "operator-ns", "my-svc.ts.net", func(s *corev1.Secret) {
+		delete(s.Labels, labelDomain)
+		s.Labels[labelProxyGroup] = "other-pg"
+	})
+	secret := &corev1.Secret{}
+	if err := fc.Get(t.Context(), types.NamespacedName{Namespace: "operator-ns", Name: "my-svc.ts.net"}, secret); err != nil {
+		t.Fatal(err)
+	}
+	h := HAIngressesFromSecret(fc, zap.NewNop().Sugar())
+	want := []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-ingress"}}}
+	if got := h(t.Context(), secret); !reflect.DeepEqual(got, want) {
+		t.Errorf("HAIngressesFromSecret() = %v, want %v", got, want)
+	}
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectEqual(t, fc, certSecret("test-pg", "operator-ns", "my-svc.ts.net", ing))
+
+	// A Secret that is not labelled as managed by the operator is left as
+	// is.
+	mustUpdate(t, fc, "operator-ns", "my-svc.ts.net", func(s *corev1.Secret) {
+		delete(s.Labels, kubetypes.LabelManaged)
+		delete(s.Labels, labelDomain)
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectEqual(t, fc, certSecret("test-pg", "operator-ns", "my-svc.ts.net", ing), func(s *corev1.Secret) {
+		delete(s.Labels, kubetypes.LabelManaged)
+		delete(s.Labels, labelDomain)
+	})
+}
+
+func TestIngressPGReconciler_MultiCluster(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
+	ingPGR.operatorID = "operator-1"
+
+	// Create initial Ingress
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, ing)
+
+	// Simulate existing Tailscale Service from another cluster
+	existingVIPSvc := &tailscale.VIPService{
+		Name: "svc:my-svc",
+		Annotations: map[string]string{
+			ownerAnnotation: `{"ownerrefs":[{"operatorID":"operator-2"}]}`,
+		},
+	}
+	ft.vipServices = map[tailcfg.ServiceName]*tailscale.VIPService{
+		"svc:my-svc": existingVIPSvc,
+	}
+
+	// Verify reconciliation adds our operator reference
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+
+	tsSvc, err := ft.GetVIPService(context.Background(), "svc:my-svc")
+	if err != nil {
+		t.Fatalf("getting Tailscale Service: %v", err)
+	}
+	if tsSvc == nil {
+		t.Fatal("Tailscale Service not found")
+	}
+
+	o, err := parseOwnerAnnotation(tsSvc)
+	if err != nil {
+		t.Fatalf("parsing owner annotation: %v", err)
+	}
+
+	wantOwnerRefs := []OwnerRef{
+		{OperatorID: "operator-2"},
+		{OperatorID: "operator-1", Tags: []string{"tag:k8s"}},
+	}
+	if !reflect.DeepEqual(o.OwnerRefs, wantOwnerRefs) {
+		t.Errorf("incorrect owner refs\ngot:  %+v\nwant: %+v", o.OwnerRefs, wantOwnerRefs)
+	}
+
+	// Delete the Ingress and verify Tailscale Service still exists with one owner ref
+	if err := fc.Delete(context.Background(), ing); err != nil {
+		t.Fatalf("deleting Ingress: %v", err)
+	}
+	expectRequeue(t, ingPGR, "default", "test-ingress")
+
+	tsSvc, err = ft.GetVIPService(context.Background(), "svc:my-svc")
+	if err != nil {
+		t.Fatalf("getting Tailscale Service after deletion: %v", err)
+	}
+	if tsSvc == nil {
+		t.Fatal("Tailscale Service was incorrectly deleted")
+	}
+
+	o, err = parseOwnerAnnotation(tsSvc)
+	if err != nil {
+		t.Fatalf("parsing owner annotation: %v", err)
+	}
+
+	wantOwnerRefs = []OwnerRef{
+		{OperatorID: "operator-2"},
+	}
+	if !reflect.DeepEqual(o.OwnerRefs, wantOwnerRefs) {
+		t.Errorf("incorrect owner refs after deletion\ngot:  %+v\nwant: %+v", o.OwnerRefs, wantOwnerRefs)
+	}
+}
+
+func TestIngressPGReconciler_CleanupPartialFailure(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	populateTLSSecret(t, fc, "test-pg", "my-svc.ts.net")
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	verifyTailscaledConfig(t, fc, "test-pg", []string{"svc:my-svc"})
+
+	// Deleting the cert Secret's RBAC fails once.
+	failed := false
+	ingPGR.Client = interceptor.NewClient(fc.(client.WithWatch), interceptor.Funcs{
+		DeleteAllOf: func(ctx context.Context, cl client.WithWatch, obj client.Object, opts ...client.DeleteAllOfOption) error {
+			if _, ok := obj.(*rbacv1.RoleBinding); ok && !failed {
+				failed = true
+				return errors.New("injected failure")
+			}
+			return cl.DeleteAllOf(ctx, obj, opts...)
+		},
+	})
+	if err := fc.Delete(t.Context(), ing); err != nil {
+		t.Fatalf("deleting Ingress: %v", err)
+	}
+	expectError(t, ingPGR, "default", "test-ingress")
+
+	// The steps before the failure are done, the ones after it are not.
+	verifyTailscaledConfig(t, fc, "test-pg", nil)
+	if _, err := ft.GetVIPService(t.Context(), "svc:my-svc"); !isErrorTailscaleServiceNotFound(err) {
+		t.Errorf("Tailscale Service not deleted: %v", err)
+	}
+	expectMissing[corev1.Secret](t, fc, "operator-ns", "my-svc.ts.net")
+	expectEqual(t, fc, certSecretRole("test-pg", "operator-ns", "my-svc.ts.net"))
+	verifyServeConfig(t, fc, "svc:my-svc", false)
+	got := &networkingv1.Ingress{}
+	if err := fc.Get(t.Context(), client.ObjectKeyFromObject(ing), got); err != nil {
+		t.Fatalf("getting Ingress: %v", err)
+	}
+	if !slices.Contains(got.Finalizers, FinalizerNamePG) {
+		t.Errorf("finalizer removed after failed cleanup")
+	}
+
+	// The retry completes the cleanup, without repeating the steps that
+	// were already done.
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectMissing[rbacv1.RoleBinding](t, fc, "operator-ns", "my-svc.ts.net")
+	expectMissing[rbacv1.Role](t, fc, "operator-ns", "my-svc.ts.net")
+	_, cfg, err := ingPGR.proxyGroupServeConfig(t.Context(), "test-pg")
+	if err != nil {
 		t.Fatal(err)
 	}
-
-	wantStatus := []networkingv1.IngressPortStatus{
-		{Port: 443, Protocol: "TCP"},
-		{Port: 80, Protocol: "TCP"},
+	if cfg.Services["svc:my-svc"] != nil {
+		t.Errorf("Tailscale Service still in serve config after cleanup")
 	}
-	if !reflect.DeepEqual(ing.Status.LoadBalancer.Ingress[0].Ports, wantStatus) {
-		t.Errorf("incorrect status ports: got %v, want %v",
-			ing.Status.LoadBalancer.Ingress[0].Ports, wantStatus)
+	if want := []tailcfg.ServiceName{"svc:my-svc"}; !slices.Equal(ft.deletedVIPServices, want) {
+		t.Errorf("deleted Tailscale Services = %v, want %v", ft.deletedVIPServices, want)
 	}
+	expectMissing[networkingv1.Ingress](t, fc, "default", "test-ingress")
+}
 
-	// Remove HTTP endpoint annotation
-	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
-		delete(ing.Annotations, "tailscale.com/http-endpoint")
-	})
+func TestIngressPGReconciler_ProxyGroupTypeChange(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
 
-	// Verify reconciliation after removing HTTP
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	populateTLSSecret(t, fc, "test-pg", "my-svc.ts.net")
 	expectReconciled(t, ingPGR, "default", "test-ingress")
-	verifyTailscaleService(t, ft, "svc:my-svc", []string{"tcp:443"})
 	verifyServeConfig(t, fc, "svc:my-svc", false)
+	verifyTailscaledConfig(t, fc, "test-pg", []string{"svc:my-svc"})
 
-	// Verify Ingress status
-	ing = &networkingv1.Ingress{}
-	if err := fc.Get(context.Background(), types.NamespacedName{
-		Name:      "test-ingress",
-		Namespace: "default",
-	}, ing); err != nil {
+	// The ProxyGroup is recreated as an egress ProxyGroup. Type is
+	// immutable, which the fake client does not enforce, so this is
+	// simulated by updating it.
+	fr := record.NewFakeRecorder(10)
+	ingPGR.recorder = fr
+	mustUpdate(t, fc, "", "test-pg", func(pg *tsapi.ProxyGroup) {
+		pg.Spec.Type = tsapi.ProxyGroupTypeEgress
+	})
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectEvents(t, fr, []string{`Warning WrongProxyGroupType ProxyGroup "test-pg" is of type "egress" but must be of type "ingress"`})
+
+	verifyTailscaledConfig(t, fc, "test-pg", nil)
+	_, cfg, err := ingPGR.proxyGroupServeConfig(t.Context(), "test-pg")
+	if err != nil {
 		t.Fatal(err)
 	}
-
-	wantStatus = []networkingv1.IngressPortStatus{
-		{Port: 443, Protocol: "TCP"},
+	if cfg.Services["svc:my-svc"] != nil {
+		t.Errorf("Tailscale Service still in serve config after ProxyGroup type change")
 	}
-	if !reflect.DeepEqual(ing.Status.LoadBalancer.Ingress[0].Ports, wantStatus) {
-		t.Errorf("incorrect status ports: got %v, want %v",
-			ing.Status.LoadBalancer.Ingress[0].Ports, wantStatus)
+	if _, err := ft.GetVIPService(t.Context(), "svc:my-svc"); !isErrorTailscaleServiceNotFound(err) {
+		t.Errorf("Tailscale Service not deleted: %v", err)
+	}
+	expectMissing[corev1.Secret](t, fc, "operator-ns", "my-svc.ts.net")
+	got := &networkingv1.Ingress{}
+	if err := fc.Get(t.Context(), client.ObjectKeyFromObject(ing), got); err != nil {
+		t.Fatalf("getting Ingress: %v", err)
+	}
+	if slices.Contains(got.Finalizers, FinalizerNamePG) {
+		t.Errorf("finalizer not removed after ProxyGroup type change")
+	}
+	if len(got.Status.LoadBalancer.Ingress) != 0 {
+		t.Errorf("Ingress status not reset after ProxyGroup type change: %+v", got.Status.LoadBalancer)
 	}
+
+	// Further reconciles keep reporting the problem, without error.
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectEvents(t, fr, []string{`Warning WrongProxyGroupType ProxyGroup "test-pg" is of type "egress" but must be of type "ingress"`})
 }
 
-func TestIngressPGReconciler_MultiCluster(t *testing.T) {
+func TestIngressPGReconciler_OwnerRefMetrics(t *testing.T) {
 	ingPGR, fc, ft := setupIngressTest(t)
 	ingPGR.operatorID = "operator-1"
 
-	// Create initial Ingress
 	ing := &networkingv1.Ingress{
 		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
 		ObjectMeta: metav1.ObjectMeta{
@@ -636,72 +5143,274 @@ func TestIngressPGReconciler_MultiCluster(t *testing.T) {
 		Spec: networkingv1.IngressSpec{
 			IngressClassName: ptr.To("tailscale"),
 			TLS: []networkingv1.IngressTLS{
-				{Hosts: []string{"my-svc"}},
+				{Hosts: []string{"my-metrics-svc"}},
 			},
 		},
 	}
 	mustCreate(t, fc, ing)
 
-	// Simulate existing Tailscale Service from another cluster
-	existingVIPSvc := &tailscale.VIPService{
-		Name: "svc:my-svc",
-		Annotations: map[string]string{
-			ownerAnnotation: `{"ownerrefs":[{"operatorID":"operator-2"}]}`,
-		},
+	ownerRefChanges.Reset()
+	const svcName = "svc:my-metrics-svc"
+	expectCounters := func(t *testing.T, adds, removes, conflicts int64) {
+		t.Helper()
+		for _, c := range []struct {
+			result string
+			want   int64
+		}{
+			{ownerRefResultAdd, adds},
+			{ownerRefResultRemove, removes},
+			{ownerRefResultConflict, conflicts},
+		} {
+			if got := int64(promtestutil.ToFloat64(ownerRefChanges.WithLabelValues(reconcilerIngressPG, c.result))); got != c.want {
+				t.Errorf("owner ref changes with result %q = %d, want %d", c.result, got, c.want)
+			}
+		}
 	}
+	ownedByOther := `{"ownerRefs":[{"operatorID":"operator-2"}]}`
 	ft.vipServices = map[tailcfg.ServiceName]*tailscale.VIPService{
-		"svc:my-svc": existingVIPSvc,
+		svcName: {
+			Name:        svcName,
+			Annotations: map[string]string{ownerAnnotation: ownedByOther},
+		},
 	}
 
-	// Verify reconciliation adds our operator reference
 	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectCounters(t, 1, 0, 0)
 
-	tsSvc, err := ft.GetVIPService(context.Background(), "svc:my-svc")
-	if err != nil {
-		t.Fatalf("getting Tailscale Service: %v", err)
-	}
-	if tsSvc == nil {
-		t.Fatal("Tailscale Service not found")
+	// Reconciling again does not change the owner references.
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectCounters(t, 1, 0, 0)
+
+	// The operator in the other cluster overwrites the owner references,
+	// so the owner reference is added again.
+	ft.vipServices[svcName].Annotations[ownerAnnotation] = ownedByOther
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectCounters(t, 2, 0, 0)
+
+	// A concurrent write to the serve config makes the reconcile fail
+	// with a conflict, to be retried.
+	mustCreate(t, fc, service())
+	ingPGR.Client = interceptor.NewClient(fc.(client.WithWatch), interceptor.Funcs{
+		Patch: func(ctx context.Context, cl client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if obj.GetName() == pgIngressCMName("test-pg") {
+				return apierrors.NewConflict(corev1.Resource("configmaps"), obj.GetName(), errors.New("the object has been modified"))
+			}
+			return cl.Patch(ctx, obj, patch, opts...)
+		},
+	})
+	mustUpdate(t, fc, "default", "test-ingress", func(ing *networkingv1.Ingress) {
+		ing.Spec.DefaultBackend = &networkingv1.IngressBackend{
+			Service: &networkingv1.IngressServiceBackend{
+				Name: "test",
+				Port: networkingv1.ServiceBackendPort{Number: 8080},
+			},
+		}
+	})
+	expectError(t, ingPGR, "default", "test-ingress")
+	expectCounters(t, 2, 0, 1)
+	ingPGR.Client = fc
+
+	if err := fc.Delete(t.Context(), ing); err != nil {
+		t.Fatalf("deleting Ingress: %v", err)
 	}
+	expectRequeue(t, ingPGR, "default", "test-ingress")
+	expectCounters(t, 2, 1, 1)
+}
 
-	o, err := parseOwnerAnnotation(tsSvc)
-	if err != nil {
-		t.Fatalf("parsing owner annotation: %v", err)
+func TestIngressPGReconciler_PreviousOperatorID(t *testing.T) {
+	ingPGR, fc, ft := setupIngressTest(t)
+	ingPGR.operatorID = "operator-1"
+	ingPGR.previousOperatorID = "operator-old"
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
 	}
+	mustCreate(t, fc, ing)
 
-	wantOwnerRefs := []OwnerRef{
-		{OperatorID: "operator-2"},
-		{OperatorID: "operator-1"},
+	expectOwnerRefs := func(t *testing.T, want []OwnerRef) {
+		t.Helper()
+		tsSvc, err := ft.GetVIPService(context.Background(), "svc:my-svc")
+		if err != nil {
+			t.Fatalf("getting Tailscale Service: %v", err)
+		}
+		o, err := parseOwnerAnnotation(tsSvc)
+		if err != nil {
+			t.Fatalf("parsing owner annotation: %v", err)
+		}
+		if !reflect.DeepEqual(o.OwnerRefs, want) {
+			t.Errorf("incorrect owner refs\ngot:  %+v\nwant: %+v", o.OwnerRefs, want)
+		}
 	}
-	if !reflect.DeepEqual(o.OwnerRefs, wantOwnerRefs) {
-		t.Errorf("incorrect owner refs\ngot:  %+v\nwant: %+v", o.OwnerRefs, wantOwnerRefs)
+
+	// Tailscale Service created by this operator before its Tailscale
+	// device was re-created, and shared with an operator in another cluster.
+	ft.vipServices = map[tailcfg.ServiceName]*tailscale.VIPService{
+		"svc:my-svc": {
+			Name: "svc:my-svc",
+			Annotations: map[string]string{
+				ownerAnnotation: `{"ownerRefs":[{"operatorID":"operator-old","tags":["tag:k8s"]},{"operatorID":"operator-2"}]}`,
+			},
+		},
 	}
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectOwnerRefs(t, []OwnerRef{
+		{OperatorID: "operator-1", Tags: []string{"tag:k8s"}},
+		{OperatorID: "operator-2"},
+	})
 
-	// Delete the Ingress and verify Tailscale Service still exists with one owner ref
+	// Owner references under both IDs, for example if an operator with the
+	// new ID had already claimed the Tailscale Service, are not duplicated.
+	ft.vipServices["svc:my-svc"].Annotations[ownerAnnotation] = `{"ownerRefs":[{"operatorID":"operator-old","tags":["tag:k8s"]},{"operatorID":"operator-2"},{"operatorID":"operator-1","tags":["tag:k8s"]}]}`
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectOwnerRefs(t, []OwnerRef{
+		{OperatorID: "operator-2"},
+		{OperatorID: "operator-1", Tags: []string{"tag:k8s"}},
+	})
+
+	// Owner references under the previous ID are cleaned up when the
+	// Ingress is deleted.
+	ft.vipServices["svc:my-svc"].Annotations[ownerAnnotation] = `{"ownerRefs":[{"operatorID":"operator-old","tags":["tag:k8s"]},{"operatorID":"operator-2"}]}`
 	if err := fc.Delete(context.Background(), ing); err != nil {
 		t.Fatalf("deleting Ingress: %v", err)
 	}
 	expectRequeue(t, ingPGR, "default", "test-ingress")
+	expectOwnerRefs(t, []OwnerRef{
+		{OperatorID: "operator-2"},
+	})
+}
 
-	tsSvc, err = ft.GetVIPService(context.Background(), "svc:my-svc")
-	if err != nil {
-		t.Fatalf("getting Tailscale Service after deletion: %v", err)
+func TestIngressPGReconciler_MultiClusterTags(t *testing.T) {
+	// Two operators in different clusters, with different default tags,
+	// sharing one Tailscale Service.
+	ingPGR1, fc1, ft := setupIngressTest(t)
+	ingPGR1.operatorID = "operator-1"
+	ingPGR1.defaultTags = []string{"tag:cluster-1", "tag:k8s"}
+	ingPGR2, fc2, _ := setupIngressTest(t)
+	ingPGR2.operatorID = "operator-2"
+	ingPGR2.defaultTags = []string{"tag:cluster-2", "tag:k8s"}
+	ingPGR2.tsClient = ft
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
 	}
-	if tsSvc == nil {
-		t.Fatal("Tailscale Service was incorrectly deleted")
+	mustCreate(t, fc1, ing.DeepCopy())
+	mustCreate(t, fc2, ing.DeepCopy())
+
+	expectTags := func(t *testing.T, want ...string) {
+		t.Helper()
+		tsSvc, err := ft.GetVIPService(t.Context(), "svc:my-svc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(tsSvc.Tags, want) {
+			t.Errorf("Tailscale Service tags = %v, want %v", tsSvc.Tags, want)
+		}
 	}
 
-	o, err = parseOwnerAnnotation(tsSvc)
-	if err != nil {
-		t.Fatalf("parsing owner annotation: %v", err)
+	expectReconciled(t, ingPGR1, "default", "test-ingress")
+	expectTags(t, "tag:cluster-1", "tag:k8s")
+
+	// The second operator adds its tags rather than replacing the first's.
+	expectReconciled(t, ingPGR2, "default", "test-ingress")
+	expectTags(t, "tag:cluster-1", "tag:cluster-2", "tag:k8s")
+
+	// Reconciling again with the first operator keeps the union.
+	expectReconciled(t, ingPGR1, "default", "test-ingress")
+	expectTags(t, "tag:cluster-1", "tag:cluster-2", "tag:k8s")
+
+	// Once the second operator stops owning the Tailscale Service, its tags
+	// are removed.
+	if err := fc2.Delete(t.Context(), ing.DeepCopy()); err != nil {
+		t.Fatal(err)
 	}
+	expectRequeue(t, ingPGR2, "default", "test-ingress")
+	expectTags(t, "tag:cluster-1", "tag:k8s")
+}
 
-	wantOwnerRefs = []OwnerRef{
-		{OperatorID: "operator-2"},
+func TestIngressPGReconciler_MultiClusterHTTPEndpoint(t *testing.T) {
+	// Ingresses in two clusters share one Tailscale Service, but only the
+	// second one requests an HTTP endpoint.
+	ingPGR1, fc1, ft := setupIngressTest(t)
+	ingPGR1.operatorID = "operator-1"
+	fr1 := record.NewFakeRecorder(10)
+	ingPGR1.recorder = fr1
+	ingPGR2, fc2, _ := setupIngressTest(t)
+	ingPGR2.operatorID = "operator-2"
+	ingPGR2.tsClient = ft
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
 	}
-	if !reflect.DeepEqual(o.OwnerRefs, wantOwnerRefs) {
-		t.Errorf("incorrect owner refs after deletion\ngot:  %+v\nwant: %+v", o.OwnerRefs, wantOwnerRefs)
+	mustCreate(t, fc1, ing.DeepCopy())
+	ing2 := ing.DeepCopy()
+	ing2.Annotations[annotationHTTPEndpoint] = "enabled"
+	mustCreate(t, fc2, ing2)
+
+	expectReconciled(t, ingPGR1, "default", "test-ingress")
+	verifyTailscaleService(t, ft, "svc:my-svc", []string{"tcp:443"})
+
+	// HTTP is enabled if any owner requests it ...
+	expectReconciled(t, ingPGR2, "default", "test-ingress")
+	verifyTailscaleService(t, ft, "svc:my-svc", []string{"tcp:443", "tcp:80"})
+
+	// ... so the first operator does not disable it again, but serves HTTP
+	// too and warns that its Ingress is overruled.
+	expectReconciled(t, ingPGR1, "default", "test-ingress")
+	verifyTailscaleService(t, ft, "svc:my-svc", []string{"tcp:443", "tcp:80"})
+	verifyServeConfig(t, fc1, "svc:my-svc", true)
+	expectEvents(t, fr1, []string{"Warning ConflictingHTTPEndpoint HTTP endpoint is not enabled for this Ingress, but Tailscale Service svc:my-svc is exposed over HTTP as it is requested by an Ingress in another cluster sharing it"})
+
+	// Once the second operator stops owning the Tailscale Service, HTTP is
+	// disabled.
+	if err := fc2.Delete(t.Context(), ing2); err != nil {
+		t.Fatal(err)
 	}
+	expectRequeue(t, ingPGR2, "default", "test-ingress")
+	verifyTailscaleService(t, ft, "svc:my-svc", []string{"tcp:443"})
+	expectReconciled(t, ingPGR1, "default", "test-ingress")
+	verifyServeConfig(t, fc1, "svc:my-svc", false)
 }
 
 func TestOwnerAnnotations(t *testing.T) {
@@ -711,6 +5420,8 @@ func TestOwnerAnnotations(t *testing.T) {
 
 	for name, tc := range map[string]struct {
 		svc             *tailscale.VIPService
+		tags            []string
+		http            bool
 		wantAnnotations map[string]string
 		wantErr         string
 	}{
@@ -738,6 +5449,39 @@ func TestOwnerAnnotations(t *testing.T) {
 				ownerAnnotation: `{"ownerRefs":[{"operatorID":"operator-2"},{"operatorID":"self-id"}]}`,
 			},
 		},
+		"add_owner_with_tags": {
+			svc: &tailscale.VIPService{
+				Annotations: map[string]string{
+					ownerAnnotation: `{"ownerRefs":[{"operatorID":"operator-2","tags":["tag:other"]}]}`,
+				},
+			},
+			tags: []string{"tag:k8s"},
+			wantAnnotations: map[string]string{
+				ownerAnnotation: `{"ownerRefs":[{"operatorID":"operator-2","tags":["tag:other"]},{"operatorID":"self-id","tags":["tag:k8s"]}]}`,
+			},
+		},
+		"update_http": {
+			svc: &tailscale.VIPService{
+				Annotations: map[string]string{
+					ownerAnnotation: `{"ownerRefs":[{"operatorID":"self-id"},{"operatorID":"operator-2"}]}`,
+				},
+			},
+			http: true,
+			wantAnnotations: map[string]string{
+				ownerAnnotation: `{"ownerRefs":[{"operatorID":"self-id","http":true},{"operatorID":"operator-2"}]}`,
+			},
+		},
+		"update_tags": {
+			svc: &tailscale.VIPService{
+				Annotations: map[string]string{
+					ownerAnnotation: `{"ownerRefs":[{"operatorID":"self-id","tags":["tag:old"]},{"operatorID":"operator-2"}]}`,
+				},
+			},
+			tags: []string{"tag:k8s"},
+			wantAnnotations: map[string]string{
+				ownerAnnotation: `{"ownerRefs":[{"operatorID":"self-id","tags":["tag:k8s"]},{"operatorID":"operator-2"}]}`,
+			},
+		},
 		"owned_by_proxygroup": {
 			svc: &tailscale.VIPService{
 				Annotations: map[string]string{
@@ -748,7 +5492,7 @@ func TestOwnerAnnotations(t *testing.T) {
 		},
 	} {
 		t.Run(name, func(t *testing.T) {
-			got, err := ownerAnnotations("self-id", tc.svc)
+			got, err := ownerAnnotations(OwnerRef{OperatorID: "self-id", Tags: tc.tags, HTTP: tc.http}, tc.svc, 0, zap.NewNop().Sugar())
 			if tc.wantErr != "" && !strings.Contains(err.Error(), tc.wantErr) {
 				t.Errorf("ownerAnnotations() error = %v, wantErr %v", err, tc.wantErr)
 			}
@@ -759,6 +5503,55 @@ func TestOwnerAnnotations(t *testing.T) {
 	}
 }
 
+func TestOwnerAnnotationsBudget(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+
+	// Many stale references, as left behind by concurrent writers, with
+	// duplicate operator IDs and tags.
+	var refs []string
+	for i := range 20 {
+		refs = append(refs, fmt.Sprintf(`{"operatorID":"operator-%d","tags":["tag:k8s","tag:k8s"]}`, i%5))
+	}
+	refs = append(refs, `{"tags":["tag:orphan"]}`)
+	svc := &tailscale.VIPService{
+		Annotations: map[string]string{
+			ownerAnnotation: `{"ownerRefs":[` + strings.Join(refs, ",") + `]}`,
+		},
+	}
+	want := `{"ownerRefs":[{"operatorID":"operator-0","tags":["tag:k8s"]},{"operatorID":"operator-1","tags":["tag:k8s"]},{"operatorID":"operator-2","tags":["tag:k8s"]},{"operatorID":"operator-3","tags":["tag:k8s"]},{"operatorID":"operator-4","tags":["tag:k8s"]},{"operatorID":"self-id","tags":["tag:k8s"]}]}`
+	ref := OwnerRef{OperatorID: "self-id", Tags: []string{"tag:k8s"}}
+
+	// Without a budget, the references are written as they are.
+	got, err := ownerAnnotations(ref, svc, 0, logger)
+	if err != nil {
+		t.Fatalf("ownerAnnotations() with no budget: %v", err)
+	}
+	if len(got[ownerAnnotation]) <= len(want) {
+		t.Errorf("ownerAnnotations() with no budget compacted the owner annotation to %q", got[ownerAnnotation])
+	}
+
+	// Within the budget after compaction.
+	got, err = ownerAnnotations(ref, svc, len(want), logger)
+	if err != nil {
+		t.Fatalf("ownerAnnotations() with budget %d: %v", len(want), err)
+	}
+	if diff := cmp.Diff(want, got[ownerAnnotation]); diff != "" {
+		t.Errorf("ownerAnnotations() mismatch (-want +got):\n%s", diff)
+	}
+
+	// Still over the budget after compaction.
+	_, err = ownerAnnotations(ref, svc, len(want)-1, logger)
+	if err == nil || !strings.Contains(err.Error(), "exceeding the budget") {
+		t.Errorf("ownerAnnotations() with budget %d: got error %v, want budget exceeded error", len(want)-1, err)
+	}
+
+	// A new Tailscale Service whose single reference does not fit.
+	_, err = ownerAnnotations(ref, nil, 10, logger)
+	if err == nil || !strings.Contains(err.Error(), "exceeding the budget") {
+		t.Errorf("ownerAnnotations() for new Tailscale Service: got error %v, want budget exceeded error", err)
+	}
+}
+
 func populateTLSSecret(t *testing.T, c client.Client, pgName, domain string) {
 	t.Helper()
 
@@ -857,6 +5650,11 @@ func verifyServeConfig(t *testing.T, fc client.Client, serviceName string, wantH
 }
 
 func verifyTailscaledConfig(t *testing.T, fc client.Client, pgName string, expectedServices []string) {
+	t.Helper()
+	verifyTailscaledConfigReplica(t, fc, pgName, 0, expectedServices)
+}
+
+func verifyTailscaledConfigReplica(t *testing.T, fc client.Client, pgName string, replica int32, expectedServices []string) {
 	t.Helper()
 	var expected string
 	if expectedServices != nil && len(expectedServices) > 0 {
@@ -868,7 +5666,7 @@ func verifyTailscaledConfig(t *testing.T, fc client.Client, pgName string, expec
 	}
 	expectEqual(t, fc, &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      pgConfigSecretName(pgName, 0),
+			Name:      pgConfigSecretName(pgName, replica),
 			Namespace: "operator-ns",
 			Labels:    pgSecretLabels(pgName, kubetypes.LabelSecretTypeConfig),
 		},
@@ -936,6 +5734,7 @@ func setupIngressTest(t *testing.T) (*HAIngressReconciler, client.Client, *fakeT
 
 	fc := fake.NewClientBuilder().
 		WithScheme(tsapi.GlobalScheme).
+		WithInterceptorFuncs(interceptor.Funcs{Patch: applyAsMergePatch}).
 		WithObjects(tsIngressClass).
 		WithStatusSubresource(&tsapi.ProxyGroup{}).
 		Build()
@@ -959,15 +5758,17 @@ func setupIngressTest(t *testing.T) (*HAIngressReconciler, client.Client, *fakeT
 	}
 
 	ingPGR := &HAIngressReconciler{
-		Client:           fc,
-		tsClient:         ft,
-		defaultTags:      []string{"tag:k8s"},
-		tsNamespace:      "operator-ns",
-		tsnetServer:      fakeTsnetServer,
-		logger:           zl.Sugar(),
-		recorder:         record.NewFakeRecorder(10),
-		lc:               lc,
-		ingressClassName: tsIngressClass.Name,
+		Client:                 fc,
+		tsClient:               ft,
+		defaultTags:            []string{"tag:k8s"},
+		tsNamespace:            "operator-ns",
+		tsnetServer:            fakeTsnetServer,
+		logger:                 zl.Sugar(),
+		recorder:               record.NewFakeRecorder(10),
+		lc:                     lc,
+		ingressClassName:       tsIngressClass.Name,
+		ingressClassController: tailscaleIngressControllerName,
+		clock:                  tstest.NewClock(tstest.ClockOpts{}),
 	}
 
 	return ingPGR, fc, ft
@@ -7,14 +7,23 @@
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"maps"
+	"net"
+	"net/netip"
+	"net/url"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"golang.org/x/net/http/httpguts"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -23,8 +32,10 @@
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"tailscale.com/internal/client/tailscale"
 	"tailscale.com/ipn"
 	"tailscale.com/kube/kubetypes"
+	"tailscale.com/tailcfg"
 	"tailscale.com/types/opt"
 	"tailscale.com/util/clientmetric"
 	"tailscale.com/util/mak"
@@ -35,6 +46,100 @@
 	tailscaleIngressControllerName = "tailscale.com/ts-ingress"                    // ingressClass.spec.controllerName for tailscale IngressClass resource
 	ingressClassDefaultAnnotation  = "ingressclass.kubernetes.io/is-default-class" // we do not support this https://kubernetes.io/docs/concepts/services-networking/ingress/#default-ingress-class
 	indexIngressProxyClass         = ".metadata.annotations.ingress-proxy-class"
+
+	// reasonProxyTooOld is the reason of the Events emitted for Ingresses
+	// whose serve config requires a newer version of the proxies.
+	reasonProxyTooOld = "ProxyTooOld"
+
+	// annotationDefaultResponse configures the response to requests whose
+	// path matches no Ingress rule. It can be set to "not-found" (default),
+	// to respond with a 404, or to "redirect:<target>", to redirect them to
+	// the absolute URL target. target can be prefixed with a 3xx status code
+	// to use instead of a 302, e.g. "redirect:301:https://example.com/". It
+	// has no effect if the Ingress has a default backend, as that already
+	// serves all unmatched paths.
+	annotationDefaultResponse     = "tailscale.com/default-response"
+	defaultResponseNotFound       = "not-found"
+	defaultResponseRedirectPrefix = "redirect:"
+
+	// annotationMaxConnections can be set to a positive integer to limit the
+	// number of requests for each path of the Ingress that each proxy
+	// concurrently sends to its backends. Requests beyond the limit are
+	// rejected with a 503.
+	annotationMaxConnections = "tailscale.com/max-connections"
+
+	// annotationBackendRetries can be set to the maximum number of times
+	// that the proxies retry a request if the backend Service cannot be
+	// reached or responds with a 502, 503 or 504, e.g. "3". The status codes
+	// to retry for can be set after a colon, e.g. "3:503,429". Only requests
+	// with an idempotent method and no body are retried.
+	annotationBackendRetries = "tailscale.com/backend-retries"
+	maxBackendRetries        = 10
+
+	// annotationResponseHeaders can be set to a JSON object of HTTP headers
+	// to set on all responses for the Ingress, e.g.
+	// {"Cache-Control": "no-store"}. Headers with an empty value are removed
+	// from backend responses.
+	annotationResponseHeaders = "tailscale.com/response-headers"
+
+	// annotationAccessLog can be set to "true" to make the proxies write an
+	// access log entry for each request to the Ingress, with the client's
+	// identity, the request path and the response status. The entries are
+	// written as JSON lines to the proxies' stderr, separately from their
+	// regular logs, from where a cluster log collector can ship them to a
+	// sink.
+	annotationAccessLog = "tailscale.com/access-log"
+
+	// annotationTCPKeepAliveIdle and annotationTCPKeepAliveInterval can be
+	// set to durations, e.g. "30s", to tune TCP keep-alives on the proxies'
+	// connections to backends, for backends behind NATs that drop idle
+	// connections. They set how long a connection must be idle before
+	// keep-alive probes are sent, and the time between probes.
+	annotationTCPKeepAliveIdle     = "tailscale.com/tcp-keepalive-idle"
+	annotationTCPKeepAliveInterval = "tailscale.com/tcp-keepalive-interval"
+
+	// annotationClientHTTPVersion can be used to configure the HTTP version
+	// that the proxies serve the Ingress to clients with. It can be set to
+	// "h2" (default), to offer HTTP/2 to clients via ALPN, or "http1", to
+	// serve HTTP/1.1 only, for legacy clients that break on HTTP/2.
+	annotationClientHTTPVersion = "tailscale.com/client-http-version"
+	clientHTTPVersionH2         = "h2"
+	clientHTTPVersionHTTP1      = "http1"
+
+	// annotationBackendDialFamily can be used to force the proxies to dial
+	// the Ingress' backends over one IP family, for dual-stack clusters
+	// where backends only listen on one of them. It can be set to "ipv4" or
+	// "ipv6", to proxy to the backend Service's ClusterIP, or its
+	// endpoints' addresses for headless Services, of that family. By
+	// default, the Service's primary ClusterIP is used.
+	annotationBackendDialFamily = "tailscale.com/backend-dial-family"
+	backendDialFamilyIPv4       = "ipv4"
+	backendDialFamilyIPv6       = "ipv6"
+
+	// funnelNever can be set as the value of the tailscale.com/funnel
+	// annotation to ensure that the Ingress is never exposed over Funnel.
+	funnelNever = "never"
+	// annotationFunnelGuard is set by the operator on Ingresses that have
+	// been marked with tailscale.com/funnel: "never". While it is present,
+	// any attempt to enable Funnel for the Ingress is rejected, even if the
+	// tailscale.com/funnel annotation is later changed. It must be removed
+	// to deliberately allow Funnel again.
+	annotationFunnelGuard = "tailscale.com/funnel-guard"
+
+	// reasonBackendPortMissing is the reason of the warning Event emitted
+	// for an Ingress backend that refers to a named port its Service does
+	// not (or no longer) have.
+	reasonBackendPortMissing = "BackendPortMissing"
+
+	// annotationStandaloneService can be set to "true" on an Ingress that
+	// is not exposed on a ProxyGroup to expose it on a Tailscale Service
+	// advertised by its standalone proxy, rather than on the proxy's own
+	// MagicDNS name. This gives small setups without a ProxyGroup the same
+	// stable Tailscale Service address as HA Ingresses. The proxy device is
+	// named after the Tailscale Service with a "-proxy" suffix, so that
+	// their MagicDNS names do not clash. It can not be combined with
+	// Funnel, which Tailscale Services do not support.
+	annotationStandaloneService = "tailscale.com/standalone-service"
 )
 
 type IngressReconciler struct {
@@ -50,8 +155,16 @@ type IngressReconciler struct {
 	// managing. This is only used for metrics.
 	managedIngresses set.Slice[types.UID]
 
-	defaultProxyClass string
-	ingressClassName  string
+	defaultProxyClass      string
+	ingressClassName       string
+	ingressClassController string           // expected spec.controller of the IngressClass
+	annotationPrefix       annotationPrefix // prefix of user-set annotations
+	annotationWarnings     annotationWarnings
+
+	// lc and operatorID are used to manage the Tailscale Services of
+	// Ingresses with the tailscale.com/standalone-service annotation.
+	lc         localClient
+	operatorID string // stableID of the operator's Tailscale device
 }
 
 var (
@@ -98,10 +211,14 @@ func (a *IngressReconciler) maybeCleanup(ctx context.Context, logger *zap.Sugare
 		a.mu.Lock()
 		defer a.mu.Unlock()
 		a.managedIngresses.Remove(ing.UID)
+		a.annotationWarnings.forget(ing.UID)
 		gaugeIngressResources.Set(int64(a.managedIngresses.Len()))
 		return nil
 	}
 
+	if err := a.cleanupStandaloneService(ctx, hostnameForIngress(ing), logger); err != nil {
+		return fmt.Errorf("failed to clean up Tailscale Service: %w", err)
+	}
 	if done, err := a.ssr.Cleanup(ctx, logger, childResourceLabels(ing.Name, ing.Namespace, "ingress"), proxyTypeIngressResource); err != nil {
 		return fmt.Errorf("failed to cleanup: %w", err)
 	} else if !done {
@@ -122,6 +239,7 @@ func (a *IngressReconciler) maybeCleanup(ctx context.Context, logger *zap.Sugare
 	a.mu.Lock()
 	defer a.mu.Unlock()
 	a.managedIngresses.Remove(ing.UID)
+	a.annotationWarnings.forget(ing.UID)
 	gaugeIngressResources.Set(int64(a.managedIngresses.Len()))
 	return nil
 }
@@ -132,9 +250,18 @@ func (a *IngressReconciler) maybeCleanup(ctx context.Context, logger *zap.Sugare
 // This function adds a finalizer to ing, ensuring that we can handle orderly
 // deprovisioning later.
 func (a *IngressReconciler) maybeProvision(ctx context.Context, logger *zap.SugaredLogger, ing *networkingv1.Ingress) error {
-	if err := validateIngressClass(ctx, a.Client, a.ingressClassName); err != nil {
+	if err := validateIngressClass(ctx, a.Client, a.ingressClassName, a.ingressClassController); err != nil {
 		logger.Warnf("error validating tailscale IngressClass: %v. In future this might be a terminal error.", err)
 	}
+	a.annotationWarnings.warn(a.recorder, a.annotationPrefix, ing)
+	if slices.Contains(ing.Finalizers, FinalizerNamePG) {
+		// The Ingress was previously exposed on a ProxyGroup. Wait for the
+		// HA Ingress reconciler to clean up its Tailscale Service, which
+		// has the same DNS name as the standalone proxy would have.
+		// Removing the finalizer triggers another reconcile.
+		logger.Infof("Ingress is being switched from a ProxyGroup to a standalone proxy, waiting for ProxyGroup resources to be cleaned up")
+		return nil
+	}
 	if !slices.Contains(ing.Finalizers, FinalizerName) {
 		// This log line is printed exactly once during initial provisioning,
 		// because once the finalizer is in place this block gets skipped. So,
@@ -147,7 +274,7 @@ func (a *IngressReconciler) maybeProvision(ctx context.Context, logger *zap.Suga
 		}
 	}
 
-	proxyClass := proxyClassForObject(ing, a.defaultProxyClass)
+	proxyClass := proxyClassForObject(ing, a.defaultProxyClass, a.annotationPrefix)
 	if proxyClass != "" {
 		if ready, err := proxyClassIsReady(ctx, proxyClass, a.Client); err != nil {
 			return fmt.Errorf("error verifying ProxyClass for Ingress: %w", err)
@@ -162,6 +289,19 @@ func (a *IngressReconciler) maybeProvision(ctx context.Context, logger *zap.Suga
 	gaugeIngressResources.Set(int64(a.managedIngresses.Len()))
 	a.mu.Unlock()
 
+	err := validateFunnel(ing, a.annotationPrefix)
+	if err == nil {
+		err = validateStandaloneService(ing, a.annotationPrefix)
+	}
+	if err != nil {
+		logger.Infof("invalid Ingress configuration: %v", err)
+		a.recorder.Event(ing, corev1.EventTypeWarning, "InvalidIngressConfiguration", err.Error())
+		return nil
+	}
+	if err := ensureFunnelGuard(ctx, a.Client, ing, a.annotationPrefix); err != nil {
+		return err
+	}
+
 	if !IsHTTPSEnabledOnTailnet(a.ssr.tsnetServer) {
 		a.recorder.Event(ing, corev1.EventTypeWarning, "HTTPSNotEnabled", "HTTPS is not enabled on the tailnet; ingress may not work")
 	}
@@ -181,19 +321,34 @@ func (a *IngressReconciler) maybeProvision(ctx context.Context, logger *zap.Suga
 			},
 		},
 	}
-	if opt.Bool(ing.Annotations[AnnotationFunnel]).EqualBool(true) {
+	if idle, interval, err := tcpKeepAlive(ing, a.annotationPrefix); err != nil {
+		a.recorder.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressAnnotation", "%v, TCP keep-alives will not be configured", err)
+	} else {
+		sc.TCP[443].KeepAliveIdle, sc.TCP[443].KeepAliveInterval = idle, interval
+	}
+	if http1Only, err := clientHTTP1Only(ing, a.annotationPrefix); err != nil {
+		a.recorder.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressAnnotation", "%v, HTTP/2 will be offered to clients", err)
+	} else {
+		sc.TCP[443].HTTP1Only = http1Only
+	}
+	if funnelEnabled(ing, a.annotationPrefix) {
 		sc.AllowFunnel = map[ipn.HostPort]bool{
 			magic443: true,
 		}
 	}
 
 	web := sc.Web[magic443]
+	if accessLog, err := accessLogEnabled(ing, a.annotationPrefix); err != nil {
+		a.recorder.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressAnnotation", "%v, access logs will not be written", err)
+	} else {
+		web.AccessLog = accessLog
+	}
 
 	var tlsHost string // hostname or FQDN or empty
 	if ing.Spec.TLS != nil && len(ing.Spec.TLS) > 0 && len(ing.Spec.TLS[0].Hosts) > 0 {
 		tlsHost = ing.Spec.TLS[0].Hosts[0]
 	}
-	handlers, err := handlersForIngress(ctx, ing, a.Client, a.recorder, tlsHost, logger)
+	handlers, err := handlersForIngress(ctx, ing, a.Client, a.recorder, tlsHost, logger, a.annotationPrefix)
 	if err != nil {
 		return fmt.Errorf("failed to get handlers for ingress: %w", err)
 	}
@@ -206,14 +361,39 @@ func (a *IngressReconciler) maybeProvision(ctx context.Context, logger *zap.Suga
 
 	crl := childResourceLabels(ing.Name, ing.Namespace, "ingress")
 	var tags []string
-	if tstr, ok := ing.Annotations[AnnotationTags]; ok {
+	if tstr, ok := a.annotationPrefix.lookup(ing.Annotations, AnnotationTags); ok {
 		tags = strings.Split(tstr, ",")
 	}
 	hostname := hostnameForIngress(ing)
+	proxyHostname := hostname
+	var svcDNSName string // set if the Ingress is exposed on a Tailscale Service
+	var advertiseServices []string
+	if isStandaloneService(ing, a.annotationPrefix) {
+		if len(tags) == 0 {
+			tags = a.ssr.defaultTags
+		}
+		svcDNSName, err = a.ensureStandaloneService(ctx, hostname, tags, logger)
+		if err != nil {
+			return fmt.Errorf("failed to ensure Tailscale Service: %w", err)
+		}
+		serviceName := tailcfg.ServiceName("svc:" + hostname)
+		sc = &ipn.ServeConfig{
+			Services: map[tailcfg.ServiceName]*ipn.ServiceConfig{
+				serviceName: {
+					TCP: sc.TCP,
+					Web: map[ipn.HostPort]*ipn.WebServerConfig{
+						ipn.HostPort(svcDNSName + ":443"): web,
+					},
+				},
+			},
+		}
+		proxyHostname = hostname + "-proxy"
+		advertiseServices = []string{serviceName.String()}
+	}
 
 	sts := &tailscaleSTSConfig{
 		Replicas:            1,
-		Hostname:            hostname,
+		Hostname:            proxyHostname,
 		ParentResourceName:  ing.Name,
 		ParentResourceUID:   string(ing.UID),
 		ServeConfig:         sc,
@@ -222,9 +402,10 @@ func (a *IngressReconciler) maybeProvision(ctx context.Context, logger *zap.Suga
 		ProxyClassName:      proxyClass,
 		proxyType:           proxyTypeIngressResource,
 		LoginServer:         a.ssr.loginServer,
+		AdvertiseServices:   advertiseServices,
 	}
 
-	if val := ing.GetAnnotations()[AnnotationExperimentalForwardClusterTrafficViaL7IngresProxy]; val == "true" {
+	if val := a.annotationPrefix.get(ing.GetAnnotations(), AnnotationExperimentalForwardClusterTrafficViaL7IngresProxy); val == "true" {
 		sts.ForwardClusterTrafficViaL7IngressProxy = true
 	}
 
@@ -236,6 +417,16 @@ func (a *IngressReconciler) maybeProvision(ctx context.Context, logger *zap.Suga
 	if err != nil {
 		return fmt.Errorf("failed to retrieve Ingress HTTPS endpoint status: %w", err)
 	}
+	// The proxy is upgraded by provisioning it, so the serve config is
+	// applied regardless of the proxy's version.
+	var capvers []tailcfg.CapabilityVersion
+	for _, dev := range devices {
+		capvers = append(capvers, dev.capver)
+	}
+	if err := checkProxyCapVers(sc.RequiredCapVer(), capvers); err != nil {
+		logger.Infof("%v", err)
+		a.recorder.Eventf(ing, corev1.EventTypeWarning, reasonProxyTooOld, "%v; until then, it ignores the settings that it does not support", err)
+	}
 
 	ing.Status.LoadBalancer.Ingress = nil
 	for _, dev := range devices {
@@ -243,9 +434,14 @@ func (a *IngressReconciler) maybeProvision(ctx context.Context, logger *zap.Suga
 			continue
 		}
 
-		logger.Debugf("setting Ingress hostname to %q", dev.ingressDNSName)
+		// Once the proxy is up, it advertises the Tailscale Service.
+		dnsName := dev.ingressDNSName
+		if svcDNSName != "" {
+			dnsName = svcDNSName
+		}
+		logger.Debugf("setting Ingress hostname to %q", dnsName)
 		ing.Status.LoadBalancer.Ingress = append(ing.Status.LoadBalancer.Ingress, networkingv1.IngressLoadBalancerIngress{
-			Hostname: dev.ingressDNSName,
+			Hostname: dnsName,
 			Ports: []networkingv1.IngressPortStatus{
 				{
 					Protocol: "TCP",
@@ -262,17 +458,132 @@ func (a *IngressReconciler) maybeProvision(ctx context.Context, logger *zap.Suga
 	return nil
 }
 
+// isStandaloneService reports whether the Ingress should be exposed on a
+// Tailscale Service advertised by its standalone proxy.
+func isStandaloneService(ing *networkingv1.Ingress, prefix annotationPrefix) bool {
+	return prefix.get(ing.Annotations, annotationStandaloneService) == "true"
+}
+
+// validateStandaloneService validates the tailscale.com/standalone-service
+// annotation.
+func validateStandaloneService(ing *networkingv1.Ingress, prefix annotationPrefix) error {
+	v, ok := prefix.lookup(ing.Annotations, annotationStandaloneService)
+	switch {
+	case !ok || v == "false":
+		return nil
+	case v != "true":
+		return fmt.Errorf("Ingress has invalid %s annotation %q: must be \"true\" or \"false\"", prefix.key(annotationStandaloneService), v)
+	case prefix.get(ing.Annotations, AnnotationProxyGroup) != "":
+		return fmt.Errorf("Ingress has both %s and %s annotations: a standalone proxy can not be requested for an Ingress exposed on a ProxyGroup, remove one of them", prefix.key(annotationStandaloneService), prefix.key(AnnotationProxyGroup))
+	case funnelEnabled(ing, prefix):
+		return fmt.Errorf("Ingress has %s annotation, but Funnel is enabled: Tailscale Services can not be exposed over Funnel", prefix.key(annotationStandaloneService))
+	}
+	return nil
+}
+
+// ensureStandaloneService ensures that the Tailscale Service named after
+// hostname exists with this operator as an owner, for an Ingress with the
+// tailscale.com/standalone-service annotation. It returns the Tailscale
+// Service's MagicDNS name.
+func (a *IngressReconciler) ensureStandaloneService(ctx context.Context, hostname string, tags []string, logger *zap.SugaredLogger) (string, error) {
+	tcd, err := tailnetCertDomain(ctx, a.lc)
+	if err != nil {
+		return "", fmt.Errorf("error determining DNS name base: %w", err)
+	}
+	serviceName := tailcfg.ServiceName("svc:" + hostname)
+	existing, err := a.ssr.tsClient.GetVIPService(ctx, serviceName)
+	if err != nil && !isErrorTailscaleServiceNotFound(err) {
+		return "", fmt.Errorf("error getting Tailscale Service %q: %w", serviceName, err)
+	}
+	annots, err := ownerAnnotations(OwnerRef{OperatorID: a.operatorID, Tags: tags}, existing, 0, logger)
+	if err != nil {
+		return "", fmt.Errorf("error ensuring ownership of Tailscale Service %s: %w", serviceName, err)
+	}
+	o, err := parseOwnerAnnotation(&tailscale.VIPService{Annotations: annots})
+	if err != nil {
+		return "", err
+	}
+	tsSvc := &tailscale.VIPService{
+		Name:        serviceName,
+		Ports:       []string{"tcp:443"},
+		Comment:     managedTSServiceComment,
+		Annotations: annots,
+		Tags:        ownerTags(o),
+	}
+	if existing != nil {
+		tsSvc.Addrs = existing.Addrs
+	}
+	if existing == nil ||
+		!slices.Equal(tsSvc.Tags, existing.Tags) ||
+		!slices.Equal(tsSvc.Ports, existing.Ports) ||
+		!ownersAreSetAndEqual(tsSvc, existing) {
+		logger.Infof("Ensuring Tailscale Service %q exists and is up to date", serviceName)
+		if err := a.ssr.tsClient.CreateOrUpdateVIPService(ctx, tsSvc); err != nil {
+			return "", fmt.Errorf("error creating Tailscale Service: %w", err)
+		}
+	}
+	return hostname + "." + tcd, nil
+}
+
+// cleanupStandaloneService removes this operator's owner reference from the
+// Tailscale Service named after hostname, if any, and deletes it if there are
+// no other owners. Tailscale Services of HA Ingresses are owned by the HA
+// Ingress reconciler, which never exposes an Ingress that is handled by this
+// reconciler, so any owner reference of this operator belongs to the Ingress.
+func (a *IngressReconciler) cleanupStandaloneService(ctx context.Context, hostname string, logger *zap.SugaredLogger) error {
+	if a.operatorID == "" {
+		return nil
+	}
+	serviceName := tailcfg.ServiceName("svc:" + hostname)
+	svc, err := a.ssr.tsClient.GetVIPService(ctx, serviceName)
+	if isErrorTailscaleServiceNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error getting Tailscale Service %q: %w", serviceName, err)
+	}
+	o, err := parseOwnerAnnotation(svc)
+	if err != nil || o == nil {
+		return err
+	}
+	ix := slices.IndexFunc(o.OwnerRefs, func(or OwnerRef) bool {
+		return or.OperatorID == a.operatorID && or.Resource == nil
+	})
+	if ix == -1 {
+		return nil
+	}
+	if len(o.OwnerRefs) == 1 {
+		logger.Infof("Deleting Tailscale Service %q", serviceName)
+		if err := a.ssr.tsClient.DeleteVIPService(ctx, serviceName); err != nil && !isErrorTailscaleServiceNotFound(err) {
+			return err
+		}
+		return nil
+	}
+	o.OwnerRefs = slices.Delete(o.OwnerRefs, ix, ix+1)
+	if tags := ownerTags(o); len(tags) > 0 {
+		svc.Tags = tags
+	}
+	b, err := json.Marshal(o)
+	if err != nil {
+		return fmt.Errorf("error marshalling updated Tailscale Service owner reference: %w", err)
+	}
+	svc.Annotations[ownerAnnotation] = string(b)
+	logger.Infof("Removing owner reference from Tailscale Service %q", serviceName)
+	return a.ssr.tsClient.CreateOrUpdateVIPService(ctx, svc)
+}
+
 func (a *IngressReconciler) shouldExpose(ing *networkingv1.Ingress) bool {
 	return ing != nil &&
 		ing.Spec.IngressClassName != nil &&
 		*ing.Spec.IngressClassName == a.ingressClassName &&
-		ing.Annotations[AnnotationProxyGroup] == ""
+		a.annotationPrefix.get(ing.Annotations, AnnotationProxyGroup) == ""
 }
 
 // validateIngressClass attempts to validate that 'tailscale' IngressClass
 // included in Tailscale installation manifests exists and has not been modified
-// to attempt to enable features that we do not support.
-func validateIngressClass(ctx context.Context, cl client.Client, ingressClassName string) error {
+// to attempt to enable features that we do not support. Its controller must
+// be controllerName, which is tailscaleIngressControllerName unless the
+// operator has been configured otherwise.
+func validateIngressClass(ctx context.Context, cl client.Client, ingressClassName, controllerName string) error {
 	ic := &networkingv1.IngressClass{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: ingressClassName,
@@ -283,8 +594,8 @@ func validateIngressClass(ctx context.Context, cl client.Client, ingressClassNam
 	} else if err != nil {
 		return fmt.Errorf("error retrieving 'tailscale' IngressClass: %w", err)
 	}
-	if ic.Spec.Controller != tailscaleIngressControllerName {
-		return fmt.Errorf("'tailscale' Ingress class controller name %s does not match tailscale Ingress controller name %s. Ensure that you are using 'tailscale' IngressClass from latest Tailscale installation manifests", ic.Spec.Controller, tailscaleIngressControllerName)
+	if ic.Spec.Controller != controllerName {
+		return fmt.Errorf("'tailscale' Ingress class controller name %s does not match tailscale Ingress controller name %s. Ensure that you are using 'tailscale' IngressClass from latest Tailscale installation manifests", ic.Spec.Controller, controllerName)
 	}
 	if ic.GetAnnotations()[ingressClassDefaultAnnotation] != "" {
 		return fmt.Errorf("%s annotation is set on 'tailscale' IngressClass, but Tailscale Ingress controller does not support default Ingress class. Ensure that you are using 'tailscale' IngressClass from latest Tailscale installation manifests", ingressClassDefaultAnnotation)
@@ -292,52 +603,42 @@ func validateIngressClass(ctx context.Context, cl client.Client, ingressClassNam
 	return nil
 }
 
-func handlersForIngress(ctx context.Context, ing *networkingv1.Ingress, cl client.Client, rec record.EventRecorder, tlsHost string, logger *zap.SugaredLogger) (handlers map[string]*ipn.HTTPHandler, err error) {
+func handlersForIngress(ctx context.Context, ing *networkingv1.Ingress, cl client.Client, rec record.EventRecorder, tlsHost string, logger *zap.SugaredLogger, prefix annotationPrefix) (handlers map[string]*ipn.HTTPHandler, err error) {
+	return handlersForIngressBackends(ing, rec, tlsHost, logger, func(b *networkingv1.IngressBackend, path string) *ipn.HTTPHandler {
+		return proxyHandlerForBackend(ctx, ing, cl, rec, b, path, prefix)
+	}, prefix)
+}
+
+// handlersForIngressBackends is like handlersForIngress, but uses
+// proxyHandler to build the handler that proxies requests for a path to its
+// backend. proxyHandler returns nil if the backend can not be proxied to.
+func handlersForIngressBackends(ing *networkingv1.Ingress, rec record.EventRecorder, tlsHost string, logger *zap.SugaredLogger, proxyHandler func(b *networkingv1.IngressBackend, path string) *ipn.HTTPHandler, prefix annotationPrefix) (handlers map[string]*ipn.HTTPHandler, err error) {
+	maxConns, err := maxConnections(ing, prefix)
+	if err != nil {
+		rec.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressAnnotation", "%v, backend connections will not be limited", err)
+	}
+	respHeaders, err := responseHeaders(ing, prefix)
+	if err != nil {
+		rec.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressAnnotation", "%v, no response headers will be set", err)
+	}
+	retries, retryCodes, err := backendRetries(ing, prefix)
+	if err != nil {
+		rec.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressAnnotation", "%v, requests to backends will not be retried", err)
+	}
+	if _, err := backendDialFamily(ing, prefix); err != nil {
+		rec.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressAnnotation", "%v, backends will be dialed over their primary IP family", err)
+	}
 	addIngressBackend := func(b *networkingv1.IngressBackend, path string) {
 		if path == "" {
 			path = "/"
 			rec.Eventf(ing, corev1.EventTypeNormal, "PathUndefined", "configured backend is missing a path, defaulting to '/'")
 		}
-
-		if b == nil {
-			return
-		}
-
-		if b.Service == nil {
-			rec.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressBackend", "backend for path %q is missing service", path)
-			return
+		if h := proxyHandler(b, path); h != nil {
+			h.MaxConns = maxConns
+			h.BackendRetries = retries
+			h.RetryStatusCodes = slices.Clone(retryCodes)
+			mak.Set(&handlers, path, h)
 		}
-		var svc corev1.Service
-		if err := cl.Get(ctx, types.NamespacedName{Namespace: ing.Namespace, Name: b.Service.Name}, &svc); err != nil {
-			rec.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressBackend", "failed to get service %q for path %q: %v", b.Service.Name, path, err)
-			return
-		}
-		if svc.Spec.ClusterIP == "" || svc.Spec.ClusterIP == "None" {
-			rec.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressBackend", "backend for path %q has invalid ClusterIP", path)
-			return
-		}
-		var port int32
-		if b.Service.Port.Name != "" {
-			for _, p := range svc.Spec.Ports {
-				if p.Name == b.Service.Port.Name {
-					port = p.Port
-					break
-				}
-			}
-		} else {
-			port = b.Service.Port.Number
-		}
-		if port == 0 {
-			rec.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressBackend", "backend for path %q has invalid port", path)
-			return
-		}
-		proto := "http://"
-		if port == 443 || b.Service.Port.Name == "https" {
-			proto = "https+insecure://"
-		}
-		mak.Set(&handlers, path, &ipn.HTTPHandler{
-			Proxy: proto + svc.Spec.ClusterIP + ":" + fmt.Sprint(port) + path,
-		})
 	}
 	addIngressBackend(ing.Spec.DefaultBackend, "/")
 	for _, rule := range ing.Spec.Rules {
@@ -360,9 +661,419 @@ func handlersForIngress(ctx context.Context, ing *networkingv1.Ingress, cl clien
 			addIngressBackend(&p.Backend, p.Path)
 		}
 	}
+	if _, ok := handlers["/"]; !ok {
+		h, err := defaultResponseHandler(ing, prefix)
+		if err != nil {
+			rec.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressAnnotation", "%v, unmatched paths will return 404", err)
+		} else if h != nil {
+			mak.Set(&handlers, "/", h)
+		}
+	}
+	if len(respHeaders) > 0 {
+		for _, h := range handlers {
+			h.ResponseHeaders = maps.Clone(respHeaders)
+		}
+	}
 	return handlers, nil
 }
 
+// proxyHandlerForBackend returns the handler that proxies requests for path to
+// the Service backend b in the Ingress' namespace. It returns nil, after
+// emitting a warning Event, if b can not be proxied to.
+func proxyHandlerForBackend(ctx context.Context, ing *networkingv1.Ingress, cl client.Client, rec record.EventRecorder, b *networkingv1.IngressBackend, path string, prefix annotationPrefix) *ipn.HTTPHandler {
+	if b == nil {
+		return nil
+	}
+
+	if b.Service == nil {
+		rec.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressBackend", "backend for path %q is missing service", path)
+		return nil
+	}
+	var svc corev1.Service
+	if err := cl.Get(ctx, types.NamespacedName{Namespace: ing.Namespace, Name: b.Service.Name}, &svc); err != nil {
+		rec.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressBackend", "failed to get service %q for path %q: %v", b.Service.Name, path, err)
+		return nil
+	}
+	var port int32
+	if b.Service.Port.Name != "" {
+		// Named ports are resolved on every reconcile, and changes to the
+		// Service trigger one, so a renamed port is never served from a
+		// previously resolved number.
+		for _, p := range svc.Spec.Ports {
+			if p.Name == b.Service.Port.Name {
+				port = p.Port
+				break
+			}
+		}
+		if port == 0 {
+			var names []string
+			for _, p := range svc.Spec.Ports {
+				if p.Name != "" {
+					names = append(names, p.Name)
+				}
+			}
+			rec.Eventf(ing, corev1.EventTypeWarning, reasonBackendPortMissing, "backend for path %q refers to port %q, which Service %q does not have; update the Ingress to use one of the Service's named ports %q", path, b.Service.Port.Name, svc.Name, names)
+			return nil
+		}
+	} else {
+		port = b.Service.Port.Number
+	}
+	if port <= 0 || port > 65535 {
+		rec.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressBackend", "backend for path %q has invalid port %d: port must be between 1 and 65535", path, port)
+		return nil
+	}
+	proto := "http://"
+	if port == 443 || b.Service.Port.Name == "https" {
+		proto = "https+insecure://"
+	}
+	family, _ := backendDialFamily(ing, prefix) // invalid values are reported by callers
+	var host string
+	switch {
+	case svc.Spec.Type == corev1.ServiceTypeExternalName:
+		// ExternalName Services have no ClusterIP, so proxy to the
+		// external DNS name directly.
+		host = strings.TrimSuffix(svc.Spec.ExternalName, ".")
+		if host == "" {
+			rec.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressBackend", "backend for path %q has empty ExternalName", path)
+			return nil
+		}
+		if family != "" {
+			rec.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressAnnotation", "backend for path %q is an ExternalName Service, which the %s annotation does not apply to", path, prefix.key(annotationBackendDialFamily))
+		}
+	case svc.Spec.ClusterIP == corev1.ClusterIPNone:
+		// Headless Services have no virtual IP, so proxy to one of
+		// their endpoints instead.
+		var err error
+		host, port, err = headlessServiceEndpoint(ctx, cl, &svc, b.Service.Port.Name, port, family)
+		if err != nil {
+			rec.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressBackend", "backend for path %q is a headless Service: %v", path, err)
+			return nil
+		}
+	case svc.Spec.ClusterIP == "":
+		rec.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressBackend", "backend for path %q has invalid ClusterIP", path)
+		return nil
+	case family != "":
+		host = clusterIPOfFamily(&svc, family)
+		if host == "" {
+			rec.Eventf(ing, corev1.EventTypeWarning, "InvalidIngressBackend", "backend for path %q has no %s ClusterIP", path, family)
+			return nil
+		}
+	default:
+		host = svc.Spec.ClusterIP
+	}
+	return &ipn.HTTPHandler{
+		Proxy: proto + net.JoinHostPort(host, fmt.Sprint(port)) + path,
+	}
+}
+
+// maxConnections returns the limit on concurrent requests for each path, as
+// configured by the tailscale.com/max-connections annotation. It returns 0 if
+// requests should not be limited.
+func maxConnections(ing *networkingv1.Ingress, prefix annotationPrefix) (int, error) {
+	v, ok := prefix.lookup(ing.Annotations, annotationMaxConnections)
+	if !ok {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("Ingress has invalid %s annotation %q: must be a positive integer", prefix.key(annotationMaxConnections), v)
+	}
+	return n, nil
+}
+
+// backendRetries returns the maximum number of retries of requests to each
+// backend, and the response status codes to retry for, as configured by the
+// tailscale.com/backend-retries annotation. It returns 0 retries if requests
+// should not be retried, and nil status codes if the proxies' defaults should
+// be used.
+func backendRetries(ing *networkingv1.Ingress, prefix annotationPrefix) (retries int, statusCodes []int, _ error) {
+	v, ok := prefix.lookup(ing.Annotations, annotationBackendRetries)
+	if !ok {
+		return 0, nil, nil
+	}
+	invalid := func(reason string) error {
+		return fmt.Errorf("Ingress has invalid %s annotation %q: %s", prefix.key(annotationBackendRetries), v, reason)
+	}
+	count, codes, hasCodes := strings.Cut(v, ":")
+	n, err := strconv.Atoi(count)
+	if err != nil || n <= 0 || n > maxBackendRetries {
+		return 0, nil, invalid(fmt.Sprintf("the number of retries must be an integer between 1 and %d", maxBackendRetries))
+	}
+	if !hasCodes {
+		return n, nil, nil
+	}
+	for c := range strings.SplitSeq(codes, ",") {
+		code, err := strconv.Atoi(strings.TrimSpace(c))
+		if err != nil || code < 400 || code > 599 {
+			return 0, nil, invalid(fmt.Sprintf("status code %q must be an HTTP error status code between 400 and 599", c))
+		}
+		if !slices.Contains(statusCodes, code) {
+			statusCodes = append(statusCodes, code)
+		}
+	}
+	return n, statusCodes, nil
+}
+
+// tcpKeepAlive returns the TCP keep-alive idle time and probe interval for
+// connections to backends, as configured by the
+// tailscale.com/tcp-keepalive-idle and tailscale.com/tcp-keepalive-interval
+// annotations. Unset values are returned as 0, to use the system defaults.
+func tcpKeepAlive(ing *networkingv1.Ingress, prefix annotationPrefix) (idle, interval time.Duration, err error) {
+	if idle, err = tcpKeepAliveDuration(ing, prefix, annotationTCPKeepAliveIdle); err != nil {
+		return 0, 0, err
+	}
+	if interval, err = tcpKeepAliveDuration(ing, prefix, annotationTCPKeepAliveInterval); err != nil {
+		return 0, 0, err
+	}
+	return idle, interval, nil
+}
+
+// tcpKeepAliveDuration returns the duration configured by the TCP keep-alive
+// annotation annot, or 0 if it is not set.
+func tcpKeepAliveDuration(ing *networkingv1.Ingress, prefix annotationPrefix, annot string) (time.Duration, error) {
+	v, ok := prefix.lookup(ing.Annotations, annot)
+	if !ok {
+		return 0, nil
+	}
+	// Keep-alive socket options have a granularity of seconds.
+	d, err := time.ParseDuration(v)
+	if err != nil || d < time.Second {
+		return 0, fmt.Errorf("Ingress has invalid %s annotation %q: must be a duration of at least 1s", prefix.key(annot), v)
+	}
+	return d, nil
+}
+
+// responseHeaders returns the HTTP headers to set on responses, as configured
+// by the tailscale.com/response-headers annotation.
+func responseHeaders(ing *networkingv1.Ingress, prefix annotationPrefix) (map[string]string, error) {
+	v, ok := prefix.lookup(ing.Annotations, annotationResponseHeaders)
+	if !ok {
+		return nil, nil
+	}
+	invalid := func(reason string) error {
+		return fmt.Errorf("Ingress has invalid %s annotation %q: %s", prefix.key(annotationResponseHeaders), v, reason)
+	}
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(v), &headers); err != nil {
+		return nil, invalid("must be a JSON object of header names to string values")
+	}
+	for name, val := range headers {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return nil, invalid(fmt.Sprintf("invalid header name %q", name))
+		}
+		if !httpguts.ValidHeaderFieldValue(val) {
+			return nil, invalid(fmt.Sprintf("invalid value for header %q", name))
+		}
+	}
+	return headers, nil
+}
+
+// accessLogEnabled reports whether the Ingress requests access logs, as
+// configured by the tailscale.com/access-log annotation.
+func accessLogEnabled(ing *networkingv1.Ingress, prefix annotationPrefix) (bool, error) {
+	v, ok := prefix.lookup(ing.Annotations, annotationAccessLog)
+	if !ok {
+		return false, nil
+	}
+	if v != "true" && v != "false" {
+		return false, fmt.Errorf("Ingress has invalid %s annotation %q: must be \"true\" or \"false\"", prefix.key(annotationAccessLog), v)
+	}
+	return v == "true", nil
+}
+
+// clientHTTP1Only reports whether the Ingress is to be served to clients over
+// HTTP/1.1 only, as configured by the tailscale.com/client-http-version
+// annotation.
+func clientHTTP1Only(ing *networkingv1.Ingress, prefix annotationPrefix) (bool, error) {
+	v, ok := prefix.lookup(ing.Annotations, annotationClientHTTPVersion)
+	if !ok {
+		return false, nil
+	}
+	if v != clientHTTPVersionH2 && v != clientHTTPVersionHTTP1 {
+		return false, fmt.Errorf("Ingress has invalid %s annotation %q: must be %q or %q", prefix.key(annotationClientHTTPVersion), v, clientHTTPVersionH2, clientHTTPVersionHTTP1)
+	}
+	return v == clientHTTPVersionHTTP1, nil
+}
+
+// backendDialFamily returns the IP family that the Ingress' backends are to be
+// dialed over, "ipv4" or "ipv6", as configured by the
+// tailscale.com/backend-dial-family annotation. It returns "" if backends are
+// to be dialed over their primary IP family.
+func backendDialFamily(ing *networkingv1.Ingress, prefix annotationPrefix) (string, error) {
+	v, ok := prefix.lookup(ing.Annotations, annotationBackendDialFamily)
+	if !ok {
+		return "", nil
+	}
+	if v != backendDialFamilyIPv4 && v != backendDialFamilyIPv6 {
+		return "", fmt.Errorf("Ingress has invalid %s annotation %q: must be %q or %q", prefix.key(annotationBackendDialFamily), v, backendDialFamilyIPv4, backendDialFamilyIPv6)
+	}
+	return v, nil
+}
+
+// isIPOfFamily reports whether ip is an address of family, "ipv4" or "ipv6".
+func isIPOfFamily(ip netip.Addr, family string) bool {
+	switch family {
+	case backendDialFamilyIPv4:
+		return ip.Unmap().Is4()
+	case backendDialFamilyIPv6:
+		return ip.Is6() && !ip.Is4In6()
+	}
+	return false
+}
+
+// clusterIPOfFamily returns the ClusterIP of svc of family, "ipv4" or "ipv6",
+// or "" if it has none.
+func clusterIPOfFamily(svc *corev1.Service, family string) string {
+	ips := svc.Spec.ClusterIPs
+	if len(ips) == 0 {
+		ips = []string{svc.Spec.ClusterIP}
+	}
+	for _, s := range ips {
+		if ip, err := netip.ParseAddr(s); err == nil && isIPOfFamily(ip, family) {
+			return s
+		}
+	}
+	return ""
+}
+
+// funnelEnabled reports whether the Ingress requests to be exposed over
+// Funnel.
+func funnelEnabled(ing *networkingv1.Ingress, prefix annotationPrefix) bool {
+	return opt.Bool(prefix.get(ing.Annotations, AnnotationFunnel)).EqualBool(true)
+}
+
+// validateFunnel returns an error if Funnel is enabled for an Ingress that
+// carries the funnel guard, i.e. one that has been marked as never to be
+// exposed over Funnel.
+func validateFunnel(ing *networkingv1.Ingress, prefix annotationPrefix) error {
+	if prefix.get(ing.Annotations, annotationFunnelGuard) == funnelNever && funnelEnabled(ing, prefix) {
+		return fmt.Errorf("Ingress has %s annotation %q, but has been marked as never to be exposed over Funnel; remove the %s annotation to allow it", prefix.key(AnnotationFunnel), prefix.get(ing.Annotations, AnnotationFunnel), prefix.key(annotationFunnelGuard))
+	}
+	return nil
+}
+
+// ensureFunnelGuard sets the funnel guard annotation on an Ingress marked with
+// tailscale.com/funnel: "never", so that Funnel cannot be accidentally
+// enabled for it later.
+func ensureFunnelGuard(ctx context.Context, cl client.Client, ing *networkingv1.Ingress, prefix annotationPrefix) error {
+	if prefix.get(ing.Annotations, AnnotationFunnel) != funnelNever || prefix.get(ing.Annotations, annotationFunnelGuard) == funnelNever {
+		return nil
+	}
+	mak.Set(&ing.Annotations, prefix.key(annotationFunnelGuard), funnelNever)
+	if err := cl.Update(ctx, ing); err != nil {
+		return fmt.Errorf("failed to add %s annotation: %w", prefix.key(annotationFunnelGuard), err)
+	}
+	return nil
+}
+
+// defaultResponseHandler returns the handler for requests whose path matches
+// no Ingress rule, as configured by the tailscale.com/default-response
+// annotation. It returns nil if such requests should get a 404, which is what
+// serve responds with for paths without a handler.
+func defaultResponseHandler(ing *networkingv1.Ingress, prefix annotationPrefix) (*ipn.HTTPHandler, error) {
+	v, ok := prefix.lookup(ing.Annotations, annotationDefaultResponse)
+	if !ok || v == defaultResponseNotFound {
+		return nil, nil
+	}
+	invalid := func(reason string) error {
+		return fmt.Errorf("Ingress has invalid %s annotation %q: %s", prefix.key(annotationDefaultResponse), v, reason)
+	}
+	target, ok := strings.CutPrefix(v, defaultResponseRedirectPrefix)
+	if !ok {
+		return nil, invalid(fmt.Sprintf("must be %q or start with %q", defaultResponseNotFound, defaultResponseRedirectPrefix))
+	}
+	// The optional status code prefix is parsed the same way as by serve.
+	u := target
+	if len(target) >= 4 && target[3] == ':' {
+		if code, err := strconv.Atoi(target[:3]); err == nil {
+			if code < 300 || code > 399 {
+				return nil, invalid(fmt.Sprintf("redirect status code %d is not a 3xx code", code))
+			}
+			u = target[4:]
+		}
+	}
+	if parsed, err := url.Parse(u); err != nil || !parsed.IsAbs() || parsed.Host == "" {
+		return nil, invalid("redirect target must be an absolute URL")
+	}
+	return &ipn.HTTPHandler{Redirect: target}, nil
+}
+
+// headlessServiceEndpoint returns the address and port of a ready endpoint of
+// the headless Service svc, for the Service port with the given name, or
+// number if portName is empty. The lowest ready address is returned, so that
+// the proxy target only changes if that endpoint goes away. If family is
+// "ipv4" or "ipv6", only endpoints of that IP family are considered. It returns
+// an error if the Service has no ready endpoints.
+func headlessServiceEndpoint(ctx context.Context, cl client.Client, svc *corev1.Service, portName string, port int32, family string) (string, int32, error) {
+	// EndpointSlice ports have the same name as the Service port they back.
+	if portName == "" {
+		for _, p := range svc.Spec.Ports {
+			if p.Port == port {
+				portName = p.Name
+				break
+			}
+		}
+	}
+	epsList := &discoveryv1.EndpointSliceList{}
+	if err := cl.List(ctx, epsList, client.InNamespace(svc.Namespace), client.MatchingLabels{discoveryv1.LabelServiceName: svc.Name}); err != nil {
+		return "", 0, fmt.Errorf("error listing EndpointSlices: %w", err)
+	}
+	var (
+		addr   netip.Addr
+		epPort int32
+	)
+	for _, eps := range epsList.Items {
+		if eps.AddressType != discoveryv1.AddressTypeIPv4 && eps.AddressType != discoveryv1.AddressTypeIPv6 {
+			continue
+		}
+		if family == backendDialFamilyIPv4 && eps.AddressType != discoveryv1.AddressTypeIPv4 ||
+			family == backendDialFamilyIPv6 && eps.AddressType != discoveryv1.AddressTypeIPv6 {
+			continue
+		}
+		var slicePort int32
+		for _, p := range eps.Ports {
+			var name string
+			if p.Name != nil {
+				name = *p.Name
+			}
+			if name == portName && p.Port != nil {
+				slicePort = *p.Port
+				break
+			}
+		}
+		if slicePort == 0 {
+			if len(svc.Spec.Ports) > 0 {
+				continue
+			}
+			// Headless Services may not define any ports, in which
+			// case traffic is sent to the endpoints on the backend port.
+			slicePort = port
+		}
+		for _, ep := range eps.Endpoints {
+			// A nil Ready condition must be interpreted as ready.
+			if ep.Conditions.Ready != nil && !*ep.Conditions.Ready {
+				continue
+			}
+			for _, a := range ep.Addresses {
+				ip, err := netip.ParseAddr(a)
+				if err != nil {
+					continue
+				}
+				if !addr.IsValid() || ip.Less(addr) {
+					addr, epPort = ip, slicePort
+				}
+			}
+		}
+	}
+	if !addr.IsValid() {
+		if family != "" {
+			return "", 0, fmt.Errorf("no ready %s endpoints", family)
+		}
+		return "", 0, errors.New("no ready endpoints")
+	}
+	return addr.String(), epPort, nil
+}
+
 // hostnameForIngress returns the hostname for an Ingress resource.
 // If the Ingress has TLS configured with a host, it returns the first component of that host.
 // Otherwise, it returns a hostname derived from the Ingress name and namespace.
@@ -374,3 +1085,16 @@ func hostnameForIngress(ing *networkingv1.Ingress) string {
 	}
 	return ing.Namespace + "-" + ing.Name + "-ingress"
 }
+
+// checkProxyCapVers returns an error if any of capvers, the capability versions
+// of the proxies of an Ingress, is older than need, the capability version
+// that the Ingress's serve config requires. Proxies whose capability version is
+// not known, which is negative, are not checked.
+func checkProxyCapVers(need tailcfg.CapabilityVersion, capvers []tailcfg.CapabilityVersion) error {
+	for _, cv := range capvers {
+		if cv >= 0 && cv < need {
+			return fmt.Errorf("the Ingress requires proxies of capability version %d or newer, but a proxy has capability version %d; upgrade the proxy image", need, cv)
+		}
+	}
+	return nil
+}
@@ -7,21 +7,29 @@
 
 import (
 	"context"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"tailscale.com/ipn"
+	"tailscale.com/ipn/ipnstate"
 	tsapi "tailscale.com/k8s-operator/apis/v1alpha1"
 	"tailscale.com/kube/kubetypes"
+	"tailscale.com/tailcfg"
 	"tailscale.com/tstest"
 	"tailscale.com/types/ptr"
 	"tailscale.com/util/mak"
@@ -36,8 +44,9 @@ func TestTailscaleIngress(t *testing.T) {
 		t.Fatal(err)
 	}
 	ingR := &IngressReconciler{
-		Client:           fc,
-		ingressClassName: "tailscale",
+		Client:                 fc,
+		ingressClassName:       "tailscale",
+		ingressClassController: tailscaleIngressControllerName,
 		ssr: &tailscaleSTSReconciler{
 			Client:            fc,
 			tsClient:          ft,
@@ -122,8 +131,9 @@ func TestTailscaleIngressHostname(t *testing.T) {
 		t.Fatal(err)
 	}
 	ingR := &IngressReconciler{
-		Client:           fc,
-		ingressClassName: "tailscale",
+		Client:                 fc,
+		ingressClassName:       "tailscale",
+		ingressClassController: tailscaleIngressControllerName,
 		ssr: &tailscaleSTSReconciler{
 			Client:            fc,
 			tsClient:          ft,
@@ -248,8 +258,9 @@ func TestTailscaleIngressWithProxyClass(t *testing.T) {
 		t.Fatal(err)
 	}
 	ingR := &IngressReconciler{
-		Client:           fc,
-		ingressClassName: "tailscale",
+		Client:                 fc,
+		ingressClassName:       "tailscale",
+		ingressClassController: tailscaleIngressControllerName,
 		ssr: &tailscaleSTSReconciler{
 			Client:            fc,
 			tsClient:          ft,
@@ -354,8 +365,9 @@ func TestTailscaleIngressWithServiceMonitor(t *testing.T) {
 		t.Fatal(err)
 	}
 	ingR := &IngressReconciler{
-		Client:           fc,
-		ingressClassName: "tailscale",
+		Client:                 fc,
+		ingressClassName:       "tailscale",
+		ingressClassController: tailscaleIngressControllerName,
 		ssr: &tailscaleSTSReconciler{
 			Client:            fc,
 			tsClient:          ft,
@@ -503,8 +515,9 @@ func TestIngressProxyClassAnnotation(t *testing.T) {
 			mustCreate(t, fc, ing)
 
 			ingR := &IngressReconciler{
-				Client:           fc,
-				ingressClassName: "tailscale",
+				Client:                 fc,
+				ingressClassName:       "tailscale",
+				ingressClassController: tailscaleIngressControllerName,
 				ssr: &tailscaleSTSReconciler{
 					Client:            fc,
 					tsClient:          &fakeTSClient{},
@@ -574,8 +587,9 @@ func TestIngressLetsEncryptStaging(t *testing.T) {
 			mustCreate(t, fc, ing)
 
 			ingR := &IngressReconciler{
-				Client:           fc,
-				ingressClassName: "tailscale",
+				Client:                 fc,
+				ingressClassName:       "tailscale",
+				ingressClassController: tailscaleIngressControllerName,
 				ssr: &tailscaleSTSReconciler{
 					Client:            fc,
 					tsClient:          &fakeTSClient{},
@@ -682,9 +696,10 @@ func TestEmptyPath(t *testing.T) {
 				t.Fatal(err)
 			}
 			ingR := &IngressReconciler{
-				recorder:         fr,
-				Client:           fc,
-				ingressClassName: "tailscale",
+				recorder:               fr,
+				Client:                 fc,
+				ingressClassName:       "tailscale",
+				ingressClassController: tailscaleIngressControllerName,
 				ssr: &tailscaleSTSReconciler{
 					Client:            fc,
 					tsClient:          ft,
@@ -733,6 +748,505 @@ func TestEmptyPath(t *testing.T) {
 	}
 }
 
+func TestIngressExternalNameBackend(t *testing.T) {
+	fc := fake.NewFakeClient(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "external",
+			Namespace: "default",
+		},
+		Spec: corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: "backend.example.com.",
+			Ports: []corev1.ServicePort{{
+				Port: 8443,
+				Name: "https",
+			}},
+		},
+	})
+	fr := record.NewFakeRecorder(1)
+	zl, err := zap.NewDevelopment()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ing := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+		Spec: networkingv1.IngressSpec{
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "external",
+					Port: networkingv1.ServiceBackendPort{Name: "https"},
+				},
+			},
+		},
+	}
+
+	handlers, err := handlersForIngress(context.Background(), ing, fc, fr, "foo.ts.net", zl.Sugar(), annotationDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]*ipn.HTTPHandler{
+		"/": {Proxy: "https+insecure://backend.example.com:8443/"},
+	}
+	if diff := cmp.Diff(want, handlers); diff != "" {
+		t.Errorf("unexpected handlers (-want +got):\n%s", diff)
+	}
+	if len(fr.Events) != 0 {
+		t.Errorf("unexpected event: %s", <-fr.Events)
+	}
+}
+
+func TestIngressHeadlessBackend(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "headless",
+			Namespace: "default",
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Ports: []corev1.ServicePort{{
+				Port:       80,
+				Name:       "http",
+				TargetPort: intstr.FromInt32(8080),
+			}},
+		},
+	}
+	eps := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "headless-abc",
+			Namespace: "default",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: "headless"},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Ports: []discoveryv1.EndpointPort{{
+			Name: ptr.To("http"),
+			Port: ptr.To(int32(8080)),
+		}},
+		Endpoints: []discoveryv1.Endpoint{
+			{Addresses: []string{"10.0.0.5"}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(true)}},
+			{Addresses: []string{"10.0.0.3"}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(false)}},
+			{Addresses: []string{"10.0.0.4"}},
+		},
+	}
+	ing := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+		Spec: networkingv1.IngressSpec{
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "headless",
+					Port: networkingv1.ServiceBackendPort{Number: 80},
+				},
+			},
+		},
+	}
+	zl, err := zap.NewDevelopment()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("ready_endpoints", func(t *testing.T) {
+		fc := fake.NewFakeClient(svc, eps)
+		fr := record.NewFakeRecorder(1)
+		handlers, err := handlersForIngress(context.Background(), ing, fc, fr, "foo.ts.net", zl.Sugar(), annotationDomain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The lowest ready endpoint address is used, on the target port.
+		want := map[string]*ipn.HTTPHandler{
+			"/": {Proxy: "http://10.0.0.4:8080/"},
+		}
+		if diff := cmp.Diff(want, handlers); diff != "" {
+			t.Errorf("unexpected handlers (-want +got):\n%s", diff)
+		}
+		if len(fr.Events) != 0 {
+			t.Errorf("unexpected event: %s", <-fr.Events)
+		}
+	})
+
+	t.Run("no_ready_endpoints", func(t *testing.T) {
+		notReady := eps.DeepCopy()
+		for i := range notReady.Endpoints {
+			notReady.Endpoints[i].Conditions.Ready = ptr.To(false)
+		}
+		fc := fake.NewFakeClient(svc, notReady)
+		fr := record.NewFakeRecorder(1)
+		handlers, err := handlersForIngress(context.Background(), ing, fc, fr, "foo.ts.net", zl.Sugar(), annotationDomain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(handlers) != 0 {
+			t.Errorf("unexpected handlers for headless Service without ready endpoints: %v", handlers)
+		}
+		select {
+		case ev := <-fr.Events:
+			if !strings.Contains(ev, "no ready endpoints") {
+				t.Errorf("unexpected event: %s", ev)
+			}
+		default:
+			t.Error("expected an InvalidIngressBackend event")
+		}
+	})
+}
+
+func TestIngressDefaultResponse(t *testing.T) {
+	zl, err := zap.NewDevelopment()
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiPath := []networkingv1.HTTPIngressPath{{
+		Path:     "/api",
+		PathType: ptrPathType(networkingv1.PathTypePrefix),
+		Backend:  *backend(),
+	}}
+	tests := []struct {
+		name           string
+		annotation     string // value of tailscale.com/default-response, if set
+		defaultBackend bool
+		wantRoot       *ipn.HTTPHandler // handler for unmatched paths
+		wantEvent      string
+	}{
+		{
+			name: "unset",
+		},
+		{
+			name:       "not_found",
+			annotation: "not-found",
+		},
+		{
+			name:       "redirect",
+			annotation: "redirect:https://example.com/",
+			wantRoot:   &ipn.HTTPHandler{Redirect: "https://example.com/"},
+		},
+		{
+			name:       "redirect_with_code",
+			annotation: "redirect:308:https://example.com/",
+			wantRoot:   &ipn.HTTPHandler{Redirect: "308:https://example.com/"},
+		},
+		{
+			name:           "default_backend_wins",
+			annotation:     "redirect:https://example.com/",
+			defaultBackend: true,
+			wantRoot:       &ipn.HTTPHandler{Proxy: "http://1.2.3.4:8080/"},
+		},
+		{
+			name:       "invalid_status_code",
+			annotation: "redirect:200:https://example.com/",
+			wantEvent:  "Warning InvalidIngressAnnotation Ingress has invalid tailscale.com/default-response annotation \"redirect:200:https://example.com/\": redirect status code 200 is not a 3xx code, unmatched paths will return 404",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc := fake.NewFakeClient(service())
+			fr := record.NewFakeRecorder(2)
+			ing := ingressWithPaths(apiPath)
+			if tt.annotation != "" {
+				mak.Set(&ing.Annotations, annotationDefaultResponse, tt.annotation)
+			}
+			if tt.defaultBackend {
+				ing.Spec.DefaultBackend = backend()
+			}
+			handlers, err := handlersForIngress(context.Background(), ing, fc, fr, "foo.tailnetxyz.ts.net", zl.Sugar(), annotationDomain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]*ipn.HTTPHandler{
+				"/api": {Proxy: "http://1.2.3.4:8080/api"},
+			}
+			if tt.wantRoot != nil {
+				want["/"] = tt.wantRoot
+			}
+			if diff := cmp.Diff(want, handlers); diff != "" {
+				t.Errorf("unexpected handlers (-want +got):\n%s", diff)
+			}
+			var gotEvent string
+			if len(fr.Events) > 0 {
+				gotEvent = <-fr.Events
+			}
+			if gotEvent != tt.wantEvent {
+				t.Errorf("got event %q, want %q", gotEvent, tt.wantEvent)
+			}
+		})
+	}
+}
+
+func TestTailscaleIngressFunnelNever(t *testing.T) {
+	fc := fake.NewFakeClient(ingressClass())
+	fr := record.NewFakeRecorder(1)
+	zl, err := zap.NewDevelopment()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ingR := &IngressReconciler{
+		Client:                 fc,
+		ingressClassName:       "tailscale",
+		ingressClassController: tailscaleIngressControllerName,
+		recorder:               fr,
+		ssr: &tailscaleSTSReconciler{
+			Client:            fc,
+			tsClient:          &fakeTSClient{},
+			tsnetServer:       &fakeTSNetServer{certDomains: []string{"foo.com"}},
+			defaultTags:       []string{"tag:k8s"},
+			operatorNamespace: "operator-ns",
+			proxyImage:        "tailscale/tailscale",
+		},
+		logger: zl.Sugar(),
+	}
+
+	// 1. Ingress marked as never to be exposed over Funnel does not allow
+	// Funnel and gets the funnel guard.
+	ing := ingress()
+	mak.Set(&ing.Annotations, AnnotationFunnel, funnelNever)
+	mustCreate(t, fc, ing)
+	mustCreate(t, fc, service())
+	expectReconciled(t, ingR, "default", "test")
+
+	fullName, shortName := findGenName(t, fc, "default", "test", "ingress")
+	opts := configOpts{
+		replicas:   ptr.To[int32](1),
+		stsName:    shortName,
+		secretName: fullName,
+		namespace:  "default",
+		parentType: "ingress",
+		hostname:   "default-test",
+		app:        kubetypes.AppIngressResource,
+		serveConfig: &ipn.ServeConfig{
+			TCP: map[uint16]*ipn.TCPPortHandler{443: {HTTPS: true}},
+			Web: map[ipn.HostPort]*ipn.WebServerConfig{"${TS_CERT_DOMAIN}:443": {Handlers: map[string]*ipn.HTTPHandler{"/": {Proxy: "http://1.2.3.4:8080/"}}}},
+		},
+	}
+	expectEqual(t, fc, expectedSecret(t, fc, opts))
+	ing.Finalizers = append(ing.Finalizers, FinalizerName)
+	mak.Set(&ing.Annotations, annotationFunnelGuard, funnelNever)
+	expectEqual(t, fc, ing)
+
+	// 2. Enabling Funnel later is rejected and the serve config is unchanged.
+	mustUpdate(t, fc, "default", "test", func(ing *networkingv1.Ingress) {
+		ing.Annotations[AnnotationFunnel] = "true"
+	})
+	expectReconciled(t, ingR, "default", "test")
+	expectEqual(t, fc, expectedSecret(t, fc, opts))
+	expectEvents(t, fr, []string{"Warning InvalidIngressConfiguration Ingress has tailscale.com/funnel annotation \"true\", but has been marked as never to be exposed over Funnel; remove the tailscale.com/funnel-guard annotation to allow it"})
+
+	// 3. Funnel can be enabled once the guard is deliberately removed.
+	mustUpdate(t, fc, "default", "test", func(ing *networkingv1.Ingress) {
+		delete(ing.Annotations, annotationFunnelGuard)
+	})
+	expectReconciled(t, ingR, "default", "test")
+	opts.serveConfig.AllowFunnel = map[ipn.HostPort]bool{"${TS_CERT_DOMAIN}:443": true}
+	expectEqual(t, fc, expectedSecret(t, fc, opts), removeAuthKeyIfExistsModifier(t))
+}
+
+func TestTailscaleIngressUnknownAnnotations(t *testing.T) {
+	fc := fake.NewFakeClient(ingressClass())
+	fr := record.NewFakeRecorder(2)
+	zl, err := zap.NewDevelopment()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ingR := &IngressReconciler{
+		Client:                 fc,
+		ingressClassName:       "tailscale",
+		ingressClassController: tailscaleIngressControllerName,
+		recorder:               fr,
+		ssr: &tailscaleSTSReconciler{
+			Client:            fc,
+			tsClient:          &fakeTSClient{},
+			tsnetServer:       &fakeTSNetServer{certDomains: []string{"foo.com"}},
+			defaultTags:       []string{"tag:k8s"},
+			operatorNamespace: "operator-ns",
+			proxyImage:        "tailscale/tailscale",
+		},
+		logger: zl.Sugar(),
+	}
+
+	// A misspelled ProxyGroup annotation, an unknown annotation that is
+	// not close to any known one, and annotations that are known or in
+	// other domains.
+	ing := ingress()
+	ing.Annotations = map[string]string{
+		"tailscale.com/proxygroup":     "test-pg",
+		"tailscale.com/no-such-thing":  "true",
+		AnnotationTags:                 "tag:k8s",
+		"example.com/tailscale.com/do": "x",
+	}
+	mustCreate(t, fc, ing)
+	mustCreate(t, fc, service())
+	expectReconciled(t, ingR, "default", "test")
+	expectEvents(t, fr, []string{
+		`Warning UnknownAnnotation unknown annotation "tailscale.com/no-such-thing" is ignored`,
+		`Warning UnknownAnnotation unknown annotation "tailscale.com/proxygroup" is ignored, did you mean "tailscale.com/proxy-group"?`,
+	})
+
+	// The warnings do not fail the reconcile; the Ingress is exposed on a
+	// standalone proxy as the ProxyGroup annotation is ignored.
+	findGenName(t, fc, "default", "test", "ingress")
+
+	// The warnings are not repeated while the unknown annotations are
+	// unchanged.
+	expectReconciled(t, ingR, "default", "test")
+	if len(fr.Events) != 0 {
+		t.Errorf("unexpected event: %s", <-fr.Events)
+	}
+
+	// They are emitted again once the unknown annotations change.
+	mustUpdate(t, fc, "default", "test", func(ing *networkingv1.Ingress) {
+		delete(ing.Annotations, "tailscale.com/no-such-thing")
+	})
+	expectReconciled(t, ingR, "default", "test")
+	expectEvents(t, fr, []string{
+		`Warning UnknownAnnotation unknown annotation "tailscale.com/proxygroup" is ignored, did you mean "tailscale.com/proxy-group"?`,
+	})
+}
+
+func TestTailscaleIngressStandaloneService(t *testing.T) {
+	fc := fake.NewFakeClient(ingressClass())
+	ft := &fakeTSClient{}
+	fr := record.NewFakeRecorder(10)
+	zl, err := zap.NewDevelopment()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ingR := &IngressReconciler{
+		Client:                 fc,
+		ingressClassName:       "tailscale",
+		ingressClassController: tailscaleIngressControllerName,
+		recorder:               fr,
+		ssr: &tailscaleSTSReconciler{
+			Client:            fc,
+			tsClient:          ft,
+			tsnetServer:       &fakeTSNetServer{certDomains: []string{"foo.com"}},
+			defaultTags:       []string{"tag:k8s"},
+			operatorNamespace: "operator-ns",
+			proxyImage:        "tailscale/tailscale",
+		},
+		logger: zl.Sugar(),
+		lc: &fakeLocalClient{
+			status: &ipnstate.Status{
+				CurrentTailnet: &ipnstate.TailnetStatus{
+					MagicDNSSuffix: "ts.net",
+				},
+			},
+		},
+		operatorID: "operator-id",
+	}
+
+	// 1. An Ingress that references no ProxyGroup, but requests a
+	// standalone Tailscale Service, gets a standalone proxy that
+	// advertises the Tailscale Service.
+	ing := ingress()
+	mak.Set(&ing.Annotations, annotationStandaloneService, "true")
+	mustCreate(t, fc, ing)
+	mustCreate(t, fc, service())
+	expectReconciled(t, ingR, "default", "test")
+
+	fullName, shortName := findGenName(t, fc, "default", "test", "ingress")
+	opts := configOpts{
+		replicas:          ptr.To[int32](1),
+		stsName:           shortName,
+		secretName:        fullName,
+		namespace:         "default",
+		parentType:        "ingress",
+		hostname:          "default-test-proxy",
+		app:               kubetypes.AppIngressResource,
+		advertiseServices: []string{"svc:default-test"},
+		serveConfig: &ipn.ServeConfig{
+			Services: map[tailcfg.ServiceName]*ipn.ServiceConfig{
+				"svc:default-test": {
+					TCP: map[uint16]*ipn.TCPPortHandler{443: {HTTPS: true}},
+					Web: map[ipn.HostPort]*ipn.WebServerConfig{"default-test.ts.net:443": {Handlers: map[string]*ipn.HTTPHandler{"/": {Proxy: "http://1.2.3.4:8080/"}}}},
+				},
+			},
+		},
+	}
+	expectEqual(t, fc, expectedSecret(t, fc, opts))
+	expectEqual(t, fc, expectedHeadlessService(shortName, "ingress"))
+	tsSvc, err := ft.GetVIPService(t.Context(), "svc:default-test")
+	if err != nil {
+		t.Fatalf("getting Tailscale Service: %v", err)
+	}
+	if !slices.Equal(tsSvc.Ports, []string{"tcp:443"}) || !slices.Equal(tsSvc.Tags, []string{"tag:k8s"}) {
+		t.Errorf("unexpected Tailscale Service ports %v and tags %v", tsSvc.Ports, tsSvc.Tags)
+	}
+	if want := `{"ownerRefs":[{"operatorID":"operator-id","tags":["tag:k8s"]}]}`; tsSvc.Annotations[ownerAnnotation] != want {
+		t.Errorf("unexpected owner annotation %q, want %q", tsSvc.Annotations[ownerAnnotation], want)
+	}
+
+	// 2. Once the proxy is up, the Ingress status contains the Tailscale
+	// Service's MagicDNS name rather than the proxy's.
+	mustUpdate(t, fc, "operator-ns", opts.secretName, func(secret *corev1.Secret) {
+		mak.Set(&secret.Data, "device_id", []byte("1234"))
+		mak.Set(&secret.Data, "device_fqdn", []byte("default-test-proxy.ts.net"))
+	})
+	expectReconciled(t, ingR, "default", "test")
+	if err := fc.Get(t.Context(), client.ObjectKeyFromObject(ing), ing); err != nil {
+		t.Fatal(err)
+	}
+	wantStatus := []networkingv1.IngressLoadBalancerIngress{
+		{Hostname: "default-test.ts.net", Ports: []networkingv1.IngressPortStatus{{Port: 443, Protocol: "TCP"}}},
+	}
+	if !reflect.DeepEqual(ing.Status.LoadBalancer.Ingress, wantStatus) {
+		t.Errorf("incorrect Ingress status: got %v, want %v", ing.Status.LoadBalancer.Ingress, wantStatus)
+	}
+
+	// 3. A standalone Tailscale Service can not be exposed over Funnel.
+	mustUpdate(t, fc, "default", "test", func(ing *networkingv1.Ingress) {
+		mak.Set(&ing.Annotations, AnnotationFunnel, "true")
+	})
+	expectReconciled(t, ingR, "default", "test")
+	expectEvents(t, fr, []string{"Warning InvalidIngressConfiguration Ingress has tailscale.com/standalone-service annotation, but Funnel is enabled: Tailscale Services can not be exposed over Funnel"})
+
+	// 4. The Tailscale Service is deleted along with the Ingress.
+	if err := fc.Delete(t.Context(), ing); err != nil {
+		t.Fatal(err)
+	}
+	expectReconciled(t, ingR, "default", "test")
+	expectReconciled(t, ingR, "default", "test")
+	if _, err := ft.GetVIPService(t.Context(), "svc:default-test"); !isErrorTailscaleServiceNotFound(err) {
+		t.Errorf("Tailscale Service not deleted along with the Ingress: %v", err)
+	}
+	expectMissing[appsv1.StatefulSet](t, fc, "operator-ns", shortName)
+}
+
+func TestValidateStandaloneService(t *testing.T) {
+	for name, tc := range map[string]struct {
+		annots  map[string]string
+		wantErr string
+	}{
+		"not_set": {},
+		"enabled": {
+			annots: map[string]string{annotationStandaloneService: "true"},
+		},
+		"invalid": {
+			annots:  map[string]string{annotationStandaloneService: "yes"},
+			wantErr: `invalid tailscale.com/standalone-service annotation "yes"`,
+		},
+		"with_proxy_group": {
+			annots:  map[string]string{annotationStandaloneService: "true", AnnotationProxyGroup: "test-pg"},
+			wantErr: "has both tailscale.com/standalone-service and tailscale.com/proxy-group annotations",
+		},
+		"disabled_with_proxy_group": {
+			annots: map[string]string{annotationStandaloneService: "false", AnnotationProxyGroup: "test-pg"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ing := ingress()
+			ing.Annotations = tc.annots
+			err := validateStandaloneService(ing, annotationDomain)
+			if tc.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Errorf("got error %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
 // ptrPathType is a helper function to return a pointer to the pathtype string (required for TestEmptyPath)
 func ptrPathType(p networkingv1.PathType) *networkingv1.PathType {
 	return &p
@@ -0,0 +1,257 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !plan9
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"tailscale.com/tailcfg"
+	"tailscale.com/util/mak"
+)
+
+// Reconcile states reported for operator-managed Tailscale Services by the
+// inspect endpoint.
+const (
+	inspectStatePending    = "Pending"    // no ProxyGroup replica advertises the Tailscale Service yet
+	inspectStateAdvertised = "Advertised" // at least one ProxyGroup replica advertises the Tailscale Service
+	inspectStateDeleting   = "Deleting"   // the owning resource is being deleted
+)
+
+const (
+	// inventoryConfigMapName is the name of the ConfigMap in the operator's
+	// namespace that lists the Tailscale Services managed by the operator,
+	// if OPERATOR_INVENTORY_INTERVAL is set.
+	inventoryConfigMapName = "tailscale-services-inventory"
+	// inventoryKey is the key in the inventory ConfigMap that holds the
+	// Tailscale Services, in the same JSON format as the inspect endpoint.
+	inventoryKey = "services.json"
+)
+
+// inspectedService describes a Tailscale Service managed by the operator and
+// its current reconcile state.
+type inspectedService struct {
+	Name                tailcfg.ServiceName `json:"name"`
+	Kind                string              `json:"kind"`
+	Namespace           string              `json:"namespace"`
+	ResourceName        string              `json:"resourceName"`
+	ProxyGroup          string              `json:"proxyGroup"`
+	AdvertisingReplicas []int               `json:"advertisingReplicas,omitempty"`
+	State               string              `json:"state"`
+}
+
+// inspectHandler serves a read-only JSON view of the Tailscale Services
+// exposed via ProxyGroups, for use by operational tooling. It is only
+// served if OPERATOR_INSPECT_ADDR is set. It is not authenticated, so it
+// must not be exposed outside of the operator Pod; see inspectListenAddr.
+type inspectHandler struct {
+	cl          client.Client
+	tsNamespace string
+	logger      *zap.SugaredLogger
+	// serviceNameStrategy must match the one used by the HA Ingress
+	// reconciler, so that Tailscale Services are looked up by their names.
+	serviceNameStrategy serviceNameStrategy
+	annotationPrefix    annotationPrefix // prefix of user-set annotations
+}
+
+func (h *inspectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	svcs, err := h.services(r.Context())
+	if err != nil {
+		h.logger.Errorf("error listing managed Tailscale Services: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(svcs); err != nil {
+		h.logger.Debugf("error writing inspect response: %v", err)
+	}
+}
+
+// services returns all Tailscale Services managed by the HA Ingress and HA
+// Service reconcilers, sorted by name.
+func (h *inspectHandler) services(ctx context.Context) ([]inspectedService, error) {
+	svcs := []inspectedService{}
+
+	ingList := &networkingv1.IngressList{}
+	if err := h.cl.List(ctx, ingList); err != nil {
+		return nil, fmt.Errorf("failed to list Ingresses: %w", err)
+	}
+	for _, ing := range ingList.Items {
+		if !slices.Contains(ing.Finalizers, FinalizerNamePG) {
+			continue
+		}
+		s, err := h.service(ctx, &ing, "Ingress", h.serviceNameStrategy.hostnameForIngress(&ing))
+		if err != nil {
+			return nil, err
+		}
+		svcs = append(svcs, s)
+	}
+
+	svcList := &corev1.ServiceList{}
+	if err := h.cl.List(ctx, svcList); err != nil {
+		return nil, fmt.Errorf("failed to list Services: %w", err)
+	}
+	for _, svc := range svcList.Items {
+		if !slices.Contains(svc.Finalizers, svcPGFinalizerName) {
+			continue
+		}
+		s, err := h.service(ctx, &svc, "Service", nameForService(&svc, h.annotationPrefix))
+		if err != nil {
+			return nil, err
+		}
+		svcs = append(svcs, s)
+	}
+
+	slices.SortFunc(svcs, func(a, b inspectedService) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return svcs, nil
+}
+
+func (h *inspectHandler) service(ctx context.Context, obj client.Object, kind, hostname string) (inspectedService, error) {
+	s := inspectedService{
+		Name:         tailcfg.ServiceName("svc:" + hostname),
+		Kind:         kind,
+		Namespace:    obj.GetNamespace(),
+		ResourceName: obj.GetName(),
+		ProxyGroup:   h.annotationPrefix.get(obj.GetAnnotations(), AnnotationProxyGroup),
+		State:        inspectStatePending,
+	}
+	if s.ProxyGroup != "" {
+		replicas, err := replicasAdvertising(ctx, h.cl, h.tsNamespace, s.ProxyGroup, s.Name, h.logger)
+		if err != nil {
+			return s, fmt.Errorf("failed to get advertising replicas for %s %s/%s: %w", kind, s.Namespace, s.ResourceName, err)
+		}
+		s.AdvertisingReplicas = replicas
+	}
+	switch {
+	case obj.GetDeletionTimestamp() != nil:
+		s.State = inspectStateDeleting
+	case len(s.AdvertisingReplicas) > 0:
+		s.State = inspectStateAdvertised
+	}
+	return s, nil
+}
+
+// syncInventory writes the Tailscale Services currently managed by the
+// operator to the inventory ConfigMap, creating it if it does not exist.
+func (h *inspectHandler) syncInventory(ctx context.Context) error {
+	svcs, err := h.services(ctx)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(svcs)
+	if err != nil {
+		return fmt.Errorf("error marshalling inventory: %w", err)
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      inventoryConfigMapName,
+			Namespace: h.tsNamespace,
+		},
+	}
+	err = h.cl.Get(ctx, client.ObjectKeyFromObject(cm), cm)
+	switch {
+	case apierrors.IsNotFound(err):
+		cm.Data = map[string]string{inventoryKey: string(b)}
+		if err := h.cl.Create(ctx, cm); err != nil {
+			return fmt.Errorf("error creating inventory ConfigMap: %w", err)
+		}
+		return nil
+	case err != nil:
+		return fmt.Errorf("error getting inventory ConfigMap: %w", err)
+	case cm.Data[inventoryKey] == string(b):
+		return nil
+	}
+	mak.Set(&cm.Data, inventoryKey, string(b))
+	if err := h.cl.Update(ctx, cm); err != nil {
+		return fmt.Errorf("error updating inventory ConfigMap: %w", err)
+	}
+	return nil
+}
+
+// runInventory updates the inventory ConfigMap every interval until ctx is
+// done. Failed updates are logged and retried on the next tick.
+func runInventory(ctx context.Context, h *inspectHandler, interval time.Duration) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := h.syncInventory(ctx); err != nil {
+			h.logger.Errorf("error updating Tailscale Service inventory: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// inspectMux returns the handler of the inspect server, which serves h as
+// well as the inventory API.
+func inspectMux(h *inspectHandler) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("GET /services", h)
+	mux.HandleFunc("GET /"+inventoryAPIVersion+"/inventory", h.serveInventory)
+	mux.HandleFunc("GET /"+inventoryAPIVersion+"/openapi.json", serveInventoryOpenAPI)
+	return mux
+}
+
+// inspectListenAddr returns the address that the inspect server listens on
+// for OPERATOR_INSPECT_ADDR addr. As the inspect server is not authenticated,
+// an address without a host, such as ":8081", is bound to localhost. The
+// server is only reachable from outside the operator Pod if a host, such as
+// "0.0.0.0", is set explicitly.
+func inspectListenAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != "" {
+		return addr
+	}
+	return net.JoinHostPort("localhost", port)
+}
+
+// runInspectServer serves h, as well as the inventory API, on addr until ctx
+// is done.
+func runInspectServer(ctx context.Context, addr string, h *inspectHandler) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           inspectMux(h),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("error serving inspect endpoint: %w", err)
+	}
+}
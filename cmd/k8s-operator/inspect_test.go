@@ -0,0 +1,212 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !plan9
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	tsapi "tailscale.com/k8s-operator/apis/v1alpha1"
+	"tailscale.com/kube/kubetypes"
+	"tailscale.com/types/ptr"
+)
+
+func TestInspectHandler(t *testing.T) {
+	fc := fake.NewClientBuilder().
+		WithScheme(tsapi.GlobalScheme).
+		WithObjects(
+			&networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "web",
+					Namespace:   "default",
+					Finalizers:  []string{FinalizerNamePG},
+					Annotations: map[string]string{AnnotationProxyGroup: "test-pg"},
+				},
+				Spec: networkingv1.IngressSpec{
+					TLS: []networkingv1.IngressTLS{{Hosts: []string{"my-web"}}},
+				},
+			},
+			// Not managed by the HA Ingress reconciler.
+			&networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "other",
+					Namespace: "default",
+				},
+			},
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "db",
+					Namespace:   "default",
+					Finalizers:  []string{svcPGFinalizerName},
+					Annotations: map[string]string{AnnotationProxyGroup: "test-pg"},
+				},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-pg-0",
+					Namespace: "operator-ns",
+					Labels:    pgSecretLabels("test-pg", kubetypes.LabelSecretTypeState),
+				},
+				Data: map[string][]byte{
+					"_current-profile": []byte("profile-foo"),
+					"profile-foo":      []byte(`{"AdvertiseServices":["svc:my-web"],"Config":{"NodeID":"node-foo"}}`),
+				},
+			},
+		).
+		Build()
+
+	h := &inspectHandler{
+		cl:          fc,
+		tsNamespace: "operator-ns",
+		logger:      zap.Must(zap.NewDevelopment()).Sugar(),
+	}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/services")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code %d", resp.StatusCode)
+	}
+	var got []inspectedService
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []inspectedService{
+		{
+			Name:         "svc:default-db",
+			Kind:         "Service",
+			Namespace:    "default",
+			ResourceName: "db",
+			ProxyGroup:   "test-pg",
+			State:        inspectStatePending,
+		},
+		{
+			Name:                "svc:my-web",
+			Kind:                "Ingress",
+			Namespace:           "default",
+			ResourceName:        "web",
+			ProxyGroup:          "test-pg",
+			AdvertisingReplicas: []int{0},
+			State:               inspectStateAdvertised,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected services (-want +got):\n%s", diff)
+	}
+
+	resp, err = http.Post(srv.URL+"/services", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST: got status code %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestInventory(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+	h := &inspectHandler{
+		cl:          fc,
+		tsNamespace: "operator-ns",
+		logger:      zap.Must(zap.NewDevelopment()).Sugar(),
+	}
+	inventory := func() []inspectedService {
+		t.Helper()
+		if err := h.syncInventory(t.Context()); err != nil {
+			t.Fatalf("syncInventory: %v", err)
+		}
+		cm := &corev1.ConfigMap{}
+		if err := fc.Get(t.Context(), types.NamespacedName{Namespace: "operator-ns", Name: inventoryConfigMapName}, cm); err != nil {
+			t.Fatalf("getting inventory ConfigMap: %v", err)
+		}
+		var svcs []inspectedService
+		if err := json.Unmarshal([]byte(cm.Data[inventoryKey]), &svcs); err != nil {
+			t.Fatalf("unmarshalling inventory: %v", err)
+		}
+		return svcs
+	}
+
+	if got := inventory(); len(got) != 0 {
+		t.Fatalf("inventory = %+v, want empty", got)
+	}
+
+	mustCreate(t, fc, service())
+	ing := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-ingress",
+			Namespace:   "default",
+			Annotations: map[string]string{AnnotationProxyGroup: "test-pg"},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{Number: 8080},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{{Hosts: []string{"my-svc"}}},
+		},
+	}
+	mustCreate(t, fc, ing)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+
+	want := []inspectedService{{
+		Name:         "svc:my-svc",
+		Kind:         "Ingress",
+		Namespace:    "default",
+		ResourceName: "test-ingress",
+		ProxyGroup:   "test-pg",
+		State:        inspectStatePending,
+	}}
+	if diff := cmp.Diff(want, inventory()); diff != "" {
+		t.Errorf("unexpected inventory (-want +got):\n%s", diff)
+	}
+
+	// Deleted Ingresses are dropped from the inventory.
+	if err := fc.Delete(t.Context(), ing); err != nil {
+		t.Fatal(err)
+	}
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	if got := inventory(); len(got) != 0 {
+		t.Errorf("inventory = %+v, want empty", got)
+	}
+}
+
+func TestInspectListenAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{addr: "", want: ""},
+		{addr: ":8081", want: "localhost:8081"},
+		{addr: "localhost:8081", want: "localhost:8081"},
+		{addr: "127.0.0.1:8081", want: "127.0.0.1:8081"},
+		{addr: "0.0.0.0:8081", want: "0.0.0.0:8081"},
+		{addr: "[::]:8081", want: "[::]:8081"},
+	}
+	for _, tt := range tests {
+		if got := inspectListenAddr(tt.addr); got != tt.want {
+			t.Errorf("inspectListenAddr(%q) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+}
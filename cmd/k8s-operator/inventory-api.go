@@ -0,0 +1,205 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !plan9
+
+package main
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+
+	tsoperator "tailscale.com/k8s-operator"
+	tsapi "tailscale.com/k8s-operator/apis/v1alpha1"
+	"tailscale.com/tailcfg"
+)
+
+// inventoryAPIVersion is the version of the inventory API schema. It prefixes
+// the paths of the inventory API endpoints and is reported in the inventory.
+// Incompatible changes to the schema require a new version.
+const inventoryAPIVersion = "v1"
+
+// inventoryStateReady is the state reported by the inventory API for Ingresses
+// and Services exposed by their own proxy once the proxy is ready. Those
+// exposed via a ProxyGroup report the inspect endpoint's states instead.
+const inventoryStateReady = "Ready"
+
+// inventoryOpenAPI is the OpenAPI description of the inventory API.
+//
+//go:embed inventory-api-v1.json
+var inventoryOpenAPI []byte
+
+// inventory lists the resources managed by the operator, as served by the
+// inventory API.
+type inventory struct {
+	APIVersion  string                `json:"apiVersion"`
+	Ingresses   []inventoryResource   `json:"ingresses"`
+	Services    []inventoryResource   `json:"services"`
+	ProxyGroups []inventoryProxyGroup `json:"proxyGroups"`
+}
+
+// inventoryResource is an Ingress or Service exposed on the tailnet by the
+// operator, either via a ProxyGroup or by its own proxy.
+type inventoryResource struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// ProxyGroup, TailscaleService and AdvertisingReplicas are only set for
+	// resources exposed via a ProxyGroup.
+	ProxyGroup          string              `json:"proxyGroup,omitempty"`
+	TailscaleService    tailcfg.ServiceName `json:"tailscaleService,omitempty"`
+	AdvertisingReplicas []int               `json:"advertisingReplicas,omitempty"`
+	// Addresses are the tailnet hostnames and IP addresses reported in the
+	// resource's load balancer status.
+	Addresses []string `json:"addresses,omitempty"`
+	State     string   `json:"state"`
+}
+
+// inventoryProxyGroup is a ProxyGroup managed by the operator.
+type inventoryProxyGroup struct {
+	Name          string               `json:"name"`
+	Type          tsapi.ProxyGroupType `json:"type"`
+	Replicas      int32                `json:"replicas"`
+	ReadyReplicas int                  `json:"readyReplicas"`
+	Ready         bool                 `json:"ready"`
+}
+
+func (h *inspectHandler) serveInventory(w http.ResponseWriter, r *http.Request) {
+	inv, err := h.inventory(r.Context())
+	if err != nil {
+		h.logger.Errorf("error listing managed resources: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(inv); err != nil {
+		h.logger.Debugf("error writing inventory response: %v", err)
+	}
+}
+
+func serveInventoryOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(inventoryOpenAPI)
+}
+
+// inventory returns the Ingresses, Services and ProxyGroups managed by the
+// operator. Ingresses and Services are sorted by namespace and name, and
+// ProxyGroups by name.
+func (h *inspectHandler) inventory(ctx context.Context) (*inventory, error) {
+	inv := &inventory{
+		APIVersion:  inventoryAPIVersion,
+		Ingresses:   []inventoryResource{},
+		Services:    []inventoryResource{},
+		ProxyGroups: []inventoryProxyGroup{},
+	}
+
+	ingList := &networkingv1.IngressList{}
+	if err := h.cl.List(ctx, ingList); err != nil {
+		return nil, fmt.Errorf("failed to list Ingresses: %w", err)
+	}
+	for _, ing := range ingList.Items {
+		var addrs []string
+		for _, lb := range ing.Status.LoadBalancer.Ingress {
+			addrs = append(addrs, cmp.Or(lb.Hostname, lb.IP))
+		}
+		switch {
+		case slices.Contains(ing.Finalizers, FinalizerNamePG):
+			s, err := h.service(ctx, &ing, "Ingress", h.serviceNameStrategy.hostnameForIngress(&ing))
+			if err != nil {
+				return nil, err
+			}
+			inv.Ingresses = append(inv.Ingresses, inventoryResourceFromService(s, addrs))
+		case slices.Contains(ing.Finalizers, FinalizerName):
+			inv.Ingresses = append(inv.Ingresses, inventoryResource{
+				Namespace: ing.Namespace,
+				Name:      ing.Name,
+				Addresses: addrs,
+				State:     standaloneState(ing.DeletionTimestamp != nil, len(addrs) > 0),
+			})
+		}
+	}
+
+	svcList := &corev1.ServiceList{}
+	if err := h.cl.List(ctx, svcList); err != nil {
+		return nil, fmt.Errorf("failed to list Services: %w", err)
+	}
+	for _, svc := range svcList.Items {
+		var addrs []string
+		for _, lb := range svc.Status.LoadBalancer.Ingress {
+			addrs = append(addrs, cmp.Or(lb.Hostname, lb.IP))
+		}
+		switch {
+		case slices.Contains(svc.Finalizers, svcPGFinalizerName):
+			s, err := h.service(ctx, &svc, "Service", nameForService(&svc, h.annotationPrefix))
+			if err != nil {
+				return nil, err
+			}
+			inv.Services = append(inv.Services, inventoryResourceFromService(s, addrs))
+		case slices.Contains(svc.Finalizers, FinalizerName):
+			inv.Services = append(inv.Services, inventoryResource{
+				Namespace: svc.Namespace,
+				Name:      svc.Name,
+				Addresses: addrs,
+				State:     standaloneState(svc.DeletionTimestamp != nil, tsoperator.SvcIsReady(&svc)),
+			})
+		}
+	}
+
+	pgList := &tsapi.ProxyGroupList{}
+	if err := h.cl.List(ctx, pgList); err != nil {
+		return nil, fmt.Errorf("failed to list ProxyGroups: %w", err)
+	}
+	for _, pg := range pgList.Items {
+		inv.ProxyGroups = append(inv.ProxyGroups, inventoryProxyGroup{
+			Name:          pg.Name,
+			Type:          pg.Spec.Type,
+			Replicas:      pgReplicas(&pg),
+			ReadyReplicas: len(pg.Status.Devices),
+			Ready:         tsoperator.ProxyGroupIsReady(&pg),
+		})
+	}
+
+	byNamespacedName := func(a, b inventoryResource) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+	}
+	slices.SortFunc(inv.Ingresses, byNamespacedName)
+	slices.SortFunc(inv.Services, byNamespacedName)
+	slices.SortFunc(inv.ProxyGroups, func(a, b inventoryProxyGroup) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return inv, nil
+}
+
+// inventoryResourceFromService returns the inventory entry of the Ingress or
+// Service exposed via a ProxyGroup that is described by s.
+func inventoryResourceFromService(s inspectedService, addrs []string) inventoryResource {
+	return inventoryResource{
+		Namespace:           s.Namespace,
+		Name:                s.ResourceName,
+		ProxyGroup:          s.ProxyGroup,
+		TailscaleService:    s.Name,
+		AdvertisingReplicas: s.AdvertisingReplicas,
+		Addresses:           addrs,
+		State:               s.State,
+	}
+}
+
+// standaloneState returns the inventory state of an Ingress or Service exposed
+// by its own proxy.
+func standaloneState(deleting, ready bool) string {
+	switch {
+	case deleting:
+		return inspectStateDeleting
+	case ready:
+		return inventoryStateReady
+	default:
+		return inspectStatePending
+	}
+}
@@ -0,0 +1,279 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !plan9
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	tsapi "tailscale.com/k8s-operator/apis/v1alpha1"
+	"tailscale.com/kube/kubetypes"
+	"tailscale.com/types/ptr"
+)
+
+func TestInventoryAPI(t *testing.T) {
+	fc := fake.NewClientBuilder().
+		WithScheme(tsapi.GlobalScheme).
+		WithObjects(
+			// Exposed via a ProxyGroup.
+			&networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "web",
+					Namespace:   "default",
+					Finalizers:  []string{FinalizerNamePG},
+					Annotations: map[string]string{AnnotationProxyGroup: "test-pg"},
+				},
+				Spec: networkingv1.IngressSpec{
+					TLS: []networkingv1.IngressTLS{{Hosts: []string{"my-web"}}},
+				},
+				Status: networkingv1.IngressStatus{
+					LoadBalancer: networkingv1.IngressLoadBalancerStatus{
+						Ingress: []networkingv1.IngressLoadBalancerIngress{{Hostname: "my-web.ts.net"}},
+					},
+				},
+			},
+			// Exposed by its own proxy.
+			&networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "blog",
+					Namespace:  "apps",
+					Finalizers: []string{FinalizerName},
+				},
+				Status: networkingv1.IngressStatus{
+					LoadBalancer: networkingv1.IngressLoadBalancerStatus{
+						Ingress: []networkingv1.IngressLoadBalancerIngress{{Hostname: "blog.ts.net"}},
+					},
+				},
+			},
+			// Not managed by the operator.
+			&networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "other",
+					Namespace: "default",
+				},
+			},
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "db",
+					Namespace:   "default",
+					Finalizers:  []string{svcPGFinalizerName},
+					Annotations: map[string]string{AnnotationProxyGroup: "test-pg"},
+				},
+			},
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "cache",
+					Namespace:  "default",
+					Finalizers: []string{FinalizerName},
+				},
+				Status: corev1.ServiceStatus{
+					Conditions: []metav1.Condition{{
+						Type:   string(tsapi.ProxyReady),
+						Status: metav1.ConditionTrue,
+					}},
+					LoadBalancer: corev1.LoadBalancerStatus{
+						Ingress: []corev1.LoadBalancerIngress{{IP: "100.64.0.1"}, {Hostname: "cache.ts.net"}},
+					},
+				},
+			},
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "queue",
+					Namespace:         "default",
+					Finalizers:        []string{FinalizerName},
+					DeletionTimestamp: ptr.To(metav1.Now()),
+				},
+			},
+			&tsapi.ProxyGroup{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "test-pg",
+					Generation: 2,
+				},
+				Spec: tsapi.ProxyGroupSpec{
+					Type:     tsapi.ProxyGroupTypeIngress,
+					Replicas: ptr.To[int32](3),
+				},
+				Status: tsapi.ProxyGroupStatus{
+					Conditions: []metav1.Condition{{
+						Type:               string(tsapi.ProxyGroupReady),
+						Status:             metav1.ConditionTrue,
+						ObservedGeneration: 2,
+					}},
+					Devices: []tsapi.TailnetDevice{{Hostname: "test-pg-0"}, {Hostname: "test-pg-1"}},
+				},
+			},
+			&tsapi.ProxyGroup{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "egress-pg",
+				},
+				Spec: tsapi.ProxyGroupSpec{
+					Type: tsapi.ProxyGroupTypeEgress,
+				},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-pg-0",
+					Namespace: "operator-ns",
+					Labels:    pgSecretLabels("test-pg", kubetypes.LabelSecretTypeState),
+				},
+				Data: map[string][]byte{
+					"_current-profile": []byte("profile-foo"),
+					"profile-foo":      []byte(`{"AdvertiseServices":["svc:my-web"],"Config":{"NodeID":"node-foo"}}`),
+				},
+			},
+		).
+		Build()
+
+	h := &inspectHandler{
+		cl:          fc,
+		tsNamespace: "operator-ns",
+		logger:      zap.Must(zap.NewDevelopment()).Sugar(),
+	}
+	srv := httptest.NewServer(inspectMux(h))
+	defer srv.Close()
+
+	get := func(t *testing.T, path string, v any) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s: unexpected status code %d", path, resp.StatusCode)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("GET %s: Content-Type = %q, want application/json", path, ct)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+	}
+
+	t.Run("inventory", func(t *testing.T) {
+		var got inventory
+		get(t, "/v1/inventory", &got)
+		want := inventory{
+			APIVersion: "v1",
+			Ingresses: []inventoryResource{
+				{
+					Namespace: "apps",
+					Name:      "blog",
+					Addresses: []string{"blog.ts.net"},
+					State:     inventoryStateReady,
+				},
+				{
+					Namespace:           "default",
+					Name:                "web",
+					ProxyGroup:          "test-pg",
+					TailscaleService:    "svc:my-web",
+					AdvertisingReplicas: []int{0},
+					Addresses:           []string{"my-web.ts.net"},
+					State:               inspectStateAdvertised,
+				},
+			},
+			Services: []inventoryResource{
+				{
+					Namespace: "default",
+					Name:      "cache",
+					Addresses: []string{"100.64.0.1", "cache.ts.net"},
+					State:     inventoryStateReady,
+				},
+				{
+					Namespace:        "default",
+					Name:             "db",
+					ProxyGroup:       "test-pg",
+					TailscaleService: "svc:default-db",
+					State:            inspectStatePending,
+				},
+				{
+					Namespace: "default",
+					Name:      "queue",
+					State:     inspectStateDeleting,
+				},
+			},
+			ProxyGroups: []inventoryProxyGroup{
+				{
+					Name:     "egress-pg",
+					Type:     tsapi.ProxyGroupTypeEgress,
+					Replicas: 2,
+				},
+				{
+					Name:          "test-pg",
+					Type:          tsapi.ProxyGroupTypeIngress,
+					Replicas:      3,
+					ReadyReplicas: 2,
+					Ready:         true,
+				},
+			},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("unexpected inventory (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("openapi", func(t *testing.T) {
+		var doc struct {
+			Info struct {
+				Version string `json:"version"`
+			} `json:"info"`
+			Paths      map[string]any `json:"paths"`
+			Components struct {
+				Schemas map[string]struct {
+					Properties map[string]any `json:"properties"`
+				} `json:"schemas"`
+			} `json:"components"`
+		}
+		get(t, "/v1/openapi.json", &doc)
+		if doc.Info.Version != inventoryAPIVersion {
+			t.Errorf("OpenAPI version = %q, want %q", doc.Info.Version, inventoryAPIVersion)
+		}
+		for _, p := range []string{"/v1/inventory", "/v1/openapi.json"} {
+			if _, ok := doc.Paths[p]; !ok {
+				t.Errorf("OpenAPI description is missing path %s", p)
+			}
+		}
+		// The schemas must describe every field of the inventory.
+		for schema, typ := range map[string]reflect.Type{
+			"Inventory":  reflect.TypeFor[inventory](),
+			"Resource":   reflect.TypeFor[inventoryResource](),
+			"ProxyGroup": reflect.TypeFor[inventoryProxyGroup](),
+		} {
+			props := doc.Components.Schemas[schema].Properties
+			for i := range typ.NumField() {
+				name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+				if _, ok := props[name]; !ok {
+					t.Errorf("OpenAPI schema %s is missing property %q", schema, name)
+				}
+			}
+			if len(props) != typ.NumField() {
+				t.Errorf("OpenAPI schema %s has %d properties, want %d", schema, len(props), typ.NumField())
+			}
+		}
+	})
+
+	t.Run("unknown_version", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/v2/inventory")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET /v2/inventory: got status code %d, want %d", resp.StatusCode, http.StatusNotFound)
+		}
+	})
+}
@@ -13,6 +13,7 @@
 	"net/http"
 	"os"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -37,6 +38,7 @@
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	kzap "sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -44,6 +46,7 @@
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"tailscale.com/client/local"
 	"tailscale.com/client/tailscale"
@@ -54,6 +57,7 @@
 	apiproxy "tailscale.com/k8s-operator/api-proxy"
 	tsapi "tailscale.com/k8s-operator/apis/v1alpha1"
 	"tailscale.com/kube/kubetypes"
+	"tailscale.com/tailcfg"
 	"tailscale.com/tsnet"
 	"tailscale.com/tstime"
 	"tailscale.com/types/logger"
@@ -85,11 +89,31 @@ func main() {
 		k8sProxyImage         = defaultEnv("K8S_PROXY_IMAGE", "tailscale/k8s-proxy:latest")
 		priorityClassName     = defaultEnv("PROXY_PRIORITY_CLASS_NAME", "")
 		tags                  = defaultEnv("PROXY_TAGS", "tag:k8s")
+		httpTags              = defaultEnv("PROXY_HTTP_TAGS", tags)
+		tcpTags               = defaultEnv("PROXY_TCP_TAGS", tags)
 		tsFirewallMode        = defaultEnv("PROXY_FIREWALL_MODE", "")
 		defaultProxyClass     = defaultEnv("PROXY_DEFAULT_CLASS", "")
 		isDefaultLoadBalancer = defaultBool("OPERATOR_DEFAULT_LOAD_BALANCER", false)
 		loginServer           = strings.TrimSuffix(defaultEnv("OPERATOR_LOGIN_SERVER", ""), "/")
 		ingressClassName      = defaultEnv("OPERATOR_INGRESS_CLASS_NAME", "tailscale")
+		ingressClassCtrl      = defaultEnv("OPERATOR_INGRESS_CLASS_CONTROLLER", tailscaleIngressControllerName)
+		watchNamespaces       = defaultEnv("OPERATOR_WATCH_NAMESPACES", "")
+		inspectAddr           = inspectListenAddr(defaultEnv("OPERATOR_INSPECT_ADDR", ""))
+		certExpiryWarning     = defaultEnv("OPERATOR_CERT_EXPIRY_WARNING", "168h")
+		serviceNameStrategy   = defaultEnv("OPERATOR_SERVICE_NAME_STRATEGY", serviceNameStrategyDefault)
+		hostnameTemplate      = defaultEnv("OPERATOR_HOSTNAME_TEMPLATE", "")
+		noAutoDeleteServices  = defaultBool("OPERATOR_NO_AUTO_DELETE_SERVICES", false)
+		pgNotReadyRequeue     = defaultEnv("OPERATOR_PROXYGROUP_NOT_READY_REQUEUE", "0s")
+		inventoryInterval     = defaultEnv("OPERATOR_INVENTORY_INTERVAL", "0s")
+		managedServiceTag     = defaultEnv("OPERATOR_MANAGED_SERVICE_TAG", "")
+		ownerAnnotBudget      = defaultEnv("OPERATOR_OWNER_ANNOTATION_BUDGET", "0")
+		previousOperatorID    = defaultEnv("OPERATOR_PREVIOUS_ID", "")
+		createGracePeriod     = defaultEnv("OPERATOR_SERVICE_CREATE_GRACE_PERIOD", "0s")
+		consolidateCerts      = defaultBool("OPERATOR_CONSOLIDATE_CERT_SECRETS", false)
+		emitAuditEvents       = defaultBool("OPERATOR_EMIT_AUDIT_EVENTS", false)
+		clusterName           = defaultEnv("OPERATOR_CLUSTER_NAME", "")
+		noServiceProvenance   = defaultBool("OPERATOR_NO_SERVICE_PROVENANCE", false)
+		annotPrefix           = defaultEnv("OPERATOR_ANNOTATION_PREFIX", annotationDomain)
 	)
 
 	var opts []kzap.Opts
@@ -113,6 +137,47 @@ func main() {
 		tsNamespace = strings.TrimSpace(string(b))
 	}
 
+	certExpiryWarningWindow, err := time.ParseDuration(certExpiryWarning)
+	if err != nil {
+		zlog.Fatalf("invalid OPERATOR_CERT_EXPIRY_WARNING %q: %v", certExpiryWarning, err)
+	}
+	pgNotReadyRequeueInterval, err := time.ParseDuration(pgNotReadyRequeue)
+	if err != nil {
+		zlog.Fatalf("invalid OPERATOR_PROXYGROUP_NOT_READY_REQUEUE %q: %v", pgNotReadyRequeue, err)
+	}
+	serviceCreateGracePeriod, err := time.ParseDuration(createGracePeriod)
+	if err != nil {
+		zlog.Fatalf("invalid OPERATOR_SERVICE_CREATE_GRACE_PERIOD %q: %v", createGracePeriod, err)
+	}
+	inventoryUpdateInterval, err := time.ParseDuration(inventoryInterval)
+	if err != nil {
+		zlog.Fatalf("invalid OPERATOR_INVENTORY_INTERVAL %q: %v", inventoryInterval, err)
+	}
+	svcNameStrategy, err := parseServiceNameStrategy(serviceNameStrategy)
+	if err != nil {
+		zlog.Fatalf("invalid OPERATOR_SERVICE_NAME_STRATEGY: %v", err)
+	}
+	if hostnameTemplate != "" {
+		if svcNameStrategy.kind != "" {
+			zlog.Fatalf("OPERATOR_HOSTNAME_TEMPLATE can not be combined with OPERATOR_SERVICE_NAME_STRATEGY %q", serviceNameStrategy)
+		}
+		if svcNameStrategy.hostnameTemplate, err = parseHostnameTemplate(hostnameTemplate); err != nil {
+			zlog.Fatalf("invalid OPERATOR_HOSTNAME_TEMPLATE: %v", err)
+		}
+	}
+	if managedServiceTag != "" {
+		if err := tailcfg.CheckTag(managedServiceTag); err != nil {
+			zlog.Fatalf("invalid OPERATOR_MANAGED_SERVICE_TAG %q: %v", managedServiceTag, err)
+		}
+	}
+	ownerAnnotationBudget, err := strconv.Atoi(ownerAnnotBudget)
+	if err != nil || ownerAnnotationBudget < 0 {
+		zlog.Fatalf("invalid OPERATOR_OWNER_ANNOTATION_BUDGET %q: must be a non-negative number of bytes", ownerAnnotBudget)
+	}
+	if err := validateAnnotationPrefix(annotPrefix); err != nil {
+		zlog.Fatalf("invalid OPERATOR_ANNOTATION_PREFIX %q: %v", annotPrefix, err)
+	}
+
 	// The operator can run either as a plain operator or it can
 	// additionally act as api-server proxy
 	// https://tailscale.com/kb/1236/kubernetes-operator/?q=kubernetes#accessing-the-kubernetes-control-plane-using-an-api-server-proxy.
@@ -156,10 +221,29 @@ func main() {
 		proxyPriorityClassName:        priorityClassName,
 		proxyActAsDefaultLoadBalancer: isDefaultLoadBalancer,
 		proxyTags:                     tags,
+		proxyHTTPTags:                 httpTags,
+		proxyTCPTags:                  tcpTags,
 		proxyFirewallMode:             tsFirewallMode,
 		defaultProxyClass:             defaultProxyClass,
 		loginServer:                   loginServer,
 		ingressClassName:              ingressClassName,
+		ingressClassController:        ingressClassCtrl,
+		watchNamespaces:               watchNamespaces,
+		inspectAddr:                   inspectAddr,
+		certExpiryWarning:             certExpiryWarningWindow,
+		serviceNameStrategy:           svcNameStrategy,
+		noAutoDeleteServices:          noAutoDeleteServices,
+		proxyGroupNotReadyRequeue:     pgNotReadyRequeueInterval,
+		inventoryInterval:             inventoryUpdateInterval,
+		managedServiceTag:             managedServiceTag,
+		ownerAnnotationBudget:         ownerAnnotationBudget,
+		previousOperatorID:            previousOperatorID,
+		serviceCreateGracePeriod:      serviceCreateGracePeriod,
+		consolidateCertSecrets:        consolidateCerts,
+		emitAuditEvents:               emitAuditEvents,
+		clusterName:                   clusterName,
+		noServiceProvenance:           noServiceProvenance,
+		annotationPrefix:              annotationPrefix(annotPrefix),
 	}
 	runReconcilers(rOpts)
 }
@@ -324,7 +408,7 @@ func runReconcilers(opts reconcilerOpts) {
 		startlog.Fatalf("could not create manager: %v", err)
 	}
 
-	svcFilter := handler.EnqueueRequestsFromMapFunc(serviceHandler)
+	svcFilter := handler.EnqueueRequestsFromMapFunc(serviceHandler(opts.annotationPrefix))
 	svcChildFilter := handler.EnqueueRequestsFromMapFunc(managedResourceHandlerForType("svc"))
 	// If a ProxyClass changes, enqueue all Services labeled with that
 	// ProxyClass's name.
@@ -359,20 +443,30 @@ func runReconcilers(opts reconcilerOpts) {
 			tsNamespace:           opts.tailscaleNamespace,
 			clock:                 tstime.DefaultClock{},
 			defaultProxyClass:     opts.defaultProxyClass,
+			annotationPrefix:      opts.annotationPrefix,
 		})
 	if err != nil {
 		startlog.Fatalf("could not create service reconciler: %v", err)
 	}
-	if err := mgr.GetFieldIndexer().IndexField(context.Background(), new(corev1.Service), indexServiceProxyClass, indexProxyClass); err != nil {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), new(corev1.Service), indexServiceProxyClass, indexProxyClass(opts.annotationPrefix)); err != nil {
 		startlog.Fatalf("failed setting up ProxyClass indexer for Services: %v", err)
 	}
 
+	lc, err := opts.tsServer.LocalClient()
+	if err != nil {
+		startlog.Fatalf("could not get local client: %v", err)
+	}
+	id, err := id(context.Background(), lc)
+	if err != nil {
+		startlog.Fatalf("error determining stable ID of the operator's Tailscale device: %v", err)
+	}
+
 	ingressChildFilter := handler.EnqueueRequestsFromMapFunc(managedResourceHandlerForType("ingress"))
 	// If a ProxyClassChanges, enqueue all Ingresses labeled with that
 	// ProxyClass's name.
 	proxyClassFilterForIngress := handler.EnqueueRequestsFromMapFunc(proxyClassHandlerForIngress(mgr.GetClient(), startlog))
 	// Enque Ingress if a managed Service or backend Service associated with a tailscale Ingress changes.
-	svcHandlerForIngress := handler.EnqueueRequestsFromMapFunc(serviceHandlerForIngress(mgr.GetClient(), startlog, opts.ingressClassName))
+	svcHandlerForIngress := handler.EnqueueRequestsFromMapFunc(serviceHandlerForIngress(mgr.GetClient(), startlog, opts.ingressClassName, opts.annotationPrefix))
 	err = builder.
 		ControllerManagedBy(mgr).
 		For(&networkingv1.Ingress{}).
@@ -380,58 +474,111 @@ func runReconcilers(opts reconcilerOpts) {
 		Watches(&appsv1.StatefulSet{}, ingressChildFilter).
 		Watches(&corev1.Secret{}, ingressChildFilter).
 		Watches(&corev1.Service{}, svcHandlerForIngress).
+		Watches(&discoveryv1.EndpointSlice{}, handler.EnqueueRequestsFromMapFunc(endpointSliceHandlerForIngress(serviceHandlerForIngress(mgr.GetClient(), startlog, opts.ingressClassName, opts.annotationPrefix)))).
 		Watches(&tsapi.ProxyClass{}, proxyClassFilterForIngress).
 		Complete(&IngressReconciler{
-			ssr:               ssr,
-			recorder:          eventRecorder,
-			Client:            mgr.GetClient(),
-			logger:            opts.log.Named("ingress-reconciler"),
-			defaultProxyClass: opts.defaultProxyClass,
-			ingressClassName:  opts.ingressClassName,
+			ssr:                    ssr,
+			recorder:               eventRecorder,
+			Client:                 mgr.GetClient(),
+			logger:                 opts.log.Named("ingress-reconciler"),
+			defaultProxyClass:      opts.defaultProxyClass,
+			ingressClassName:       opts.ingressClassName,
+			ingressClassController: opts.ingressClassController,
+			lc:                     lc,
+			operatorID:             id,
+			annotationPrefix:       opts.annotationPrefix,
 		})
 	if err != nil {
 		startlog.Fatalf("could not create ingress reconciler: %v", err)
 	}
-	if err := mgr.GetFieldIndexer().IndexField(context.Background(), new(networkingv1.Ingress), indexIngressProxyClass, indexProxyClass); err != nil {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), new(networkingv1.Ingress), indexIngressProxyClass, indexProxyClass(opts.annotationPrefix)); err != nil {
 		startlog.Fatalf("failed setting up ProxyClass indexer for Ingresses: %v", err)
 	}
 
-	lc, err := opts.tsServer.LocalClient()
-	if err != nil {
-		startlog.Fatalf("could not get local client: %v", err)
+	// Updates of the Tailscale Services that ProxyGroup replicas advertise
+	// are completed on shutdown, so that the replicas are left consistent.
+	pw := &pendingWrites{
+		logger:  opts.log.Named("pending-writes"),
+		timeout: pendingWritesTimeout,
 	}
-	id, err := id(context.Background(), lc)
-	if err != nil {
-		startlog.Fatalf("error determining stable ID of the operator's Tailscale device: %v", err)
+	if err := mgr.Add(pw); err != nil {
+		startlog.Fatalf("could not add pending writes tracker: %v", err)
 	}
+
 	ingressProxyGroupFilter := handler.EnqueueRequestsFromMapFunc(ingressesFromIngressProxyGroup(mgr.GetClient(), opts.log))
+	var watchNamespaces []string
+	if opts.watchNamespaces != "" {
+		watchNamespaces = strings.Split(opts.watchNamespaces, ",")
+	}
+	// HA Ingresses are also reconciled if the tailnet's MagicDNS suffix
+	// changes, as their DNS names are derived from it.
+	tailnetDNSSuffixEvents := make(chan event.GenericEvent)
+	svcHandlerForIngressPG := serviceHandlerForIngressPG(mgr.GetClient(), startlog, opts.ingressClassName, opts.annotationPrefix)
 	err = builder.
 		ControllerManagedBy(mgr).
 		For(&networkingv1.Ingress{}).
 		Named("ingress-pg-reconciler").
-		Watches(&corev1.Service{}, handler.EnqueueRequestsFromMapFunc(serviceHandlerForIngressPG(mgr.GetClient(), startlog, opts.ingressClassName))).
+		Watches(&corev1.Service{}, handler.EnqueueRequestsFromMapFunc(svcHandlerForIngressPG)).
+		Watches(&discoveryv1.EndpointSlice{}, handler.EnqueueRequestsFromMapFunc(endpointSliceHandlerForIngress(svcHandlerForIngressPG))).
 		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(HAIngressesFromSecret(mgr.GetClient(), startlog))).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(HAIngressesFromServeConfig(mgr.GetClient(), startlog))).
 		Watches(&tsapi.ProxyGroup{}, ingressProxyGroupFilter).
+		WatchesRawSource(source.Channel(tailnetDNSSuffixEvents, &handler.EnqueueRequestForObject{})).
 		Complete(&HAIngressReconciler{
-			recorder:         eventRecorder,
-			tsClient:         opts.tsClient,
-			tsnetServer:      opts.tsServer,
-			defaultTags:      strings.Split(opts.proxyTags, ","),
-			Client:           mgr.GetClient(),
-			logger:           opts.log.Named("ingress-pg-reconciler"),
-			lc:               lc,
-			operatorID:       id,
-			tsNamespace:      opts.tailscaleNamespace,
-			ingressClassName: opts.ingressClassName,
+			recorder:                  eventRecorder,
+			tsClient:                  opts.tsClient,
+			tsnetServer:               opts.tsServer,
+			defaultTags:               strings.Split(opts.proxyHTTPTags, ","),
+			Client:                    mgr.GetClient(),
+			logger:                    opts.log.Named("ingress-pg-reconciler"),
+			lc:                        lc,
+			operatorID:                id,
+			tsNamespace:               opts.tailscaleNamespace,
+			ingressClassName:          opts.ingressClassName,
+			ingressClassController:    opts.ingressClassController,
+			watchNamespaces:           watchNamespaces,
+			clock:                     tstime.DefaultClock{},
+			certExpiryWarning:         opts.certExpiryWarning,
+			serviceNameStrategy:       opts.serviceNameStrategy,
+			noAutoDeleteServices:      opts.noAutoDeleteServices,
+			managedServiceTag:         opts.managedServiceTag,
+			ownerAnnotationBudget:     opts.ownerAnnotationBudget,
+			previousOperatorID:        opts.previousOperatorID,
+			proxyGroupNotReadyRequeue: opts.proxyGroupNotReadyRequeue,
+			createGracePeriod:         opts.serviceCreateGracePeriod,
+			consolidateCertSecrets:    opts.consolidateCertSecrets,
+			emitAuditEvents:           opts.emitAuditEvents,
+			podReader:                 mgr.GetAPIReader(),
+			pendingWrites:             pw,
+			clusterName:               opts.clusterName,
+			noServiceProvenance:       opts.noServiceProvenance,
+			annotationPrefix:          opts.annotationPrefix,
 		})
 	if err != nil {
 		startlog.Fatalf("could not create ingress-pg-reconciler: %v", err)
 	}
-	if err := mgr.GetFieldIndexer().IndexField(context.Background(), new(networkingv1.Ingress), indexIngressProxyGroup, indexPGIngresses); err != nil {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), new(networkingv1.Ingress), indexIngressProxyGroup, indexPGIngresses(opts.annotationPrefix)); err != nil {
 		startlog.Fatalf("failed setting up indexer for HA Ingresses: %v", err)
 	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), new(networkingv1.Ingress), indexIngressTLSSecret, indexTLSSecretIngresses(opts.annotationPrefix)); err != nil {
+		startlog.Fatalf("failed setting up TLS Secret indexer for HA Ingresses: %v", err)
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), new(networkingv1.Ingress), indexIngressReadyGate, indexReadyGateIngresses(opts.annotationPrefix)); err != nil {
+		startlog.Fatalf("failed setting up ready gate indexer for HA Ingresses: %v", err)
+	}
+	if err := mgr.Add(&tailnetDNSSuffixWatcher{
+		Client:              mgr.GetClient(),
+		lc:                  lc,
+		tsNamespace:         opts.tailscaleNamespace,
+		logger:              opts.log.Named("tailnet-dns-suffix-watcher"),
+		events:              tailnetDNSSuffixEvents,
+		serviceNameStrategy: opts.serviceNameStrategy,
+		annotationPrefix:    opts.annotationPrefix,
+	}); err != nil {
+		startlog.Fatalf("could not add tailnet DNS suffix watcher: %v", err)
+	}
 
-	ingressSvcFromEpsFilter := handler.EnqueueRequestsFromMapFunc(ingressSvcFromEps(mgr.GetClient(), opts.log.Named("service-pg-reconciler")))
+	ingressSvcFromEpsFilter := handler.EnqueueRequestsFromMapFunc(ingressSvcFromEps(mgr.GetClient(), opts.log.Named("service-pg-reconciler"), opts.annotationPrefix))
 	err = builder.
 		ControllerManagedBy(mgr).
 		For(&corev1.Service{}, builder.WithPredicates(serviceManagedResourceFilterPredicate())).
@@ -440,20 +587,28 @@ func runReconcilers(opts reconcilerOpts) {
 		Watches(&tsapi.ProxyGroup{}, ingressProxyGroupFilter).
 		Watches(&discoveryv1.EndpointSlice{}, ingressSvcFromEpsFilter).
 		Complete(&HAServiceReconciler{
-			recorder:    eventRecorder,
-			tsClient:    opts.tsClient,
-			defaultTags: strings.Split(opts.proxyTags, ","),
-			Client:      mgr.GetClient(),
-			logger:      opts.log.Named("service-pg-reconciler"),
-			lc:          lc,
-			clock:       tstime.DefaultClock{},
-			operatorID:  id,
-			tsNamespace: opts.tailscaleNamespace,
+			recorder:              eventRecorder,
+			tsClient:              opts.tsClient,
+			defaultTags:           strings.Split(opts.proxyTCPTags, ","),
+			Client:                mgr.GetClient(),
+			logger:                opts.log.Named("service-pg-reconciler"),
+			lc:                    lc,
+			clock:                 tstime.DefaultClock{},
+			operatorID:            id,
+			tsNamespace:           opts.tailscaleNamespace,
+			noAutoDeleteServices:  opts.noAutoDeleteServices,
+			managedServiceTag:     opts.managedServiceTag,
+			ownerAnnotationBudget: opts.ownerAnnotationBudget,
+			previousOperatorID:    opts.previousOperatorID,
+			pendingWrites:         pw,
+			clusterName:           opts.clusterName,
+			noServiceProvenance:   opts.noServiceProvenance,
+			annotationPrefix:      opts.annotationPrefix,
 		})
 	if err != nil {
 		startlog.Fatalf("could not create service-pg-reconciler: %v", err)
 	}
-	if err := mgr.GetFieldIndexer().IndexField(context.Background(), new(corev1.Service), indexIngressProxyGroup, indexPGIngresses); err != nil {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), new(corev1.Service), indexIngressProxyGroup, indexPGIngresses(opts.annotationPrefix)); err != nil {
 		startlog.Fatalf("failed setting up indexer for HA Services: %v", err)
 	}
 
@@ -499,7 +654,7 @@ func runReconcilers(opts reconcilerOpts) {
 		startlog.Fatalf("could not create nameserver reconciler: %v", err)
 	}
 
-	egressSvcFilter := handler.EnqueueRequestsFromMapFunc(egressSvcsHandler)
+	egressSvcFilter := handler.EnqueueRequestsFromMapFunc(egressSvcsHandler(opts.annotationPrefix))
 	egressProxyGroupFilter := handler.EnqueueRequestsFromMapFunc(egressSvcsFromEgressProxyGroup(mgr.GetClient(), opts.log))
 	err = builder.
 		ControllerManagedBy(mgr).
@@ -507,16 +662,17 @@ func runReconcilers(opts reconcilerOpts) {
 		Watches(&corev1.Service{}, egressSvcFilter).
 		Watches(&tsapi.ProxyGroup{}, egressProxyGroupFilter).
 		Complete(&egressSvcsReconciler{
-			Client:      mgr.GetClient(),
-			tsNamespace: opts.tailscaleNamespace,
-			recorder:    eventRecorder,
-			clock:       tstime.DefaultClock{},
-			logger:      opts.log.Named("egress-svcs-reconciler"),
+			Client:           mgr.GetClient(),
+			tsNamespace:      opts.tailscaleNamespace,
+			recorder:         eventRecorder,
+			clock:            tstime.DefaultClock{},
+			logger:           opts.log.Named("egress-svcs-reconciler"),
+			annotationPrefix: opts.annotationPrefix,
 		})
 	if err != nil {
 		startlog.Fatalf("could not create egress Services reconciler: %v", err)
 	}
-	if err := mgr.GetFieldIndexer().IndexField(context.Background(), new(corev1.Service), indexEgressProxyGroup, indexEgressServices); err != nil {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), new(corev1.Service), indexEgressProxyGroup, indexEgressServices(opts.annotationPrefix)); err != nil {
 		startlog.Fatalf("failed setting up indexer for egress Services: %v", err)
 	}
 
@@ -527,10 +683,11 @@ func runReconcilers(opts reconcilerOpts) {
 		Watches(&corev1.Service{}, egressSvcFilter).
 		Watches(&discoveryv1.EndpointSlice{}, egressSvcFromEpsFilter).
 		Complete(&egressSvcsReadinessReconciler{
-			Client:      mgr.GetClient(),
-			tsNamespace: opts.tailscaleNamespace,
-			clock:       tstime.DefaultClock{},
-			logger:      opts.log.Named("egress-svcs-readiness-reconciler"),
+			Client:           mgr.GetClient(),
+			tsNamespace:      opts.tailscaleNamespace,
+			clock:            tstime.DefaultClock{},
+			logger:           opts.log.Named("egress-svcs-readiness-reconciler"),
+			annotationPrefix: opts.annotationPrefix,
 		})
 	if err != nil {
 		startlog.Fatalf("could not create egress Services readiness reconciler: %v", err)
@@ -539,7 +696,7 @@ func runReconcilers(opts reconcilerOpts) {
 	epsFilter := handler.EnqueueRequestsFromMapFunc(egressEpsHandler)
 	podsFilter := handler.EnqueueRequestsFromMapFunc(egressEpsFromPGPods(mgr.GetClient(), opts.tailscaleNamespace))
 	secretsFilter := handler.EnqueueRequestsFromMapFunc(egressEpsFromPGStateSecrets(mgr.GetClient(), opts.tailscaleNamespace))
-	epsFromExtNSvcFilter := handler.EnqueueRequestsFromMapFunc(epsFromExternalNameService(mgr.GetClient(), opts.log, opts.tailscaleNamespace))
+	epsFromExtNSvcFilter := handler.EnqueueRequestsFromMapFunc(epsFromExternalNameService(mgr.GetClient(), opts.log, opts.tailscaleNamespace, opts.annotationPrefix))
 
 	err = builder.
 		ControllerManagedBy(mgr).
@@ -619,6 +776,7 @@ func runReconcilers(opts reconcilerOpts) {
 			tsNamespace:           opts.tailscaleNamespace,
 			logger:                opts.log.Named("dns-records-reconciler"),
 			isDefaultLoadBalancer: opts.proxyActAsDefaultLoadBalancer,
+			annotationPrefix:      opts.annotationPrefix,
 		})
 	if err != nil {
 		startlog.Fatalf("could not create DNS records reconciler: %v", err)
@@ -659,15 +817,16 @@ func runReconcilers(opts reconcilerOpts) {
 		Named("kube-apiserver-ts-service-reconciler").
 		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(kubeAPIServerPGsFromSecret(mgr.GetClient(), startlog))).
 		Complete(&KubeAPIServerTSServiceReconciler{
-			Client:      mgr.GetClient(),
-			recorder:    eventRecorder,
-			logger:      opts.log.Named("kube-apiserver-ts-service-reconciler"),
-			tsClient:    opts.tsClient,
-			tsNamespace: opts.tailscaleNamespace,
-			lc:          lc,
-			defaultTags: strings.Split(opts.proxyTags, ","),
-			operatorID:  id,
-			clock:       tstime.DefaultClock{},
+			Client:               mgr.GetClient(),
+			recorder:             eventRecorder,
+			logger:               opts.log.Named("kube-apiserver-ts-service-reconciler"),
+			tsClient:             opts.tsClient,
+			tsNamespace:          opts.tailscaleNamespace,
+			lc:                   lc,
+			defaultTags:          strings.Split(opts.proxyTags, ","),
+			operatorID:           id,
+			clock:                tstime.DefaultClock{},
+			noAutoDeleteServices: opts.noAutoDeleteServices,
 		})
 	if err != nil {
 		startlog.Fatalf("could not create Kubernetes API server Tailscale Service reconciler: %v", err)
@@ -709,6 +868,30 @@ func runReconcilers(opts reconcilerOpts) {
 		startlog.Fatalf("could not create ProxyGroup reconciler: %v", err)
 	}
 
+	ih := &inspectHandler{
+		cl:                  mgr.GetClient(),
+		tsNamespace:         opts.tailscaleNamespace,
+		logger:              opts.log.Named("inspect"),
+		serviceNameStrategy: opts.serviceNameStrategy,
+		annotationPrefix:    opts.annotationPrefix,
+	}
+	if opts.inspectAddr != "" {
+		if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			return runInspectServer(ctx, opts.inspectAddr, ih)
+		})); err != nil {
+			startlog.Fatalf("could not add inspect server: %v", err)
+		}
+		startlog.Infof("Serving managed Tailscale Service state and inventory API on %s", opts.inspectAddr)
+	}
+	if opts.inventoryInterval > 0 {
+		if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			return runInventory(ctx, ih, opts.inventoryInterval)
+		})); err != nil {
+			startlog.Fatalf("could not add Tailscale Service inventory: %v", err)
+		}
+		startlog.Infof("Updating Tailscale Service inventory in ConfigMap %s/%s every %v", opts.tailscaleNamespace, inventoryConfigMapName, opts.inventoryInterval)
+	}
+
 	startlog.Infof("Startup complete, operator running, version: %s", version.Long())
 	if err := mgr.Start(signals.SetupSignalHandler()); err != nil {
 		startlog.Fatalf("could not start manager: %v", err)
@@ -733,6 +916,16 @@ type reconcilerOpts struct {
 	// default to tag:k8s.
 	// https://tailscale.com/kb/1085/auth-keys
 	proxyTags string
+	// proxyHTTPTags are the default ACL tags for Tailscale Services that
+	// terminate HTTP(S) on a ProxyGroup, i.e. those created for HA
+	// Ingresses. Defaults to proxyTags. Can be overridden per Ingress with
+	// the tailscale.com/tags annotation.
+	proxyHTTPTags string
+	// proxyTCPTags are the default ACL tags for Tailscale Services that
+	// pass TCP traffic through a ProxyGroup, i.e. those created for HA
+	// Services. Defaults to proxyTags. Can be overridden per Service with
+	// the tailscale.com/tags annotation.
+	proxyTCPTags string
 	// proxyActAsDefaultLoadBalancer determines whether this operator
 	// instance should act as the default ingress controller when looking at
 	// Ingress resources with unset ingress.spec.ingressClassName.
@@ -759,6 +952,91 @@ type reconcilerOpts struct {
 	// ingressClassName is the name of the ingress class used by reconcilers of Ingress resources. This defaults
 	// to "tailscale" but can be customised.
 	ingressClassName string
+	// ingressClassController is the spec.controller value that the
+	// IngressClass must have. This defaults to "tailscale.com/ts-ingress" but
+	// can be customised for distributions that rebrand the controller.
+	ingressClassController string
+	// watchNamespaces is a comma-separated list of namespaces in which HA
+	// Ingresses should be managed. If empty, Ingresses in all namespaces
+	// are managed.
+	watchNamespaces string
+	// inspectAddr, if set, is the address on which the operator serves a
+	// read-only JSON list of the Tailscale Services it manages and their
+	// reconcile state, e.g. "localhost:8081", as well as the versioned
+	// inventory API listing the Ingresses, Services and ProxyGroups it
+	// manages. The endpoints are not authenticated; an address without a
+	// host listens on localhost only.
+	inspectAddr string
+	// certExpiryWarning is how long before the TLS cert of an HA Ingress
+	// expires that a warning Event is emitted for it, as renewal should
+	// have happened by then. Zero disables the warning.
+	certExpiryWarning time.Duration
+	// serviceNameStrategy determines how Tailscale Service names are
+	// derived for HA Ingresses that do not set a TLS host, by a built-in
+	// strategy or a hostname template. Changing it renames the Tailscale
+	// Services of existing such Ingresses.
+	serviceNameStrategy serviceNameStrategy
+	// noAutoDeleteServices, if set, stops the operator from deleting
+	// Tailscale Services that are no longer used. Their owner references
+	// are removed instead, and they must be deleted manually.
+	noAutoDeleteServices bool
+	// proxyGroupNotReadyRequeue, if positive, is how often HA Ingresses
+	// whose ProxyGroup does not exist or is not ready are reconciled again,
+	// in addition to when the ProxyGroup changes. It is distinct from the
+	// backoff applied on errors.
+	proxyGroupNotReadyRequeue time.Duration
+	// inventoryInterval, if positive, is how often the ConfigMap listing
+	// the Tailscale Services managed by the operator is updated.
+	inventoryInterval time.Duration
+	// managedServiceTag, if set, is an ACL tag added to all Tailscale
+	// Services for HA Ingresses and Services, in addition to their default
+	// or custom tags.
+	managedServiceTag string
+	// ownerAnnotationBudget, if positive, is the maximum size in bytes of
+	// the annotation listing the owner references of a Tailscale Service.
+	// If adding or updating the operator's owner reference would exceed it,
+	// the owner references are compacted, and if they still do not fit,
+	// the operator reports an error instead of making an update that the
+	// control plane would reject. Zero means no limit.
+	ownerAnnotationBudget int
+	// previousOperatorID, if set, is the stable ID of the operator's
+	// Tailscale device before it was re-created, for example after its
+	// state Secret was lost. Owner references of Tailscale Services under
+	// it are rewritten to the operator's current ID on reconcile, so that
+	// the operator does not treat Tailscale Services it created as owned by
+	// another operator instance.
+	previousOperatorID string
+	// serviceCreateGracePeriod, if positive, is how long a new HA Ingress
+	// must remain unchanged before a Tailscale Service is created for it.
+	// Edits during the grace period restart it, and deleting the Ingress,
+	// or no longer exposing it on a ProxyGroup, cancels the creation. It is
+	// set by OPERATOR_SERVICE_CREATE_GRACE_PERIOD. Pending creations are
+	// only tracked in memory, so the grace period of each Ingress restarts
+	// when the operator restarts, delaying its creation by up to one more
+	// grace period.
+	serviceCreateGracePeriod time.Duration
+	// consolidateCertSecrets, if set, makes the operator store the TLS
+	// certs of all HA Ingresses on a ProxyGroup in a single Secret keyed by
+	// domain, rather than in a Secret per domain, to reduce the number of
+	// Secrets that the operator and proxies watch. Existing per-domain
+	// Secrets are migrated as their Ingresses are reconciled.
+	consolidateCertSecrets bool
+	// emitAuditEvents, if set, makes the operator emit a Normal Event for
+	// each change it makes to the Tailscale Service or TLS cert of an HA
+	// Ingress, so that its actions can be audited from the Event stream.
+	emitAuditEvents bool
+	// clusterName, if set, is the name of the operator's cluster. It is
+	// included in the provenance in the comments of the Tailscale Services
+	// of HA Ingresses and Services.
+	clusterName string
+	// noServiceProvenance, if set, stops the operator from including the
+	// provenance of Tailscale Services, i.e. the HA Ingress or Service and
+	// cluster that they are for, in their comments.
+	noServiceProvenance bool
+	// annotationPrefix is the prefix of the annotations that users set to
+	// configure the operator. It defaults to tailscale.com/ but can be
+	// customised for forks that use their own domain.
+	annotationPrefix annotationPrefix
 }
 
 // enqueueAllIngressEgressProxySvcsinNS returns a reconcile request for each
@@ -880,12 +1158,14 @@ func managedResourceHandlerForType(typ string) handler.MapFunc {
 
 // indexProxyClass is used to select ProxyClass-backed objects which are
 // locally indexed in the cache for efficient listing without requiring labels.
-func indexProxyClass(o client.Object) []string {
-	if !hasProxyClassAnnotation(o) {
-		return nil
-	}
+func indexProxyClass(prefix annotationPrefix) client.IndexerFunc {
+	return func(o client.Object) []string {
+		if !hasProxyClassAnnotation(o, prefix) {
+			return nil
+		}
 
-	return []string{o.GetAnnotations()[LabelAnnotationProxyClass]}
+		return []string{prefix.get(o.GetAnnotations(), LabelAnnotationProxyClass)}
+	}
 }
 
 // proxyClassHandlerForSvc returns a handler that, for a given ProxyClass,
@@ -1108,7 +1388,7 @@ func serviceAccountHandlerForProxyGroup(cl client.Client, logger *zap.SugaredLog
 // The Services of interest are backend Services for tailscale Ingress and
 // managed Services for an StatefulSet for a proxy configured for tailscale
 // Ingress
-func serviceHandlerForIngress(cl client.Client, logger *zap.SugaredLogger, ingressClassName string) handler.MapFunc {
+func serviceHandlerForIngress(cl client.Client, logger *zap.SugaredLogger, ingressClassName string, prefix annotationPrefix) handler.MapFunc {
 	return func(ctx context.Context, o client.Object) []reconcile.Request {
 		if isManagedByType(o, "ingress") {
 			ingName := parentFromObjectLabels(o)
@@ -1124,7 +1404,7 @@ func serviceHandlerForIngress(cl client.Client, logger *zap.SugaredLogger, ingre
 			if ing.Spec.IngressClassName == nil || *ing.Spec.IngressClassName != ingressClassName {
 				continue
 			}
-			if hasProxyGroupAnnotation(&ing) {
+			if hasProxyGroupAnnotation(&ing, prefix) {
 				// We don't want to reconcile backend Services for Ingresses for ProxyGroups.
 				continue
 			}
@@ -1146,27 +1426,29 @@ func serviceHandlerForIngress(cl client.Client, logger *zap.SugaredLogger, ingre
 	}
 }
 
-func serviceHandler(_ context.Context, o client.Object) []reconcile.Request {
-	if _, ok := o.GetAnnotations()[AnnotationProxyGroup]; ok {
-		// Do not reconcile Services for ProxyGroup.
-		return nil
-	}
-	if isManagedByType(o, "svc") {
-		// If this is a Service managed by a Service we want to enqueue its parent
-		return []reconcile.Request{{NamespacedName: parentFromObjectLabels(o)}}
-	}
-	if isManagedResource(o) {
-		// If this is a Servce managed by a resource that is not a Service, we leave it alone
-		return nil
-	}
-	// If this is not a managed Service we want to enqueue it
-	return []reconcile.Request{
-		{
-			NamespacedName: types.NamespacedName{
-				Namespace: o.GetNamespace(),
-				Name:      o.GetName(),
+func serviceHandler(prefix annotationPrefix) handler.MapFunc {
+	return func(_ context.Context, o client.Object) []reconcile.Request {
+		if _, ok := prefix.lookup(o.GetAnnotations(), AnnotationProxyGroup); ok {
+			// Do not reconcile Services for ProxyGroup.
+			return nil
+		}
+		if isManagedByType(o, "svc") {
+			// If this is a Service managed by a Service we want to enqueue its parent
+			return []reconcile.Request{{NamespacedName: parentFromObjectLabels(o)}}
+		}
+		if isManagedResource(o) {
+			// If this is a Servce managed by a resource that is not a Service, we leave it alone
+			return nil
+		}
+		// If this is not a managed Service we want to enqueue it
+		return []reconcile.Request{
+			{
+				NamespacedName: types.NamespacedName{
+					Namespace: o.GetNamespace(),
+					Name:      o.GetName(),
+				},
 			},
-		},
+		}
 	}
 }
 
@@ -1180,17 +1462,19 @@ func isMagicDNSName(name string) bool {
 // egressSvcsHandler returns accepts a Kubernetes object and returns a reconcile
 // request for it , if the object is a Tailscale egress Service meant to be
 // exposed on a ProxyGroup.
-func egressSvcsHandler(_ context.Context, o client.Object) []reconcile.Request {
-	if !isEgressSvcForProxyGroup(o) {
-		return nil
-	}
-	return []reconcile.Request{
-		{
-			NamespacedName: types.NamespacedName{
-				Namespace: o.GetNamespace(),
-				Name:      o.GetName(),
+func egressSvcsHandler(prefix annotationPrefix) handler.MapFunc {
+	return func(_ context.Context, o client.Object) []reconcile.Request {
+		if !isEgressSvcForProxyGroup(o, prefix) {
+			return nil
+		}
+		return []reconcile.Request{
+			{
+				NamespacedName: types.NamespacedName{
+					Namespace: o.GetNamespace(),
+					Name:      o.GetName(),
+				},
 			},
-		},
+		}
 	}
 }
 
@@ -1265,7 +1549,7 @@ func egressEpsFromPGStateSecrets(cl client.Client, ns string) handler.MapFunc {
 	}
 }
 
-func ingressSvcFromEps(cl client.Client, logger *zap.SugaredLogger) handler.MapFunc {
+func ingressSvcFromEps(cl client.Client, logger *zap.SugaredLogger, prefix annotationPrefix) handler.MapFunc {
 	return func(ctx context.Context, o client.Object) []reconcile.Request {
 		svcName := o.GetLabels()[discoveryv1.LabelServiceName]
 		if svcName == "" {
@@ -1279,7 +1563,7 @@ func ingressSvcFromEps(cl client.Client, logger *zap.SugaredLogger) handler.MapF
 			return nil
 		}
 
-		pgName := svc.Annotations[AnnotationProxyGroup]
+		pgName := prefix.get(svc.Annotations, AnnotationProxyGroup)
 		if pgName == "" {
 			return nil
 		}
@@ -1349,6 +1633,28 @@ func isTLSSecret(secret *corev1.Secret) bool {
 		secret.ObjectMeta.Labels[labelProxyGroup] != ""
 }
 
+// isDriftedTLSSecret reports whether secret is a managed TLS Secret of an
+// Ingress that has lost or gained some of the labels checked by isTLSSecret,
+// so that the Ingress can be reconciled to restore them.
+func isDriftedTLSSecret(secret *corev1.Secret) bool {
+	return secret.Type == corev1.SecretTypeTLS &&
+		secret.ObjectMeta.Labels[kubetypes.LabelManaged] == "true" &&
+		secret.ObjectMeta.Labels[LabelParentNamespace] != "" &&
+		secret.ObjectMeta.Labels[LabelParentName] != "" &&
+		!isTLSSecret(secret)
+}
+
+// isConsolidatedCertSecret reports whether secret is the consolidated cert
+// Secret of a ProxyGroup, which stores the TLS certs of all of its HA
+// Ingresses if cert Secrets are consolidated.
+func isConsolidatedCertSecret(secret *corev1.Secret) bool {
+	pgName := secret.ObjectMeta.Labels[labelProxyGroup]
+	return secret.ObjectMeta.Labels[kubetypes.LabelManaged] == "true" &&
+		secret.ObjectMeta.Labels[kubetypes.LabelSecretType] == kubetypes.LabelSecretTypeCerts &&
+		pgName != "" &&
+		secret.Name == consolidatedCertSecretName(pgName)
+}
+
 func isPGStateSecret(secret *corev1.Secret) bool {
 	return secret.ObjectMeta.Labels[kubetypes.LabelManaged] == "true" &&
 		secret.ObjectMeta.Labels[LabelParentType] == "proxygroup" &&
@@ -1364,7 +1670,7 @@ func HAIngressesFromSecret(cl client.Client, logger *zap.SugaredLogger) handler.
 			logger.Infof("[unexpected] Secret handler triggered for an object that is not a Secret")
 			return nil
 		}
-		if isTLSSecret(secret) {
+		if isTLSSecret(secret) || isDriftedTLSSecret(secret) {
 			return []reconcile.Request{
 				{
 					NamespacedName: types.NamespacedName{
@@ -1374,11 +1680,37 @@ func HAIngressesFromSecret(cl client.Client, logger *zap.SugaredLogger) handler.
 				},
 			}
 		}
-		if !isPGStateSecret(secret) {
-			return nil
+		if secret.ObjectMeta.Labels[kubetypes.LabelManaged] != "true" {
+			// The Secret may be an externally managed TLS Secret, whose
+			// changes need to be copied to the ProxyGroup's TLS Secret,
+			// a trust bundle Secret, whose changes need to be copied
+			// to the serve config, or the ready gate of an Ingress.
+			ingList := &networkingv1.IngressList{}
+			if err := cl.List(ctx, ingList, client.MatchingFields{indexIngressTLSSecret: secret.Name}); err != nil {
+				logger.Infof("error listing Ingresses, skipping a reconcile for event on Secret %s: %v", secret.Name, err)
+				return nil
+			}
+			reqs := make([]reconcile.Request, 0)
+			for _, ing := range ingList.Items {
+				reqs = append(reqs, reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Namespace: ing.Namespace,
+						Name:      ing.Name,
+					},
+				})
+			}
+			return reqs
 		}
-		pgName, ok := secret.ObjectMeta.Labels[LabelParentName]
-		if !ok {
+		// Events on the ProxyGroup's state Secrets or its consolidated
+		// cert Secret affect all of its Ingresses.
+		var pgName string
+		switch {
+		case isConsolidatedCertSecret(secret):
+			pgName = secret.ObjectMeta.Labels[labelProxyGroup]
+		case isPGStateSecret(secret):
+			pgName = secret.ObjectMeta.Labels[LabelParentName]
+		}
+		if pgName == "" {
 			return nil
 		}
 
@@ -1400,6 +1732,46 @@ func HAIngressesFromSecret(cl client.Client, logger *zap.SugaredLogger) handler.
 	}
 }
 
+// HAIngressesFromServeConfig returns a handler that returns reconcile requests
+// for all HA Ingresses exposed on a ProxyGroup in response to an event on that
+// ProxyGroup's ingress serve config ConfigMap. This ensures that manual edits to
+// the serve config are reverted. Events on other ConfigMaps trigger reconciles
+// of the HA Ingresses whose ready gate they are.
+func HAIngressesFromServeConfig(cl client.Client, logger *zap.SugaredLogger) handler.MapFunc {
+	return func(ctx context.Context, o client.Object) []reconcile.Request {
+		cm, ok := o.(*corev1.ConfigMap)
+		if !ok {
+			logger.Infof("[unexpected] ConfigMap handler triggered for an object that is not a ConfigMap")
+			return nil
+		}
+		var field client.MatchingFields
+		switch {
+		case cm.Labels[kubetypes.LabelManaged] != "true":
+			field = client.MatchingFields{indexIngressReadyGate: cm.Name}
+		case cm.Labels[LabelParentType] == "proxygroup" && cm.Name == pgIngressCMName(cm.Labels[LabelParentName]):
+			field = client.MatchingFields{indexIngressProxyGroup: cm.Labels[LabelParentName]}
+		default:
+			return nil
+		}
+
+		ingList := &networkingv1.IngressList{}
+		if err := cl.List(ctx, ingList, field); err != nil {
+			logger.Infof("error listing Ingresses, skipping a reconcile for event on ConfigMap %s: %v", cm.Name, err)
+			return nil
+		}
+		reqs := make([]reconcile.Request, 0)
+		for _, ing := range ingList.Items {
+			reqs = append(reqs, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: ing.Namespace,
+					Name:      ing.Name,
+				},
+			})
+		}
+		return reqs
+	}
+}
+
 // HAServiceFromSecret returns a handler that returns reconcile requests for
 // all HA Services that should be reconciled in response to a Secret event.
 func HAServicesFromSecret(cl client.Client, logger *zap.SugaredLogger) handler.MapFunc {
@@ -1500,7 +1872,7 @@ func egressSvcsFromEgressProxyGroup(cl client.Client, logger *zap.SugaredLogger)
 	}
 }
 
-// ingressesFromIngressProxyGroup is an event handler for ingress ProxyGroups. It returns reconcile requests for all
+// ingressesFromIngressProxyGroup is an event handler for ProxyGroups. It returns reconcile requests for all
 // user-created Ingresses that should be exposed on this ProxyGroup.
 func ingressesFromIngressProxyGroup(cl client.Client, logger *zap.SugaredLogger) handler.MapFunc {
 	return func(ctx context.Context, o client.Object) []reconcile.Request {
@@ -1509,9 +1881,9 @@ func ingressesFromIngressProxyGroup(cl client.Client, logger *zap.SugaredLogger)
 			logger.Infof("[unexpected] ProxyGroup handler triggered for an object that is not a ProxyGroup")
 			return nil
 		}
-		if pg.Spec.Type != tsapi.ProxyGroupTypeIngress {
-			return nil
-		}
+		// Ingresses are also reconciled for ProxyGroups of other types, so
+		// that they are cleaned up if their ProxyGroup is recreated with a
+		// different type.
 		ingList := &networkingv1.IngressList{}
 		if err := cl.List(ctx, ingList, client.MatchingFields{indexIngressProxyGroup: pg.Name}); err != nil {
 			logger.Infof("error listing Ingresses: %v, skipping a reconcile for event on ProxyGroup %s", err, pg.Name)
@@ -1532,19 +1904,19 @@ func ingressesFromIngressProxyGroup(cl client.Client, logger *zap.SugaredLogger)
 
 // epsFromExternalNameService is an event handler for ExternalName Services that define a Tailscale egress service that
 // should be exposed on a ProxyGroup. It returns reconcile requests for EndpointSlices created for this Service.
-func epsFromExternalNameService(cl client.Client, logger *zap.SugaredLogger, ns string) handler.MapFunc {
+func epsFromExternalNameService(cl client.Client, logger *zap.SugaredLogger, ns string, prefix annotationPrefix) handler.MapFunc {
 	return func(ctx context.Context, o client.Object) []reconcile.Request {
 		svc, ok := o.(*corev1.Service)
 		if !ok {
 			logger.Infof("[unexpected] Service handler triggered for an object that is not a Service")
 			return nil
 		}
-		if !isEgressSvcForProxyGroup(svc) {
+		if !isEgressSvcForProxyGroup(svc, prefix) {
 			return nil
 		}
 		epsList := &discoveryv1.EndpointSliceList{}
 		if err := cl.List(ctx, epsList, client.InNamespace(ns),
-			client.MatchingLabels(egressSvcChildResourceLabels(svc))); err != nil {
+			client.MatchingLabels(egressSvcChildResourceLabels(svc, prefix))); err != nil {
 			logger.Infof("error listing EndpointSlices: %v, skipping a reconcile for event on Service %s", err, svc.Name)
 			return nil
 		}
@@ -1643,26 +2015,79 @@ func crdTransformer(log *zap.SugaredLogger) toolscache.TransformFunc {
 
 // indexEgressServices adds a local index to cached Tailscale egress Services meant to be exposed on a ProxyGroup. The
 // index is used a list filter.
-func indexEgressServices(o client.Object) []string {
-	if !isEgressSvcForProxyGroup(o) {
-		return nil
+func indexEgressServices(prefix annotationPrefix) client.IndexerFunc {
+	return func(o client.Object) []string {
+		if !isEgressSvcForProxyGroup(o, prefix) {
+			return nil
+		}
+		return []string{prefix.get(o.GetAnnotations(), AnnotationProxyGroup)}
 	}
-	return []string{o.GetAnnotations()[AnnotationProxyGroup]}
 }
 
 // indexPGIngresses is used to select ProxyGroup-backed Services which are
 // locally indexed in the cache for efficient listing without requiring labels.
-func indexPGIngresses(o client.Object) []string {
-	if !hasProxyGroupAnnotation(o) {
+func indexPGIngresses(prefix annotationPrefix) client.IndexerFunc {
+	return func(o client.Object) []string {
+		if !hasProxyGroupAnnotation(o, prefix) {
+			return nil
+		}
+		return []string{prefix.get(o.GetAnnotations(), AnnotationProxyGroup)}
+	}
+}
+
+// indexTLSSecretIngresses indexes HA Ingresses by the names of the externally
+// managed Secrets they reference via the tailscale.com/tls-secret,
+// tailscale.com/trust-bundle-secret and tailscale.com/ready-gate annotations.
+func indexTLSSecretIngresses(prefix annotationPrefix) client.IndexerFunc {
+	return func(o client.Object) []string {
+		if !hasProxyGroupAnnotation(o, prefix) {
+			return nil
+		}
+		var names []string
+		for _, a := range []string{annotationTLSSecret, annotationTrustBundleSecret} {
+			if name, ok := prefix.lookup(o.GetAnnotations(), a); ok && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+		if name, ok := readyGateOfKind(o, readyGateSecret, prefix); ok && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+		return names
+	}
+}
+
+// indexReadyGateIngresses indexes HA Ingresses by the name of the ConfigMap
+// referenced by their tailscale.com/ready-gate annotation.
+func indexReadyGateIngresses(prefix annotationPrefix) client.IndexerFunc {
+	return func(o client.Object) []string {
+		if !hasProxyGroupAnnotation(o, prefix) {
+			return nil
+		}
+		if name, ok := readyGateOfKind(o, readyGateConfigMap, prefix); ok {
+			return []string{name}
+		}
 		return nil
 	}
-	return []string{o.GetAnnotations()[AnnotationProxyGroup]}
+}
+
+// readyGateOfKind returns the name of the resource referenced by o's
+// tailscale.com/ready-gate annotation if it is of the provided kind.
+func readyGateOfKind(o client.Object, kind string, prefix annotationPrefix) (string, bool) {
+	v, ok := prefix.lookup(o.GetAnnotations(), annotationReadyGate)
+	if !ok {
+		return "", false
+	}
+	k, name, err := parseReadyGate(v)
+	if err != nil || k != kind {
+		return "", false
+	}
+	return name, true
 }
 
 // serviceHandlerForIngressPG returns a handler for Service events that ensures that if the Service
 // associated with an event is a backend Service for a tailscale Ingress with ProxyGroup annotation,
 // the associated Ingress gets reconciled.
-func serviceHandlerForIngressPG(cl client.Client, logger *zap.SugaredLogger, ingressClassName string) handler.MapFunc {
+func serviceHandlerForIngressPG(cl client.Client, logger *zap.SugaredLogger, ingressClassName string, prefix annotationPrefix) handler.MapFunc {
 	return func(ctx context.Context, o client.Object) []reconcile.Request {
 		ingList := networkingv1.IngressList{}
 		if err := cl.List(ctx, &ingList, client.InNamespace(o.GetNamespace())); err != nil {
@@ -1674,12 +2099,15 @@ func serviceHandlerForIngressPG(cl client.Client, logger *zap.SugaredLogger, ing
 			if ing.Spec.IngressClassName == nil || *ing.Spec.IngressClassName != ingressClassName {
 				continue
 			}
-			if !hasProxyGroupAnnotation(&ing) {
+			if !hasProxyGroupAnnotation(&ing, prefix) {
 				continue
 			}
 			if ing.Spec.DefaultBackend != nil && ing.Spec.DefaultBackend.Service != nil && ing.Spec.DefaultBackend.Service.Name == o.GetName() {
 				reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&ing)})
 			}
+			if b, err := httpBackend(&ing, prefix); err == nil && b != nil && b.Service.Name == o.GetName() {
+				reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&ing)})
+			}
 			for _, rule := range ing.Spec.Rules {
 				if rule.HTTP == nil {
 					continue
@@ -1695,12 +2123,32 @@ func serviceHandlerForIngressPG(cl client.Client, logger *zap.SugaredLogger, ing
 	}
 }
 
-func hasProxyGroupAnnotation(obj client.Object) bool {
-	return obj.GetAnnotations()[AnnotationProxyGroup] != ""
+// endpointSliceHandlerForIngress returns a handler for EndpointSlice events
+// that enqueues the Ingresses that svcHandler enqueues for the EndpointSlice's
+// Service. Proxy targets for headless backend Services are resolved from their
+// endpoints, so they need to be updated when the endpoints change.
+func endpointSliceHandlerForIngress(svcHandler handler.MapFunc) handler.MapFunc {
+	return func(ctx context.Context, o client.Object) []reconcile.Request {
+		svcName, ok := o.GetLabels()[discoveryv1.LabelServiceName]
+		if !ok {
+			return nil
+		}
+		svc := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      svcName,
+				Namespace: o.GetNamespace(),
+			},
+		}
+		return svcHandler(ctx, svc)
+	}
+}
+
+func hasProxyGroupAnnotation(obj client.Object, prefix annotationPrefix) bool {
+	return prefix.get(obj.GetAnnotations(), AnnotationProxyGroup) != ""
 }
 
-func hasProxyClassAnnotation(obj client.Object) bool {
-	return obj.GetAnnotations()[LabelAnnotationProxyClass] != ""
+func hasProxyClassAnnotation(obj client.Object, prefix annotationPrefix) bool {
+	return prefix.get(obj.GetAnnotations(), LabelAnnotationProxyClass) != ""
 }
 
 func id(ctx context.Context, lc *local.Client) (string, error) {
@@ -0,0 +1,122 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !plan9
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// pendingWritesTimeout is how long the operator waits on shutdown for
+// pending aggregate writes to complete. It is shorter than the manager's
+// default graceful shutdown timeout of 30s, so that the writes complete
+// before the manager gives up on its runnables.
+const pendingWritesTimeout = 20 * time.Second
+
+// errShuttingDown is returned instead of starting an aggregate write once
+// the operator has started to shut down.
+var errShuttingDown = errors.New("operator is shutting down")
+
+// pendingWrites tracks aggregate writes, which update the config Secrets of
+// all replicas of a ProxyGroup together to change the Tailscale Services
+// that they advertise. The API calls of a reconcile fail once the operator
+// starts to shut down, so a write that is in progress on SIGTERM would
+// otherwise leave some replicas advertising a Tailscale Service and others
+// not, until the operator is running again. Instead, aggregate writes are
+// made on a context that is not canceled on shutdown, and pendingWrites,
+// as a manager.Runnable, waits for them on shutdown.
+//
+// A nil *pendingWrites does not track writes, which are then made on the
+// reconcile's context.
+type pendingWrites struct {
+	logger *zap.SugaredLogger
+	// timeout is how long shutdown waits for pending writes. An aggregate
+	// write fails if it takes longer than timeout.
+	timeout time.Duration
+
+	wg sync.WaitGroup // counts pending writes
+
+	mu       sync.Mutex // protects following
+	stopping bool       // whether new writes are refused
+}
+
+// Start implements manager.Runnable. Once ctx is done, it waits for pending
+// aggregate writes to complete, up to the timeout.
+func (p *pendingWrites) Start(ctx context.Context) error {
+	<-ctx.Done()
+	if err := p.flush(); err != nil {
+		return err
+	}
+	p.logger.Debugf("pending aggregate writes completed")
+	return nil
+}
+
+// flush refuses new aggregate writes and waits for the pending ones to
+// complete. It returns an error if they do not complete within the timeout.
+func (p *pendingWrites) flush() error {
+	p.mu.Lock()
+	p.stopping = true
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-time.After(p.timeout):
+		return fmt.Errorf("timed out after %v waiting for pending aggregate writes", p.timeout)
+	}
+}
+
+// begin registers the start of an aggregate write. It returns the context
+// that the write's API calls must be made with, and a func to call once the
+// write is done. It returns errShuttingDown if the write must not be started
+// because the operator is shutting down, or ctx is done.
+func (p *pendingWrites) begin(ctx context.Context) (context.Context, func(), error) {
+	if p == nil {
+		return ctx, func() {}, nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopping || ctx.Err() != nil {
+		return nil, nil, errShuttingDown
+	}
+	p.wg.Add(1)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), p.timeout)
+	return ctx, func() {
+		cancel()
+		p.wg.Done()
+	}, nil
+}
+
+// applyConfigSecrets applies the config Secrets of a ProxyGroup's replicas as
+// a single aggregate write.
+func (p *pendingWrites) applyConfigSecrets(ctx context.Context, cl client.Client, fieldManager string, secrets []*corev1.Secret) error {
+	if len(secrets) == 0 {
+		return nil
+	}
+	ctx, done, err := p.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	for _, s := range secrets {
+		if err := applyConfigSecret(ctx, cl, fieldManager, s); err != nil {
+			return fmt.Errorf("error updating ProxyGroup config Secret: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !plan9
+
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tsoperator "tailscale.com/k8s-operator"
+	"tailscale.com/kube/kubetypes"
+	"tailscale.com/types/ptr"
+)
+
+func TestPendingWritesShutdown(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+	for i := range int32(3) {
+		if i == 0 {
+			continue
+		}
+		mustCreate(t, fc, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pgConfigSecretName("test-pg", i),
+				Namespace: "operator-ns",
+				Labels:    pgSecretLabels("test-pg", kubetypes.LabelSecretTypeConfig),
+			},
+			Data: map[string][]byte{
+				tsoperator.TailscaledConfigFileName(pgMinCapabilityVersion): []byte("{}"),
+			},
+		})
+	}
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, service())
+	mustCreate(t, fc, ing)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	// With the cert issued, the next reconcile advertises the Tailscale
+	// Service on all replicas.
+	populateTLSSecret(t, fc, "test-pg", "my-svc.ts.net")
+
+	pw := &pendingWrites{logger: zap.NewNop().Sugar(), timeout: time.Minute}
+	ingPGR.pendingWrites = pw
+	// The first update of a config Secret blocks until resumed. Like the
+	// real client, the fake client then fails if its context is done.
+	patching, resume := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	ingPGR.Client = interceptor.NewClient(fc.(client.WithWatch), interceptor.Funcs{
+		Patch: func(ctx context.Context, cl client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if obj.GetObjectKind().GroupVersionKind().Kind == "Secret" {
+				once.Do(func() {
+					close(patching)
+					<-resume
+				})
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+			return cl.Patch(ctx, obj, patch, opts...)
+		},
+	})
+
+	// The operator is shut down, which cancels the reconcile's context,
+	// while the replicas' config Secrets are being updated.
+	ctx, cancel := context.WithCancel(t.Context())
+	reconciled := make(chan error, 1)
+	go func() {
+		_, err := ingPGR.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-ingress"}})
+		reconciled <- err
+	}()
+	<-patching
+	cancel()
+	flushed := make(chan error, 1)
+	go func() { flushed <- pw.flush() }()
+	select {
+	case err := <-flushed:
+		t.Fatalf("flush returned while a write was pending: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(resume)
+	if err := <-flushed; err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	<-reconciled
+
+	// The pending write was completed, so all replicas advertise the
+	// Tailscale Service.
+	for i := range int32(3) {
+		verifyTailscaledConfigReplica(t, fc, "test-pg", i, []string{"svc:my-svc"})
+	}
+
+	// No new writes are started once shutdown has begun, so the replicas
+	// stay consistent.
+	if err := fc.Delete(t.Context(), ing); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ingPGR.Reconcile(t.Context(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "test-ingress"}})
+	if !errors.Is(err, errShuttingDown) {
+		t.Errorf("Reconcile after shutdown: got error %v, want %v", err, errShuttingDown)
+	}
+	for i := range int32(3) {
+		verifyTailscaledConfigReplica(t, fc, "test-pg", i, []string{"svc:my-svc"})
+	}
+}
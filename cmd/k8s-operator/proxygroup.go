@@ -240,6 +240,10 @@ func (r *ProxyGroupReconciler) validate(ctx context.Context, pg *tsapi.ProxyGrou
 	}
 
 	var errs []error
+	if _, err := tsapi.ParseProxyGroupType(string(pg.Spec.Type)); err != nil {
+		// Only possible if the CRD's validation was bypassed.
+		errs = append(errs, err)
+	}
 	if isAuthAPIServerProxy(pg) {
 		// Validate that the static ServiceAccount already exists.
 		sa := &corev1.ServiceAccount{}
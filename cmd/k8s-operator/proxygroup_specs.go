@@ -513,6 +513,9 @@ func pgIngressCM(pg *tsapi.ProxyGroup, namespace string) *corev1.ConfigMap {
 			Namespace:       namespace,
 			Labels:          pgLabels(pg.Name, nil),
 			OwnerReferences: pgOwnerReference(pg),
+			// Set on creation only, so that the HA Ingress reconciler
+			// tracks the Tailscale Services that it manages from the start.
+			Annotations: map[string]string{annotationManagedServices: ""},
 		},
 	}
 }
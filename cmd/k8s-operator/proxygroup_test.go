@@ -1575,6 +1575,10 @@ type testCase struct {
 			typ:           tsapi.ProxyGroupTypeEgress,
 			initContainer: true,
 		},
+		"unknown_type": {
+			typ:          "foo",
+			expectedErrs: 1,
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			pc := &tsapi.ProxyClass{
@@ -25,8 +25,10 @@
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apiserver/pkg/storage/names"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/yaml"
 
@@ -151,6 +153,9 @@ type tailscaleSTSConfig struct {
 	// HostnamePrefix specifies the desired prefix for the device's hostname. The hostname will be suffixed with the
 	// ordinal number generated by the StatefulSet.
 	HostnamePrefix string
+
+	// AdvertiseServices are the Tailscale Services that the proxy should advertise.
+	AdvertiseServices []string
 }
 
 type connector struct {
@@ -1028,6 +1033,7 @@ func tailscaledConfig(stsC *tailscaleSTSConfig, newAuthkey string, oldSecret *co
 		Hostname:            &hostname,
 		NoStatefulFiltering: "true", // Explicitly enforce default value, see #14216
 		AppConnector:        &ipn.AppConnectorPrefs{Advertise: false},
+		AdvertiseServices:   stsC.AdvertiseServices,
 	}
 
 	if stsC.LoginServer != "" {
@@ -1191,6 +1197,59 @@ func createOrUpdate[T any, O ptrObject[T]](ctx context.Context, c client.Client,
 	})
 }
 
+const (
+	// haIngressFieldManager and haServiceFieldManager are the field managers
+	// that the HA Ingress and HA Service reconcilers server-side apply
+	// ProxyGroup ConfigMaps and Secrets with. They must differ, as both
+	// reconcilers apply different keys of the same ProxyGroup ConfigMap, and
+	// an apply removes the fields that its manager no longer sets.
+	haIngressFieldManager = "tailscale-operator-ha-ingress"
+	haServiceFieldManager = "tailscale-operator-ha-service"
+)
+
+// applyConfigMap server-side applies the BinaryData key and the annotations
+// annots of cm as fieldManager, taking ownership of them from any other
+// manager. Other keys of the ConfigMap, such as ones set by hand, are left as
+// is.
+//
+// The apply carries cm's resourceVersion, so it fails with a conflict if the
+// ConfigMap was modified since it was read. This matters for the ProxyGroup
+// serve config, which holds a single blob of all the Tailscale Services; the
+// caller is expected to requeue and retry from a fresh read.
+func applyConfigMap(ctx context.Context, c client.Client, fieldManager string, cm *corev1.ConfigMap, key string, annots ...string) error {
+	ac := corev1ac.ConfigMap(cm.Name, cm.Namespace).
+		WithResourceVersion(cm.ResourceVersion).
+		WithBinaryData(map[string][]byte{key: cm.BinaryData[key]})
+	for _, k := range annots {
+		ac.WithAnnotations(map[string]string{k: cm.Annotations[k]})
+	}
+	return apply(ctx, c, fieldManager, ac, cm)
+}
+
+// applyConfigSecret server-side applies the tailscaled config files of the
+// ProxyGroup config Secret s as fieldManager. Like applyConfigMap, it fails
+// with a conflict if s was modified since it was read.
+func applyConfigSecret(ctx context.Context, c client.Client, fieldManager string, s *corev1.Secret) error {
+	ac := corev1ac.Secret(s.Name, s.Namespace).
+		WithResourceVersion(s.ResourceVersion).
+		WithData(s.Data)
+	return apply(ctx, c, fieldManager, ac, s)
+}
+
+// apply server-side applies the apply configuration ac as fieldManager, and
+// stores the resulting object in obj.
+func apply(ctx context.Context, c client.Client, fieldManager string, ac any, obj client.Object) error {
+	m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ac)
+	if err != nil {
+		return fmt.Errorf("error converting apply configuration: %w", err)
+	}
+	u := &unstructured.Unstructured{Object: m}
+	if err := c.Patch(ctx, u, client.Apply, client.ForceOwnership, client.FieldOwner(fieldManager)); err != nil {
+		return err
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, obj)
+}
+
 // getSingleObject searches for k8s objects of type T
 // (e.g. corev1.Service) with the given labels, and returns
 // it. Returns nil if no objects match the labels, and an error if
@@ -1244,8 +1303,8 @@ func defaultEnv(envName, defVal string) string {
 	return v
 }
 
-func nameForService(svc *corev1.Service) string {
-	if h, ok := svc.Annotations[AnnotationHostname]; ok {
+func nameForService(svc *corev1.Service, prefix annotationPrefix) string {
+	if h, ok := prefix.lookup(svc.Annotations, AnnotationHostname); ok {
 		return h
 	}
 	return svc.Namespace + "-" + svc.Name
@@ -1253,13 +1312,13 @@ func nameForService(svc *corev1.Service) string {
 
 // proxyClassForObject returns the proxy class for the given object. If the
 // object does not have a proxy class label, it returns the default proxy class
-func proxyClassForObject(o client.Object, proxyDefaultClass string) string {
+func proxyClassForObject(o client.Object, proxyDefaultClass string, prefix annotationPrefix) string {
 	proxyClass, exists := o.GetLabels()[LabelAnnotationProxyClass]
 	if exists {
 		return proxyClass
 	}
 
-	proxyClass, exists = o.GetAnnotations()[LabelAnnotationProxyClass]
+	proxyClass, exists = prefix.lookup(o.GetAnnotations(), LabelAnnotationProxyClass)
 	if exists {
 		return proxyClass
 	}
@@ -6,10 +6,13 @@
 package main
 
 import (
+	"context"
 	_ "embed"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"regexp"
+	"slices"
 	"strings"
 	"testing"
 
@@ -17,12 +20,24 @@
 	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/applyconfigurations"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"sigs.k8s.io/yaml"
 	tsapi "tailscale.com/k8s-operator/apis/v1alpha1"
+	"tailscale.com/kube/ingressservices"
 	"tailscale.com/kube/kubetypes"
 	"tailscale.com/types/ptr"
+	"tailscale.com/util/mak"
 )
 
 // Test_statefulSetNameBase tests that parent name portion in a StatefulSet name
@@ -413,3 +428,164 @@ func updateMap(a, b map[string]string) {
 		a[key] = val
 	}
 }
+
+// newFieldManagedClient returns a fake client for built-in types that tracks
+// managed fields and handles server-side apply patches, which the fake client
+// does not support on its own.
+func newFieldManagedClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	sch := clientgoscheme.Scheme
+	tracker := clienttesting.NewFieldManagedObjectTracker(sch, clientgoscheme.Codecs.UniversalDecoder(), applyconfigurations.NewTypeConverter(sch))
+	return fake.NewClientBuilder().
+		WithScheme(sch).
+		WithObjectTracker(tracker).
+		WithObjects(objs...).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, cl client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				if patch.Type() != types.ApplyPatchType {
+					return cl.Patch(ctx, obj, patch, opts...)
+				}
+				gvk, err := apiutil.GVKForObject(obj, sch)
+				if err != nil {
+					return err
+				}
+				gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+				po := &client.PatchOptions{}
+				po.ApplyOptions(opts)
+				if err := tracker.Apply(gvr, obj, obj.GetNamespace(), *po.AsPatchOptions()); err != nil {
+					return err
+				}
+				return cl.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+			},
+		}).
+		Build()
+}
+
+// managedKeys returns the keys of field that are owned by manager in obj.
+func managedKeys(t *testing.T, obj client.Object, manager, field string) []string {
+	t.Helper()
+	var keys []string
+	for _, mf := range obj.GetManagedFields() {
+		if mf.Manager != manager || mf.FieldsV1 == nil {
+			continue
+		}
+		var fields map[string]map[string]any
+		if err := json.Unmarshal(mf.FieldsV1.Raw, &fields); err != nil {
+			t.Fatalf("error parsing managed fields: %v", err)
+		}
+		for k := range fields["f:"+field] {
+			if k, ok := strings.CutPrefix(k, "f:"); ok {
+				keys = append(keys, k)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func Test_applyConfigMap(t *testing.T) {
+	key := types.NamespacedName{Name: "test-pg-ingress-config", Namespace: "operator-ns"}
+	fc := newFieldManagedClient(t, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+		BinaryData: map[string][]byte{serveConfigKey: []byte(`{"Services":{}}`)},
+	})
+
+	// Someone edits the ConfigMap by hand, including the serve config.
+	cm := &corev1.ConfigMap{}
+	if err := fc.Get(t.Context(), key, cm); err != nil {
+		t.Fatal(err)
+	}
+	mak.Set(&cm.Data, "manual", "edit")
+	mak.Set(&cm.BinaryData, serveConfigKey, []byte(`{"Services":{"svc:manual":{}}}`))
+	if err := fc.Update(t.Context(), cm, client.FieldOwner("kubectl-edit")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The HA Ingress reconciler takes over the serve config without a
+	// conflict, and leaves the other key as is.
+	if err := fc.Get(t.Context(), key, cm); err != nil {
+		t.Fatal(err)
+	}
+	mak.Set(&cm.BinaryData, serveConfigKey, []byte(`{"Services":{"svc:foo":{}}}`))
+	mak.Set(&cm.Annotations, annotationManagedServices, "svc:foo")
+	if err := applyConfigMap(t.Context(), fc, haIngressFieldManager, cm, serveConfigKey, annotationManagedServices); err != nil {
+		t.Fatalf("applyConfigMap: %v", err)
+	}
+
+	// The HA Service reconciler applies its own key of the same ConfigMap,
+	// which leaves the serve config applied by the HA Ingress reconciler as
+	// is.
+	if err := fc.Get(t.Context(), key, cm); err != nil {
+		t.Fatal(err)
+	}
+	mak.Set(&cm.BinaryData, ingressservices.IngressConfigKey, []byte(`{}`))
+	if err := applyConfigMap(t.Context(), fc, haServiceFieldManager, cm, ingressservices.IngressConfigKey); err != nil {
+		t.Fatalf("applyConfigMap: %v", err)
+	}
+
+	got := &corev1.ConfigMap{}
+	if err := fc.Get(t.Context(), key, got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]string{"manual": "edit"}, got.Data); diff != "" {
+		t.Errorf("manual edit was lost (-want +got):\n%s", diff)
+	}
+	wantBinaryData := map[string][]byte{
+		serveConfigKey:                   []byte(`{"Services":{"svc:foo":{}}}`),
+		ingressservices.IngressConfigKey: []byte(`{}`),
+	}
+	if diff := cmp.Diff(wantBinaryData, got.BinaryData); diff != "" {
+		t.Errorf("unexpected binary data (-want +got):\n%s", diff)
+	}
+	for _, tt := range []struct {
+		manager, field string
+		want           []string
+	}{
+		{"kubectl-edit", "data", []string{"manual"}},
+		{"kubectl-edit", "binaryData", nil},
+		{haIngressFieldManager, "binaryData", []string{serveConfigKey}},
+		{haServiceFieldManager, "binaryData", []string{ingressservices.IngressConfigKey}},
+	} {
+		if diff := cmp.Diff(tt.want, managedKeys(t, got, tt.manager, tt.field)); diff != "" {
+			t.Errorf("unexpected %s keys owned by %s (-want +got):\n%s", tt.field, tt.manager, diff)
+		}
+	}
+	var annotsOwner []string
+	for _, mf := range got.GetManagedFields() {
+		if strings.Contains(string(mf.FieldsV1.Raw), `"f:`+annotationManagedServices+`"`) {
+			annotsOwner = append(annotsOwner, mf.Manager)
+		}
+	}
+	if diff := cmp.Diff([]string{haIngressFieldManager}, annotsOwner); diff != "" {
+		t.Errorf("unexpected owners of the managed services annotation (-want +got):\n%s", diff)
+	}
+}
+
+func Test_applyConfigMapConflict(t *testing.T) {
+	fc := fake.NewClientBuilder().
+		WithScheme(tsapi.GlobalScheme).
+		WithInterceptorFuncs(interceptor.Funcs{Patch: applyAsMergePatch}).
+		WithObjects(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-pg-ingress-config", Namespace: "operator-ns"},
+			BinaryData: map[string][]byte{serveConfigKey: []byte(`{"Services":{}}`)},
+		}).
+		Build()
+	key := types.NamespacedName{Name: "test-pg-ingress-config", Namespace: "operator-ns"}
+
+	// Read the ConfigMap, then have another writer modify it before our write
+	// lands, so that our copy is stale.
+	cm := &corev1.ConfigMap{}
+	if err := fc.Get(t.Context(), key, cm); err != nil {
+		t.Fatal(err)
+	}
+	mustUpdate(t, fc, key.Namespace, key.Name, func(cm *corev1.ConfigMap) {
+		mak.Set(&cm.BinaryData, serveConfigKey, []byte(`{"Services":{"svc:bar":{}}}`))
+	})
+
+	// Applying the stale copy must conflict rather than silently overwrite
+	// the other writer's serve config.
+	mak.Set(&cm.BinaryData, serveConfigKey, []byte(`{"Services":{"svc:foo":{}}}`))
+	if err := applyConfigMap(t.Context(), fc, haIngressFieldManager, cm, serveConfigKey); !apierrors.IsConflict(err) {
+		t.Fatalf("expected conflict applying stale copy, got %v", err)
+	}
+}
@@ -62,8 +62,23 @@ type HAServiceReconciler struct {
 	tsClient              tsClient
 	tsNamespace           string
 	lc                    localClient
-	defaultTags           []string
-	operatorID            string // stableID of the operator's Tailscale device
+	defaultTags           []string         // default tags for TCP passthrough Tailscale Services
+	operatorID            string           // stableID of the operator's Tailscale device
+	previousOperatorID    string           // if set, the operator's stable ID before its Tailscale device was re-created
+	noAutoDeleteServices  bool             // if set, unused Tailscale Services are retained rather than deleted
+	managedServiceTag     string           // if set, added to the tags of all Tailscale Services
+	ownerAnnotationBudget int              // if positive, the maximum size in bytes of the owner annotation on Tailscale Services
+	annotationPrefix      annotationPrefix // prefix of user-set annotations
+	annotationWarnings    annotationWarnings
+	// pendingWrites, if set, tracks the updates to the ProxyGroup replicas'
+	// config Secrets, so that they are completed on shutdown.
+	pendingWrites *pendingWrites
+	// clusterName, if set, identifies the operator's cluster in the
+	// provenance in Tailscale Service comments.
+	clusterName string
+	// noServiceProvenance, if set, leaves out the provenance of Tailscale
+	// Services, i.e. the Service and cluster, from their comments.
+	noServiceProvenance bool
 
 	clock tstime.Clock
 
@@ -100,7 +115,7 @@ func (r *HAServiceReconciler) Reconcile(ctx context.Context, req reconcile.Reque
 		return res, fmt.Errorf("failed to get Service: %w", err)
 	}
 
-	hostname := nameForService(svc)
+	hostname := nameForService(svc, r.annotationPrefix)
 	logger = logger.With("hostname", hostname)
 
 	if !svc.DeletionTimestamp.IsZero() || !r.isTailscaleService(svc) {
@@ -116,6 +131,7 @@ func (r *HAServiceReconciler) Reconcile(ctx context.Context, req reconcile.Reque
 	needsRequeue, err = r.maybeProvision(ctx, hostname, svc, logger)
 	if err != nil {
 		if strings.Contains(err.Error(), optimisticLockErrorMsg) {
+			ownerRefChanges.WithLabelValues(reconcilerServicePG, ownerRefResultConflict).Inc()
 			logger.Infof("optimistic lock error, retrying: %s", err)
 		} else {
 			return reconcile.Result{}, err
@@ -145,13 +161,14 @@ func (r *HAServiceReconciler) maybeProvision(ctx context.Context, hostname strin
 		}
 	}()
 
-	pgName := svc.Annotations[AnnotationProxyGroup]
+	pgName := r.annotationPrefix.get(svc.Annotations, AnnotationProxyGroup)
 	if pgName == "" {
 		logger.Infof("[unexpected] no ProxyGroup annotation, skipping Tailscale Service provisioning")
 		return false, nil
 	}
 
 	logger = logger.With("ProxyGroup", pgName)
+	r.annotationWarnings.warn(r.recorder, r.annotationPrefix, svc)
 
 	pg := &tsapi.ProxyGroup{}
 	if err := r.Get(ctx, client.ObjectKey{Name: pgName}, pg); err != nil {
@@ -215,9 +232,13 @@ func (r *HAServiceReconciler) maybeProvision(ctx context.Context, hostname strin
 	// This checks and ensures that Tailscale Service's owner references are updated
 	// for this Service and errors if that is not possible (i.e. because it
 	// appears that the Tailscale Service has been created by a non-operator actor).
-	updatedAnnotations, err := ownerAnnotations(r.operatorID, existingTSSvc)
+	ownedTSSvc, err := migrateOwnerRefs(existingTSSvc, r.previousOperatorID, r.operatorID, logger)
 	if err != nil {
-		instr := fmt.Sprintf("To proceed, you can either manually delete the existing Tailscale Service or choose a different hostname with the '%s' annotaion", AnnotationHostname)
+		return false, fmt.Errorf("error migrating owner references of Tailscale Service %q: %w", serviceName, err)
+	}
+	updatedAnnotations, err := ownerAnnotations(OwnerRef{OperatorID: r.operatorID, Tags: withManagedServiceTag(tailscaleServiceTags(svc, r.defaultTags, r.annotationPrefix), r.managedServiceTag)}, ownedTSSvc, r.ownerAnnotationBudget, logger)
+	if err != nil {
+		instr := fmt.Sprintf("To proceed, you can either manually delete the existing Tailscale Service or choose a different hostname with the '%s' annotaion", r.annotationPrefix.key(AnnotationHostname))
 		msg := fmt.Sprintf("error ensuring ownership of Tailscale Service %s: %v. %s", hostname, err, instr)
 		logger.Warn(msg)
 		r.recorder.Event(svc, corev1.EventTypeWarning, "InvalidTailscaleService", msg)
@@ -225,20 +246,26 @@ func (r *HAServiceReconciler) maybeProvision(ctx context.Context, hostname strin
 		return false, nil
 	}
 
-	tags := r.defaultTags
-	if tstr, ok := svc.Annotations[AnnotationTags]; ok && tstr != "" {
-		tags = strings.Split(tstr, ",")
-	}
-
 	tsSvc := &tailscale.VIPService{
 		Name:        serviceName,
-		Tags:        tags,
 		Ports:       []string{"do-not-validate"}, // we don't want to validate ports
-		Comment:     managedTSServiceComment,
+		Comment:     tailscaleServiceComment("Service", svc, r.clusterName, r.noServiceProvenance, r.annotationPrefix),
 		Annotations: updatedAnnotations,
 	}
+	// Tag the Tailscale Service with the tags of all owners, which may be
+	// operators in other clusters with different default tags.
+	o, err := parseOwnerAnnotation(tsSvc)
+	if err != nil {
+		return false, err
+	}
+	tsSvc.Tags = ownerTags(o)
 	if existingTSSvc != nil {
 		tsSvc.Addrs = existingTSSvc.Addrs
+		if len(o.OwnerRefs) > 1 {
+			// As for HA Ingresses, keep the comment of a Tailscale
+			// Service shared with operators in other clusters.
+			tsSvc.Comment = existingTSSvc.Comment
+		}
 	}
 
 	// TODO(irbekrm): right now if two Service resources attempt to apply different Tailscale Service configs (different
@@ -246,11 +273,15 @@ func (r *HAServiceReconciler) maybeProvision(ctx context.Context, hostname strin
 	// with the same generation number has been reconciled ~more than N times and stop attempting to apply updates.
 	if existingTSSvc == nil ||
 		!reflect.DeepEqual(tsSvc.Tags, existingTSSvc.Tags) ||
+		tsSvc.Comment != existingTSSvc.Comment ||
 		!ownersAreSetAndEqual(tsSvc, existingTSSvc) {
 		logger.Infof("Ensuring Tailscale Service exists and is up to date")
 		if err := r.tsClient.CreateOrUpdateVIPService(ctx, tsSvc); err != nil {
 			return false, fmt.Errorf("error creating Tailscale Service: %w", err)
 		}
+		if !hasOwnerRef(ownedTSSvc, r.operatorID) {
+			ownerRefChanges.WithLabelValues(reconcilerServicePG, ownerRefResultAdd).Inc()
+		}
 		existingTSSvc = tsSvc
 	}
 
@@ -317,7 +348,7 @@ func (r *HAServiceReconciler) maybeProvision(ctx context.Context, hostname strin
 			return false, fmt.Errorf("error marshaling ingress config: %w", err)
 		}
 		mak.Set(&cm.BinaryData, ingressservices.IngressConfigKey, cfgBytes)
-		if err := r.Update(ctx, cm); err != nil {
+		if err := applyConfigMap(ctx, r.Client, haServiceFieldManager, cm, ingressservices.IngressConfigKey); err != nil {
 			return false, fmt.Errorf("error updating ingress config: %w", err)
 		}
 	}
@@ -373,6 +404,7 @@ func (r *HAServiceReconciler) maybeProvision(ctx context.Context, hostname strin
 // corresponding to this Service.
 func (r *HAServiceReconciler) maybeCleanup(ctx context.Context, hostname string, svc *corev1.Service, logger *zap.SugaredLogger) (svcChanged bool, err error) {
 	logger.Debugf("Ensuring any resources for Service are cleaned up")
+	r.annotationWarnings.forget(svc.UID)
 	ix := slices.Index(svc.Finalizers, svcPGFinalizerName)
 	if ix < 0 {
 		logger.Debugf("no finalizer, nothing to do")
@@ -389,13 +421,16 @@ func (r *HAServiceReconciler) maybeCleanup(ctx context.Context, hostname string,
 
 	serviceName := tailcfg.ServiceName("svc:" + hostname)
 	//  1. Clean up the Tailscale Service.
-	svcChanged, err = cleanupTailscaleService(ctx, r.tsClient, serviceName, r.operatorID, logger)
+	svcChanged, retained, err := cleanupTailscaleService(ctx, r.tsClient, serviceName, r.operatorID, r.previousOperatorID, r.noAutoDeleteServices, reconcilerServicePG, logger)
 	if err != nil {
 		return false, fmt.Errorf("error deleting Tailscale Service: %w", err)
 	}
+	if retained {
+		r.recorder.Eventf(svc, corev1.EventTypeWarning, warningTailscaleServiceRetained, msgTailscaleServiceRetained, serviceName)
+	}
 
 	// 2. Unadvertise the Tailscale Service.
-	pgName := svc.Annotations[AnnotationProxyGroup]
+	pgName := r.annotationPrefix.get(svc.Annotations, AnnotationProxyGroup)
 	if err = r.maybeUpdateAdvertiseServicesConfig(ctx, svc, pgName, serviceName, nil, false, logger); err != nil {
 		return false, fmt.Errorf("failed to update tailscaled config services: %w", err)
 	}
@@ -417,7 +452,7 @@ func (r *HAServiceReconciler) maybeCleanup(ctx context.Context, hostname string,
 		return false, fmt.Errorf("error marshaling ingress config: %w", err)
 	}
 	mak.Set(&cm.BinaryData, ingressservices.IngressConfigKey, cfgBytes)
-	return true, r.Update(ctx, cm)
+	return true, applyConfigMap(ctx, r.Client, haServiceFieldManager, cm, ingressservices.IngressConfigKey)
 }
 
 // Tailscale Services that are associated with the provided ProxyGroup and no longer managed this operator's instance are deleted, if not owned by other operator instances, else the owner reference is cleaned up.
@@ -437,7 +472,7 @@ func (r *HAServiceReconciler) maybeCleanupProxyGroup(ctx context.Context, proxyG
 	for tsSvcName, cfg := range config {
 		found := false
 		for _, svc := range svcList.Items {
-			if strings.EqualFold(fmt.Sprintf("svc:%s", nameForService(&svc)), tsSvcName) {
+			if strings.EqualFold(fmt.Sprintf("svc:%s", nameForService(&svc, r.annotationPrefix)), tsSvcName) {
 				found = true
 				break
 			}
@@ -450,7 +485,7 @@ func (r *HAServiceReconciler) maybeCleanupProxyGroup(ctx context.Context, proxyG
 				return false, fmt.Errorf("failed to update tailscaled config services: %w", err)
 			}
 
-			svcsChanged, err = cleanupTailscaleService(ctx, r.tsClient, tailcfg.ServiceName(tsSvcName), r.operatorID, logger)
+			svcsChanged, _, err = cleanupTailscaleService(ctx, r.tsClient, tailcfg.ServiceName(tsSvcName), r.operatorID, r.previousOperatorID, r.noAutoDeleteServices, reconcilerServicePG, logger)
 			if err != nil {
 				return false, fmt.Errorf("deleting Tailscale Service %q: %w", tsSvcName, err)
 			}
@@ -470,7 +505,7 @@ func (r *HAServiceReconciler) maybeCleanupProxyGroup(ctx context.Context, proxyG
 			return false, fmt.Errorf("marshaling serve config: %w", err)
 		}
 		mak.Set(&cm.BinaryData, ingressservices.IngressConfigKey, configBytes)
-		if err := r.Update(ctx, cm); err != nil {
+		if err := applyConfigMap(ctx, r.Client, haServiceFieldManager, cm, ingressservices.IngressConfigKey); err != nil {
 			return false, fmt.Errorf("updating serve config: %w", err)
 		}
 	}
@@ -495,7 +530,7 @@ func (r *HAServiceReconciler) deleteFinalizer(ctx context.Context, svc *corev1.S
 }
 
 func (r *HAServiceReconciler) isTailscaleService(svc *corev1.Service) bool {
-	proxyGroup := svc.Annotations[AnnotationProxyGroup]
+	proxyGroup := r.annotationPrefix.get(svc.Annotations, AnnotationProxyGroup)
 	return r.shouldExpose(svc) && proxyGroup != ""
 }
 
@@ -507,7 +542,7 @@ func (r *HAServiceReconciler) shouldExposeClusterIP(svc *corev1.Service) bool {
 	if svc.Spec.ClusterIP == "" || svc.Spec.ClusterIP == "None" {
 		return false
 	}
-	return isTailscaleLoadBalancerService(svc, r.isDefaultLoadBalancer) || hasExposeAnnotation(svc)
+	return isTailscaleLoadBalancerService(svc, r.isDefaultLoadBalancer) || hasExposeAnnotation(svc, r.annotationPrefix)
 }
 
 // tailnetCertDomain returns the base domain (TCD) of the current tailnet.
@@ -522,31 +557,36 @@ func (r *HAServiceReconciler) tailnetCertDomain(ctx context.Context) (string, er
 // cleanupTailscaleService deletes any Tailscale Service by the provided name if it is not owned by operator instances other than this one.
 // If a Tailscale Service is found, but contains other owner references, only removes this operator's owner reference.
 // If a Tailscale Service by the given name is not found or does not contain this operator's owner reference, do nothing.
-// It returns true if an existing Tailscale Service was updated to remove owner reference, as well as any error that occurred.
-func cleanupTailscaleService(ctx context.Context, tsClient tsClient, name tailcfg.ServiceName, operatorID string, logger *zap.SugaredLogger) (updated bool, err error) {
+// If noAutoDelete is set, the last owner reference is removed instead of deleting the Tailscale Service.
+// Owner references under previousOperatorID, if set, are treated as this operator's.
+// The removal of the owner reference is counted in ownerRefChanges for reconciler.
+// It returns whether an existing Tailscale Service was updated to remove owner reference, whether it was retained
+// rather than deleted, as well as any error that occurred.
+func cleanupTailscaleService(ctx context.Context, tsClient tsClient, name tailcfg.ServiceName, operatorID, previousOperatorID string, noAutoDelete bool, reconciler string, logger *zap.SugaredLogger) (updated, retained bool, err error) {
 	svc, err := tsClient.GetVIPService(ctx, name)
 	if err != nil {
 		errResp := &tailscale.ErrResponse{}
 		ok := errors.As(err, errResp)
 		if ok && errResp.Status == http.StatusNotFound {
-			return false, nil
+			return false, false, nil
 		}
 		if !ok {
-			return false, fmt.Errorf("unexpected error getting Tailscale Service %q: %w", name.String(), err)
+			return false, false, fmt.Errorf("unexpected error getting Tailscale Service %q: %w", name.String(), err)
 		}
 
-		return false, fmt.Errorf("error getting Tailscale Service: %w", err)
+		return false, false, fmt.Errorf("error getting Tailscale Service: %w", err)
 	}
 	if svc == nil {
-		return false, nil
+		return false, false, nil
 	}
 	o, err := parseOwnerAnnotation(svc)
 	if err != nil {
-		return false, fmt.Errorf("error parsing Tailscale Service owner annotation: %w", err)
+		return false, false, fmt.Errorf("error parsing Tailscale Service owner annotation: %w", err)
 	}
 	if o == nil || len(o.OwnerRefs) == 0 {
-		return false, nil
+		return false, false, nil
 	}
+	o.OwnerRefs, _ = migrateOwnerRefList(o.OwnerRefs, previousOperatorID, operatorID)
 	// Comparing with the operatorID only means that we will not be able to
 	// clean up Tailscale Services in cases where the operator was deleted from the
 	// cluster before deleting the Ingress. Perhaps the comparison could be
@@ -555,20 +595,48 @@ func cleanupTailscaleService(ctx context.Context, tsClient tsClient, name tailcf
 		return or.OperatorID == operatorID
 	})
 	if ix == -1 {
-		return false, nil
+		return false, false, nil
 	}
 	if len(o.OwnerRefs) == 1 {
+		if noAutoDelete {
+			if err := retainTailscaleService(ctx, tsClient, svc, logger); err != nil {
+				return true, true, err
+			}
+			ownerRefChanges.WithLabelValues(reconciler, ownerRefResultRemove).Inc()
+			return true, true, nil
+		}
 		logger.Infof("Deleting Tailscale Service %q", name)
-		return false, tsClient.DeleteVIPService(ctx, name)
+		if err := tsClient.DeleteVIPService(ctx, name); err != nil {
+			return false, false, err
+		}
+		ownerRefChanges.WithLabelValues(reconciler, ownerRefResultRemove).Inc()
+		return false, false, nil
 	}
 	o.OwnerRefs = slices.Delete(o.OwnerRefs, ix, ix+1)
+	if tags := ownerTags(o); len(tags) > 0 {
+		svc.Tags = tags
+	}
 	logger.Infof("Updating Tailscale Service %q", name)
 	json, err := json.Marshal(o)
 	if err != nil {
-		return false, fmt.Errorf("error marshalling updated Tailscale Service owner reference: %w", err)
+		return false, false, fmt.Errorf("error marshalling updated Tailscale Service owner reference: %w", err)
 	}
 	svc.Annotations[ownerAnnotation] = string(json)
-	return true, tsClient.CreateOrUpdateVIPService(ctx, svc)
+	if err := tsClient.CreateOrUpdateVIPService(ctx, svc); err != nil {
+		return true, false, err
+	}
+	ownerRefChanges.WithLabelValues(reconciler, ownerRefResultRemove).Inc()
+	return true, false, nil
+}
+
+// retainTailscaleService removes all owner references from a Tailscale
+// Service that would otherwise be deleted, as automatic deletion of Tailscale
+// Services is disabled. The operator then no longer manages it, nor reuses
+// it, so it needs to be deleted manually.
+func retainTailscaleService(ctx context.Context, tsClient tsClient, svc *tailscale.VIPService, logger *zap.SugaredLogger) error {
+	logger.Infof("Automatic deletion of Tailscale Services is disabled, removing owner references from Tailscale Service %q instead of deleting it", svc.Name)
+	delete(svc.Annotations, ownerAnnotation)
+	return tsClient.CreateOrUpdateVIPService(ctx, svc)
 }
 
 func (a *HAServiceReconciler) backendRoutesSetup(ctx context.Context, serviceName, replicaName, pgName string, wantsCfg *ingressservices.Config, logger *zap.SugaredLogger) (bool, error) {
@@ -654,6 +722,7 @@ func (a *HAServiceReconciler) maybeUpdateAdvertiseServicesConfig(ctx context.Con
 		}
 	}
 
+	var changed []*corev1.Secret
 	for _, secret := range secrets.Items {
 		var updated bool
 		for fileName, confB := range secret.Data {
@@ -699,12 +768,10 @@ func (a *HAServiceReconciler) maybeUpdateAdvertiseServicesConfig(ctx context.Con
 			updated = true
 		}
 		if updated {
-			if err := a.Update(ctx, &secret); err != nil {
-				return fmt.Errorf("error updating ProxyGroup config Secret: %w", err)
-			}
+			changed = append(changed, &secret)
 		}
 	}
-	return nil
+	return a.pendingWrites.applyConfigSecrets(ctx, a.Client, haServiceFieldManager, changed)
 }
 
 func (a *HAServiceReconciler) numberPodsAdvertising(ctx context.Context, pgName string, serviceName tailcfg.ServiceName) (int, error) {
@@ -807,7 +874,7 @@ func (r *HAServiceReconciler) validateService(ctx context.Context, svc *corev1.S
 		errs = append(errs, fmt.Errorf("ProxyGroup %q is of type %q but must be of type %q",
 			pg.Name, pg.Spec.Type, tsapi.ProxyGroupTypeIngress))
 	}
-	if violations := validateService(svc); len(violations) > 0 {
+	if violations := validateService(svc, r.annotationPrefix); len(violations) > 0 {
 		errs = append(errs, fmt.Errorf("invalid Service: %s", strings.Join(violations, ", ")))
 	}
 	svcList := &corev1.ServiceList{}
@@ -815,9 +882,9 @@ func (r *HAServiceReconciler) validateService(ctx context.Context, svc *corev1.S
 		errs = append(errs, fmt.Errorf("[unexpected] error listing Services: %w", err))
 		return errors.Join(errs...)
 	}
-	svcName := nameForService(svc)
+	svcName := nameForService(svc, r.annotationPrefix)
 	for _, s := range svcList.Items {
-		if r.shouldExpose(&s) && nameForService(&s) == svcName && s.UID != svc.UID {
+		if r.shouldExpose(&s) && nameForService(&s, r.annotationPrefix) == svcName && s.UID != svc.UID {
 			errs = append(errs, fmt.Errorf("found duplicate Service %q for hostname %q - multiple HA Services for the same hostname in the same cluster are not allowed", client.ObjectKeyFromObject(&s), svcName))
 		}
 	}
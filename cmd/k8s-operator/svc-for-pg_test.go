@@ -22,6 +22,7 @@
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"tailscale.com/ipn/ipnstate"
 	tsoperator "tailscale.com/k8s-operator"
 	tsapi "tailscale.com/k8s-operator/apis/v1alpha1"
@@ -172,9 +173,10 @@ func setupServiceTest(t *testing.T) (*HAServiceReconciler, *corev1.Secret, clien
 
 	fc := fake.NewClientBuilder().
 		WithScheme(tsapi.GlobalScheme).
+		WithInterceptorFuncs(interceptor.Funcs{Patch: applyAsMergePatch}).
 		WithObjects(pg, pgCfgSecret, pgConfigMap, pgPod, pgStateSecret).
 		WithStatusSubresource(pg).
-		WithIndex(new(corev1.Service), indexIngressProxyGroup, indexPGIngresses).
+		WithIndex(new(corev1.Service), indexIngressProxyGroup, indexPGIngresses(annotationDomain)).
 		Build()
 
 	// Set ProxyGroup status to ready
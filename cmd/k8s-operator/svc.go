@@ -67,6 +67,9 @@ type ServiceReconciler struct {
 	clock tstime.Clock
 
 	defaultProxyClass string
+
+	annotationPrefix   annotationPrefix // prefix of user-set annotations
+	annotationWarnings annotationWarnings
 }
 
 var (
@@ -94,8 +97,8 @@ func childResourceLabels(name, ns, typ string) map[string]string {
 }
 
 func (a *ServiceReconciler) isTailscaleService(svc *corev1.Service) bool {
-	targetIP := tailnetTargetAnnotation(svc)
-	targetFQDN := svc.Annotations[AnnotationTailnetTargetFQDN]
+	targetIP := tailnetTargetAnnotation(svc, a.annotationPrefix)
+	targetFQDN := a.annotationPrefix.get(svc.Annotations, AnnotationTailnetTargetFQDN)
 	return a.shouldExpose(svc) || targetIP != "" || targetFQDN != ""
 }
 
@@ -114,7 +117,7 @@ func (a *ServiceReconciler) Reconcile(ctx context.Context, req reconcile.Request
 		return reconcile.Result{}, fmt.Errorf("failed to get svc: %w", err)
 	}
 
-	if _, ok := svc.Annotations[AnnotationProxyGroup]; ok {
+	if _, ok := a.annotationPrefix.lookup(svc.Annotations, AnnotationProxyGroup); ok {
 		return reconcile.Result{}, nil // this reconciler should not look at Services for ProxyGroup
 	}
 
@@ -153,6 +156,7 @@ func (a *ServiceReconciler) maybeCleanup(ctx context.Context, logger *zap.Sugare
 		defer a.mu.Unlock()
 		a.managedIngressProxies.Remove(svc.UID)
 		a.managedEgressProxies.Remove(svc.UID)
+		a.annotationWarnings.forget(svc.UID)
 		gaugeIngressProxies.Set(int64(a.managedIngressProxies.Len()))
 		gaugeEgressProxies.Set(int64(a.managedEgressProxies.Len()))
 
@@ -189,6 +193,7 @@ func (a *ServiceReconciler) maybeCleanup(ctx context.Context, logger *zap.Sugare
 	defer a.mu.Unlock()
 	a.managedIngressProxies.Remove(svc.UID)
 	a.managedEgressProxies.Remove(svc.UID)
+	a.annotationWarnings.forget(svc.UID)
 	gaugeIngressProxies.Set(int64(a.managedIngressProxies.Len()))
 	gaugeEgressProxies.Set(int64(a.managedEgressProxies.Len()))
 
@@ -212,6 +217,8 @@ func (a *ServiceReconciler) maybeProvision(ctx context.Context, logger *zap.Suga
 		}
 	}()
 
+	a.annotationWarnings.warn(a.recorder, a.annotationPrefix, svc)
+
 	// Run for proxy config related validations here as opposed to running
 	// them earlier. This is to prevent cleanup being blocked on a
 	// misconfigured proxy param.
@@ -222,7 +229,7 @@ func (a *ServiceReconciler) maybeProvision(ctx context.Context, logger *zap.Suga
 		tsoperator.SetServiceCondition(svc, tsapi.ProxyReady, metav1.ConditionFalse, reasonProxyInvalid, msg, a.clock, logger)
 		return nil
 	}
-	if violations := validateService(svc); len(violations) > 0 {
+	if violations := validateService(svc, a.annotationPrefix); len(violations) > 0 {
 		msg := fmt.Sprintf("unable to provision proxy resources: invalid Service: %s", strings.Join(violations, ", "))
 		a.recorder.Event(svc, corev1.EventTypeWarning, "INVALIDSERVICE", msg)
 		a.logger.Error(msg)
@@ -230,7 +237,7 @@ func (a *ServiceReconciler) maybeProvision(ctx context.Context, logger *zap.Suga
 		return nil
 	}
 
-	proxyClass := proxyClassForObject(svc, a.defaultProxyClass)
+	proxyClass := proxyClassForObject(svc, a.defaultProxyClass, a.annotationPrefix)
 	if proxyClass != "" {
 		if ready, err := proxyClassIsReady(ctx, proxyClass, a.Client); err != nil {
 			errMsg := fmt.Errorf("error verifying ProxyClass for Service: %w", err)
@@ -259,7 +266,7 @@ func (a *ServiceReconciler) maybeProvision(ctx context.Context, logger *zap.Suga
 	}
 	crl := childResourceLabels(svc.Name, svc.Namespace, "svc")
 	var tags []string
-	if tstr, ok := svc.Annotations[AnnotationTags]; ok {
+	if tstr, ok := a.annotationPrefix.lookup(svc.Annotations, AnnotationTags); ok {
 		tags = strings.Split(tstr, ",")
 	}
 
@@ -267,7 +274,7 @@ func (a *ServiceReconciler) maybeProvision(ctx context.Context, logger *zap.Suga
 		Replicas:            1,
 		ParentResourceName:  svc.Name,
 		ParentResourceUID:   string(svc.UID),
-		Hostname:            nameForService(svc),
+		Hostname:            nameForService(svc, a.annotationPrefix),
 		Tags:                tags,
 		ChildResourceLabels: crl,
 		ProxyClassName:      proxyClass,
@@ -287,12 +294,12 @@ func (a *ServiceReconciler) maybeProvision(ctx context.Context, logger *zap.Suga
 		sts.ClusterTargetDNSName = svc.Spec.ExternalName
 		a.managedIngressProxies.Add(svc.UID)
 		gaugeIngressProxies.Set(int64(a.managedIngressProxies.Len()))
-	} else if ip := tailnetTargetAnnotation(svc); ip != "" {
+	} else if ip := tailnetTargetAnnotation(svc, a.annotationPrefix); ip != "" {
 		sts.TailnetTargetIP = ip
 		a.managedEgressProxies.Add(svc.UID)
 		gaugeEgressProxies.Set(int64(a.managedEgressProxies.Len()))
-	} else if fqdn := svc.Annotations[AnnotationTailnetTargetFQDN]; fqdn != "" {
-		fqdn := svc.Annotations[AnnotationTailnetTargetFQDN]
+	} else if fqdn := a.annotationPrefix.get(svc.Annotations, AnnotationTailnetTargetFQDN); fqdn != "" {
+		fqdn := a.annotationPrefix.get(svc.Annotations, AnnotationTailnetTargetFQDN)
 		if !strings.HasSuffix(fqdn, ".") {
 			fqdn = fqdn + "."
 		}
@@ -375,34 +382,34 @@ func (a *ServiceReconciler) maybeProvision(ctx context.Context, logger *zap.Suga
 	return nil
 }
 
-func validateService(svc *corev1.Service) []string {
+func validateService(svc *corev1.Service, prefix annotationPrefix) []string {
 	violations := make([]string, 0)
-	if svc.Annotations[AnnotationTailnetTargetFQDN] != "" && svc.Annotations[AnnotationTailnetTargetIP] != "" {
-		violations = append(violations, fmt.Sprintf("only one of annotations %s and %s can be set", AnnotationTailnetTargetIP, AnnotationTailnetTargetFQDN))
+	if prefix.get(svc.Annotations, AnnotationTailnetTargetFQDN) != "" && prefix.get(svc.Annotations, AnnotationTailnetTargetIP) != "" {
+		violations = append(violations, fmt.Sprintf("only one of annotations %s and %s can be set", prefix.key(AnnotationTailnetTargetIP), prefix.key(AnnotationTailnetTargetFQDN)))
 	}
-	if fqdn := svc.Annotations[AnnotationTailnetTargetFQDN]; fqdn != "" {
+	if fqdn := prefix.get(svc.Annotations, AnnotationTailnetTargetFQDN); fqdn != "" {
 		if !isMagicDNSName(fqdn) {
-			violations = append(violations, fmt.Sprintf("invalid value of annotation %s: %q does not appear to be a valid MagicDNS name", AnnotationTailnetTargetFQDN, fqdn))
+			violations = append(violations, fmt.Sprintf("invalid value of annotation %s: %q does not appear to be a valid MagicDNS name", prefix.key(AnnotationTailnetTargetFQDN), fqdn))
 		}
 	}
-	if ipStr := svc.Annotations[AnnotationTailnetTargetIP]; ipStr != "" {
+	if ipStr := prefix.get(svc.Annotations, AnnotationTailnetTargetIP); ipStr != "" {
 		ip, err := netip.ParseAddr(ipStr)
 		if err != nil {
-			violations = append(violations, fmt.Sprintf("invalid value of annotation %s: %q could not be parsed as a valid IP Address, error: %s", AnnotationTailnetTargetIP, ipStr, err))
+			violations = append(violations, fmt.Sprintf("invalid value of annotation %s: %q could not be parsed as a valid IP Address, error: %s", prefix.key(AnnotationTailnetTargetIP), ipStr, err))
 		} else if !ip.IsValid() {
-			violations = append(violations, fmt.Sprintf("parsed IP address in annotation %s: %q is not valid", AnnotationTailnetTargetIP, ipStr))
+			violations = append(violations, fmt.Sprintf("parsed IP address in annotation %s: %q is not valid", prefix.key(AnnotationTailnetTargetIP), ipStr))
 		}
 	}
 
-	svcName := nameForService(svc)
+	svcName := nameForService(svc, prefix)
 	if err := dnsname.ValidLabel(svcName); err != nil {
-		if _, ok := svc.Annotations[AnnotationHostname]; ok {
+		if _, ok := prefix.lookup(svc.Annotations, AnnotationHostname); ok {
 			violations = append(violations, fmt.Sprintf("invalid Tailscale hostname specified %q: %s", svcName, err))
 		} else {
-			violations = append(violations, fmt.Sprintf("invalid Tailscale hostname %q, use %q annotation to override: %s", svcName, AnnotationHostname, err))
+			violations = append(violations, fmt.Sprintf("invalid Tailscale hostname %q, use %q annotation to override: %s", svcName, prefix.key(AnnotationHostname), err))
 		}
 	}
-	violations = append(violations, tagViolations(svc)...)
+	violations = append(violations, tagViolations(svc, prefix)...)
 	return violations
 }
 
@@ -411,14 +418,14 @@ func (a *ServiceReconciler) shouldExpose(svc *corev1.Service) bool {
 }
 
 func (a *ServiceReconciler) shouldExposeDNSName(svc *corev1.Service) bool {
-	return hasExposeAnnotation(svc) && svc.Spec.Type == corev1.ServiceTypeExternalName && svc.Spec.ExternalName != ""
+	return hasExposeAnnotation(svc, a.annotationPrefix) && svc.Spec.Type == corev1.ServiceTypeExternalName && svc.Spec.ExternalName != ""
 }
 
 func (a *ServiceReconciler) shouldExposeClusterIP(svc *corev1.Service) bool {
 	if svc.Spec.ClusterIP == "" || svc.Spec.ClusterIP == "None" {
 		return false
 	}
-	return isTailscaleLoadBalancerService(svc, a.isDefaultLoadBalancer) || hasExposeAnnotation(svc)
+	return isTailscaleLoadBalancerService(svc, a.isDefaultLoadBalancer) || hasExposeAnnotation(svc, a.annotationPrefix)
 }
 
 func isTailscaleLoadBalancerService(svc *corev1.Service, isDefaultLoadBalancer bool) bool {
@@ -430,22 +437,22 @@ func isTailscaleLoadBalancerService(svc *corev1.Service, isDefaultLoadBalancer b
 
 // hasExposeAnnotation reports whether Service has the tailscale.com/expose
 // annotation set
-func hasExposeAnnotation(svc *corev1.Service) bool {
-	return svc != nil && svc.Annotations[AnnotationExpose] == "true"
+func hasExposeAnnotation(svc *corev1.Service, prefix annotationPrefix) bool {
+	return svc != nil && prefix.get(svc.Annotations, AnnotationExpose) == "true"
 }
 
 // tailnetTargetAnnotation returns the value of tailscale.com/tailnet-ip
 // annotation or of the deprecated tailscale.com/ts-tailnet-target-ip
 // annotation. If neither is set, it returns an empty string. If both are set,
 // it returns the value of the new annotation.
-func tailnetTargetAnnotation(svc *corev1.Service) string {
+func tailnetTargetAnnotation(svc *corev1.Service, prefix annotationPrefix) string {
 	if svc == nil {
 		return ""
 	}
-	if ip := svc.Annotations[AnnotationTailnetTargetIP]; ip != "" {
+	if ip := prefix.get(svc.Annotations, AnnotationTailnetTargetIP); ip != "" {
 		return ip
 	}
-	return svc.Annotations[annotationTailnetTargetIPOld]
+	return prefix.get(svc.Annotations, annotationTailnetTargetIPOld)
 }
 
 func proxyClassIsReady(ctx context.Context, name string, cl client.Client) (bool, error) {
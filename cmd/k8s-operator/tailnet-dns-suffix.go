@@ -0,0 +1,98 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !plan9
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const tailnetDNSSuffixCheckInterval = time.Minute
+
+// tailnetDNSSuffixWatcher periodically checks the tailnet's MagicDNS suffix.
+// If it changes (for example, because the tailnet was renamed), all DNS names
+// derived from it are stale, so it enqueues all HA Ingresses for the
+// ingress-pg-reconciler to rebuild their serve config and TLS certs, and
+// removes the cert resources for the old DNS names.
+type tailnetDNSSuffixWatcher struct {
+	client.Client
+	lc          localClient
+	tsNamespace string
+	logger      *zap.SugaredLogger
+	events      chan<- event.GenericEvent
+	// serviceNameStrategy must match the one used by the HA Ingress
+	// reconciler, so that cert resources are cleaned up for the right domain.
+	serviceNameStrategy serviceNameStrategy
+	annotationPrefix    annotationPrefix // prefix of user-set annotations
+
+	suffix string // last observed MagicDNS suffix
+}
+
+// Start implements manager.Runnable.
+func (w *tailnetDNSSuffixWatcher) Start(ctx context.Context) error {
+	ticker := time.NewTicker(tailnetDNSSuffixCheckInterval)
+	defer ticker.Stop()
+	for {
+		if err := w.check(ctx); err != nil {
+			w.logger.Infof("error checking tailnet MagicDNS suffix: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// check enqueues all HA Ingresses if the tailnet's MagicDNS suffix has changed
+// since the last check.
+func (w *tailnetDNSSuffixWatcher) check(ctx context.Context) error {
+	suffix, err := tailnetCertDomain(ctx, w.lc)
+	if err != nil {
+		return err
+	}
+	if suffix == "" || suffix == w.suffix {
+		return nil
+	}
+	old := w.suffix
+	if old == "" {
+		w.suffix = suffix
+		return nil
+	}
+	w.logger.Infof("Tailnet MagicDNS suffix changed from %q to %q, reconciling HA Ingresses", old, suffix)
+
+	ingList := &networkingv1.IngressList{}
+	if err := w.List(ctx, ingList); err != nil {
+		return fmt.Errorf("failed to list Ingresses: %w", err)
+	}
+	for _, ing := range ingList.Items {
+		if !slices.Contains(ing.Finalizers, FinalizerNamePG) {
+			continue
+		}
+		if pg := w.annotationPrefix.get(ing.Annotations, AnnotationProxyGroup); pg != "" {
+			oldDomain := w.serviceNameStrategy.hostnameForIngress(&ing) + "." + old
+			if err := cleanupCertResourcesForDomain(ctx, w.Client, w.tsNamespace, pg, oldDomain); err != nil {
+				return fmt.Errorf("failed to clean up cert resources for %s: %w", oldDomain, err)
+			}
+		}
+		select {
+		case w.events <- event.GenericEvent{Object: &ing}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	// Only record the new suffix once all Ingresses have been enqueued, so
+	// that a failure part way through is retried on the next check.
+	w.suffix = suffix
+	return nil
+}
@@ -0,0 +1,136 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+//go:build !plan9
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"tailscale.com/ipn"
+	"tailscale.com/types/ptr"
+)
+
+func TestTailnetDNSSuffixWatcher(t *testing.T) {
+	ingPGR, fc, _ := setupIngressTest(t)
+	lc := ingPGR.lc.(*fakeLocalClient)
+
+	ing := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-ingress",
+			Namespace: "default",
+			UID:       types.UID("1234-UID"),
+			Annotations: map[string]string{
+				"tailscale.com/proxy-group": "test-pg",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("tailscale"),
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "test",
+					Port: networkingv1.ServiceBackendPort{
+						Number: 8080,
+					},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"my-svc"}},
+			},
+		},
+	}
+	mustCreate(t, fc, ing)
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectServeConfigHost(t, fc, "my-svc.ts.net:443")
+	expectCertSecret(t, fc, "my-svc.ts.net", true)
+
+	events := make(chan event.GenericEvent, 1)
+	w := &tailnetDNSSuffixWatcher{
+		Client:      fc,
+		lc:          lc,
+		tsNamespace: "operator-ns",
+		logger:      ingPGR.logger,
+		events:      events,
+	}
+
+	// The first check only records the current suffix.
+	if err := w.check(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("unexpected event on first check: %v", (<-events).Object.GetName())
+	}
+
+	// Rename the tailnet.
+	lc.status.CurrentTailnet.MagicDNSSuffix = "renamed.ts.net"
+	if err := w.check(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ev := <-events:
+		if got := client.ObjectKeyFromObject(ev.Object); got != client.ObjectKeyFromObject(ing) {
+			t.Fatalf("got event for %v, want %v", got, client.ObjectKeyFromObject(ing))
+		}
+	default:
+		t.Fatal("expected the Ingress to be enqueued after the MagicDNS suffix changed")
+	}
+	expectCertSecret(t, fc, "my-svc.ts.net", false)
+
+	// Reconciling the enqueued Ingress rebuilds its config for the new suffix.
+	expectReconciled(t, ingPGR, "default", "test-ingress")
+	expectServeConfigHost(t, fc, "my-svc.renamed.ts.net:443")
+	expectCertSecret(t, fc, "my-svc.renamed.ts.net", true)
+
+	// Further checks with the same suffix are no-ops.
+	if err := w.check(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("unexpected event for unchanged suffix: %v", (<-events).Object.GetName())
+	}
+}
+
+// expectServeConfigHost asserts that the svc:my-svc serve config is
+// configured for exactly the provided host.
+func expectServeConfigHost(t *testing.T, fc client.Client, host ipn.HostPort) {
+	t.Helper()
+	cm := &corev1.ConfigMap{}
+	if err := fc.Get(t.Context(), types.NamespacedName{Namespace: "operator-ns", Name: "test-pg-ingress-config"}, cm); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ipn.ServeConfig{}
+	if err := json.Unmarshal(cm.BinaryData[serveConfigKey], cfg); err != nil {
+		t.Fatal(err)
+	}
+	svc := cfg.Services["svc:my-svc"]
+	if svc == nil {
+		t.Fatal("Tailscale Service svc:my-svc not found in serve config")
+	}
+	if len(svc.Web) != 1 || svc.Web[host] == nil {
+		t.Fatalf("serve config web hosts = %v, want only %q", svc.Web, host)
+	}
+}
+
+func expectCertSecret(t *testing.T, fc client.Client, domain string, wantExists bool) {
+	t.Helper()
+	err := fc.Get(t.Context(), types.NamespacedName{Namespace: "operator-ns", Name: domain}, &corev1.Secret{})
+	switch {
+	case err == nil && !wantExists:
+		t.Fatalf("cert Secret %q exists, want deleted", domain)
+	case apierrors.IsNotFound(err) && wantExists:
+		t.Fatalf("cert Secret %q not found", domain)
+	case err != nil && !apierrors.IsNotFound(err):
+		t.Fatal(err)
+	}
+}
@@ -13,6 +13,7 @@
 	"net/netip"
 	"path"
 	"reflect"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
@@ -20,6 +21,7 @@
 
 	"github.com/google/go-cmp/cmp"
 	"go.uber.org/zap"
+	"golang.org/x/net/dns/dnsmessage"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -35,6 +37,7 @@
 	tsapi "tailscale.com/k8s-operator/apis/v1alpha1"
 	"tailscale.com/kube/kubetypes"
 	"tailscale.com/tailcfg"
+	"tailscale.com/types/dnstype"
 	"tailscale.com/types/ptr"
 	"tailscale.com/util/mak"
 )
@@ -73,6 +76,7 @@ type configOpts struct {
 	replicas                                       *int32
 	enableMetrics                                  bool
 	serviceMonitorLabels                           tsapi.Labels
+	advertiseServices                              []string
 }
 
 func expectedSTS(t *testing.T, cl client.Client, opts configOpts) *appsv1.StatefulSet {
@@ -516,6 +520,7 @@ func expectedSecret(t *testing.T, cl client.Client, opts configOpts) *corev1.Sec
 		AcceptRoutes:        "false",
 		AppConnector:        &ipn.AppConnectorPrefs{Advertise: false},
 		NoStatefulFiltering: "true",
+		AdvertiseServices:   opts.advertiseServices,
 	}
 	if opts.proxyClass != "" {
 		t.Logf("applying configuration from ProxyClass %s", opts.proxyClass)
@@ -636,6 +641,32 @@ func findGenNames(t *testing.T, cl client.Client, ns, name, typ string) []string
 	return names
 }
 
+// applyAsMergePatch is an interceptor Patch func that makes the fake client,
+// which does not support server-side apply, handle apply patches as JSON
+// merge patches. That is close enough for tests that do not check field
+// ownership; see newFieldManagedClient for ones that do.
+func applyAsMergePatch(ctx context.Context, cl client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return cl.Patch(ctx, obj, patch, opts...)
+	}
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	// Unlike the API server, the fake client would store the apply
+	// configuration's apiVersion and kind.
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	delete(m, "apiVersion")
+	delete(m, "kind")
+	if data, err = json.Marshal(m); err != nil {
+		return err
+	}
+	return cl.Patch(ctx, obj, client.RawPatch(types.MergePatchType, data))
+}
+
 func mustCreate(t *testing.T, client client.Client, obj client.Object) {
 	t.Helper()
 	if err := client.Create(context.Background(), obj); err != nil {
@@ -827,6 +858,8 @@ type fakeTSClient struct {
 	keyRequests []tailscale.KeyCapabilities
 	deleted     []string
 	vipServices map[tailcfg.ServiceName]*tailscale.VIPService
+	// deletedVIPServices records the names passed to DeleteVIPService.
+	deletedVIPServices []tailcfg.ServiceName
 }
 type fakeTSNetServer struct {
 	certDomains []string
@@ -966,6 +999,7 @@ func (c *fakeTSClient) CreateOrUpdateVIPService(ctx context.Context, svc *tailsc
 func (c *fakeTSClient) DeleteVIPService(ctx context.Context, name tailcfg.ServiceName) error {
 	c.Lock()
 	defer c.Unlock()
+	c.deletedVIPServices = append(c.deletedVIPServices, name)
 	if c.vipServices != nil {
 		delete(c.vipServices, name)
 	}
@@ -974,6 +1008,39 @@ func (c *fakeTSClient) DeleteVIPService(ctx context.Context, name tailcfg.Servic
 
 type fakeLocalClient struct {
 	status *ipnstate.Status
+	// unresolvedNames are the DNS names that QueryDNS reports as not
+	// existing. All other names resolve to 100.64.0.1.
+	unresolvedNames []string
+}
+
+func (f *fakeLocalClient) QueryDNS(ctx context.Context, name string, queryType string) ([]byte, []*dnstype.Resolver, error) {
+	fqdn, err := dnsmessage.NewName(name + ".")
+	if err != nil {
+		return nil, nil, err
+	}
+	h := dnsmessage.Header{Response: true, Authoritative: true}
+	if slices.Contains(f.unresolvedNames, name) {
+		h.RCode = dnsmessage.RCodeNameError
+	}
+	b := dnsmessage.NewBuilder(nil, h)
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, nil, err
+	}
+	if err := b.Question(dnsmessage.Question{Name: fqdn, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, nil, err
+	}
+	if h.RCode == dnsmessage.RCodeSuccess {
+		if err := b.StartAnswers(); err != nil {
+			return nil, nil, err
+		}
+		rh := dnsmessage.ResourceHeader{Name: fqdn, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 600}
+		if err := b.AResource(rh, dnsmessage.AResource{A: [4]byte{100, 64, 0, 1}}); err != nil {
+			return nil, nil, err
+		}
+	}
+	msg, err := b.Finish()
+	return msg, nil, err
 }
 
 func (f *fakeLocalClient) StatusWithoutPeers(ctx context.Context) (*ipnstate.Status, error) {
@@ -500,6 +500,9 @@ func genView(buf *bytes.Buffer, it *codegen.ImportTracker, typ *types.Named, fie
 		}
 		writeTemplateWithComment("unsupportedField", fname)
 	}
+	if slices.Contains(nestedAccessorTypes, args.StructName) {
+		genNestedAccessors(buf, it, typ, args.ViewName+args.TypeParamNames)
+	}
 	for i := range typ.NumMethods() {
 		f := typ.Method(i)
 		if !f.Exported() {
@@ -524,7 +527,90 @@ func genView(buf *bytes.Buffer, it *codegen.ImportTracker, typ *types.Named, fie
 		}
 	}
 	fmt.Fprintf(buf, "\n")
-	buf.Write(codegen.AssertStructUnchanged(t, args.StructName, typeParams, "View", it))
+	assertUnchanged := codegen.AssertStructUnchanged
+	if orderedTypes[args.StructName] {
+		assertUnchanged = codegen.AssertStructUnchangedOrdered
+	}
+	buf.Write(assertUnchanged(t, args.StructName, typeParams, "View", it))
+}
+
+// genNestedAccessors writes convenience accessors to the view of typ that
+// traverse nested view fields, such as v.ConfigDNS() for v.ж.Config.DNS.
+// Only paths made up of pointers to named struct types that have (or will
+// have) a view are followed. The accessors are nil-safe: if any pointer along
+// the path is nil, they return an invalid view.
+func genNestedAccessors(buf *bytes.Buffer, it *codegen.ImportTracker, typ *types.Named, viewName string) {
+	outer, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		return
+	}
+	hasName := func(name string) bool {
+		for i := range outer.NumFields() {
+			if outer.Field(i).Name() == name {
+				return true
+			}
+		}
+		return codegen.LookupMethod(typ, name) != nil
+	}
+
+	var walk func(path []string, st *types.Named, seen []*types.Named)
+	walk = func(path []string, st *types.Named, seen []*types.Named) {
+		t, ok := st.Underlying().(*types.Struct)
+		if !ok {
+			return
+		}
+		for i := range t.NumFields() {
+			f := t.Field(i)
+			if !f.Exported() || codegen.HasNoClone(t.Tag(i)) {
+				continue
+			}
+			base, fieldViewName, ok := nestedViewField(it, f.Type())
+			if !ok || slices.Contains(seen, base) {
+				continue
+			}
+			fieldPath := append(slices.Clip(path), f.Name())
+			if name := strings.Join(fieldPath, ""); len(fieldPath) > 1 && !hasName(name) {
+				var nilChecks []string
+				for j := 1; j < len(fieldPath); j++ {
+					nilChecks = append(nilChecks, "v.ж."+strings.Join(fieldPath[:j], ".")+" == nil")
+				}
+				fmt.Fprintf(buf, "// %s returns a read-only view of v.%s.\n", name, strings.Join(fieldPath, "."))
+				fmt.Fprintf(buf, "// It returns an invalid view if any value along the path is nil.\n")
+				fmt.Fprintf(buf, "func (v %s) %s() %s {\n", viewName, name, fieldViewName)
+				fmt.Fprintf(buf, "\tif %s {\n", strings.Join(nilChecks, " || "))
+				fmt.Fprintf(buf, "\t\treturn %s{}\n", fieldViewName)
+				fmt.Fprintf(buf, "\t}\n")
+				fmt.Fprintf(buf, "\treturn v.ж.%s.View()\n", strings.Join(fieldPath, "."))
+				fmt.Fprintf(buf, "}\n\n")
+			}
+			walk(fieldPath, base, append(slices.Clip(seen), base))
+		}
+	}
+	walk(nil, typ, []*types.Named{typ})
+}
+
+// nestedViewField reports whether a field of type ft can be traversed by
+// genNestedAccessors. If so, it returns the named struct type that ft points
+// to and the name of its view type.
+func nestedViewField(it *codegen.ImportTracker, ft types.Type) (base *types.Named, viewName string, ok bool) {
+	ptr, ok := ft.(*types.Pointer)
+	if !ok {
+		return nil, "", false
+	}
+	base, ok = types.Unalias(ptr.Elem()).(*types.Named)
+	if !ok || base.TypeArgs().Len() > 0 {
+		return nil, "", false
+	}
+	if _, ok := base.Underlying().(*types.Struct); !ok || codegen.IsViewType(base) {
+		return nil, "", false
+	}
+	if viewType := viewTypeForValueType(base); viewType != nil && codegen.IsViewType(viewType) {
+		return base, it.QualifiedName(viewType), true
+	}
+	if name := it.QualifiedName(base); slices.Contains(typeNames, name) {
+		return base, appendNameSuffix(name, "View"), true
+	}
+	return nil, "", false
 }
 
 func appendNameSuffix(name, suffix string) string {
@@ -691,7 +777,11 @@ func collectTypeParams(list *types.TypeParamList) []*types.TypeParam {
 
 	flagCloneOnlyTypes = flag.String("clone-only-type", "", "comma-separated list of types (a subset of --type) that should only generate a go:generate clone line and not actual views")
 
-	typeNames []string
+	flagNestedAccessors = flag.String("nested-accessors", "", "comma-separated list of types (a subset of --type) whose views should get accessors for nested view fields")
+
+	typeNames           []string
+	nestedAccessorTypes []string
+	orderedTypes        map[string]bool // types marked //codegen:ordered
 )
 
 func main() {
@@ -703,6 +793,9 @@ func main() {
 		os.Exit(2)
 	}
 	typeNames = strings.Split(*flagTypes, ",")
+	if *flagNestedAccessors != "" {
+		nestedAccessorTypes = strings.Split(*flagNestedAccessors, ",")
+	}
 
 	var flagArgs []string
 	flagArgs = append(flagArgs, fmt.Sprintf("-clonefunc=%v", *flagCloneFunc))
@@ -718,6 +811,7 @@ func main() {
 	}
 	it := codegen.NewImportTracker(pkg.Types)
 	fieldComments := getFieldComments(pkg.Syntax)
+	orderedTypes = codegen.OrderedTypes(pkg.Syntax)
 
 	cloneOnlyType := map[string]bool{}
 	for _, t := range strings.Split(*flagCloneOnlyTypes, ",") {
@@ -7,11 +7,15 @@
 	"bytes"
 	"fmt"
 	"go/ast"
+	"go/format"
 	"go/parser"
 	"go/token"
 	"go/types"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"tailscale.com/cmd/viewer/tests"
 	"tailscale.com/util/codegen"
 )
 
@@ -77,3 +81,152 @@ func TestViewerImports(t *testing.T) {
 		})
 	}
 }
+
+func TestNestedAccessors(t *testing.T) {
+	const content = `
+type Outer struct {
+	Name   string
+	Config *Middle
+}
+
+type Middle struct {
+	Port int
+	DNS  *Inner
+}
+
+type Inner struct {
+	Names    []string
+	Resolver *Leaf
+}
+
+type Leaf struct {
+	Addr string
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "test.go", "package test\n\n"+content, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{}
+	pkg, err := conf.Check("", fset, []*ast.File{f}, &types.Info{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldTypeNames, oldNested := typeNames, nestedAccessorTypes
+	t.Cleanup(func() { typeNames, nestedAccessorTypes = oldTypeNames, oldNested })
+	typeNames = []string{"Outer", "Middle", "Inner", "Leaf"}
+	nestedAccessorTypes = []string{"Outer"}
+
+	outer := pkg.Scope().Lookup("Outer").(*types.TypeName).Type().(*types.Named)
+
+	var buf bytes.Buffer
+	buf.WriteString("package test\n\n")
+	genNestedAccessors(&buf, codegen.NewImportTracker(pkg), outer, "OuterView")
+	got, err := format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, buf.Bytes())
+	}
+
+	const want = `package test
+
+// ConfigDNS returns a read-only view of v.Config.DNS.
+// It returns an invalid view if any value along the path is nil.
+func (v OuterView) ConfigDNS() InnerView {
+	if v.ж.Config == nil {
+		return InnerView{}
+	}
+	return v.ж.Config.DNS.View()
+}
+
+// ConfigDNSResolver returns a read-only view of v.Config.DNS.Resolver.
+// It returns an invalid view if any value along the path is nil.
+func (v OuterView) ConfigDNSResolver() LeafView {
+	if v.ж.Config == nil || v.ж.Config.DNS == nil {
+		return LeafView{}
+	}
+	return v.ж.Config.DNS.Resolver.View()
+}
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("generated code mismatch (-want +got):\n%s", diff)
+	}
+
+	// The accessors are only generated for the requested types.
+	var view bytes.Buffer
+	genView(&view, codegen.NewImportTracker(pkg), outer, nil)
+	if !strings.Contains(view.String(), "func (v OuterView) ConfigDNS() InnerView") {
+		t.Errorf("OuterView is missing nested accessors:\n%s", view.String())
+	}
+	view.Reset()
+	middle := pkg.Scope().Lookup("Middle").(*types.TypeName).Type().(*types.Named)
+	genView(&view, codegen.NewImportTracker(pkg), middle, nil)
+	if strings.Contains(view.String(), "DNSResolver()") {
+		t.Errorf("MiddleView unexpectedly has nested accessors:\n%s", view.String())
+	}
+}
+
+func TestAsStruct(t *testing.T) {
+	const content = `
+type Owned struct {
+	Names []string
+}
+
+func (src *Owned) Clone() *Owned { return src }
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "test.go", "package test\n\n"+content, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := new(types.Config).Check("", fset, []*ast.File{f}, &types.Info{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	owned := pkg.Scope().Lookup("Owned").(*types.TypeName).Type().(*types.Named)
+
+	var buf bytes.Buffer
+	buf.WriteString("package test\n\n")
+	genView(&buf, codegen.NewImportTracker(pkg), owned, nil)
+	gen, err := format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, buf.Bytes())
+	}
+	// AsStruct materializes an owned value by reusing the type's Clone
+	// method.
+	const want = `// AsStruct returns a clone of the underlying value which aliases no memory with
+// the original.
+func (v OwnedView) AsStruct() *Owned {
+	if v.ж == nil {
+		return nil
+	}
+	return v.ж.Clone()
+}
+`
+	if got := string(gen); !strings.Contains(got, want) {
+		t.Errorf("generated view is missing AsStruct:\n%s\nwant:\n%s", got, want)
+	}
+
+	// The materialized value is fully independent of the original.
+	n := 1
+	orig := &tests.Map{
+		SliceInt:         map[string][]int{"a": {1, 2}},
+		StructPtrWithPtr: map[string]*tests.StructWithPtrs{"a": {Int: &n, Value: &tests.StructWithoutPtrs{Int: 1}}},
+	}
+	v := orig.View()
+	if (tests.MapView{}).AsStruct() != nil {
+		t.Error("AsStruct of an invalid view is not nil")
+	}
+	got := v.AsStruct()
+	got.SliceInt["a"][0] = 100
+	*got.StructPtrWithPtr["a"].Int = 100
+	got.StructPtrWithPtr["a"].Value.Int = 100
+	got.SliceInt["b"] = nil
+	if orig.SliceInt["a"][0] != 1 || n != 1 || orig.StructPtrWithPtr["a"].Value.Int != 1 {
+		t.Errorf("AsStruct result aliases the original: %+v", orig)
+	}
+	if _, ok := orig.SliceInt["b"]; ok {
+		t.Error("AsStruct result shares a map with the original")
+	}
+}
@@ -8,6 +8,7 @@
 import (
 	"maps"
 	"net/netip"
+	"time"
 
 	"tailscale.com/drive"
 	"tailscale.com/tailcfg"
@@ -221,11 +222,14 @@ func (src *TCPPortHandler) Clone() *TCPPortHandler {
 
 // A compilation failure here means this code must be regenerated, with the command at the top of this file.
 var _TCPPortHandlerCloneNeedsRegeneration = TCPPortHandler(struct {
-	HTTPS         bool
-	HTTP          bool
-	TCPForward    string
-	TerminateTLS  string
-	ProxyProtocol int
+	HTTPS             bool
+	HTTP              bool
+	TCPForward        string
+	TerminateTLS      string
+	ProxyProtocol     int
+	KeepAliveIdle     time.Duration
+	KeepAliveInterval time.Duration
+	HTTP1Only         bool
 }{})
 
 // Clone makes a deep copy of HTTPHandler.
@@ -237,16 +241,24 @@ func (src *HTTPHandler) Clone() *HTTPHandler {
 	dst := new(HTTPHandler)
 	*dst = *src
 	dst.AcceptAppCaps = append(src.AcceptAppCaps[:0:0], src.AcceptAppCaps...)
+	dst.ResponseHeaders = maps.Clone(src.ResponseHeaders)
+	dst.RetryStatusCodes = append(src.RetryStatusCodes[:0:0], src.RetryStatusCodes...)
+	dst.ProxyTargets = append(src.ProxyTargets[:0:0], src.ProxyTargets...)
 	return dst
 }
 
 // A compilation failure here means this code must be regenerated, with the command at the top of this file.
 var _HTTPHandlerCloneNeedsRegeneration = HTTPHandler(struct {
-	Path          string
-	Proxy         string
-	Text          string
-	AcceptAppCaps []tailcfg.PeerCapability
-	Redirect      string
+	Path             string
+	Proxy            string
+	Text             string
+	AcceptAppCaps    []tailcfg.PeerCapability
+	Redirect         string
+	MaxConns         int
+	ResponseHeaders  map[string]string
+	BackendRetries   int
+	RetryStatusCodes []int
+	ProxyTargets     []string
 }{})
 
 // Clone makes a deep copy of WebServerConfig.
@@ -272,5 +284,7 @@ func (src *WebServerConfig) Clone() *WebServerConfig {
 
 // A compilation failure here means this code must be regenerated, with the command at the top of this file.
 var _WebServerConfigCloneNeedsRegeneration = WebServerConfig(struct {
-	Handlers map[string]*HTTPHandler
+	Handlers  map[string]*HTTPHandler
+	AccessLog bool
+	ClientCAs string
 }{})
@@ -9,6 +9,7 @@
 	jsonv1 "encoding/json"
 	"errors"
 	"net/netip"
+	"time"
 
 	jsonv2 "github.com/go-json-experiment/json"
 	"github.com/go-json-experiment/json/jsontext"
@@ -826,13 +827,33 @@ func (v TCPPortHandlerView) TerminateTLS() string { return v.ж.TerminateTLS }
 // This is only valid if TCPForward is non-empty.
 func (v TCPPortHandlerView) ProxyProtocol() int { return v.ж.ProxyProtocol }
 
+// KeepAliveIdle, if positive, is how long a connection to a backend
+// must be idle before TCP keep-alive probes are sent on it.
+// KeepAliveInterval, if positive, is the time between probes. If zero,
+// the system defaults are used.
+//
+// They apply to the connection to TCPForward and, for HTTPS and HTTP
+// handlers, to connections to HTTPHandler.Proxy backends.
+func (v TCPPortHandlerView) KeepAliveIdle() time.Duration     { return v.ж.KeepAliveIdle }
+func (v TCPPortHandlerView) KeepAliveInterval() time.Duration { return v.ж.KeepAliveInterval }
+
+// HTTP1Only, if true, means that connections are served over HTTP/1.1
+// only, for clients that break on HTTP/2: HTTP/2 is not offered to
+// clients via ALPN.
+//
+// This is only valid if HTTPS is true.
+func (v TCPPortHandlerView) HTTP1Only() bool { return v.ж.HTTP1Only }
+
 // A compilation failure here means this code must be regenerated, with the command at the top of this file.
 var _TCPPortHandlerViewNeedsRegeneration = TCPPortHandler(struct {
-	HTTPS         bool
-	HTTP          bool
-	TCPForward    string
-	TerminateTLS  string
-	ProxyProtocol int
+	HTTPS             bool
+	HTTP              bool
+	TCPForward        string
+	TerminateTLS      string
+	ProxyProtocol     int
+	KeepAliveIdle     time.Duration
+	KeepAliveInterval time.Duration
+	HTTP1Only         bool
 }{})
 
 // View returns a read-only view of HTTPHandler.
@@ -925,13 +946,52 @@ func (v HTTPHandlerView) AcceptAppCaps() views.Slice[tailcfg.PeerCapability] {
 //   - ${REQUEST_URI}: replaced with the request's full URI (path and query string)
 func (v HTTPHandlerView) Redirect() string { return v.ж.Redirect }
 
+// MaxConns, if positive, is the maximum number of requests that this
+// handler may proxy concurrently. Requests beyond the limit are rejected
+// with HTTP 503 (Service Unavailable). Handlers are limited separately,
+// even if they proxy to the same backend. It is only used if Proxy is
+// non-empty.
+func (v HTTPHandlerView) MaxConns() int { return v.ж.MaxConns }
+
+// ResponseHeaders, if non-empty, are HTTP headers to set on responses
+// served by this handler, keyed by header name. Headers with an empty
+// value are removed from responses instead.
+func (v HTTPHandlerView) ResponseHeaders() views.Map[string, string] {
+	return views.MapOf(v.ж.ResponseHeaders)
+}
+
+// BackendRetries, if positive, is the maximum number of times a request
+// to Proxy is retried if the backend cannot be reached, or responds with
+// one of RetryStatusCodes. Only requests with an idempotent method and no
+// body are retried. It is only used if Proxy is non-empty.
+func (v HTTPHandlerView) BackendRetries() int { return v.ж.BackendRetries }
+
+// RetryStatusCodes are the HTTP status codes of backend responses that
+// requests are retried for, if BackendRetries is positive. If empty,
+// requests are retried for 502, 503 and 504 responses.
+func (v HTTPHandlerView) RetryStatusCodes() views.Slice[int] {
+	return views.SliceOf(v.ж.RetryStatusCodes)
+}
+
+// ProxyTargets, if non-empty, are further backends in the same form as
+// Proxy. Requests are load balanced across Proxy and ProxyTargets in
+// round-robin order, separately for each handler. MaxConns limits the
+// requests to all of them together. It is only used if Proxy is
+// non-empty.
+func (v HTTPHandlerView) ProxyTargets() views.Slice[string] { return views.SliceOf(v.ж.ProxyTargets) }
+
 // A compilation failure here means this code must be regenerated, with the command at the top of this file.
 var _HTTPHandlerViewNeedsRegeneration = HTTPHandler(struct {
-	Path          string
-	Proxy         string
-	Text          string
-	AcceptAppCaps []tailcfg.PeerCapability
-	Redirect      string
+	Path             string
+	Proxy            string
+	Text             string
+	AcceptAppCaps    []tailcfg.PeerCapability
+	Redirect         string
+	MaxConns         int
+	ResponseHeaders  map[string]string
+	BackendRetries   int
+	RetryStatusCodes []int
+	ProxyTargets     []string
 }{})
 
 // View returns a read-only view of WebServerConfig.
@@ -1008,7 +1068,23 @@ func (v WebServerConfigView) Handlers() views.MapFn[string, *HTTPHandler, HTTPHa
 	})
 }
 
+// AccessLog, if true, means that tailscaled writes an access log entry
+// for each request to this host, with the client's identity, the
+// request path and the response status. Entries are written as JSON
+// lines to stderr, or to the file named by TS_SERVE_ACCESS_LOG_FILE,
+// and not to the regular logs, from where they can be shipped to a log
+// sink.
+func (v WebServerConfigView) AccessLog() bool { return v.ж.AccessLog }
+
+// ClientCAs, if non-empty, is a PEM-encoded bundle of CA certificates.
+// HTTPS clients of this host must then present a TLS client
+// certificate that is signed by one of them. Connections are rejected
+// if the bundle contains no valid certificates.
+func (v WebServerConfigView) ClientCAs() string { return v.ж.ClientCAs }
+
 // A compilation failure here means this code must be regenerated, with the command at the top of this file.
 var _WebServerConfigViewNeedsRegeneration = WebServerConfig(struct {
-	Handlers map[string]*HTTPHandler
+	Handlers  map[string]*HTTPHandler
+	AccessLog bool
+	ClientCAs string
 }{})
@@ -9,6 +9,7 @@
 	"cmp"
 	"context"
 	"crypto/sha256"
+	"crypto/x509"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
@@ -310,15 +311,17 @@ type LocalBackend struct {
 	capForcedNetfilter string // TODO(nickkhyl): move to nodeBackend
 
 	// ServeConfig fields. (also guarded by mu)
-	lastServeConfJSON mem.RO                   // last JSON that was parsed into serveConfig
-	serveConfig       ipn.ServeConfigView      // or !Valid if none
-	ipVIPServiceMap   netmap.IPServiceMappings // map of VIPService IPs to their corresponding service names; TODO(nickkhyl): move to nodeBackend
+	lastServeConfJSON mem.RO                    // last JSON that was parsed into serveConfig
+	serveConfig       ipn.ServeConfigView       // or !Valid if none
+	serveClientCAs    map[string]*x509.CertPool // WebServerConfig.ClientCAs in serveConfig => its parsed certs, or nil if none are valid
+	ipVIPServiceMap   netmap.IPServiceMappings  // map of VIPService IPs to their corresponding service names; TODO(nickkhyl): move to nodeBackend
 
 	webClient          webClient
 	webClientListeners map[netip.AddrPort]*localListener // listeners for local web client traffic
 
 	serveListeners     map[netip.AddrPort]*localListener // listeners for local serve traffic
 	serveProxyHandlers sync.Map                          // string (HTTPHandler.Proxy) => *reverseProxy
+	serveHandlers      sync.Map                          // serveHandlerKey => *serveHandlerState
 
 	// dialPlan is any dial plan that we've received from the control
 	// server during a previous connection; it is cleared on logout.
@@ -11,11 +11,13 @@
 	"context"
 	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"maps"
 	"mime"
 	"net"
@@ -35,6 +37,7 @@
 
 	"github.com/pires/go-proxyproto"
 	"go4.org/mem"
+	"tailscale.com/envknob"
 	"tailscale.com/ipn"
 	"tailscale.com/net/netutil"
 	"tailscale.com/syncs"
@@ -83,10 +86,20 @@ type serveHTTPContext struct {
 	ForVIPService tailcfg.ServiceName // "" means local
 	DestPort      uint16
 
+	// BackendKeepAlive is the TCP keep-alive configuration for connections
+	// to proxied backends, as set in the TCP handler for DestPort. It is
+	// unused if its Enable field is false.
+	BackendKeepAlive net.KeepAliveConfig
+
 	// provides funnel-specific context, nil if not funneled
 	Funnel *funnelFlow
 	// AppCapabilities lists all PeerCapabilities that should be forwarded by serve
 	AppCapabilities views.Slice[tailcfg.PeerCapability]
+	// BackendRetries and RetryStatusCodes are the retry configuration of
+	// the HTTP handler that the request is proxied by, as set in
+	// HTTPHandler.BackendRetries and HTTPHandler.RetryStatusCodes.
+	BackendRetries   int
+	RetryStatusCodes views.Slice[int]
 }
 
 // funnelFlow represents a funneled connection initiated via IngressPeer
@@ -535,9 +548,10 @@ func (b *LocalBackend) tcpHandlerForVIPService(dstAddr, srcAddr netip.AddrPort)
 			Handler: http.HandlerFunc(b.serveWebHandler),
 			BaseContext: func(_ net.Listener) context.Context {
 				return serveHTTPContextKey.WithValue(context.Background(), &serveHTTPContext{
-					SrcAddr:       srcAddr,
-					ForVIPService: dstSvc,
-					DestPort:      dport,
+					SrcAddr:          srcAddr,
+					ForVIPService:    dstSvc,
+					DestPort:         dport,
+					BackendKeepAlive: backendKeepAlive(tcph),
 				})
 			},
 		}
@@ -545,9 +559,8 @@ func (b *LocalBackend) tcpHandlerForVIPService(dstAddr, srcAddr netip.AddrPort)
 			// TODO(kevinliang10): just leaving this TLS cert creation as if we don't have other
 			// hostnames, but for services this getTLSServeCetForPort will need a version that also take
 			// in the hostname. How to store the TLS cert is still being discussed.
-			hs.TLSConfig = &tls.Config{
-				GetCertificate: b.getTLSServeCertForPort(dport, dstSvc),
-			}
+			hs.TLSConfig = b.serveTLSConfig(dport, dstSvc, tcph.HTTP1Only())
+			setHTTP1Only(hs, tcph)
 			return func(c net.Conn) error {
 				return hs.ServeTLS(netutil.NewOneConnListener(c, nil), "", "")
 			}
@@ -569,6 +582,9 @@ func (b *LocalBackend) tcpHandlerForVIPService(dstAddr, srcAddr netip.AddrPort)
 				return nil
 			}
 			defer backConn.Close()
+			if ka := backendKeepAlive(tcph); ka.Enable {
+				b.setBackendKeepAlive(backConn, ka)
+			}
 			if sni := tcph.TerminateTLS(); sni != "" {
 				conn = tls.Server(conn, &tls.Config{
 					GetCertificate: func(hi *tls.ClientHelloInfo) (*tls.Certificate, error) {
@@ -625,16 +641,16 @@ func (b *LocalBackend) tcpHandlerForServe(dport uint16, srcAddr netip.AddrPort,
 			Handler: http.HandlerFunc(b.serveWebHandler),
 			BaseContext: func(_ net.Listener) context.Context {
 				return serveHTTPContextKey.WithValue(context.Background(), &serveHTTPContext{
-					Funnel:   f,
-					SrcAddr:  srcAddr,
-					DestPort: dport,
+					Funnel:           f,
+					SrcAddr:          srcAddr,
+					DestPort:         dport,
+					BackendKeepAlive: backendKeepAlive(tcph),
 				})
 			},
 		}
 		if tcph.HTTPS() {
-			hs.TLSConfig = &tls.Config{
-				GetCertificate: b.getTLSServeCertForPort(dport, ""),
-			}
+			hs.TLSConfig = b.serveTLSConfig(dport, "", tcph.HTTP1Only())
+			setHTTP1Only(hs, tcph)
 			return func(c net.Conn) error {
 				return hs.ServeTLS(netutil.NewOneConnListener(c, nil), "", "")
 			}
@@ -656,6 +672,9 @@ func (b *LocalBackend) tcpHandlerForServe(dport uint16, srcAddr netip.AddrPort,
 				return nil
 			}
 			defer backConn.Close()
+			if ka := backendKeepAlive(tcph); ka.Enable {
+				b.setBackendKeepAlive(backConn, ka)
+			}
 			if sni := tcph.TerminateTLS(); sni != "" {
 				conn = tls.Server(conn, &tls.Config{
 					GetCertificate: func(hi *tls.ClientHelloInfo) (*tls.Certificate, error) {
@@ -763,29 +782,12 @@ func (b *LocalBackend) tcpHandlerForServe(dport uint16, srcAddr netip.AddrPort,
 	return nil
 }
 
-func (b *LocalBackend) getServeHandler(r *http.Request) (_ ipn.HTTPHandlerView, at string, ok bool) {
+// getServeHandler returns the handler of wsc that serves r, and its mount
+// point.
+func (b *LocalBackend) getServeHandler(wsc ipn.WebServerConfigView, r *http.Request) (_ ipn.HTTPHandlerView, at string, ok bool) {
 	var z ipn.HTTPHandlerView // zero value
 
-	hostname := r.Host
-	if r.TLS == nil {
-		tcd := "." + b.CurrentProfile().NetworkProfile().MagicDNSName
-		if host, _, err := net.SplitHostPort(hostname); err == nil {
-			hostname = host
-		}
-		if !strings.HasSuffix(hostname, tcd) {
-			hostname += tcd
-		}
-	} else {
-		hostname = r.TLS.ServerName
-	}
-
-	sctx, ok := serveHTTPContextKey.ValueOk(r.Context())
-	if !ok {
-		b.logf("[unexpected] localbackend: no serveHTTPContext in request")
-		return z, "", false
-	}
-	wsc, ok := b.webServerConfig(hostname, sctx.ForVIPService, sctx.DestPort)
-	if !ok {
+	if !wsc.Valid() {
 		return z, "", false
 	}
 
@@ -808,6 +810,32 @@ func (b *LocalBackend) getServeHandler(r *http.Request) (_ ipn.HTTPHandlerView,
 	}
 }
 
+// webServerConfigForRequest returns the WebServerConfig of the host and port
+// that r was received on, and its key in the serve config.
+func (b *LocalBackend) webServerConfigForRequest(r *http.Request) (_ ipn.WebServerConfigView, key ipn.HostPort, ok bool) {
+	var z ipn.WebServerConfigView // zero value
+
+	hostname := r.Host
+	if r.TLS == nil {
+		tcd := "." + b.CurrentProfile().NetworkProfile().MagicDNSName
+		if host, _, err := net.SplitHostPort(hostname); err == nil {
+			hostname = host
+		}
+		if !strings.HasSuffix(hostname, tcd) {
+			hostname += tcd
+		}
+	} else {
+		hostname = r.TLS.ServerName
+	}
+
+	sctx, ok := serveHTTPContextKey.ValueOk(r.Context())
+	if !ok {
+		b.logf("[unexpected] localbackend: no serveHTTPContext in request")
+		return z, "", false
+	}
+	return b.webServerConfigWithKey(hostname, sctx.ForVIPService, sctx.DestPort)
+}
+
 // proxyHandlerForBackend creates a new HTTP reverse proxy for a particular backend that
 // we serve requests for. `backend` is a HTTPHandler.Proxy string (url, hostport or just port).
 func (b *LocalBackend) proxyHandlerForBackend(backend string) (http.Handler, error) {
@@ -846,6 +874,35 @@ type reverseProxy struct {
 	closed atomic.Bool
 }
 
+// serveHandlerKey identifies an HTTPHandler in the serve config by the key of
+// its WebServerConfig and its mount point.
+type serveHandlerKey struct {
+	hp    ipn.HostPort
+	mount string
+}
+
+// serveHandlerState is the state of an HTTPHandler that outlives a request.
+// Handlers that proxy to the same backends have separate states.
+type serveHandlerState struct {
+	// inFlight is the number of requests in flight to the handler's
+	// backends, for enforcing its MaxConns.
+	inFlight atomic.Int64
+	// next is the number of requests that have been load balanced across
+	// the handler's Proxy and ProxyTargets. It selects the backend for the
+	// next request.
+	next atomic.Uint64
+}
+
+// serveHandler returns the state of the HTTPHandler identified by k,
+// creating it on the handler's first request.
+func (b *LocalBackend) serveHandler(k serveHandlerKey) *serveHandlerState {
+	if v, ok := b.serveHandlers.Load(k); ok {
+		return v.(*serveHandlerState)
+	}
+	v, _ := b.serveHandlers.LoadOrStore(k, new(serveHandlerState))
+	return v.(*serveHandlerState)
+}
+
 // close ensures that any open backend connections get closed.
 func (rp *reverseProxy) close() {
 	rp.closed.Store(true)
@@ -898,15 +955,96 @@ func (rp *reverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	} else {
 		p.Transport = rp.getTransport()
 	}
+	if c, ok := serveHTTPContextKey.ValueOk(r.Context()); ok && c.BackendRetries > 0 {
+		p.Transport = &retryTransport{
+			rt:          p.Transport,
+			logf:        rp.logf,
+			retries:     c.BackendRetries,
+			statusCodes: c.RetryStatusCodes,
+		}
+	}
 	p.ServeHTTP(w, r)
 }
 
+// defaultRetryStatusCodes are the backend response status codes that requests
+// are retried for if HTTPHandler.RetryStatusCodes is empty.
+var defaultRetryStatusCodes = views.SliceOf([]int{
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+})
+
+// retryBackoff is the delay before the first retry of a request to a backend.
+// It grows linearly with each further retry.
+var retryBackoff = 50 * time.Millisecond
+
+// retryTransport is an http.RoundTripper that retries requests to a backend
+// that cannot be reached, or that responds with one of statusCodes, up to
+// retries times. Only requests that can be safely replayed, with an
+// idempotent method and no body, are retried.
+type retryTransport struct {
+	rt          http.RoundTripper
+	logf        logger.Logf
+	retries     int
+	statusCodes views.Slice[int] // if empty, defaultRetryStatusCodes
+}
+
+func (t *retryTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if !isReplayable(r) {
+		return t.rt.RoundTrip(r)
+	}
+	for attempt := 1; ; attempt++ {
+		res, err := t.rt.RoundTrip(r)
+		if attempt > t.retries || !t.shouldRetry(res, err) {
+			return res, err
+		}
+		if err != nil {
+			t.logf("serve: retrying request to backend (%d/%d) after error: %v", attempt, t.retries, err)
+		} else {
+			t.logf("serve: retrying request to backend (%d/%d) after status %d", attempt, t.retries, res.StatusCode)
+			// Drain some of the body, so that the connection can be
+			// reused.
+			io.CopyN(io.Discard, res.Body, 4<<10)
+			res.Body.Close()
+		}
+		select {
+		case <-r.Context().Done():
+			return nil, r.Context().Err()
+		case <-time.After(time.Duration(attempt) * retryBackoff):
+		}
+	}
+}
+
+// shouldRetry reports whether a request that got res or err from the backend
+// should be retried.
+func (t *retryTransport) shouldRetry(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	codes := t.statusCodes
+	if codes.Len() == 0 {
+		codes = defaultRetryStatusCodes
+	}
+	return views.SliceContains(codes, res.StatusCode)
+}
+
+// isReplayable reports whether r can be sent to the backend again after a
+// failed attempt: its method is idempotent and it has no body.
+func isReplayable(r *http.Request) bool {
+	switch r.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	return r.Body == nil || r.Body == http.NoBody
+}
+
 // getTransport returns the Transport used for regular (non-GRPC) requests
 // to the backend. The Transport gets created lazily, at most once.
 func (rp *reverseProxy) getTransport() *http.Transport {
 	return rp.httpTransport.Get(func() *http.Transport {
 		return &http.Transport{
-			DialContext: rp.lb.dialer.SystemDial,
+			DialContext: rp.dialBackend,
 			TLSClientConfig: &tls.Config{
 				InsecureSkipVerify: rp.insecure,
 			},
@@ -929,13 +1067,71 @@ func (rp *reverseProxy) getH2CTransport() http.RoundTripper {
 		tr := &http.Transport{
 			Protocols: &p,
 			DialTLSContext: func(ctx context.Context, network string, addr string) (net.Conn, error) {
-				return rp.lb.dialer.SystemDial(ctx, "tcp", rp.url.Host)
+				return rp.dialBackend(ctx, "tcp", rp.url.Host)
 			},
 		}
 		return tr
 	})
 }
 
+// dialBackend dials a connection to the backend. If the request that the
+// connection is dialed for was received on a TCP handler that configures
+// keep-alives for backend connections, they are applied to the connection.
+func (rp *reverseProxy) dialBackend(ctx context.Context, network, addr string) (net.Conn, error) {
+	c, err := rp.lb.dialer.SystemDial(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	if sctx, ok := serveHTTPContextKey.ValueOk(ctx); ok && sctx.BackendKeepAlive.Enable {
+		rp.lb.setBackendKeepAlive(c, sctx.BackendKeepAlive)
+	}
+	return c, nil
+}
+
+// backendKeepAlive returns the TCP keep-alive configuration for connections
+// to the backends of tcph. Its Enable field is false if tcph does not
+// configure keep-alives.
+func backendKeepAlive(tcph ipn.TCPPortHandlerView) net.KeepAliveConfig {
+	idle, interval := tcph.KeepAliveIdle(), tcph.KeepAliveInterval()
+	if idle <= 0 && interval <= 0 {
+		return net.KeepAliveConfig{}
+	}
+	// Negative values leave the system defaults in place.
+	ka := net.KeepAliveConfig{Enable: true, Idle: -1, Interval: -1, Count: -1}
+	if idle > 0 {
+		ka.Idle = idle
+	}
+	if interval > 0 {
+		ka.Interval = interval
+	}
+	return ka
+}
+
+// setHTTP1Only configures hs to serve HTTP/1.1 only, without offering HTTP/2
+// to clients via ALPN, if tcph asks for it.
+func setHTTP1Only(hs *http.Server, tcph ipn.TCPPortHandlerView) {
+	if !tcph.HTTP1Only() {
+		return
+	}
+	var p http.Protocols
+	p.SetHTTP1(true)
+	hs.Protocols = &p
+}
+
+// setBackendKeepAlive applies the TCP keep-alive configuration ka to c, a
+// connection to a backend, if c supports it.
+func (b *LocalBackend) setBackendKeepAlive(c net.Conn, ka net.KeepAliveConfig) {
+	kc, ok := c.(interface {
+		SetKeepAliveConfig(net.KeepAliveConfig) error
+	})
+	if !ok {
+		return
+	}
+	if err := kc.SetKeepAliveConfig(ka); err != nil {
+		b.logf("serve: failed to set TCP keep-alive for backend connection to %v: %v", c.RemoteAddr(), err)
+	}
+}
+
 // This is not a generally reliable way how to determine whether a request is
 // for a h2c server, but sufficient for our particular use case.
 func (rp *reverseProxy) shouldProxyViaH2C(r *http.Request) bool {
@@ -1059,11 +1255,24 @@ func parseRedirectWithCode(redirect string) (code int, url string) {
 // serveWebHandler is an http.HandlerFunc that maps incoming requests to the
 // correct *http.
 func (b *LocalBackend) serveWebHandler(w http.ResponseWriter, r *http.Request) {
-	h, mountPoint, ok := b.getServeHandler(r)
+	wsc, wscKey, ok := b.webServerConfigForRequest(r)
 	if !ok {
 		http.NotFound(w, r)
 		return
 	}
+	if wsc.AccessLog() {
+		lw := &accessLogWriter{ResponseWriter: w}
+		w = lw
+		defer b.logServeAccess(r, lw, time.Now())
+	}
+	h, mountPoint, ok := b.getServeHandler(wsc, r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if hdrs := h.ResponseHeaders(); hdrs.Len() > 0 {
+		w = &responseHeaderWriter{ResponseWriter: w, headers: hdrs}
+	}
 	if s := h.Text(); s != "" {
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 		io.WriteString(w, s)
@@ -1086,13 +1295,40 @@ func (b *LocalBackend) serveWebHandler(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "unknown proxy destination", http.StatusInternalServerError)
 			return
 		}
+		// Only handlers that limit or load balance their requests have
+		// state; see setServeProxyHandlersLocked.
+		var hs *serveHandlerState
+		if h.MaxConns() > 0 || h.ProxyTargets().Len() > 0 {
+			hs = b.serveHandler(serveHandlerKey{wscKey, mountPoint})
+		}
+		if targets := h.ProxyTargets(); targets.Len() > 0 {
+			// Load balance round-robin across Proxy and ProxyTargets.
+			n := uint64(targets.Len() + 1)
+			if i := (hs.next.Add(1) - 1) % n; i > 0 {
+				v = targets.At(int(i) - 1)
+				if p, ok = b.serveProxyHandlers.Load(v); !ok {
+					http.Error(w, "unknown proxy destination", http.StatusInternalServerError)
+					return
+				}
+			}
+		}
 		// Inject app capabilities to forward into the request context
 		c, ok := serveHTTPContextKey.ValueOk(r.Context())
 		if !ok {
 			return
 		}
 		c.AppCapabilities = h.AcceptAppCaps()
-		h := p.(http.Handler)
+		c.BackendRetries = h.BackendRetries()
+		c.RetryStatusCodes = h.RetryStatusCodes()
+		if max := h.MaxConns(); max > 0 {
+			if hs.inFlight.Add(1) > int64(max) {
+				hs.inFlight.Add(-1)
+				http.Error(w, "too many concurrent connections to backend", http.StatusServiceUnavailable)
+				return
+			}
+			defer hs.inFlight.Add(-1)
+		}
+		h := http.Handler(p.(*reverseProxy))
 		// Trim the mount point from the URL path before proxying. (#6571)
 		if r.URL.Path != "/" {
 			h = http.StripPrefix(strings.TrimSuffix(mountPoint, "/"), h)
@@ -1104,6 +1340,143 @@ func (b *LocalBackend) serveWebHandler(w http.ResponseWriter, r *http.Request) {
 	http.Error(w, "empty handler", 500)
 }
 
+// responseHeaderWriter is an http.ResponseWriter that sets the configured
+// HTTPHandler.ResponseHeaders just before the response header is written, so
+// that they take precedence over headers set by the handler or the backend.
+type responseHeaderWriter struct {
+	http.ResponseWriter
+	headers views.Map[string, string]
+	wrote   bool
+}
+
+func (w *responseHeaderWriter) setHeaders() {
+	if w.wrote {
+		return
+	}
+	w.wrote = true
+	h := w.Header()
+	for k, v := range w.headers.All() {
+		if v == "" {
+			h.Del(k)
+		} else {
+			h.Set(k, v)
+		}
+	}
+}
+
+func (w *responseHeaderWriter) WriteHeader(code int) {
+	w.setHeaders()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseHeaderWriter) Write(b []byte) (int, error) {
+	w.setHeaders()
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (w *responseHeaderWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// serveAccessLogEntry is an entry of the access log of a serve host with
+// WebServerConfig.AccessLog set.
+type serveAccessLogEntry struct {
+	Time     time.Time
+	Host     string
+	Src      netip.AddrPort
+	User     string   `json:",omitempty"` // login name of the client's user, if not tagged
+	Node     string   `json:",omitempty"` // name of the client's node
+	Tags     []string `json:",omitempty"` // tags of the client's node
+	Funnel   bool     `json:",omitempty"` // whether the request came over Funnel
+	Method   string
+	Path     string
+	Status   int
+	Bytes    int64
+	Duration time.Duration
+}
+
+// serveAccessLogWriter returns where serve access log entries are written:
+// the file named by TS_SERVE_ACCESS_LOG_FILE, or stderr. Both bypass the
+// regular logs, which may be uploaded, as entries include client identities.
+// It is a variable for tests.
+var serveAccessLogWriter = sync.OnceValue(func() io.Writer {
+	name := envknob.String("TS_SERVE_ACCESS_LOG_FILE")
+	if name == "" {
+		return os.Stderr
+	}
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		log.Printf("serve: opening access log file: %v; writing access logs to stderr", err)
+		return os.Stderr
+	}
+	return f
+})
+
+// logServeAccess writes the access log entry for the request r, which started
+// at start and whose response was written to w.
+func (b *LocalBackend) logServeAccess(r *http.Request, w *accessLogWriter, start time.Time) {
+	e := serveAccessLogEntry{
+		Time:     start.UTC(),
+		Host:     r.Host,
+		Method:   r.Method,
+		Path:     r.URL.Path,
+		Status:   w.status,
+		Bytes:    w.bytes,
+		Duration: time.Since(start),
+	}
+	if r.TLS != nil {
+		e.Host = r.TLS.ServerName
+	}
+	if e.Status == 0 {
+		e.Status = http.StatusOK
+	}
+	if c, ok := serveHTTPContextKey.ValueOk(r.Context()); ok {
+		e.Src = c.SrcAddr
+		e.Funnel = c.Funnel != nil
+		if node, user, ok := b.WhoIs("tcp", c.SrcAddr); ok {
+			e.Node = node.Name()
+			if node.IsTagged() {
+				e.Tags = node.Tags().AsSlice()
+			} else {
+				e.User = user.LoginName
+			}
+		}
+	}
+	j, err := json.Marshal(e)
+	if err != nil {
+		b.logf("serve: marshalling access log entry: %v", err)
+		return
+	}
+	serveAccessLogWriter().Write(append(j, '\n'))
+}
+
+// accessLogWriter is an http.ResponseWriter that records the response status
+// and size for the access log.
+type accessLogWriter struct {
+	http.ResponseWriter
+	status int // or 0 if WriteHeader has not been called
+	bytes  int64
+}
+
+func (w *accessLogWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *accessLogWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (w *accessLogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func (b *LocalBackend) serveFileOrDirectory(w http.ResponseWriter, r *http.Request, fileOrDir, mountPoint string) {
 	fi, err := os.Stat(fileOrDir)
 	if err != nil {
@@ -1206,20 +1579,29 @@ func allNumeric(s string) bool {
 }
 
 func (b *LocalBackend) webServerConfig(hostname string, forVIPService tailcfg.ServiceName, port uint16) (c ipn.WebServerConfigView, ok bool) {
+	c, _, ok = b.webServerConfigWithKey(hostname, forVIPService, port)
+	return c, ok
+}
+
+// webServerConfigWithKey is like webServerConfig, but also returns the key of
+// the WebServerConfig in the serve config.
+func (b *LocalBackend) webServerConfigWithKey(hostname string, forVIPService tailcfg.ServiceName, port uint16) (c ipn.WebServerConfigView, key ipn.HostPort, ok bool) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
 	if !b.serveConfig.Valid() {
-		return c, false
+		return c, "", false
 	}
 	if forVIPService != "" {
 		magicDNSSuffix := b.currentNode().NetMap().MagicDNSSuffix()
 		fqdn := strings.Join([]string{forVIPService.WithoutPrefix(), magicDNSSuffix}, ".")
-		key := ipn.HostPort(net.JoinHostPort(fqdn, fmt.Sprintf("%d", port)))
-		return b.serveConfig.FindServiceWeb(forVIPService, key)
+		key = ipn.HostPort(net.JoinHostPort(fqdn, fmt.Sprintf("%d", port)))
+		c, ok = b.serveConfig.FindServiceWeb(forVIPService, key)
+		return c, key, ok
 	}
-	key := ipn.HostPort(net.JoinHostPort(hostname, fmt.Sprintf("%d", port)))
-	return b.serveConfig.FindWeb(key)
+	key = ipn.HostPort(net.JoinHostPort(hostname, fmt.Sprintf("%d", port)))
+	c, ok = b.serveConfig.FindWeb(key)
+	return c, key, ok
 }
 
 func (b *LocalBackend) getTLSServeCertForPort(port uint16, forVIPService tailcfg.ServiceName) func(hi *tls.ClientHelloInfo) (*tls.Certificate, error) {
@@ -1246,6 +1628,41 @@ func (b *LocalBackend) getTLSServeCertForPort(port uint16, forVIPService tailcfg
 	}
 }
 
+// serveTLSConfig returns the TLS config for HTTPS connections to port. If the
+// WebServerConfig for the SNI name of a connection has ClientCAs set, the
+// client must present a certificate signed by one of them.
+func (b *LocalBackend) serveTLSConfig(port uint16, forVIPService tailcfg.ServiceName, http1Only bool) *tls.Config {
+	getCert := b.getTLSServeCertForPort(port, forVIPService)
+	return &tls.Config{
+		GetCertificate: getCert,
+		GetConfigForClient: func(hi *tls.ClientHelloInfo) (*tls.Config, error) {
+			if hi == nil || hi.ServerName == "" {
+				return nil, nil // GetCertificate rejects the connection
+			}
+			conf, ok := b.webServerConfig(hi.ServerName, forVIPService, port)
+			if !ok || conf.ClientCAs() == "" {
+				return nil, nil
+			}
+			pool := b.serveClientCAPool(conf.ClientCAs())
+			if pool == nil {
+				return nil, fmt.Errorf("no valid client CA certificates configured for %s", hi.ServerName)
+			}
+			// The returned config replaces the one that http.Server
+			// set up, so ALPN has to be configured in the same way.
+			nextProtos := []string{"h2", "http/1.1"}
+			if http1Only {
+				nextProtos = []string{"http/1.1"}
+			}
+			return &tls.Config{
+				GetCertificate: getCert,
+				ClientAuth:     tls.RequireAndVerifyClientCert,
+				ClientCAs:      pool,
+				NextProtos:     nextProtos,
+			}, nil
+		},
+	}
+}
+
 // setServeProxyHandlersLocked ensures there is an http proxy handler for each
 // backend specified in serveConfig. It expects serveConfig to be valid and
 // up-to-date, so should be called after reloadServeConfigLocked.
@@ -1254,27 +1671,32 @@ func (b *LocalBackend) setServeProxyHandlersLocked() {
 		return
 	}
 	var backends map[string]bool
-	for _, conf := range b.serveConfig.Webs() {
-		for _, h := range conf.Handlers().All() {
-			backend := h.Proxy()
-			if backend == "" {
+	var stateful map[serveHandlerKey]bool // handlers with MaxConns or ProxyTargets set
+	for hp, conf := range b.serveConfig.Webs() {
+		for mount, h := range conf.Handlers().All() {
+			if h.Proxy() == "" {
 				// Only create proxy handlers for servers with a proxy backend.
 				continue
 			}
-			mak.Set(&backends, backend, true)
-			if _, ok := b.serveProxyHandlers.Load(backend); ok {
-				continue
+			if h.MaxConns() > 0 || h.ProxyTargets().Len() > 0 {
+				mak.Set(&stateful, serveHandlerKey{hp, mount}, true)
 			}
+			for _, backend := range append([]string{h.Proxy()}, h.ProxyTargets().AsSlice()...) {
+				mak.Set(&backends, backend, true)
+				if _, ok := b.serveProxyHandlers.Load(backend); ok {
+					continue
+				}
 
-			b.logf("serve: creating a new proxy handler for %s", backend)
-			p, err := b.proxyHandlerForBackend(backend)
-			if err != nil {
-				// The backend endpoint (h.Proxy) should have been validated by expandProxyTarget
-				// in the CLI, so just log the error here.
-				b.logf("[unexpected] could not create proxy for %v: %s", backend, err)
-				continue
+				b.logf("serve: creating a new proxy handler for %s", backend)
+				p, err := b.proxyHandlerForBackend(backend)
+				if err != nil {
+					// The backend endpoint (h.Proxy) should have been validated by expandProxyTarget
+					// in the CLI, so just log the error here.
+					b.logf("[unexpected] could not create proxy for %v: %s", backend, err)
+					continue
+				}
+				b.serveProxyHandlers.Store(backend, p)
 			}
-			b.serveProxyHandlers.Store(backend, p)
 		}
 	}
 
@@ -1289,6 +1711,15 @@ func (b *LocalBackend) setServeProxyHandlersLocked() {
 		}
 		return true
 	})
+
+	// Forget the state of handlers that no longer need it. Requests still
+	// in flight to them release their slots to the removed state.
+	b.serveHandlers.Range(func(key, _ any) bool {
+		if !stateful[key.(serveHandlerKey)] {
+			b.serveHandlers.Delete(key)
+		}
+		return true
+	})
 }
 
 // VIPServices returns the list of tailnet services that this node
@@ -1457,6 +1888,7 @@ func (b *LocalBackend) reloadServeConfigLocked(prefs ipn.PrefsView) {
 		// Don't try to load the serve config.
 		b.lastServeConfJSON = mem.B(nil)
 		b.serveConfig = ipn.ServeConfigView{}
+		b.serveClientCAs = nil
 		return
 	}
 
@@ -1467,6 +1899,7 @@ func (b *LocalBackend) reloadServeConfigLocked(prefs ipn.PrefsView) {
 	if err != nil {
 		b.lastServeConfJSON = mem.B(nil)
 		b.serveConfig = ipn.ServeConfigView{}
+		b.serveClientCAs = nil
 		return
 	}
 	if b.lastServeConfJSON.Equal(mem.B(confj)) {
@@ -1477,6 +1910,7 @@ func (b *LocalBackend) reloadServeConfigLocked(prefs ipn.PrefsView) {
 	if err := json.Unmarshal(confj, &conf); err != nil {
 		b.logf("invalid ServeConfig %q in StateStore: %v", confKey, err)
 		b.serveConfig = ipn.ServeConfigView{}
+		b.serveClientCAs = nil
 		return
 	}
 
@@ -1487,6 +1921,38 @@ func (b *LocalBackend) reloadServeConfigLocked(prefs ipn.PrefsView) {
 	})
 
 	b.serveConfig = conf.View()
+	b.serveClientCAs = clientCAPools(b.serveConfig)
+}
+
+// clientCAPools returns the certificate pools of the ClientCAs of the
+// WebServerConfigs in sc, keyed by their PEM bundle, so that they are parsed
+// once when the config is loaded rather than on every TLS handshake. Bundles
+// that contain no valid certificates map to nil.
+func clientCAPools(sc ipn.ServeConfigView) map[string]*x509.CertPool {
+	var pools map[string]*x509.CertPool
+	for _, conf := range sc.Webs() {
+		bundle := conf.ClientCAs()
+		if bundle == "" {
+			continue
+		}
+		if _, ok := pools[bundle]; ok {
+			continue
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(bundle)) {
+			pool = nil
+		}
+		mak.Set(&pools, bundle, pool)
+	}
+	return pools
+}
+
+// serveClientCAPool returns the parsed certificates of bundle, a
+// WebServerConfig.ClientCAs in the serve config, or nil if none are valid.
+func (b *LocalBackend) serveClientCAPool(bundle string) *x509.CertPool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.serveClientCAs[bundle]
 }
 
 func (b *LocalBackend) setVIPServicesTCPPortsInterceptedLocked(svcPorts map[tailcfg.ServiceName][]uint16) {
@@ -9,14 +9,22 @@
 	"bytes"
 	"cmp"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"mime"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/netip"
@@ -24,7 +32,9 @@
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -72,6 +82,38 @@ type res struct {
 	}
 }
 
+func TestBackendKeepAlive(t *testing.T) {
+	tests := []struct {
+		name string
+		tcph *ipn.TCPPortHandler
+		want net.KeepAliveConfig
+	}{
+		{"unset", &ipn.TCPPortHandler{HTTPS: true}, net.KeepAliveConfig{}},
+		{"idle", &ipn.TCPPortHandler{HTTPS: true, KeepAliveIdle: time.Minute}, net.KeepAliveConfig{Enable: true, Idle: time.Minute, Interval: -1, Count: -1}},
+		{"interval", &ipn.TCPPortHandler{TCPForward: "127.0.0.1:8080", KeepAliveInterval: 10 * time.Second}, net.KeepAliveConfig{Enable: true, Idle: -1, Interval: 10 * time.Second, Count: -1}},
+		{"both", &ipn.TCPPortHandler{HTTPS: true, KeepAliveIdle: time.Minute, KeepAliveInterval: 10 * time.Second}, net.KeepAliveConfig{Enable: true, Idle: time.Minute, Interval: 10 * time.Second, Count: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := backendKeepAlive(tt.tcph.View()); got != tt.want {
+				t.Errorf("backendKeepAlive = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetHTTP1Only(t *testing.T) {
+	hs := new(http.Server)
+	setHTTP1Only(hs, (&ipn.TCPPortHandler{HTTPS: true}).View())
+	if hs.Protocols != nil {
+		t.Errorf("Protocols = %v, want nil", hs.Protocols)
+	}
+	setHTTP1Only(hs, (&ipn.TCPPortHandler{HTTPS: true, HTTP1Only: true}).View())
+	if hs.Protocols == nil || !hs.Protocols.HTTP1() || hs.Protocols.HTTP2() || hs.Protocols.UnencryptedHTTP2() {
+		t.Errorf("Protocols = %v, want HTTP/1 only", hs.Protocols)
+	}
+}
+
 func TestParseRedirectWithRedirectCode(t *testing.T) {
 	tests := []struct {
 		in       string
@@ -208,7 +250,8 @@ func TestGetServeHandler(t *testing.T) {
 				DestPort: port,
 			}))
 
-			h, got, ok := b.getServeHandler(req)
+			wsc, _, _ := b.webServerConfigForRequest(req)
+			h, got, ok := b.getServeHandler(wsc, req)
 			if (got != "") != ok {
 				t.Fatalf("got ok=%v, but got mountPoint=%q", ok, got)
 			}
@@ -707,6 +750,422 @@ func(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func TestServeHTTPProxyMaxConns(t *testing.T) {
+	b := newTestBackend(t)
+	arrived := make(chan bool)
+	unblock := make(chan bool)
+	testServ := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/block" {
+				arrived <- true
+				<-unblock
+			}
+		},
+	))
+	defer testServ.Close()
+
+	conf := &ipn.ServeConfig{
+		Web: map[ipn.HostPort]*ipn.WebServerConfig{
+			"example.ts.net:443": {Handlers: map[string]*ipn.HTTPHandler{
+				"/":       {Proxy: testServ.URL, MaxConns: 2},
+				"/other/": {Proxy: testServ.URL, MaxConns: 2},
+			}},
+		},
+	}
+	if err := b.SetServeConfig(conf, ""); err != nil {
+		t.Fatal(err)
+	}
+	serve := func(path string) int {
+		req := &http.Request{
+			URL: &url.URL{Path: path},
+			TLS: &tls.ConnectionState{ServerName: "example.ts.net"},
+		}
+		req = req.WithContext(serveHTTPContextKey.WithValue(req.Context(),
+			&serveHTTPContext{
+				DestPort: 443,
+				SrcAddr:  netip.MustParseAddrPort("1.2.3.4:1234"), // random src
+			}))
+		w := httptest.NewRecorder()
+		b.serveWebHandler(w, req)
+		return w.Code
+	}
+
+	// Fill all slots with requests that block in the backend.
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if code := serve("/block"); code != http.StatusOK {
+				t.Errorf("blocked request: got status %d, want %d", code, http.StatusOK)
+			}
+		}()
+		<-arrived
+	}
+	if code := serve("/"); code != http.StatusServiceUnavailable {
+		t.Errorf("request over the limit: got status %d, want %d", code, http.StatusServiceUnavailable)
+	}
+	// Another handler with the same backend is limited separately.
+	if code := serve("/other/foo"); code != http.StatusOK {
+		t.Errorf("request to other handler: got status %d, want %d", code, http.StatusOK)
+	}
+
+	close(unblock)
+	wg.Wait()
+	if code := serve("/"); code != http.StatusOK {
+		t.Errorf("request after slots were released: got status %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestServeHTTPProxyTargets(t *testing.T) {
+	b := newTestBackend(t)
+	var mu sync.Mutex
+	var got []string
+	newServ := func(name string) *httptest.Server {
+		s := httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				got = append(got, name+r.URL.Path)
+			},
+		))
+		t.Cleanup(s.Close)
+		return s
+	}
+	servA, servB, servC := newServ("a"), newServ("b"), newServ("c")
+
+	conf := &ipn.ServeConfig{
+		Web: map[ipn.HostPort]*ipn.WebServerConfig{
+			"example.ts.net:443": {Handlers: map[string]*ipn.HTTPHandler{
+				"/":       {Proxy: servA.URL, ProxyTargets: []string{servB.URL, servC.URL}},
+				"/other/": {Proxy: servA.URL, ProxyTargets: []string{servB.URL}},
+			}},
+		},
+	}
+	if err := b.SetServeConfig(conf, ""); err != nil {
+		t.Fatal(err)
+	}
+	// Each handler load balances separately, even with the same backends.
+	for _, path := range []string{"/foo", "/foo", "/other/foo", "/foo", "/other/foo", "/foo"} {
+		req := &http.Request{
+			URL: &url.URL{Path: path},
+			TLS: &tls.ConnectionState{ServerName: "example.ts.net"},
+		}
+		req = req.WithContext(serveHTTPContextKey.WithValue(req.Context(),
+			&serveHTTPContext{
+				DestPort: 443,
+				SrcAddr:  netip.MustParseAddrPort("1.2.3.4:1234"), // random src
+			}))
+		w := httptest.NewRecorder()
+		b.serveWebHandler(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+		}
+	}
+	want := []string{"a/foo", "b/foo", "a/foo", "c/foo", "b/foo", "a/foo"}
+	if !slices.Equal(got, want) {
+		t.Errorf("backends = %q, want %q", got, want)
+	}
+}
+
+func TestServeHTTPProxyRetries(t *testing.T) {
+	tstest.Replace(t, &retryBackoff, 0)
+	b := newTestBackend(t)
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+	testServ := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			attempts[r.Method+" "+r.URL.Path]++
+			n := attempts[r.Method+" "+r.URL.Path]
+			mu.Unlock()
+			switch {
+			case r.URL.Path == "/flaky" && n <= 2:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			case r.URL.Path == "/down":
+				w.WriteHeader(http.StatusBadGateway)
+			case r.URL.Path == "/error":
+				w.WriteHeader(http.StatusInternalServerError)
+			case r.URL.Path == "/teapot" && n <= 1:
+				w.WriteHeader(http.StatusTeapot)
+			}
+		},
+	))
+	defer testServ.Close()
+
+	conf := &ipn.ServeConfig{
+		Web: map[ipn.HostPort]*ipn.WebServerConfig{
+			"example.ts.net:443": {Handlers: map[string]*ipn.HTTPHandler{
+				"/":       {Proxy: testServ.URL, BackendRetries: 2},
+				"/teapot": {Proxy: testServ.URL + "/teapot", BackendRetries: 1, RetryStatusCodes: []int{http.StatusTeapot}},
+			}},
+		},
+	}
+	if err := b.SetServeConfig(conf, ""); err != nil {
+		t.Fatal(err)
+	}
+	serve := func(method, path string) int {
+		req := &http.Request{
+			Method: method,
+			URL:    &url.URL{Path: path},
+			TLS:    &tls.ConnectionState{ServerName: "example.ts.net"},
+		}
+		req = req.WithContext(serveHTTPContextKey.WithValue(req.Context(),
+			&serveHTTPContext{
+				DestPort: 443,
+				SrcAddr:  netip.MustParseAddrPort("1.2.3.4:1234"), // random src
+			}))
+		w := httptest.NewRecorder()
+		b.serveWebHandler(w, req)
+		return w.Code
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		wantCode     int
+		wantAttempts int
+	}{
+		{"recovers", "GET", "/flaky", http.StatusOK, 3},
+		{"gives_up", "GET", "/down", http.StatusBadGateway, 3},
+		{"status_not_retried", "GET", "/error", http.StatusInternalServerError, 1},
+		{"method_not_retried", "POST", "/down", http.StatusBadGateway, 1},
+		{"custom_status", "GET", "/teapot", http.StatusOK, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := serve(tt.method, tt.path); code != tt.wantCode {
+				t.Errorf("got status %d, want %d", code, tt.wantCode)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if got := attempts[tt.method+" "+tt.path]; got != tt.wantAttempts {
+				t.Errorf("backend got %d requests, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestServeHTTPResponseHeaders(t *testing.T) {
+	b := newTestBackend(t)
+	testServ := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "private")
+			w.Header().Set("X-Backend", "1")
+			io.WriteString(w, "ok")
+		},
+	))
+	defer testServ.Close()
+
+	headers := map[string]string{
+		"Cache-Control": "no-store",
+		"X-Backend":     "", // removed
+		"X-Extra":       "extra",
+	}
+	conf := &ipn.ServeConfig{
+		Web: map[ipn.HostPort]*ipn.WebServerConfig{
+			"example.ts.net:443": {Handlers: map[string]*ipn.HTTPHandler{
+				"/":     {Proxy: testServ.URL, ResponseHeaders: headers},
+				"/text": {Text: "hello", ResponseHeaders: headers},
+				"/none": {Text: "hello"},
+			}},
+		},
+	}
+	if err := b.SetServeConfig(conf, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want http.Header
+	}{
+		{
+			path: "/",
+			want: http.Header{"Cache-Control": {"no-store"}, "X-Extra": {"extra"}},
+		},
+		{
+			path: "/text",
+			want: http.Header{"Cache-Control": {"no-store"}, "X-Extra": {"extra"}},
+		},
+		{
+			path: "/none",
+			want: http.Header{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := &http.Request{
+				URL: &url.URL{Path: tt.path},
+				TLS: &tls.ConnectionState{ServerName: "example.ts.net"},
+			}
+			req = req.WithContext(serveHTTPContextKey.WithValue(req.Context(),
+				&serveHTTPContext{
+					DestPort: 443,
+					SrcAddr:  netip.MustParseAddrPort("1.2.3.4:1234"), // random src
+				}))
+			w := httptest.NewRecorder()
+			b.serveWebHandler(w, req)
+
+			got := w.Result().Header
+			for _, k := range []string{"Cache-Control", "X-Backend", "X-Extra"} {
+				if !slices.Equal(got.Values(k), tt.want.Values(k)) {
+					t.Errorf("header %s = %q, want %q", k, got.Values(k), tt.want.Values(k))
+				}
+			}
+		})
+	}
+}
+
+func TestServeHTTPAccessLog(t *testing.T) {
+	var logBuf bytes.Buffer
+	tstest.Replace(t, &serveAccessLogWriter, func() io.Writer { return &logBuf })
+
+	b := newTestBackend(t)
+	conf := &ipn.ServeConfig{
+		Web: map[ipn.HostPort]*ipn.WebServerConfig{
+			"example.ts.net:443": {
+				AccessLog: true,
+				Handlers: map[string]*ipn.HTTPHandler{
+					"/text":     {Text: "hello"},
+					"/redirect": {Redirect: "301:https://example.com/"},
+				},
+			},
+			"other.ts.net:443": {Handlers: map[string]*ipn.HTTPHandler{
+				"/text": {Text: "hello"},
+			}},
+		},
+	}
+	if err := b.SetServeConfig(conf, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(host, path string) {
+		req := &http.Request{
+			Method: "GET",
+			Host:   host,
+			URL:    &url.URL{Path: path},
+			TLS:    &tls.ConnectionState{ServerName: host},
+		}
+		req = req.WithContext(serveHTTPContextKey.WithValue(req.Context(),
+			&serveHTTPContext{
+				DestPort: 443,
+				SrcAddr:  netip.MustParseAddrPort("1.2.3.4:1234"), // random src
+			}))
+		b.serveWebHandler(httptest.NewRecorder(), req)
+	}
+	serve("example.ts.net", "/text")
+	serve("example.ts.net", "/redirect")
+	serve("example.ts.net", "/missing")
+	serve("other.ts.net", "/text") // access log not enabled
+
+	type entry struct {
+		Host   string
+		Src    string
+		Method string
+		Path   string
+		Status int
+		Bytes  int64
+	}
+	var got []entry
+	dec := json.NewDecoder(&logBuf)
+	for dec.More() {
+		var e entry
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("decoding access log: %v", err)
+		}
+		got = append(got, e)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d access log entries, want 3: %+v", len(got), got)
+	}
+	// The sizes of the redirect and not found responses are up to net/http.
+	want := []entry{
+		{Host: "example.ts.net", Src: "1.2.3.4:1234", Method: "GET", Path: "/text", Status: 200, Bytes: 5},
+		{Host: "example.ts.net", Src: "1.2.3.4:1234", Method: "GET", Path: "/redirect", Status: 301, Bytes: got[1].Bytes},
+		{Host: "example.ts.net", Src: "1.2.3.4:1234", Method: "GET", Path: "/missing", Status: 404, Bytes: got[2].Bytes},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("access log entries:\ngot:  %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestServeTLSConfigClientCAs(t *testing.T) {
+	key := must.Get(ecdsa.GenerateKey(elliptic.P256(), rand.Reader))
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der := must.Get(x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key))
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	b := newTestBackend(t)
+	conf := &ipn.ServeConfig{
+		Web: map[ipn.HostPort]*ipn.WebServerConfig{
+			"example.ts.net:443": {
+				ClientCAs: string(caPEM),
+				Handlers:  map[string]*ipn.HTTPHandler{"/": {Text: "hello"}},
+			},
+			"other.ts.net:443": {
+				Handlers: map[string]*ipn.HTTPHandler{"/": {Text: "hello"}},
+			},
+			"invalid.ts.net:443": {
+				ClientCAs: "not a certificate",
+				Handlers:  map[string]*ipn.HTTPHandler{"/": {Text: "hello"}},
+			},
+		},
+	}
+	if err := b.SetServeConfig(conf, ""); err != nil {
+		t.Fatal(err)
+	}
+	// The bundles are parsed when the config is set.
+	b.mu.Lock()
+	pools := len(b.serveClientCAs)
+	b.mu.Unlock()
+	if pools != 2 {
+		t.Errorf("got %d parsed client CA bundles, want 2", pools)
+	}
+
+	tc := b.serveTLSConfig(443, "", false)
+	got, err := tc.GetConfigForClient(&tls.ClientHelloInfo{ServerName: "example.ts.net"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.ClientAuth != tls.RequireAndVerifyClientCert || got.ClientCAs == nil {
+		t.Fatalf("config for example.ts.net does not require client certificates: %+v", got)
+	}
+	if want := []string{"h2", "http/1.1"}; !slices.Equal(got.NextProtos, want) {
+		t.Errorf("NextProtos = %q, want %q", got.NextProtos, want)
+	}
+	if got.GetCertificate == nil {
+		t.Errorf("config for example.ts.net has no GetCertificate")
+	}
+
+	got, err = b.serveTLSConfig(443, "", true).GetConfigForClient(&tls.ClientHelloInfo{ServerName: "example.ts.net"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"http/1.1"}; !slices.Equal(got.NextProtos, want) {
+		t.Errorf("NextProtos with HTTP1Only = %q, want %q", got.NextProtos, want)
+	}
+
+	// Hosts without client CAs use the default config.
+	if got, err := tc.GetConfigForClient(&tls.ClientHelloInfo{ServerName: "other.ts.net"}); err != nil || got != nil {
+		t.Errorf("GetConfigForClient(other.ts.net) = %v, %v; want nil, nil", got, err)
+	}
+
+	// Connections are rejected if the bundle is invalid, rather than
+	// accepted without verifying client certificates.
+	if _, err := tc.GetConfigForClient(&tls.ClientHelloInfo{ServerName: "invalid.ts.net"}); err == nil {
+		t.Errorf("GetConfigForClient(invalid.ts.net) succeeded, want error")
+	}
+}
+
 func TestServeHTTPProxyHeaders(t *testing.T) {
 	b := newTestBackend(t)
 
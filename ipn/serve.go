@@ -13,6 +13,7 @@
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"tailscale.com/ipn/ipnstate"
 	"tailscale.com/tailcfg"
@@ -121,6 +122,20 @@ type FunnelConn struct {
 // WebServerConfig describes a web server's configuration.
 type WebServerConfig struct {
 	Handlers map[string]*HTTPHandler // mountPoint => handler
+
+	// AccessLog, if true, means that tailscaled writes an access log entry
+	// for each request to this host, with the client's identity, the
+	// request path and the response status. Entries are written as JSON
+	// lines to stderr, or to the file named by TS_SERVE_ACCESS_LOG_FILE,
+	// and not to the regular logs, from where they can be shipped to a log
+	// sink.
+	AccessLog bool `json:",omitempty"`
+
+	// ClientCAs, if non-empty, is a PEM-encoded bundle of CA certificates.
+	// HTTPS clients of this host must then present a TLS client
+	// certificate that is signed by one of them. Connections are rejected
+	// if the bundle contains no valid certificates.
+	ClientCAs string `json:",omitempty"`
 }
 
 // TCPPortHandler describes what to do when handling a TCP
@@ -156,6 +171,23 @@ type TCPPortHandler struct {
 	//
 	// This is only valid if TCPForward is non-empty.
 	ProxyProtocol int `json:",omitzero"`
+
+	// KeepAliveIdle, if positive, is how long a connection to a backend
+	// must be idle before TCP keep-alive probes are sent on it.
+	// KeepAliveInterval, if positive, is the time between probes. If zero,
+	// the system defaults are used.
+	//
+	// They apply to the connection to TCPForward and, for HTTPS and HTTP
+	// handlers, to connections to HTTPHandler.Proxy backends.
+	KeepAliveIdle     time.Duration `json:",omitzero"`
+	KeepAliveInterval time.Duration `json:",omitzero"`
+
+	// HTTP1Only, if true, means that connections are served over HTTP/1.1
+	// only, for clients that break on HTTP/2: HTTP/2 is not offered to
+	// clients via ALPN.
+	//
+	// This is only valid if HTTPS is true.
+	HTTP1Only bool `json:",omitempty"`
 }
 
 // HTTPHandler is either a path or a proxy to serve.
@@ -178,6 +210,36 @@ type HTTPHandler struct {
 	//   - ${REQUEST_URI}: replaced with the request's full URI (path and query string)
 	Redirect string `json:",omitempty"`
 
+	// MaxConns, if positive, is the maximum number of requests that this
+	// handler may proxy concurrently. Requests beyond the limit are rejected
+	// with HTTP 503 (Service Unavailable). Handlers are limited separately,
+	// even if they proxy to the same backend. It is only used if Proxy is
+	// non-empty.
+	MaxConns int `json:",omitzero"`
+
+	// ResponseHeaders, if non-empty, are HTTP headers to set on responses
+	// served by this handler, keyed by header name. Headers with an empty
+	// value are removed from responses instead.
+	ResponseHeaders map[string]string `json:",omitempty"`
+
+	// BackendRetries, if positive, is the maximum number of times a request
+	// to Proxy is retried if the backend cannot be reached, or responds with
+	// one of RetryStatusCodes. Only requests with an idempotent method and no
+	// body are retried. It is only used if Proxy is non-empty.
+	BackendRetries int `json:",omitzero"`
+
+	// RetryStatusCodes are the HTTP status codes of backend responses that
+	// requests are retried for, if BackendRetries is positive. If empty,
+	// requests are retried for 502, 503 and 504 responses.
+	RetryStatusCodes []int `json:",omitempty"`
+
+	// ProxyTargets, if non-empty, are further backends in the same form as
+	// Proxy. Requests are load balanced across Proxy and ProxyTargets in
+	// round-robin order, separately for each handler. MaxConns limits the
+	// requests to all of them together. It is only used if Proxy is
+	// non-empty.
+	ProxyTargets []string `json:",omitempty"`
+
 	// TODO(bradfitz): bool to not enumerate directories? TTL on mapping for
 	// temporary ones? Error codes?
 }
@@ -925,3 +987,74 @@ func (v ServiceConfigView) ServicePortRange() []tailcfg.ProtoPortRange {
 	}
 	return ranges
 }
+
+// RequiredCapVer returns the minimum capability version of a client that
+// honors all the fields set in sc. Older clients ignore the fields that they
+// do not know, so sc is not served as configured by them.
+func (sc *ServeConfig) RequiredCapVer() tailcfg.CapabilityVersion {
+	var v tailcfg.CapabilityVersion
+	if sc == nil {
+		return v
+	}
+	for _, h := range sc.TCP {
+		v = max(v, h.requiredCapVer())
+	}
+	for _, w := range sc.Web {
+		v = max(v, w.requiredCapVer())
+	}
+	for _, svc := range sc.Services {
+		v = max(v, svc.RequiredCapVer())
+	}
+	for _, fg := range sc.Foreground {
+		v = max(v, fg.RequiredCapVer())
+	}
+	return v
+}
+
+// RequiredCapVer is like ServeConfig.RequiredCapVer, for the config of a
+// single service.
+func (sc *ServiceConfig) RequiredCapVer() tailcfg.CapabilityVersion {
+	var v tailcfg.CapabilityVersion
+	if sc == nil {
+		return v
+	}
+	for _, h := range sc.TCP {
+		v = max(v, h.requiredCapVer())
+	}
+	for _, w := range sc.Web {
+		v = max(v, w.requiredCapVer())
+	}
+	return v
+}
+
+// serveFieldsCapVer is the capability version from which clients support
+// all the serve config fields checked by the requiredCapVer methods.
+const serveFieldsCapVer tailcfg.CapabilityVersion = 132
+
+func (h *TCPPortHandler) requiredCapVer() tailcfg.CapabilityVersion {
+	if h != nil && (h.KeepAliveIdle > 0 || h.KeepAliveInterval > 0 || h.HTTP1Only) {
+		return serveFieldsCapVer
+	}
+	return 0
+}
+
+func (w *WebServerConfig) requiredCapVer() tailcfg.CapabilityVersion {
+	var v tailcfg.CapabilityVersion
+	if w == nil {
+		return v
+	}
+	for _, h := range w.Handlers {
+		v = max(v, h.requiredCapVer())
+	}
+	if w.AccessLog || w.ClientCAs != "" {
+		v = serveFieldsCapVer
+	}
+	return v
+}
+
+func (h *HTTPHandler) requiredCapVer() tailcfg.CapabilityVersion {
+	if h != nil && (h.MaxConns > 0 || len(h.ResponseHeaders) > 0 || h.BackendRetries > 0 || len(h.ProxyTargets) > 0) {
+		return serveFieldsCapVer
+	}
+	return 0
+}
@@ -5,6 +5,7 @@
 
 import (
 	"testing"
+	"time"
 
 	"tailscale.com/ipn/ipnstate"
 	"tailscale.com/tailcfg"
@@ -417,3 +418,137 @@ func TestIsFunnelOn(t *testing.T) {
 		})
 	}
 }
+
+func TestRequiredCapVer(t *testing.T) {
+	tests := []struct {
+		name string
+		sc   *ServeConfig
+		want tailcfg.CapabilityVersion
+	}{
+		{
+			name: "nil",
+			want: 0,
+		},
+		{
+			name: "no-new-fields",
+			sc: &ServeConfig{
+				TCP: map[uint16]*TCPPortHandler{443: {HTTPS: true}},
+				Web: map[HostPort]*WebServerConfig{
+					"foo.test.ts.net:443": {Handlers: map[string]*HTTPHandler{
+						"/": {Proxy: "http://127.0.0.1:3000"},
+					}},
+				},
+			},
+			want: 0,
+		},
+		{
+			name: "max-conns",
+			sc: &ServeConfig{
+				Web: map[HostPort]*WebServerConfig{
+					"foo.test.ts.net:443": {Handlers: map[string]*HTTPHandler{
+						"/": {Proxy: "http://127.0.0.1:3000", MaxConns: 10},
+					}},
+				},
+			},
+			want: 132,
+		},
+		{
+			name: "service",
+			sc: &ServeConfig{
+				Services: map[tailcfg.ServiceName]*ServiceConfig{
+					"svc:foo": {Web: map[HostPort]*WebServerConfig{
+						"foo.test.ts.net:443": {Handlers: map[string]*HTTPHandler{
+							"/": {Proxy: "http://127.0.0.1:3000", MaxConns: 10},
+						}},
+					}},
+				},
+			},
+			want: 132,
+		},
+		{
+			name: "foreground",
+			sc: &ServeConfig{
+				Foreground: map[string]*ServeConfig{
+					"session": {Web: map[HostPort]*WebServerConfig{
+						"foo.test.ts.net:443": {Handlers: map[string]*HTTPHandler{
+							"/": {Proxy: "http://127.0.0.1:3000", MaxConns: 10},
+						}},
+					}},
+				},
+			},
+			want: 132,
+		},
+		{
+			name: "response-headers",
+			sc: &ServeConfig{
+				Web: map[HostPort]*WebServerConfig{
+					"foo.test.ts.net:443": {Handlers: map[string]*HTTPHandler{
+						"/": {Proxy: "http://127.0.0.1:3000", ResponseHeaders: map[string]string{"Cache-Control": "no-store"}},
+					}},
+				},
+			},
+			want: 132,
+		},
+		{
+			name: "tcp-keepalive",
+			sc: &ServeConfig{
+				TCP: map[uint16]*TCPPortHandler{443: {HTTPS: true, KeepAliveIdle: time.Minute}},
+			},
+			want: 132,
+		},
+		{
+			name: "access-log",
+			sc: &ServeConfig{
+				Web: map[HostPort]*WebServerConfig{
+					"foo.test.ts.net:443": {AccessLog: true},
+				},
+			},
+			want: 132,
+		},
+		{
+			name: "backend-retries",
+			sc: &ServeConfig{
+				Web: map[HostPort]*WebServerConfig{
+					"foo.test.ts.net:443": {Handlers: map[string]*HTTPHandler{
+						"/": {Proxy: "http://127.0.0.1:3000", BackendRetries: 3},
+					}},
+				},
+			},
+			want: 132,
+		},
+		{
+			name: "http1-only",
+			sc: &ServeConfig{
+				TCP: map[uint16]*TCPPortHandler{443: {HTTPS: true, HTTP1Only: true}},
+			},
+			want: 132,
+		},
+		{
+			name: "client-cas",
+			sc: &ServeConfig{
+				Web: map[HostPort]*WebServerConfig{
+					"foo.test.ts.net:443": {ClientCAs: "-----BEGIN CERTIFICATE-----"},
+				},
+			},
+			want: 132,
+		},
+		{
+			name: "proxy-targets",
+			sc: &ServeConfig{
+				Web: map[HostPort]*WebServerConfig{
+					"foo.test.ts.net:443": {Handlers: map[string]*HTTPHandler{
+						"/": {Proxy: "http://127.0.0.1:3000", ProxyTargets: []string{"http://127.0.0.1:3001"}},
+					}},
+				},
+			},
+			want: 132,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sc.RequiredCapVer(); got != tt.want {
+				t.Errorf("RequiredCapVer() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
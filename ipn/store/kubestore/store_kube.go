@@ -158,13 +158,21 @@ func (s *Store) WriteTLSCertAndKey(domain string, cert, key []byte) (err error)
 		domain + ".key": key,
 	}
 	// If we run in cert share mode, cert and key for a DNS name are written
-	// to a separate Secret.
+	// to a separate Secret, or to the ProxyGroup's consolidated cert Secret
+	// if the operator has created that instead.
 	if s.certShareMode == "rw" {
 		secretName = domain
 		data = map[string][]byte{
 			keyTLSCert: cert,
 			keyTLSKey:  key,
 		}
+		if s.useConsolidatedCertSecret(domain) {
+			secretName = s.consolidatedCertSecretName()
+			data = map[string][]byte{
+				domain + ".crt": cert,
+				domain + ".key": key,
+			}
+		}
 	}
 	if err := s.updateSecret(data, secretName); err != nil {
 		return fmt.Errorf("error writing TLS cert and key to Secret: %w", err)
@@ -183,7 +191,8 @@ func (s *Store) WriteTLSCertAndKey(domain string, cert, key []byte) (err error)
 }
 
 // ReadTLSCertAndKey reads a TLS cert and key from memory or from a
-// domain-specific Secret. It first checks the in-memory store, if not found in
+// domain-specific Secret, or the ProxyGroup's consolidated cert Secret. It
+// first checks the in-memory store, if not found in
 // memory and running cert store in read-only mode, looks up a Secret.
 // Note that write replicas of HA Ingress always retrieve TLS certs from Secrets.
 func (s *Store) ReadTLSCertAndKey(domain string) (cert, key []byte, err error) {
@@ -206,33 +215,37 @@ func (s *Store) ReadTLSCertAndKey(domain string) (cert, key []byte, err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 	secret, err := s.client.GetSecret(ctx, domain)
-	if err != nil {
-		if kubeclient.IsNotFoundErr(err) {
-			// TODO(irbekrm): we should return a more specific error
-			// that wraps ipn.ErrStateNotExist here.
-			return nil, nil, ipn.ErrStateNotExist
-		}
-		st, ok := err.(*kubeapi.Status)
-		if ok && st.Code == http.StatusForbidden && (s.certShareMode == "ro" || s.certShareMode == "rw") {
-			// In cert share mode, we read from a dedicated Secret per domain.
-			// To get here, we already had a cache miss from our in-memory
-			// store. For write replicas, that means it wasn't available on
-			// start and it wasn't written since. For read replicas, that means
-			// it wasn't available on start and it hasn't been reloaded in the
-			// background. So getting a "forbidden" error is an expected
-			// "not found" case where we've been asked for a cert we don't
-			// expect to issue, and so the forbidden error reflects that the
-			// operator didn't assign permission for a Secret for that domain.
-			//
-			// This code path gets triggered by the admin UI's machine page,
-			// which queries for the node's own TLS cert existing via the
-			// "tls-cert-status" c2n API.
-			return nil, nil, ipn.ErrStateNotExist
+	switch {
+	case err == nil:
+		cert = secret.Data[keyTLSCert]
+		key = secret.Data[keyTLSKey]
+	case kubeclient.IsNotFoundErr(err), isForbiddenErr(err):
+		// In cert share mode, we read from a dedicated Secret per domain.
+		// To get here, we already had a cache miss from our in-memory
+		// store. For write replicas, that means it wasn't available on
+		// start and it wasn't written since. For read replicas, that means
+		// it wasn't available on start and it hasn't been reloaded in the
+		// background. So getting a "forbidden" error is an expected
+		// "not found" case where we've been asked for a cert we don't
+		// expect to issue, and so the forbidden error reflects that the
+		// operator didn't assign permission for a Secret for that domain.
+		//
+		// This code path gets triggered by the admin UI's machine page,
+		// which queries for the node's own TLS cert existing via the
+		// "tls-cert-status" c2n API.
+		//
+		// The operator can also be configured to store the certs of
+		// all of the ProxyGroup's domains in a single consolidated
+		// Secret, instead of a Secret per domain, so look there too.
+		//
+		// TODO(irbekrm): we should return a more specific error
+		// that wraps ipn.ErrStateNotExist here.
+		if cert, key, err = s.readConsolidatedCert(ctx, domain); err != nil {
+			return nil, nil, err
 		}
+	default:
 		return nil, nil, fmt.Errorf("getting TLS Secret %q: %w", domain, err)
 	}
-	cert = secret.Data[keyTLSCert]
-	key = secret.Data[keyTLSKey]
 	if len(cert) == 0 || len(key) == 0 {
 		return nil, nil, ipn.ErrStateNotExist
 	}
@@ -385,6 +398,10 @@ func (s *Store) loadCerts(ctx context.Context, sel map[string]string) error {
 		return fmt.Errorf("error listing TLS Secrets: %w", err)
 	}
 	for _, secret := range ss.Items {
+		if secret.Name == s.consolidatedCertSecretName() {
+			s.loadConsolidatedCerts(&secret)
+			continue
+		}
 		if !hasTLSData(&secret) {
 			continue
 		}
@@ -398,6 +415,66 @@ func (s *Store) loadCerts(ctx context.Context, sel map[string]string) error {
 	return nil
 }
 
+// loadConsolidatedCerts loads the TLS certs and keys from secret, the
+// ProxyGroup's consolidated cert Secret, which stores them as <domain>.crt
+// and <domain>.key.
+func (s *Store) loadConsolidatedCerts(secret *kubeapi.Secret) {
+	for k, cert := range secret.Data {
+		domain, ok := strings.CutSuffix(k, ".crt")
+		// Only load certs for valid domain names (ending in .ts.net)
+		if !ok || !strings.HasSuffix(domain, ".ts.net") {
+			continue
+		}
+		key := secret.Data[domain+".key"]
+		if len(cert) == 0 || len(key) == 0 {
+			continue
+		}
+		s.memory.WriteState(ipn.StateKey(domain+".crt"), cert)
+		s.memory.WriteState(ipn.StateKey(domain+".key"), key)
+	}
+}
+
+// readConsolidatedCert reads the TLS cert and key for domain from the
+// ProxyGroup's consolidated cert Secret. It returns ipn.ErrStateNotExist if
+// there is no such Secret that this node can read, or it has no cert for
+// domain.
+func (s *Store) readConsolidatedCert(ctx context.Context, domain string) (cert, key []byte, err error) {
+	name := s.consolidatedCertSecretName()
+	if name == "" {
+		return nil, nil, ipn.ErrStateNotExist
+	}
+	secret, err := s.client.GetSecret(ctx, name)
+	if err != nil {
+		if kubeclient.IsNotFoundErr(err) || isForbiddenErr(err) {
+			return nil, nil, ipn.ErrStateNotExist
+		}
+		return nil, nil, fmt.Errorf("getting TLS Secret %q: %w", name, err)
+	}
+	cert, key = secret.Data[domain+".crt"], secret.Data[domain+".key"]
+	if len(cert) == 0 || len(key) == 0 {
+		return nil, nil, ipn.ErrStateNotExist
+	}
+	return cert, key, nil
+}
+
+// useConsolidatedCertSecret reports whether the TLS cert and key for domain
+// are to be written to the ProxyGroup's consolidated cert Secret, because
+// there is no Secret for domain that this node can access, but there is a
+// consolidated one.
+func (s *Store) useConsolidatedCertSecret(domain string) bool {
+	name := s.consolidatedCertSecretName()
+	if name == "" {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if _, err := s.client.GetSecret(ctx, domain); err == nil || !kubeclient.IsNotFoundErr(err) && !isForbiddenErr(err) {
+		return false
+	}
+	_, err := s.client.GetSecret(ctx, name)
+	return err == nil
+}
+
 // canCreateSecret returns true if this node should be allowed to create the given
 // Secret in its namespace.
 func (s *Store) canCreateSecret(secret string) bool {
@@ -423,14 +500,10 @@ func (s *Store) canPatchSecret(secret string) bool {
 // Currently (7/2025) this only applies to the Kubernetes Operator's ProxyGroup
 // when spec.Type is "ingress" or "kube-apiserver".
 func (s *Store) certSecretSelector() map[string]string {
-	if s.podName == "" {
-		return map[string]string{}
-	}
-	p := strings.LastIndex(s.podName, "-")
-	if p == -1 {
+	pgName := s.proxyGroupName()
+	if pgName == "" {
 		return map[string]string{}
 	}
-	pgName := s.podName[:p]
 	return map[string]string{
 		kubetypes.LabelSecretType:   kubetypes.LabelSecretTypeCerts,
 		kubetypes.LabelManaged:      "true",
@@ -438,6 +511,35 @@ func (s *Store) certSecretSelector() map[string]string {
 	}
 }
 
+// proxyGroupName returns the name of the ProxyGroup that this node is a
+// replica of, derived from its Pod name, or "" if unknown.
+func (s *Store) proxyGroupName() string {
+	p := strings.LastIndex(s.podName, "-")
+	if p == -1 {
+		return ""
+	}
+	return s.podName[:p]
+}
+
+// consolidatedCertSecretName returns the name of the Secret in which the
+// operator can store the TLS certs of all HTTPS endpoints of this node's
+// ProxyGroup, instead of a Secret per endpoint, or "" if the ProxyGroup is
+// unknown.
+func (s *Store) consolidatedCertSecretName() string {
+	pgName := s.proxyGroupName()
+	if pgName == "" {
+		return ""
+	}
+	return pgName + kubetypes.ProxyGroupCertsSecretSuffix
+}
+
+// isForbiddenErr reports whether err is a Kubernetes API error with a 403
+// Forbidden status.
+func isForbiddenErr(err error) bool {
+	st, ok := err.(*kubeapi.Status)
+	return ok && st.Code == http.StatusForbidden
+}
+
 // hasTLSData returns true if the provided Secret contains non-empty TLS cert and key.
 func hasTLSData(s *kubeapi.Secret) bool {
 	return len(s.Data[keyTLSCert]) != 0 && len(s.Data[keyTLSKey]) != 0
@@ -627,6 +627,35 @@ func TestNewWithClient(t *testing.T) {
 				"app2.tailnetxyz.ts.net.key": []byte(testKey + "2"),
 			},
 		},
+		{
+			name:     "load_certs_from_consolidated_secret",
+			certMode: "ro",
+			stateSecretContents: map[string][]byte{
+				"foo": []byte("bar"),
+			},
+			TLSSecrets: []kubeapi.Secret{
+				makeSecret("app1.tailnetxyz.ts.net", certSecretsLabels, "1"),
+				{
+					ObjectMeta: kubeapi.ObjectMeta{
+						Name:   "ingress-proxies-certs",
+						Labels: certSecretsLabels,
+					},
+					Data: map[string][]byte{
+						"app2.tailnetxyz.ts.net.crt": []byte(testCert + "2"),
+						"app2.tailnetxyz.ts.net.key": []byte(testKey + "2"),
+						"app3.tailnetxyz.ts.net.crt": []byte(testCert + "3"),
+						"app3.tailnetxyz.ts.net.key": {}, // not issued yet
+					},
+				},
+			},
+			wantMemoryStoreContents: map[ipn.StateKey][]byte{
+				"foo":                        []byte("bar"),
+				"app1.tailnetxyz.ts.net.crt": []byte(testCert + "1"),
+				"app1.tailnetxyz.ts.net.key": []byte(testKey + "1"),
+				"app2.tailnetxyz.ts.net.crt": []byte(testCert + "2"),
+				"app2.tailnetxyz.ts.net.key": []byte(testKey + "2"),
+			},
+		},
 		{
 			name:     "list_cert_secrets_fails",
 			certMode: "ro",
@@ -732,3 +761,98 @@ func TestNewWithClient(t *testing.T) {
 		})
 	}
 }
+
+func TestConsolidatedCertSecret(t *testing.T) {
+	const (
+		testDomain        = "my-app.tailnetxyz.ts.net"
+		consolidatedName  = "ingress-proxies-certs"
+		testCert, testKey = "fake-cert", "fake-key"
+	)
+
+	for _, tt := range []struct {
+		name          string
+		secrets       map[string]map[string][]byte // existing Secrets by name
+		domainGetErr  error                        // error from GetSecret for the domain's Secret
+		wantWriteName string                       // Secret that the cert is written to
+		wantWriteData map[string][]byte
+	}{
+		{
+			name: "per_domain_secret_preferred",
+			secrets: map[string]map[string][]byte{
+				testDomain:       {"tls.crt": nil, "tls.key": nil},
+				consolidatedName: {"other.tailnetxyz.ts.net.crt": []byte("other")},
+			},
+			wantWriteName: testDomain,
+			wantWriteData: map[string][]byte{
+				"tls.crt": []byte(testCert),
+				"tls.key": []byte(testKey),
+			},
+		},
+		{
+			name: "consolidated_secret",
+			secrets: map[string]map[string][]byte{
+				consolidatedName: {"other.tailnetxyz.ts.net.crt": []byte("other")},
+			},
+			domainGetErr:  &kubeapi.Status{Code: 403},
+			wantWriteName: consolidatedName,
+			wantWriteData: map[string][]byte{
+				"other.tailnetxyz.ts.net.crt":  []byte("other"),
+				"my-app.tailnetxyz.ts.net.crt": []byte(testCert),
+				"my-app.tailnetxyz.ts.net.key": []byte(testKey),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			secrets := tt.secrets
+			client := &kubeclient.FakeClient{
+				GetSecretImpl: func(ctx context.Context, name string) (*kubeapi.Secret, error) {
+					if name == testDomain && tt.domainGetErr != nil {
+						return nil, tt.domainGetErr
+					}
+					data, ok := secrets[name]
+					if !ok {
+						return nil, &kubeapi.Status{Code: 404}
+					}
+					return &kubeapi.Secret{ObjectMeta: kubeapi.ObjectMeta{Name: name}, Data: data}, nil
+				},
+				JSONPatchResourceImpl: func(ctx context.Context, name, resourceType string, patches []kubeclient.JSONPatch) error {
+					if name != tt.wantWriteName {
+						t.Errorf("JSONPatchResource called with wrong name, got %q, want %q", name, tt.wantWriteName)
+					}
+					for _, p := range patches {
+						if key, ok := strings.CutPrefix(p.Path, "/data/"); ok && p.Op == "add" {
+							secrets[name][key] = p.Value.([]byte)
+						}
+					}
+					return nil
+				},
+			}
+			s := &Store{
+				client:        client,
+				secretName:    "ts-state",
+				certShareMode: "rw",
+				podName:       "ingress-proxies-1",
+				memory:        mem.Store{},
+			}
+
+			if err := s.WriteTLSCertAndKey(testDomain, []byte(testCert), []byte(testKey)); err != nil {
+				t.Fatalf("WriteTLSCertAndKey() error = %v", err)
+			}
+			if diff := cmp.Diff(secrets[tt.wantWriteName], tt.wantWriteData); diff != "" {
+				t.Errorf("secret data mismatch (-got +want):\n%s", diff)
+			}
+
+			// Write replicas read certs from the Secrets, not memory.
+			cert, key, err := s.ReadTLSCertAndKey(testDomain)
+			if err != nil {
+				t.Fatalf("ReadTLSCertAndKey() error = %v", err)
+			}
+			if string(cert) != testCert || string(key) != testKey {
+				t.Errorf("ReadTLSCertAndKey() = %q, %q; want %q, %q", cert, key, testCert, testKey)
+			}
+			if _, _, err := s.ReadTLSCertAndKey("unknown.tailnetxyz.ts.net"); err != ipn.ErrStateNotExist {
+				t.Errorf("ReadTLSCertAndKey() for unknown domain error = %v, want %v", err, ipn.ErrStateNotExist)
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@
 
 //go:build !plan9
 
+//go:generate go run tailscale.com/cmd/enumparser -type ProxyGroupType
+
 package v1alpha1
 
 import (
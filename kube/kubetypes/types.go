@@ -61,6 +61,13 @@
 	LabelSecretTypeState  = "state"
 	LabelSecretTypeCerts  = "certs"
 
+	// ProxyGroupCertsSecretSuffix is appended to the name of a ProxyGroup to
+	// name the Secret that the operator can be configured to store the TLS
+	// certs of all of the ProxyGroup's HTTPS endpoints in, instead of a
+	// Secret per endpoint. Certs and keys are stored as <domain>.crt and
+	// <domain>.key, as in a state Secret.
+	ProxyGroupCertsSecretSuffix = "-certs"
+
 	KubeAPIServerConfigFile                     = "config.hujson"
 	APIServerProxyModeAuth   APIServerProxyMode = "auth"
 	APIServerProxyModeNoAuth APIServerProxyMode = "noauth"
@@ -116,6 +116,15 @@ func (c sysConn) Close() error {
 	return nil
 }
 
+// SetKeepAliveConfig configures TCP keep-alives on the underlying connection.
+// It returns [errors.ErrUnsupported] if that is not a TCP connection.
+func (c sysConn) SetKeepAliveConfig(cfg net.KeepAliveConfig) error {
+	if kc, ok := c.Conn.(interface{ SetKeepAliveConfig(net.KeepAliveConfig) error }); ok {
+		return kc.SetKeepAliveConfig(cfg)
+	}
+	return errors.ErrUnsupported
+}
+
 // SetTUNName sets the name of the tun device in use ("tailscale0", "utun6",
 // etc). This is needed on some platforms to set sockopts to bind
 // to the same interface index.
@@ -178,7 +178,8 @@
 //   - 129: 2025-10-04: Fixed sleep/wake deadlock in magicsock when using peer relay (PR #17449)
 //   - 130: 2025-10-06: client can send key.HardwareAttestationPublic and key.HardwareAttestationKeySignature in MapRequest
 //   - 131: 2025-11-25: client respects [NodeAttrDefaultAutoUpdate]
-const CurrentCapabilityVersion CapabilityVersion = 131
+//   - 132: 2026-10-16: serve config supports ipn.HTTPHandler.MaxConns, ResponseHeaders, BackendRetries, RetryStatusCodes and ProxyTargets, ipn.TCPPortHandler.KeepAliveIdle, KeepAliveInterval and HTTP1Only, and ipn.WebServerConfig.AccessLog and ClientCAs
+const CurrentCapabilityVersion CapabilityVersion = 132
 
 // ID is an integer ID for a user, node, or login allocated by the
 // control plane.
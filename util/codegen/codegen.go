@@ -14,6 +14,7 @@
 	"io"
 	"os"
 	"reflect"
+	"slices"
 	"strings"
 
 	"golang.org/x/tools/go/packages"
@@ -69,6 +70,211 @@ func HasNoClone(structTag string) bool {
 	return false
 }
 
+// HasNoParse reports whether the provided comment group contains a
+// //codegen:noparse directive.
+func HasNoParse(cg *ast.CommentGroup) bool {
+	return hasDirective(cg, "//codegen:noparse")
+}
+
+// HasOrdered reports whether the provided comment group contains a
+// //codegen:ordered directive.
+func HasOrdered(cg *ast.CommentGroup) bool {
+	return hasDirective(cg, "//codegen:ordered")
+}
+
+func hasDirective(cg *ast.CommentGroup, directive string) bool {
+	if cg == nil {
+		return false
+	}
+	for _, c := range cg.List {
+		if strings.TrimSpace(c.Text) == directive {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderedTypes returns the names of the types declared in files whose doc
+// comment contains a //codegen:ordered directive. Generators should use
+// AssertStructUnchangedOrdered for these types.
+func OrderedTypes(files []*ast.File) map[string]bool {
+	return TypesWithDirective(files, "//codegen:ordered")
+}
+
+// TypesWithDirective returns the names of the types declared in files whose
+// doc comment contains the provided directive, such as "//codegen:ordered".
+func TypesWithDirective(files []*ast.File, directive string) map[string]bool {
+	var names map[string]bool
+	for _, file := range files {
+		for _, d := range file.Decls {
+			decl, ok := d.(*ast.GenDecl)
+			if !ok || decl.Tok != token.TYPE {
+				continue
+			}
+			for _, s := range decl.Specs {
+				spec := s.(*ast.TypeSpec)
+				if hasDirective(spec.Doc, directive) || (len(decl.Specs) == 1 && hasDirective(decl.Doc, directive)) {
+					mak.Set(&names, spec.Name.Name, true)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// TypeDirectiveArgs returns the space-separated arguments of the provided
+// directive, such as "//codegen:cloneskip", keyed by the names of the types
+// declared in files whose doc comment contains it. A directive with a single
+// argument may also be written as directive=arg, such as
+// "//codegen:maxdepth=8". Types whose directive has no arguments are omitted.
+func TypeDirectiveArgs(files []*ast.File, directive string) map[string][]string {
+	var args map[string][]string
+	for _, file := range files {
+		for _, d := range file.Decls {
+			decl, ok := d.(*ast.GenDecl)
+			if !ok || decl.Tok != token.TYPE {
+				continue
+			}
+			for _, s := range decl.Specs {
+				spec := s.(*ast.TypeSpec)
+				a := directiveArgs(spec.Doc, directive)
+				if a == nil && len(decl.Specs) == 1 {
+					a = directiveArgs(decl.Doc, directive)
+				}
+				if a != nil {
+					mak.Set(&args, spec.Name.Name, a)
+				}
+			}
+		}
+	}
+	return args
+}
+
+func directiveArgs(cg *ast.CommentGroup, directive string) []string {
+	if cg == nil {
+		return nil
+	}
+	for _, c := range cg.List {
+		text := strings.TrimSpace(c.Text)
+		if rest, ok := strings.CutPrefix(text, directive+" "); ok {
+			if args := strings.Fields(rest); len(args) > 0 {
+				return args
+			}
+		}
+		if arg, ok := strings.CutPrefix(text, directive+"="); ok && arg != "" {
+			return []string{arg}
+		}
+	}
+	return nil
+}
+
+// MissingGenerated returns the types that a //go:generate directive in files
+// passes to cmd/cloner or cmd/viewer, but that lack a method these tools
+// generate in pkg: Clone for cmd/cloner, and Clone and View for cmd/viewer,
+// except for its --clone-only-type types. A non-empty result means that code
+// generation has to be rerun, e.g. after adding a type to a directive. It can
+// be used in a verify step. The result is sorted and its entries have the form
+// "T.Method".
+func MissingGenerated(files []*ast.File, pkg *types.Package) []string {
+	var missing []string
+	check := func(typeName, method string) {
+		if typeName == "" {
+			return
+		}
+		obj, ok := pkg.Scope().Lookup(typeName).(*types.TypeName)
+		if !ok || LookupMethod(obj.Type(), method) == nil {
+			missing = append(missing, typeName+"."+method)
+		}
+	}
+	for _, file := range files {
+		for _, cg := range file.Comments {
+			for _, c := range cg.List {
+				tool, flags, ok := generateDirective(c.Text)
+				if !ok {
+					continue
+				}
+				switch tool {
+				case "tailscale.com/cmd/cloner":
+					for _, name := range flags["type"] {
+						check(name, "Clone")
+					}
+				case "tailscale.com/cmd/viewer":
+					cloneOnly := flags["clone-only-type"]
+					for _, name := range flags["type"] {
+						check(name, "Clone")
+						if !slices.Contains(cloneOnly, name) {
+							check(name, "View")
+						}
+					}
+				}
+			}
+		}
+	}
+	slices.Sort(missing)
+	return slices.Compact(missing)
+}
+
+// generateDirective parses a comment of the form "//go:generate go run <tool>
+// <flags>". It returns the tool's package path and the comma-separated values
+// of its flags, keyed by flag name without leading dashes.
+func generateDirective(comment string) (tool string, flags map[string][]string, ok bool) {
+	rest, ok := strings.CutPrefix(comment, "//go:generate ")
+	if !ok {
+		return "", nil, false
+	}
+	args := strings.Fields(rest)
+	if len(args) < 3 || args[0] != "go" || args[1] != "run" {
+		return "", nil, false
+	}
+	tool, args = args[2], args[3:]
+	for i := 0; i < len(args); i++ {
+		name, ok := strings.CutPrefix(args[i], "-")
+		if !ok {
+			continue
+		}
+		name = strings.TrimPrefix(name, "-")
+		name, value, hasValue := strings.Cut(name, "=")
+		if !hasValue && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			i++
+			value = args[i]
+		}
+		mak.Set(&flags, name, strings.Split(value, ","))
+	}
+	return tool, flags, true
+}
+
+// TypedConstants returns the package-level constants of type typ declared in
+// files, in declaration order. Constants whose doc or line comment contains a
+// //codegen:noparse directive are omitted.
+func TypedConstants(files []*ast.File, info *types.Info, typ *types.Named) []*types.Const {
+	var consts []*types.Const
+	for _, file := range files {
+		for _, d := range file.Decls {
+			decl, ok := d.(*ast.GenDecl)
+			if !ok || decl.Tok != token.CONST {
+				continue
+			}
+			for _, s := range decl.Specs {
+				spec := s.(*ast.ValueSpec)
+				if HasNoParse(spec.Doc) || HasNoParse(spec.Comment) {
+					continue
+				}
+				for _, name := range spec.Names {
+					if name.Name == "_" {
+						continue
+					}
+					c, ok := info.Defs[name].(*types.Const)
+					if !ok || !types.Identical(c.Type(), typ) {
+						continue
+					}
+					consts = append(consts, c)
+				}
+			}
+		}
+	}
+	return consts
+}
+
 const copyrightHeader = `// Copyright (c) Tailscale Inc & AUTHORS
 // SPDX-License-Identifier: BSD-3-Clause
 
@@ -144,18 +350,28 @@ func (it *ImportTracker) Write(w io.Writer) {
 	fmt.Fprintf(w, ")\n\n")
 }
 
-func writeHeader(w io.Writer, tool, pkg string) {
+func writeHeader(w io.Writer, tool, pkg, buildConstraint string) {
 	if *flagCopyright {
 		fmt.Fprint(w, copyrightHeader)
 	}
+	if buildConstraint != "" {
+		fmt.Fprintf(w, "%s\n\n", buildConstraint)
+	}
 	fmt.Fprintf(w, genAndPackageHeader, tool, pkg)
 }
 
 // WritePackageFile adds a file with the provided imports and contents to package.
 // The tool param is used to identify the tool that generated package file.
 func WritePackageFile(tool string, pkg *packages.Package, path string, it *ImportTracker, contents *bytes.Buffer) error {
+	return WriteConstrainedPackageFile(tool, pkg, path, "", it, contents)
+}
+
+// WriteConstrainedPackageFile is like WritePackageFile, but places the
+// //go:build line buildConstraint after the copyright header.
+// If buildConstraint is empty, it is identical to WritePackageFile.
+func WriteConstrainedPackageFile(tool string, pkg *packages.Package, path, buildConstraint string, it *ImportTracker, contents *bytes.Buffer) error {
 	buf := new(bytes.Buffer)
-	writeHeader(buf, tool, pkg.Name)
+	writeHeader(buf, tool, pkg.Name, buildConstraint)
 	it.Write(buf)
 	if _, err := buf.Write(contents.Bytes()); err != nil {
 		return err
@@ -231,6 +447,21 @@ func namedTypes(pkg *packages.Package) map[string]types.Type {
 // If non-nil, AssertStructUnchanged will add elements to imports
 // for each package path that the caller must import for the returned code to compile.
 func AssertStructUnchanged(t *types.Struct, tname string, params *types.TypeParamList, ctx string, it *ImportTracker) []byte {
+	return assertStructUnchanged(t, tname, params, ctx, it, false)
+}
+
+// AssertStructUnchangedOrdered is like AssertStructUnchanged, but for types
+// whose wire format depends on their exact declaration, such as those marked
+// //codegen:ordered. The conversion emitted by AssertStructUnchanged already
+// fails to compile if fields are added, removed, renamed, retyped or
+// reordered, but ignores struct tags. The assignment emitted here requires
+// the type to be identical to the one it was generated from, so it also fails
+// if any struct tag changes.
+func AssertStructUnchangedOrdered(t *types.Struct, tname string, params *types.TypeParamList, ctx string, it *ImportTracker) []byte {
+	return assertStructUnchanged(t, tname, params, ctx, it, true)
+}
+
+func assertStructUnchanged(t *types.Struct, tname string, params *types.TypeParamList, ctx string, it *ImportTracker, ordered bool) []byte {
 	buf := new(bytes.Buffer)
 	w := func(format string, args ...any) {
 		fmt.Fprintf(buf, format+"\n", args...)
@@ -242,6 +473,8 @@ func AssertStructUnchanged(t *types.Struct, tname string, params *types.TypePara
 		constraints, identifiers := FormatTypeParams(params, it)
 		w("func _%s%sNeedsRegeneration%s (%s%s) {", tname, ctx, constraints, tname, identifiers)
 		w("_%s%sNeedsRegeneration(struct {", tname, ctx)
+	} else if ordered {
+		w("var _%s%sNeedsRegeneration %s = struct {", tname, ctx, tname)
 	} else {
 		w("var _%s%sNeedsRegeneration = %s(struct {", tname, ctx, tname)
 	}
@@ -255,7 +488,7 @@ func AssertStructUnchanged(t *types.Struct, tname string, params *types.TypePara
 		}
 		qname := it.QualifiedName(ft)
 		var tag string
-		if hasTypeParams {
+		if hasTypeParams || ordered {
 			tag = t.Tag(i)
 			if tag != "" {
 				tag = "`" + tag + "`"
@@ -268,9 +501,12 @@ func AssertStructUnchanged(t *types.Struct, tname string, params *types.TypePara
 		}
 	}
 
-	if hasTypeParams {
+	switch {
+	case hasTypeParams:
 		w("}{})\n}")
-	} else {
+	case ordered:
+		w("}{}")
+	default:
 		w("}{})")
 	}
 	return buf.Bytes()
@@ -283,19 +519,75 @@ func IsInvalid(t types.Type) bool {
 	return t.String() == "invalid type"
 }
 
+// immutableTypes are the types, by their fully qualified names, that contain
+// pointers but are free from memory aliasing/mutation concerns, as the memory
+// they point to is never modified. Values of these types can be shared rather
+// than cloned.
+var immutableTypes = map[string]bool{
+	// time.Time contains a *time.Location, which is never modified.
+	"time.Time":          true,
+	"*time.Location":     true,
+	"inet.af/netip.Addr": true,
+	// A compiled regexp is safe for concurrent use and can only be modified
+	// by its Longest method, which must be called before it is shared. Only
+	// pointers are exempt, as a regexp.Regexp must not be copied by value.
+	"*regexp.Regexp": true,
+	// Errors are immutable by convention and can be shared.
+	"error": true,
+}
+
+// concurrentMapTypes are the concurrency-safe map types, by the fully
+// qualified names of their origin types, whose values are copied by ranging
+// over their entries with their iteration API. They contain a mutex, so
+// they must not be copied as values.
+var concurrentMapTypes = map[string]bool{
+	"sync.Map":                true,
+	"tailscale.com/syncs.Map": true,
+}
+
+// IsConcurrentMap reports whether typ is one of the concurrency-safe map
+// types sync.Map and tailscale.com/syncs.Map. Values of these types must be
+// copied by ranging over their entries and storing them in the copy, rather
+// than by copying the value, which would copy its mutex and share its
+// entries.
+func IsConcurrentMap(typ types.Type) bool {
+	named, ok := NamedTypeOf(typ)
+	if !ok {
+		return false
+	}
+	obj := named.Origin().Obj()
+	if obj.Pkg() == nil {
+		return false
+	}
+	return concurrentMapTypes[obj.Pkg().Path()+"."+obj.Name()]
+}
+
 // ContainsPointers reports whether typ contains any pointers,
 // either explicitly or implicitly.
 // It has special handling for some types that contain pointers
 // that we know are free from memory aliasing/mutation concerns.
+//
+// In particular, error values are treated as pointer-free: errors are
+// immutable by convention, so fields of type error are copied by reference
+// rather than cloned. Other interface types are still reported as
+// containing pointers.
+//
+// The standard library type time.Time, as well as pointers to time.Location
+// and regexp.Regexp, are also treated as pointer-free; see immutableTypes.
+// Other standard library types, such as url.URL, are reported as containing
+// pointers.
+//
+// Concurrency-safe maps, as reported by IsConcurrentMap, are always reported
+// as containing pointers, even if their keys and values do not, as they can
+// not be copied by value.
 func ContainsPointers(typ types.Type) bool {
 	s := typ.String()
-	switch s {
-	case "time.Time":
-		// time.Time contains a pointer that does not need cloning.
-		return false
-	case "inet.af/netip.Addr":
+	if immutableTypes[s] {
 		return false
 	}
+	if IsConcurrentMap(typ) {
+		return true
+	}
 	if strings.HasPrefix(s, "unique.Handle[") {
 		// unique.Handle contains a pointer that does not need cloning.
 		return false
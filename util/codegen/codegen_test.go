@@ -5,8 +5,15 @@
 
 import (
 	"cmp"
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"go/types"
+	"maps"
 	"net/netip"
+	"net/url"
+	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
@@ -96,6 +103,29 @@ type StructWithNetipTypes struct {
 	_ netip.Prefix
 }
 
+type StructWithError struct{ _ error }
+
+type StructWithURL struct{ _ url.URL }
+
+type StructWithURLPtr struct{ _ *url.URL }
+
+type StructWithRegexpPtr struct{ _ *regexp.Regexp }
+
+type StructWithRegexp struct{ _ regexp.Regexp }
+
+type StructWithLocation struct{ _ *time.Location }
+
+type StructWithErrorAndInterface struct {
+	_ error
+	_ Interface
+}
+
+type RecursiveStruct struct {
+	_ [2]int
+	_ []RecursiveStruct
+	_ map[string]*RecursiveStruct
+}
+
 type Interface interface {
 	Method()
 }
@@ -185,6 +215,38 @@ func TestGenericContainsPointers(t *testing.T) {
 			typ:         "StructWithNetipTypes",
 			wantPointer: false,
 		},
+		{
+			typ:         "StructWithError",
+			wantPointer: false, // errors are copied by reference
+		},
+		{
+			typ:         "StructWithURL",
+			wantPointer: true, // url.URL has a *url.Userinfo
+		},
+		{
+			typ:         "StructWithURLPtr",
+			wantPointer: true,
+		},
+		{
+			typ:         "StructWithRegexpPtr",
+			wantPointer: false, // compiled regexps are immutable
+		},
+		{
+			typ:         "StructWithRegexp",
+			wantPointer: true, // a regexp.Regexp must not be copied by value
+		},
+		{
+			typ:         "StructWithLocation",
+			wantPointer: false,
+		},
+		{
+			typ:         "StructWithErrorAndInterface",
+			wantPointer: true,
+		},
+		{
+			typ:         "RecursiveStruct",
+			wantPointer: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -273,6 +335,88 @@ type args struct {
 	}
 }
 
+func TestAssertStructUnchangedOrdered(t *testing.T) {
+	const orig = `package t1
+
+// T1 is encoded in binary by field order.
+//
+//codegen:ordered
+type T1 struct {
+	P1 int    ` + "`bin:\"1\"`" + `
+	P2 string ` + "`bin:\"2\"`" + `
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "t1.go", orig, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := OrderedTypes([]*ast.File{f}), map[string]bool{"T1": true}; !maps.Equal(got, want) {
+		t.Fatalf("OrderedTypes() = %v, want %v", got, want)
+	}
+	pkg, err := new(types.Config).Check("t1", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := pkg.Scope().Lookup("T1").Type().Underlying().(*types.Struct)
+	it := NewImportTracker(pkg)
+
+	ordered := AssertStructUnchangedOrdered(st, "T1", nil, "Clone", it)
+	want := "var _T1CloneNeedsRegeneration T1 = struct {\n\tP1 int `bin:\"1\"`\n\tP2 string `bin:\"2\"`\n}{}"
+	if !strings.Contains(string(ordered), want) {
+		t.Fatalf("AssertStructUnchangedOrdered() = \n%s\nwant: \n%s", ordered, want)
+	}
+	unordered := AssertStructUnchanged(st, "T1", nil, "Clone", it)
+
+	// check type-checks the generated assertion against the declaration of T1
+	// in src, reporting whether it compiles.
+	check := func(t *testing.T, src string, assertion []byte) bool {
+		t.Helper()
+		f, err := parser.ParseFile(fset, "t1.go", src+"\n"+string(assertion), 0)
+		if err != nil {
+			t.Fatalf("parsing generated code: %v", err)
+		}
+		_, err = new(types.Config).Check("t1", fset, []*ast.File{f}, nil)
+		return err == nil
+	}
+
+	tests := []struct {
+		name          string
+		src           string
+		wantOrdered   bool // whether the ordered assertion still compiles
+		wantUnordered bool // whether the default assertion still compiles
+	}{
+		{
+			name:          "unchanged",
+			src:           orig,
+			wantOrdered:   true,
+			wantUnordered: true,
+		},
+		{
+			name:          "fields_swapped",
+			src:           "package t1\ntype T1 struct {\n\tP2 string `bin:\"2\"`\n\tP1 int `bin:\"1\"`\n}\n",
+			wantOrdered:   false,
+			wantUnordered: false,
+		},
+		{
+			name:          "tags_swapped",
+			src:           "package t1\ntype T1 struct {\n\tP1 int `bin:\"2\"`\n\tP2 string `bin:\"1\"`\n}\n",
+			wantOrdered:   false,
+			wantUnordered: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := check(t, tt.src, ordered); got != tt.wantOrdered {
+				t.Errorf("ordered assertion compiles = %v, want %v", got, tt.wantOrdered)
+			}
+			if got := check(t, tt.src, unordered); got != tt.wantUnordered {
+				t.Errorf("default assertion compiles = %v, want %v", got, tt.wantUnordered)
+			}
+		})
+	}
+}
+
 type NamedType struct{}
 
 func (NamedType) Method() {}
@@ -451,6 +595,106 @@ func TestLookupMethod(t *testing.T) {
 	}
 }
 
+func TestMissingGenerated(t *testing.T) {
+	const src = `package t1
+
+//go:generate go run tailscale.com/cmd/cloner -clonefunc=false -type=Cloned,Ungenerated
+//go:generate go run tailscale.com/cmd/viewer --type=Viewed,CloneOnly,Unviewed --clone-only-type=CloneOnly
+//go:generate go run golang.org/x/tools/cmd/stringer -type Unrelated
+
+type Cloned struct{ P *int }
+
+func (src *Cloned) Clone() *Cloned { return src }
+
+// Ungenerated was added to the cloner directive without rerunning it.
+type Ungenerated struct{ P *int }
+
+type Viewed struct{ P *int }
+
+func (src *Viewed) Clone() *Viewed { return src }
+func (src *Viewed) View() Viewed   { return *src }
+
+type CloneOnly struct{ P *int }
+
+func (src *CloneOnly) Clone() *CloneOnly { return src }
+
+// Unviewed only has the Clone method generated by cmd/cloner.
+type Unviewed struct{ P *int }
+
+func (src *Unviewed) Clone() *Unviewed { return src }
+
+type Unrelated struct{}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "t1.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := new(types.Config).Check("t1", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := MissingGenerated([]*ast.File{f}, pkg)
+	want := []string{"Ungenerated.Clone", "Unviewed.View"}
+	if !slices.Equal(got, want) {
+		t.Errorf("MissingGenerated() = %q, want %q", got, want)
+	}
+
+	// Generated code that is up to date is not reported.
+	lp, _, err := LoadTypes("", "tailscale.com/tailcfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := MissingGenerated(lp.Syntax, lp.Types); len(got) > 0 {
+		t.Errorf("MissingGenerated(tailcfg) = %q, want none", got)
+	}
+}
+
+func TestTypeDirectiveArgs(t *testing.T) {
+	const src = `package t1
+
+// Skipped has fields that are not cloned.
+//
+//codegen:cloneskip *Cache  tmp*
+type Skipped struct{}
+
+type (
+	// Grouped is declared in a group.
+	//codegen:cloneskip Buf
+	Grouped struct{}
+
+	// NoArgs has the directive without arguments.
+	//codegen:cloneskip
+	NoArgs struct{}
+
+	// Assigned has its argument assigned with =.
+	//codegen:cloneskip=Buf
+	Assigned struct{}
+
+	// EmptyAssigned has the directive with an empty assignment.
+	//codegen:cloneskip=
+	EmptyAssigned struct{}
+)
+
+//codegen:cloneskipped Other
+type Other struct{}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "t1.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := TypeDirectiveArgs([]*ast.File{f}, "//codegen:cloneskip")
+	want := map[string][]string{
+		"Skipped":  {"*Cache", "tmp*"},
+		"Grouped":  {"Buf"},
+		"Assigned": {"Buf"},
+	}
+	if !maps.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("TypeDirectiveArgs() = %q, want %q", got, want)
+	}
+}
+
 var namedTestTypes = sync.OnceValues(func() (map[string]types.Type, error) {
 	_, namedTypes, err := LoadTypes("test", ".")
 	return namedTypes, err
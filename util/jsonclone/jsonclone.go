@@ -0,0 +1,49 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package jsonclone deep-copies values of arbitrary JSON, as decoded into an
+// any by encoding/json. It is used by code generated by cmd/cloner for
+// fields of type any.
+package jsonclone
+
+// Value returns a deep copy of v, which is expected to be a JSON value as
+// decoded by encoding/json: nil, a bool, float64, json.Number or string, or a
+// map[string]any or []any of such values.
+//
+// Maps and slices are copied recursively, preserving nil. All other values
+// are returned as is, as JSON scalars are immutable. Values of other
+// reference types are therefore shared with v.
+func Value(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return Map(v)
+	case []any:
+		return Slice(v)
+	default:
+		return v
+	}
+}
+
+// Map returns a deep copy of the JSON object m. See Value.
+func Map(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	dst := make(map[string]any, len(m))
+	for k, v := range m {
+		dst[k] = Value(v)
+	}
+	return dst
+}
+
+// Slice returns a deep copy of the JSON array s. See Value.
+func Slice(s []any) []any {
+	if s == nil {
+		return nil
+	}
+	dst := make([]any, len(s))
+	for i, v := range s {
+		dst[i] = Value(v)
+	}
+	return dst
+}
@@ -0,0 +1,49 @@
+// Copyright (c) Tailscale Inc & AUTHORS
+// SPDX-License-Identifier: BSD-3-Clause
+
+package jsonclone
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValue(t *testing.T) {
+	var v any
+	if err := json.Unmarshal([]byte(`{"a":[1,"two",{"b":true}],"c":null,"d":{"e":[]}}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := json.Marshal(v)
+
+	got := Value(v)
+	if !reflect.DeepEqual(got, v) {
+		t.Fatalf("Value() = %v, want %v", got, v)
+	}
+
+	// Modifying the copy must not affect the original.
+	m := got.(map[string]any)
+	m["c"] = "set"
+	a := m["a"].([]any)
+	a[0] = 2.0
+	a[2].(map[string]any)["b"] = false
+	m["d"].(map[string]any)["e"] = append(m["d"].(map[string]any)["e"].([]any), 1)
+	if after, _ := json.Marshal(v); string(after) != string(want) {
+		t.Errorf("original modified through copy: got %s, want %s", after, want)
+	}
+}
+
+func TestNil(t *testing.T) {
+	if got := Value(nil); got != nil {
+		t.Errorf("Value(nil) = %v, want nil", got)
+	}
+	if got := Map(nil); got != nil {
+		t.Errorf("Map(nil) = %v, want nil", got)
+	}
+	if got := Slice(nil); got != nil {
+		t.Errorf("Slice(nil) = %v, want nil", got)
+	}
+	if got := Value(map[string]any{}); got == nil {
+		t.Error("Value of empty map is nil, want empty map")
+	}
+}